@@ -4,13 +4,17 @@ import (
 	"context"
 	"time"
 
+	"github.com/BuxOrg/bux/datastore"
 	"github.com/BuxOrg/bux/utils"
 )
 
 // NewAccessKey will create a new access key for the given xpub
 //
+// scopes limit the requests of the access key (empty is full access to the xPub)
+// expiresIn is the lifetime of the access key (zero never expires)
 // opts are options and can include "metadata"
-func (c *Client) NewAccessKey(ctx context.Context, xPubKey string, opts ...ModelOps) (*AccessKey, error) {
+func (c *Client) NewAccessKey(ctx context.Context, xPubKey string, scopes []string, expiresIn time.Duration,
+	opts ...ModelOps) (*AccessKey, error) {
 
 	// Check for existing NewRelic transaction
 	ctx = c.GetOrStartTxn(ctx, "new_access_key")
@@ -33,8 +37,8 @@ func (c *Client) NewAccessKey(ctx context.Context, xPubKey string, opts ...Model
 	}
 
 	// Create the model & set the default options (gives options from client->model)
-	accessKey := newAccessKey(
-		xPub.ID, c.DefaultModelOptions(append(opts, New())...)...,
+	accessKey := newAccessKeyWithScopes(
+		xPub.ID, scopes, expiresIn, c.DefaultModelOptions(append(opts, New())...)...,
 	)
 
 	// Save the model
@@ -46,6 +50,50 @@ func (c *Client) NewAccessKey(ctx context.Context, xPubKey string, opts ...Model
 	return accessKey, nil
 }
 
+// GetAccessKeyByID will get an access key of the xPub
+func (c *Client) GetAccessKeyByID(ctx context.Context, xPubKey, id string) (*AccessKey, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_access_key_by_id")
+
+	// Get the access key
+	accessKey, err := GetAccessKey(
+		ctx, id, c.DefaultModelOptions()...,
+	)
+	if err != nil {
+		return nil, err
+	} else if accessKey == nil {
+		return nil, ErrMissingAccessKey
+	}
+
+	// make sure this is the correct xPub
+	if accessKey.XpubID != utils.Hash(xPubKey) {
+		return nil, utils.ErrXpubNoMatch
+	}
+
+	return accessKey, nil
+}
+
+// GetAccessKeys will get the access keys of an xPub
+//
+// queryParams is the page, page size and sorting of the results (nil is all results)
+func (c *Client) GetAccessKeys(ctx context.Context, xPubKey string, usingMetadata *Metadata,
+	queryParams *datastore.QueryParams) ([]*AccessKey, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_access_keys")
+
+	// Get the access keys
+	accessKeys, err := getAccessKeysByXpubID(
+		ctx, utils.Hash(xPubKey), usingMetadata, queryParams, c.DefaultModelOptions()...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return accessKeys, nil
+}
+
 // RevokeAccessKey will revoke an access key
 //
 // opts are options and can include "metadata"
@@ -76,6 +124,8 @@ func (c *Client) RevokeAccessKey(ctx context.Context, xPubKey, id string, opts .
 		ctx, id, c.DefaultModelOptions(opts...)...,
 	); err != nil {
 		return nil, err
+	} else if accessKey == nil {
+		return nil, ErrMissingAccessKey
 	}
 
 	// make sure this is the correct xPub
@@ -94,3 +144,30 @@ func (c *Client) RevokeAccessKey(ctx context.Context, xPubKey, id string, opts .
 	// Return the updated model
 	return accessKey, nil
 }
+
+// DeleteAccessKey will (soft) delete an access key of the xPub
+//
+// A deleted access key can no longer be used for authentication
+func (c *Client) DeleteAccessKey(ctx context.Context, xPubKey, id string) error {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "delete_access_key")
+
+	// Get the access key
+	accessKey, err := GetAccessKey(
+		ctx, id, c.DefaultModelOptions()...,
+	)
+	if err != nil {
+		return err
+	} else if accessKey == nil {
+		return ErrMissingAccessKey
+	}
+
+	// make sure this is the correct xPub
+	if accessKey.XpubID != utils.Hash(xPubKey) {
+		return utils.ErrXpubNoMatch
+	}
+
+	// Mark the access key as deleted
+	return accessKey.Delete(ctx)
+}
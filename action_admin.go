@@ -0,0 +1,85 @@
+package bux
+
+import (
+	"context"
+
+	"github.com/BuxOrg/bux/datastore"
+	"github.com/BuxOrg/bux/utils"
+)
+
+// AddAdmin will add an admin (xPub or the public key of an access key) to the engine
+//
+// A removed admin is restored
+// opts are options and can include "metadata"
+func (c *Client) AddAdmin(ctx context.Context, key string, opts ...ModelOps) (*Admin, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "add_admin")
+
+	// Validate the key
+	if err := validateAdminKey(key); err != nil {
+		return nil, err
+	}
+
+	// The access key needs to exist
+	if !isXPubKey(key) {
+		accessKey, err := GetAccessKey(ctx, utils.Hash(key), c.DefaultModelOptions()...)
+		if err != nil {
+			return nil, err
+		} else if accessKey == nil {
+			return nil, ErrUnknownAccessKey
+		}
+	}
+
+	// Get the admin (including removed)
+	admin, err := getAdmin(ctx, utils.Hash(key), c.DefaultModelOptions(WithDeleted())...)
+	if err != nil {
+		return nil, err
+	} else if admin != nil {
+		if err = admin.Restore(ctx); err != nil {
+			return nil, err
+		}
+		return admin, nil
+	}
+
+	// Create the model & set the default options (gives options from client->model)
+	admin = newAdmin(key, c.DefaultModelOptions(append(opts, New())...)...)
+
+	// Save the model
+	if err = admin.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	// Return the created model
+	return admin, nil
+}
+
+// RemoveAdmin will remove an admin (xPub or the public key of an access key) from the engine
+func (c *Client) RemoveAdmin(ctx context.Context, key string) error {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "remove_admin")
+
+	// Get the admin
+	admin, err := getAdmin(ctx, utils.Hash(key), c.DefaultModelOptions()...)
+	if err != nil {
+		return err
+	} else if admin == nil {
+		return ErrMissingAdmin
+	}
+
+	// Mark the admin as deleted
+	return admin.Delete(ctx)
+}
+
+// ListAdmins will get all the admins of the engine
+//
+// queryParams is the page, page size and sorting of the results (nil is all results)
+func (c *Client) ListAdmins(ctx context.Context, queryParams *datastore.QueryParams) ([]*Admin, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "list_admins")
+
+	// Get the admins
+	return getAdmins(ctx, queryParams, c.DefaultModelOptions()...)
+}
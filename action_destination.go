@@ -3,7 +3,9 @@ package bux
 import (
 	"context"
 
+	"github.com/BuxOrg/bux/datastore"
 	"github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bk/bip32"
 )
 
 // NewDestination will get a new destination for an existing xPub
@@ -49,6 +51,76 @@ func (c *Client) NewDestination(ctx context.Context, xPubKey string, chain uint3
 	return destination, nil
 }
 
+// NewMultiSigDestination will get a new m-of-n (bare) multisig destination for existing xPubs
+//
+// The destination (utxos and balance) belongs to the first xPub, the public keys of all the xPubs
+// are derived at the next num (chain) of the first xPub and are used in the given order
+func (c *Client) NewMultiSigDestination(ctx context.Context, xPubKeys []string, required int, chain uint32,
+	metadata *map[string]interface{}) (*Destination, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "new_multisig_destination")
+
+	// Validate the number of keys (m-of-n)
+	if len(xPubKeys) == 0 || required < 1 || required > len(xPubKeys) {
+		return nil, utils.ErrInvalidMultiSig
+	}
+
+	// Validate that the values are unique xPubs (and registered)
+	var owner *Xpub
+	hdKeys := make([]*bip32.ExtendedKey, 0, len(xPubKeys))
+	for _, xPubKey := range xPubKeys {
+		hdKey, err := utils.ValidateXPub(xPubKey)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range hdKeys {
+			if key.String() == hdKey.String() {
+				return nil, ErrDuplicateMultiSigXpub
+			}
+		}
+
+		var xPub *Xpub
+		if xPub, err = getXpub(
+			ctx, xPubKey, c.DefaultModelOptions()...,
+		); err != nil {
+			return nil, err
+		} else if xPub == nil {
+			return nil, ErrMissingXpub
+		} else if owner == nil {
+			owner = xPub
+		}
+		hdKeys = append(hdKeys, hdKey)
+	}
+
+	// Increment the next num of the first xPub (owner of the destination)
+	num, err := owner.IncrementNextNum(ctx, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the multisig destination
+	var destination *Destination
+	if destination, err = newMultiSigAddress(
+		hdKeys, required, chain, num, c.DefaultModelOptions(New())...,
+	); err != nil {
+		return nil, err
+	}
+
+	// Check if metadata is set
+	if metadata != nil {
+		destination.Metadata = *metadata
+	}
+
+	// Save the destination
+	if err = destination.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	// Return the model
+	return destination, nil
+}
+
 // NewDestinationForLockingScript will create a new destination based on a locking script
 func (c *Client) NewDestinationForLockingScript(ctx context.Context, xPubKey, lockingScript, destinationType string,
 	metadata map[string]interface{}) (*Destination, error) {
@@ -89,15 +161,17 @@ func (c *Client) NewDestinationForLockingScript(ctx context.Context, xPubKey, lo
 }
 
 // GetDestinations will get destinations based on an xPub
-func (c *Client) GetDestinations(ctx context.Context, xPubKey string, usingMetadata *Metadata) ([]*Destination, error) {
+//
+// queryParams is the page, page size and sorting of the results (nil is all results)
+func (c *Client) GetDestinations(ctx context.Context, xPubKey string, usingMetadata *Metadata,
+	queryParams *datastore.QueryParams) ([]*Destination, error) {
 
 	// Check for existing NewRelic transaction
 	ctx = c.GetOrStartTxn(ctx, "get_destinations")
 
 	// Get the destinations
-	// todo: add params for: page size and page (right now it is unlimited)
 	destinations, err := getDestinationsByXpubID(
-		ctx, utils.Hash(xPubKey), usingMetadata, 0, 0, c.DefaultModelOptions()...,
+		ctx, utils.Hash(xPubKey), usingMetadata, queryParams, c.DefaultModelOptions()...,
 	)
 	if err != nil {
 		return nil, err
@@ -106,6 +180,18 @@ func (c *Client) GetDestinations(ctx context.Context, xPubKey string, usingMetad
 	return destinations, nil
 }
 
+// GetDestinationsCount will get a count of the destinations based on an xPub
+func (c *Client) GetDestinationsCount(ctx context.Context, xPubKey string, usingMetadata *Metadata) (int64, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_destinations_count")
+
+	// Get the count
+	return getDestinationsCountByXpubID(
+		ctx, utils.Hash(xPubKey), usingMetadata, c.DefaultModelOptions()...,
+	)
+}
+
 // GetDestinationByLockingScript will get a destination for a locking script
 func (c *Client) GetDestinationByLockingScript(ctx context.Context, xPubKey, lockingScript string) (*Destination, error) {
 
@@ -118,6 +204,8 @@ func (c *Client) GetDestinationByLockingScript(ctx context.Context, xPubKey, loc
 	)
 	if err != nil {
 		return nil, err
+	} else if destination == nil {
+		return nil, ErrMissingDestination
 	}
 
 	// Check that the id matches
@@ -140,6 +228,8 @@ func (c *Client) GetDestinationByAddress(ctx context.Context, xPubKey, address s
 	)
 	if err != nil {
 		return nil, err
+	} else if destination == nil {
+		return nil, ErrMissingDestination
 	}
 
 	// Check that the id matches
@@ -149,3 +239,53 @@ func (c *Client) GetDestinationByAddress(ctx context.Context, xPubKey, address s
 
 	return destination, nil
 }
+
+// DeleteDestination will (soft) delete a destination of the xPub
+//
+// The record is kept (marked as deleted) and is excluded from all queries (incl. the lookup of incoming payments)
+func (c *Client) DeleteDestination(ctx context.Context, xPubKey, id string) error {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "delete_destination")
+
+	// Get the destination
+	destination, err := getDestinationByID(
+		ctx, id, c.DefaultModelOptions()...,
+	)
+	if err != nil {
+		return err
+	} else if destination == nil {
+		return ErrMissingDestination
+	} else if destination.XpubID != utils.Hash(xPubKey) {
+		return ErrXpubIDMisMatch
+	}
+
+	// Mark the destination as deleted
+	return destination.Delete(ctx)
+}
+
+// RestoreDestination will restore a (soft) deleted destination of the xPub
+func (c *Client) RestoreDestination(ctx context.Context, xPubKey, id string) (*Destination, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "restore_destination")
+
+	// Get the destination (including deleted)
+	destination, err := getDestinationByID(
+		ctx, id, c.DefaultModelOptions(WithDeleted())...,
+	)
+	if err != nil {
+		return nil, err
+	} else if destination == nil {
+		return nil, ErrMissingDestination
+	} else if destination.XpubID != utils.Hash(xPubKey) {
+		return nil, ErrXpubIDMisMatch
+	}
+
+	// Remove the deleted mark
+	if err = destination.Restore(ctx); err != nil {
+		return nil, err
+	}
+
+	return destination, nil
+}
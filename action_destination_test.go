@@ -142,7 +142,7 @@ func (ts *EmbeddedDBTestSuite) TestClient_GetDestinations() {
 
 			var getDestinations []*Destination
 			getDestinations, err = tc.client.GetDestinations(
-				tc.ctx, rawKey, nil,
+				tc.ctx, rawKey, nil, nil,
 			)
 			require.NoError(t, err)
 			require.NotNil(t, getDestinations)
@@ -150,6 +150,11 @@ func (ts *EmbeddedDBTestSuite) TestClient_GetDestinations() {
 			assert.Equal(t, destination.Address, getDestinations[0].Address)
 			assert.Equal(t, testReferenceID, getDestinations[0].Metadata[ReferenceIDField])
 			assert.Equal(t, destination.XpubID, getDestinations[0].XpubID)
+
+			var count int64
+			count, err = tc.client.GetDestinationsCount(tc.ctx, rawKey, nil)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), count)
 		})
 
 		ts.T().Run(testCase.name+" - no destinations found", func(t *testing.T) {
@@ -169,10 +174,15 @@ func (ts *EmbeddedDBTestSuite) TestClient_GetDestinations() {
 			// use the wrong xpub
 			var getDestinations []*Destination
 			getDestinations, err = tc.client.GetDestinations(
-				tc.ctx, testXPub, nil,
+				tc.ctx, testXPub, nil, nil,
 			)
 			require.NoError(t, err)
 			assert.Equal(t, 0, len(getDestinations))
+
+			var count int64
+			count, err = tc.client.GetDestinationsCount(tc.ctx, testXPub, nil)
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), count)
 		})
 	}
 }
@@ -291,3 +301,66 @@ func (ts *EmbeddedDBTestSuite) TestClient_GetDestinationByLockingScript() {
 		})
 	}
 }
+
+// TestClient_GetDestinations_metadataOperators will test the metadata operators of the method GetDestinations()
+func (ts *EmbeddedDBTestSuite) TestClient_GetDestinations_metadataOperators() {
+
+	for _, testCase := range dbTestCases {
+		ts.T().Run(testCase.name+" - operators", func(t *testing.T) {
+			tc := ts.genericDBClient(t, testCase.database, false)
+			defer tc.Close(tc.ctx)
+
+			_, _, rawKey := CreateNewXPub(tc.ctx, t, tc.client)
+
+			for _, metadata := range []map[string]interface{}{
+				{"order_total": 500, "order_id": "ORD-001"},
+				{"order_total": 1500, "order_id": "ORD-002"},
+				{"order_total": "2000", "order_id": "ord-003", "paid": true},
+			} {
+				metadata := metadata
+				_, err := tc.client.NewDestination(
+					tc.ctx, rawKey, utils.ChainExternal, utils.ScriptTypePubKeyHash, &metadata,
+				)
+				require.NoError(t, err)
+			}
+
+			getOrderIDs := func(metadata Metadata) []string {
+				destinations, err := tc.client.GetDestinations(tc.ctx, rawKey, &metadata, nil)
+				require.NoError(t, err)
+				orderIDs := make([]string, 0, len(destinations))
+				for _, destination := range destinations {
+					orderIDs = append(orderIDs, destination.Metadata["order_id"].(string))
+				}
+				return orderIDs
+			}
+
+			// Numbers only match numbers (the string "2000" is not greater than 1000)
+			assert.ElementsMatch(t, []string{"ORD-002"}, getOrderIDs(Metadata{
+				"order_total": map[string]interface{}{"$gt": 1000},
+			}))
+			assert.ElementsMatch(t, []string{"ORD-001", "ORD-002"}, getOrderIDs(Metadata{
+				"order_total": map[string]interface{}{"$gte": 500, "$lt": 2000},
+			}))
+			assert.ElementsMatch(t, []string{"ORD-001", "ord-003"}, getOrderIDs(Metadata{
+				"order_id": map[string]interface{}{"$in": []interface{}{"ORD-001", "ord-003", "ORD-999"}},
+			}))
+			assert.ElementsMatch(t, []string{"ord-003"}, getOrderIDs(Metadata{
+				"paid": map[string]interface{}{"$exists": true},
+			}))
+			assert.ElementsMatch(t, []string{"ORD-001", "ORD-002"}, getOrderIDs(Metadata{
+				"paid": map[string]interface{}{"$exists": false},
+			}))
+
+			// Prefix matching is case-sensitive
+			assert.ElementsMatch(t, []string{"ORD-001", "ORD-002"}, getOrderIDs(Metadata{
+				"order_id": map[string]interface{}{"$prefix": "ORD-"},
+			}))
+
+			// Operators and values combined
+			assert.ElementsMatch(t, []string{"ORD-002"}, getOrderIDs(Metadata{
+				"order_id":    map[string]interface{}{"$prefix": "ORD-"},
+				"order_total": 1500,
+			}))
+		})
+	}
+}
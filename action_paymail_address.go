@@ -0,0 +1,139 @@
+package bux
+
+import (
+	"context"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/tonicpow/go-paymail"
+)
+
+// NewPaymailAddress will create a new paymail address for the given xPub
+//
+// xPubKey is the raw public xPub
+// address is the paymail address (alias@domain.com)
+// opts are options and can include "metadata"
+func (c *Client) NewPaymailAddress(ctx context.Context, xPubKey, address, publicName, avatar string,
+	opts ...ModelOps) (*PaymailAddress, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "new_paymail_address")
+
+	// Validate that the value is an xPub
+	_, err := utils.ValidateXPub(xPubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the paymail address
+	if err = paymail.ValidatePaymail(address); err != nil {
+		return nil, ErrPaymailAddressIsInvalid
+	}
+
+	// Get the xPub (by key - converts to id)
+	var xPub *Xpub
+	if xPub, err = getXpub(
+		ctx, xPubKey, // Pass the context and key everytime (for now)
+		c.DefaultModelOptions()..., // Passing down the Datastore and client information into the model
+	); err != nil {
+		return nil, err
+	} else if xPub == nil {
+		return nil, ErrMissingXpub
+	}
+
+	// Check for an existing paymail address
+	var paymailAddress *PaymailAddress
+	if paymailAddress, err = getPaymailAddress(
+		ctx, address, c.DefaultModelOptions(opts...)...,
+	); err != nil {
+		return nil, err
+	} else if paymailAddress != nil && !paymailAddress.IsDeleted() {
+		return nil, ErrPaymailAlreadyExists
+	}
+
+	// Create the model & set the default options (gives options from client->model)
+	if paymailAddress == nil {
+		paymailAddress = newPaymailAddress(
+			address, xPubKey, c.DefaultModelOptions(append(opts, New())...)...,
+		)
+	} else {
+
+		// Re-use the previously deleted address for this xPub
+		if err = paymailAddress.Restore(ctx); err != nil {
+			return nil, err
+		}
+		paymailAddress.XpubID = xPub.ID
+	}
+	paymailAddress.Avatar = avatar
+	paymailAddress.PublicName = publicName
+
+	// Save the model
+	if err = paymailAddress.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	// Return the created model
+	return paymailAddress, nil
+}
+
+// GetPaymailAddress will get an existing paymail address from the Datastore
+//
+// address is the paymail address (alias@domain.com)
+func (c *Client) GetPaymailAddress(ctx context.Context, address string) (*PaymailAddress, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_paymail_address")
+
+	// Get the paymail address
+	paymailAddress, err := getPaymailAddress(
+		ctx, address, c.DefaultModelOptions()...,
+	)
+	if err != nil {
+		return nil, err
+	} else if paymailAddress == nil || paymailAddress.IsDeleted() {
+		return nil, ErrMissingPaymail
+	}
+
+	// Return the model
+	return paymailAddress, nil
+}
+
+// GetPaymailAddressesByXpubID will get all the (active) paymail addresses for the given xPubID
+func (c *Client) GetPaymailAddressesByXpubID(ctx context.Context, xPubID string,
+	usingMetadata *Metadata) ([]*PaymailAddress, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_paymail_addresses_by_xpub_id")
+
+	// Get the paymail addresses
+	// todo: add params for: page size and page (right now it is unlimited)
+	paymailAddresses, err := getPaymailAddressesByXpubID(
+		ctx, xPubID, usingMetadata, 0, 0, c.DefaultModelOptions()...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return paymailAddresses, nil
+}
+
+// DeletePaymailAddress will delete a paymail address
+//
+// The record is kept (marked as deleted) and will no longer resolve any Paymail requests
+func (c *Client) DeletePaymailAddress(ctx context.Context, address string, opts ...ModelOps) error {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "delete_paymail_address")
+
+	// Get the paymail address
+	paymailAddress, err := getPaymailAddress(
+		ctx, address, c.DefaultModelOptions(opts...)...,
+	)
+	if err != nil {
+		return err
+	} else if paymailAddress == nil || paymailAddress.IsDeleted() {
+		return ErrMissingPaymail
+	}
+
+	// Mark the address as deleted
+	return paymailAddress.Delete(ctx)
+}
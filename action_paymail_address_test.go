@@ -0,0 +1,115 @@
+package bux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient_NewPaymailAddress will test the method NewPaymailAddress()
+func (ts *EmbeddedDBTestSuite) TestClient_NewPaymailAddress() {
+
+	for _, testCase := range dbTestCases {
+
+		ts.T().Run(testCase.name+" - valid", func(t *testing.T) {
+			tc := ts.genericDBClient(t, testCase.database, false)
+			defer tc.Close(tc.ctx)
+
+			ctx := context.Background()
+
+			_, err := tc.client.NewXpub(ctx, testXPub, tc.client.DefaultModelOptions()...)
+			require.NoError(t, err)
+
+			var paymailAddress *PaymailAddress
+			paymailAddress, err = tc.client.NewPaymailAddress(
+				ctx, testXPub, testPaymailAlias+"@"+testPaymailDomain, "Tester", "https://test.com/avatar.png",
+			)
+			require.NoError(t, err)
+			require.NotNil(t, paymailAddress)
+			assert.Equal(t, testPaymailAlias, paymailAddress.Alias)
+			assert.Equal(t, testPaymailDomain, paymailAddress.Domain)
+			assert.Equal(t, "Tester", paymailAddress.PublicName)
+			assert.Equal(t, "https://test.com/avatar.png", paymailAddress.Avatar)
+			assert.Equal(t, testXPubID, paymailAddress.XpubID)
+
+			var paymailAddresses []*PaymailAddress
+			paymailAddresses, err = tc.client.GetPaymailAddressesByXpubID(ctx, testXPubID, nil)
+			require.NoError(t, err)
+			require.Len(t, paymailAddresses, 1)
+			assert.Equal(t, paymailAddress.ID, paymailAddresses[0].ID)
+		})
+
+		ts.T().Run(testCase.name+" - error - already exists", func(t *testing.T) {
+			tc := ts.genericDBClient(t, testCase.database, false)
+			defer tc.Close(tc.ctx)
+
+			ctx := context.Background()
+
+			_, err := tc.client.NewXpub(ctx, testXPub, tc.client.DefaultModelOptions()...)
+			require.NoError(t, err)
+
+			_, err = tc.client.NewPaymailAddress(ctx, testXPub, testPaymailAlias+"@"+testPaymailDomain, "", "")
+			require.NoError(t, err)
+
+			var paymailAddress *PaymailAddress
+			paymailAddress, err = tc.client.NewPaymailAddress(ctx, testXPub, testPaymailAlias+"@"+testPaymailDomain, "", "")
+			require.ErrorIs(t, err, ErrPaymailAlreadyExists)
+			require.Nil(t, paymailAddress)
+		})
+
+		ts.T().Run(testCase.name+" - error - missing xpub", func(t *testing.T) {
+			tc := ts.genericDBClient(t, testCase.database, false)
+			defer tc.Close(tc.ctx)
+
+			paymailAddress, err := tc.client.NewPaymailAddress(
+				context.Background(), testXPub, testPaymailAlias+"@"+testPaymailDomain, "", "",
+			)
+			require.ErrorIs(t, err, ErrMissingXpub)
+			require.Nil(t, paymailAddress)
+		})
+	}
+}
+
+// TestClient_DeletePaymailAddress will test the method DeletePaymailAddress()
+func (ts *EmbeddedDBTestSuite) TestClient_DeletePaymailAddress() {
+
+	for _, testCase := range dbTestCases {
+
+		ts.T().Run(testCase.name+" - valid", func(t *testing.T) {
+			tc := ts.genericDBClient(t, testCase.database, false)
+			defer tc.Close(tc.ctx)
+
+			ctx := context.Background()
+
+			_, err := tc.client.NewXpub(ctx, testXPub, tc.client.DefaultModelOptions()...)
+			require.NoError(t, err)
+
+			_, err = tc.client.NewPaymailAddress(ctx, testXPub, testPaymailAlias+"@"+testPaymailDomain, "", "")
+			require.NoError(t, err)
+
+			err = tc.client.DeletePaymailAddress(ctx, testPaymailAlias+"@"+testPaymailDomain)
+			require.NoError(t, err)
+
+			var paymailAddress *PaymailAddress
+			paymailAddress, err = tc.client.GetPaymailAddress(ctx, testPaymailAlias+"@"+testPaymailDomain)
+			require.ErrorIs(t, err, ErrMissingPaymail)
+			require.Nil(t, paymailAddress)
+
+			// Re-create the deleted address
+			paymailAddress, err = tc.client.NewPaymailAddress(ctx, testXPub, testPaymailAlias+"@"+testPaymailDomain, "", "")
+			require.NoError(t, err)
+			require.NotNil(t, paymailAddress)
+			assert.False(t, paymailAddress.IsDeleted())
+		})
+
+		ts.T().Run(testCase.name+" - error - missing paymail", func(t *testing.T) {
+			tc := ts.genericDBClient(t, testCase.database, false)
+			defer tc.Close(tc.ctx)
+
+			err := tc.client.DeletePaymailAddress(context.Background(), testPaymailAlias+"@"+testPaymailDomain)
+			require.ErrorIs(t, err, ErrMissingPaymail)
+		})
+	}
+}
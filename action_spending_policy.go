@@ -0,0 +1,145 @@
+package bux
+
+import (
+	"context"
+
+	"github.com/BuxOrg/bux/utils"
+)
+
+// SetSpendingPolicy will set (create or update) the spending policy of an xPub or an access key of the xPub
+//
+// accessKeyID is empty for the policy of the xPub
+// rules are the limits of the policy (zero values are not limited)
+// opts are options and can include "metadata"
+func (c *Client) SetSpendingPolicy(ctx context.Context, xPubKey, accessKeyID string, rules *SpendingRules,
+	opts ...ModelOps) (*SpendingPolicy, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "set_spending_policy")
+
+	// Check the owner of the policy
+	xPubID, err := c.getSpendingPolicyOwner(ctx, xPubKey, accessKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock the spending policies of the xPub (see RecordTransaction)
+	unlock, err := newWaitWriteLock(
+		ctx, "action-spending-policies-"+xPubID, c.Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the policy (including removed)
+	var policy *SpendingPolicy
+	if policy, err = getSpendingPolicy(
+		ctx, newSpendingPolicy(xPubID, accessKeyID, nil).ID, c.DefaultModelOptions(append(opts, WithDeleted())...)...,
+	); err != nil {
+		return nil, err
+	} else if policy == nil {
+
+		// Create the model & set the default options (gives options from client->model)
+		policy = newSpendingPolicy(xPubID, accessKeyID, rules, c.DefaultModelOptions(append(opts, New())...)...)
+	} else {
+		if policy.IsDeleted() {
+			if err = policy.Restore(ctx); err != nil {
+				return nil, err
+			}
+		}
+		policy.setRules(rules)
+	}
+
+	// Save the model
+	if err = policy.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	// Return the model
+	return policy, nil
+}
+
+// GetSpendingPolicy will get the spending policy of an xPub or an access key of the xPub
+//
+// accessKeyID is empty for the policy of the xPub
+func (c *Client) GetSpendingPolicy(ctx context.Context, xPubKey, accessKeyID string) (*SpendingPolicy, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_spending_policy")
+
+	// Check the owner of the policy
+	xPubID, err := c.getSpendingPolicyOwner(ctx, xPubKey, accessKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the policy
+	var policy *SpendingPolicy
+	if policy, err = getSpendingPolicy(
+		ctx, newSpendingPolicy(xPubID, accessKeyID, nil).ID, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	} else if policy == nil {
+		return nil, ErrMissingSpendingPolicy
+	}
+
+	return policy, nil
+}
+
+// DeleteSpendingPolicy will remove the spending policy of an xPub or an access key of the xPub
+//
+// accessKeyID is empty for the policy of the xPub
+func (c *Client) DeleteSpendingPolicy(ctx context.Context, xPubKey, accessKeyID string) error {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "delete_spending_policy")
+
+	// Get the policy
+	policy, err := c.GetSpendingPolicy(ctx, xPubKey, accessKeyID)
+	if err != nil {
+		return err
+	}
+
+	// Lock the spending policies of the xPub (see RecordTransaction)
+	unlock, err := newWaitWriteLock(
+		ctx, "action-spending-policies-"+policy.XpubID, c.Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return err
+	}
+
+	// Mark the policy as deleted
+	return policy.Delete(ctx)
+}
+
+// getSpendingPolicyOwner will check that the xPub (and the access key of the xPub) exists and return the xPub ID
+func (c *Client) getSpendingPolicyOwner(ctx context.Context, xPubKey, accessKeyID string) (string, error) {
+
+	// Validate that the value is an xPub
+	if _, err := utils.ValidateXPub(xPubKey); err != nil {
+		return "", err
+	}
+
+	// Get the xPub (by key - converts to id)
+	xPub, err := getXpub(ctx, xPubKey, c.DefaultModelOptions()...)
+	if err != nil {
+		return "", err
+	} else if xPub == nil {
+		return "", ErrMissingXpub
+	} else if len(accessKeyID) == 0 {
+		return xPub.ID, nil
+	}
+
+	// Get the access key (needs to belong to the xPub)
+	var accessKey *AccessKey
+	if accessKey, err = GetAccessKey(ctx, accessKeyID, c.DefaultModelOptions()...); err != nil {
+		return "", err
+	} else if accessKey == nil {
+		return "", ErrMissingAccessKey
+	} else if accessKey.XpubID != xPub.ID {
+		return "", utils.ErrXpubNoMatch
+	}
+	return xPub.ID, nil
+}
@@ -2,7 +2,9 @@ package bux
 
 import (
 	"context"
+	"time"
 
+	"github.com/BuxOrg/bux/datastore"
 	"github.com/BuxOrg/bux/utils"
 )
 
@@ -13,6 +15,9 @@ import (
 // External (unknown) transactions: no reference id but some output(s) match known outputs, tx is valid (mempool/on-chain)
 // Unknown transactions: no matching outputs, tx will be disregarded
 //
+// Transactions of drafts are checked against the spending policies (returns a SpendingPolicyError)
+// Requests authenticated without a scope are rate limited here (RateLimitRecord)
+//
 // xPubKey is the raw public xPub
 // txHex is the raw transaction hex
 // draftID is the unique draft id from a previously started New() transaction (draft_transaction.ID)
@@ -23,6 +28,11 @@ func (c *Client) RecordTransaction(ctx context.Context, xPubKey, txHex, draftID
 	// Check for existing NewRelic transaction
 	ctx = c.GetOrStartTxn(ctx, "record_transaction")
 
+	// Rate limit of the request (if it has no scope)
+	if err := c.allowAction(ctx, RateLimitRecord); err != nil {
+		return nil, err
+	}
+
 	// Create the model & set the default options (gives options from client->model)
 	newOpts := c.DefaultModelOptions(append(opts, WithXPub(xPubKey), New())...)
 	transaction := newTransactionWithDraftID(
@@ -44,6 +54,26 @@ func (c *Client) RecordTransaction(ctx context.Context, xPubKey, txHex, draftID
 		return nil, err
 	}
 
+	// Lock the spending policies of the xPub (checking, recording and adding to the daily spent is atomic)
+	if len(transaction.DraftID) > 0 {
+		var unlockPolicies func()
+		unlockPolicies, err = newWaitWriteLock(
+			ctx, "action-spending-policies-"+transaction.xPubID, c.Cachestore(),
+		)
+		defer unlockPolicies()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check the spending policies of the xPub and the access key (of the request)
+	policies, satoshisSent, err := checkTransactionSpendingPolicies(
+		ctx, transaction, getAccessKeyIDFromContext(ctx), c.DefaultModelOptions()...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	// OPTION: check incoming transactions (if enabled, will add to queue for checking on-chain)
 	if !c.IsITCEnabled() {
 		transaction.DebugLog("incoming transaction check is disabled")
@@ -80,12 +110,53 @@ func (c *Client) RecordTransaction(ctx context.Context, xPubKey, txHex, draftID
 		return nil, err
 	}
 
+	// Add the satoshis sent to the daily spent of the policies
+	for _, policy := range policies {
+		if err = policy.addDailySpent(ctx, satoshisSent); err != nil {
+			return nil, err
+		}
+	}
+
 	// Return the response
 	return transaction, nil
 }
 
+// RecordSPVTransaction will verify the ancestry of the transaction and then record it (see: RecordTransaction)
+//
+// The payload is either a BEEF (BRC-62) hex string or an SPV envelope (JSON)
+// All inputs must spend outputs of ancestors that are included in the payload. Each ancestor must have
+// a valid merkle proof, or have its own inputs satisfied by the payload.
+//
+// xPubKey is the raw public xPub
+// spvPayload is the BEEF (hex) or SPV envelope (JSON)
+// draftID is the unique draft id from a previously started New() transaction (draft_transaction.ID)
+// opts are model options and can include "metadata"
+func (c *Client) RecordSPVTransaction(ctx context.Context, xPubKey, spvPayload, draftID string,
+	opts ...ModelOps) (*Transaction, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "record_spv_transaction")
+
+	// Parse the payload
+	envelope, err := newSPVEnvelope(spvPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify the inputs using the ancestry
+	if err = envelope.verify(ctx, c); err != nil {
+		return nil, err
+	}
+
+	// Record the verified transaction
+	return c.RecordTransaction(ctx, xPubKey, envelope.transaction.String(), draftID, opts...)
+}
+
 // NewTransaction will create a new draft transaction and return it
 //
+// The draft is checked against the spending policies of the xPub and the access key of the request (ctx)
+// Requests authenticated without a scope are rate limited here (RateLimitDraft)
+//
 // ctx is the context
 // rawXpubKey is the raw xPub key
 // config is the TransactionConfig
@@ -97,6 +168,11 @@ func (c *Client) NewTransaction(ctx context.Context, rawXpubKey string, config *
 	// Check for existing NewRelic draftTransaction
 	ctx = c.GetOrStartTxn(ctx, "new_transaction")
 
+	// Rate limit of the request (if it has no scope)
+	if err := c.allowAction(ctx, RateLimitDraft); err != nil {
+		return nil, err
+	}
+
 	// Create the lock and set the release for after the function completes
 	unlock, err := newWaitWriteLock(
 		ctx, "action-xpub-"+utils.Hash(rawXpubKey), c.Cachestore(),
@@ -111,9 +187,9 @@ func (c *Client) NewTransaction(ctx context.Context, rawXpubKey string, config *
 		opts = append(opts, WithMetadatas(metadata))
 	}
 
-	// todo: this needs adjusting via Chainstate or mAPI
+	// Get the fee unit from the miner (mAPI)
 	if config.FeeUnit == nil {
-		config.FeeUnit = c.GetFeeUnit(ctx, "miner")
+		config.FeeUnit = c.GetFeeUnit(ctx, config.Miner)
 	}
 
 	// Create the model & set the default options (gives options from client->model)
@@ -131,6 +207,302 @@ func (c *Client) NewTransaction(ctx context.Context, rawXpubKey string, config *
 	return draftTransaction, nil
 }
 
+// CancelDraftTransaction will cancel the draft transaction and release the reserved utxos
+//
+// ctx is the context
+// rawXpubKey is the raw xPub key (owner of the draft)
+// draftID is the draft transaction ID
+func (c *Client) CancelDraftTransaction(ctx context.Context, rawXpubKey, draftID string) (*DraftTransaction, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "cancel_draft_transaction")
+
+	// Create the lock and set the release for after the function completes
+	unlock, err := newWaitWriteLock(
+		ctx, "action-xpub-"+utils.Hash(rawXpubKey), c.Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the draft (only active drafts of the xPub)
+	var draftTransaction *DraftTransaction
+	if draftTransaction, err = getActiveDraftTransaction(
+		ctx, rawXpubKey, draftID, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+
+	// Cancel the draft (the reserved utxos are released by the AfterUpdated hook)
+	draftTransaction.Status = DraftStatusCanceled
+	if err = draftTransaction.Save(ctx); err != nil {
+		return nil, err
+	}
+	publishEvent(ctx, c, EventTypeDraftTransactionCanceled, draftTransaction)
+
+	return draftTransaction, nil
+}
+
+// AddDraftSignatures will add the signatures of a cosigner to the multisig inputs of the draft transaction
+//
+// ctx is the context
+// rawXpubKey is the raw xPub key of the cosigner (does not need to be the owner of the draft)
+// draftID is the draft transaction ID
+// signatures are the signatures (hex) for each input of the draft, see: SignMultiSigDraft()
+func (c *Client) AddDraftSignatures(ctx context.Context, rawXpubKey, draftID string,
+	signatures []string) (*DraftTransaction, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "add_draft_signatures")
+
+	// Validate that the value is an xPub
+	hdKey, err := utils.ValidateXPub(rawXpubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the lock and set the release for after the function completes
+	var unlock func()
+	unlock, err = newWaitWriteLock(
+		ctx, "action-draft-signatures-"+draftID, c.Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the draft (cosigners are checked against the multisig inputs)
+	var draftTransaction *DraftTransaction
+	if draftTransaction, err = getDraftTransactionByID(
+		ctx, draftID, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	} else if draftTransaction == nil {
+		return nil, ErrDraftNotFound
+	} else if !draftTransaction.isActive() {
+		return nil, ErrDraftNotActive
+	}
+
+	// Verify and add the signatures
+	if err = draftTransaction.addMultiSigSignatures(hdKey, signatures); err != nil {
+		return nil, err
+	}
+
+	if err = draftTransaction.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	return draftTransaction, nil
+}
+
+// RecordMultiSigTransaction will record the multisig draft transaction, once all the required signatures are added
+//
+// ctx is the context
+// rawXpubKey is the raw xPub key (owner of the draft)
+// draftID is the draft transaction ID
+func (c *Client) RecordMultiSigTransaction(ctx context.Context, rawXpubKey, draftID string,
+	opts ...ModelOps) (*Transaction, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "record_multisig_transaction")
+
+	// Get the draft (only active drafts of the xPub)
+	draftTransaction, err := getActiveDraftTransaction(
+		ctx, rawXpubKey, draftID, c.DefaultModelOptions()...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the signed transaction from the collected signatures
+	var txHex string
+	if txHex, err = draftTransaction.getMultiSigTxHex(); err != nil {
+		return nil, err
+	}
+
+	return c.RecordTransaction(ctx, rawXpubKey, txHex, draftID, opts...)
+}
+
+// ExtendDraftTransaction will extend the expiration of the draft transaction (keeps the utxos reserved)
+//
+// ctx is the context
+// rawXpubKey is the raw xPub key (owner of the draft)
+// draftID is the draft transaction ID
+// expiresIn is the new expiration from now (default if zero)
+func (c *Client) ExtendDraftTransaction(ctx context.Context, rawXpubKey, draftID string,
+	expiresIn time.Duration) (*DraftTransaction, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "extend_draft_transaction")
+
+	// Create the lock and set the release for after the function completes
+	unlock, err := newWaitWriteLock(
+		ctx, "action-xpub-"+utils.Hash(rawXpubKey), c.Cachestore(),
+	)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the draft (only active drafts of the xPub)
+	var draftTransaction *DraftTransaction
+	if draftTransaction, err = getActiveDraftTransaction(
+		ctx, rawXpubKey, draftID, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	}
+
+	// Set the new expiration
+	if expiresIn <= 0 {
+		expiresIn = defaultDraftTxExpiresIn
+	}
+	draftTransaction.ExpiresAt = time.Now().UTC().Add(expiresIn)
+	if err = draftTransaction.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	return draftTransaction, nil
+}
+
+// SendTransaction will create a new draft transaction, sign it (server-side), record and broadcast it
+// This requires a signer (WithSigner) that holds the xPriv for the xPub
+//
+// ctx is the context
+// rawXpubKey is the raw xPub key
+// config is the TransactionConfig (broadcast & sync by default)
+// metadata is added to the model
+// opts are additional model options to be applied
+//
+// The recorded transaction is returned even if the broadcast fails (the broadcast task retries it)
+func (c *Client) SendTransaction(ctx context.Context, rawXpubKey string, config *TransactionConfig,
+	metadata map[string]interface{}, opts ...ModelOps) (*Transaction, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "send_transaction")
+
+	// Server-side signing is not enabled
+	if c.options.signer == nil {
+		return nil, ErrMissingSigner
+	}
+
+	// Broadcast (and sync) by default (the config of the caller is not changed)
+	sendConfig := *config
+	if sendConfig.Sync == nil {
+		sendConfig.Sync = DefaultSyncConfig()
+	}
+
+	// Create the draft transaction (reserves the utxos)
+	draftTransaction, err := c.NewTransaction(ctx, rawXpubKey, &sendConfig, metadata, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sign the inputs of the draft
+	var txHex string
+	if txHex, err = c.options.signer.SignDraftTransaction(ctx, draftTransaction); err == nil && len(txHex) == 0 {
+		err = ErrMissingXPriv
+	}
+	if err != nil {
+		c.cancelSendDraft(ctx, rawXpubKey, draftTransaction.ID)
+		return nil, err
+	}
+
+	// Record the signed transaction
+	var transaction *Transaction
+	if transaction, err = c.RecordTransaction(ctx, rawXpubKey, txHex, draftTransaction.ID, opts...); err != nil {
+		c.cancelSendDraft(ctx, rawXpubKey, draftTransaction.ID)
+		return nil, err
+	}
+
+	// Broadcast now, the transaction is recorded (utxos are spent) and the broadcast task will retry on failure
+	if transaction.syncTransaction != nil && transaction.syncTransaction.BroadcastStatus == SyncStatusReady {
+		if err = processBroadcastTransaction(ctx, transaction.syncTransaction); err != nil {
+			c.Logger().Error(ctx, "error broadcasting transaction "+transaction.ID+": "+err.Error())
+		}
+	}
+
+	return transaction, nil
+}
+
+// cancelSendDraft will cancel the draft of a failed send (removes the utxo reservations)
+//
+// Only active drafts are canceled, a failure is logged (the draft expires and releases the utxos)
+func (c *Client) cancelSendDraft(ctx context.Context, rawXpubKey, draftID string) {
+	if _, err := c.CancelDraftTransaction(ctx, rawXpubKey, draftID); err != nil {
+		c.Logger().Error(ctx, "error canceling draft transaction "+draftID+": "+err.Error())
+	}
+}
+
+// BumpTransactionFee will create a draft transaction (child) spending the change of an unconfirmed transaction
+// (parent), with a fee high enough to pay for both transactions (child-pays-for-parent)
+//
+// The draft is signed and recorded like any other draft, the recorded child is linked to the parent (ParentID)
+//
+// ctx is the context
+// rawXpubKey is the raw xPub key (owner of the change outputs)
+// txID is the ID of the (parent) transaction
+// feeUnit is the fee unit for both transactions (uses the fee unit of the miner if nil)
+// metadata is added to the model
+// opts are additional model options to be applied
+func (c *Client) BumpTransactionFee(ctx context.Context, rawXpubKey, txID string, feeUnit *utils.FeeUnit,
+	metadata map[string]interface{}, opts ...ModelOps) (*DraftTransaction, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "bump_transaction_fee")
+
+	// Get the (parent) transaction
+	transaction, err := getTransactionByID(ctx, rawXpubKey, txID, c.DefaultModelOptions()...)
+	if err != nil {
+		return nil, err
+	} else if transaction == nil {
+		return nil, ErrMissingTransaction
+	} else if len(transaction.BlockHash) > 0 {
+		return nil, ErrTransactionAlreadyMined
+	}
+
+	// Get the unspent change of the xPub
+	var utxos []*Utxo
+	if utxos, err = getUtxosByConditions(ctx, map[string]interface{}{
+		draftIDField:       nil,
+		spendingTxIDField:  nil,
+		transactionIDField: txID,
+		typeField:          utils.ScriptTypePubKeyHash,
+		xPubIDField:        utils.Hash(rawXpubKey),
+	}, 0, 0, "", "", c.DefaultModelOptions()...); err != nil {
+		return nil, err
+	} else if len(utxos) == 0 {
+		return nil, ErrMissingChangeUtxos
+	}
+
+	fromUtxos := make([]*UtxoPointer, 0, len(utxos))
+	for _, utxo := range utxos {
+		fromUtxos = append(fromUtxos, &UtxoPointer{
+			OutputIndex:   utxo.OutputIndex,
+			TransactionID: utxo.TransactionID,
+		})
+	}
+
+	// The child sends all the change back to the (internal) change destination of the xPub
+	var destination *Destination
+	if destination, err = getDestinationByLockingScript(
+		ctx, utxos[0].ScriptPubKey, c.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	} else if destination == nil {
+		return nil, ErrMissingDestination
+	}
+
+	// Create the draft (broadcast & sync, the parent is broadcast with the child)
+	return c.NewTransaction(ctx, rawXpubKey, &TransactionConfig{
+		FeeUnit:   feeUnit,
+		FromUtxos: fromUtxos,
+		ParentID:  txID,
+		SendAllTo: destination.Address,
+		Sync:      DefaultSyncConfig(),
+	}, metadata, opts...)
+}
+
 // GetTransaction will get a transaction from the Datastore
 //
 // ctx is the context
@@ -151,21 +523,47 @@ func (c *Client) GetTransaction(ctx context.Context, rawXpubKey, txID string) (*
 	return transaction, nil
 }
 
+// GetTransactionMerkleProof will get the merkle proof (TSC) of a mined transaction from the Datastore
+//
+// ctx is the context
+// rawXpubKey is the raw xPub key
+// txID is the transaction ID
+func (c *Client) GetTransactionMerkleProof(ctx context.Context, rawXpubKey, txID string) (*MerkleProof, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_transaction_merkle_proof")
+
+	// Get the transaction by ID
+	transaction, err := getTransactionByID(
+		ctx, rawXpubKey, txID, c.DefaultModelOptions(WithXPub(rawXpubKey))...,
+	)
+	if err != nil {
+		return nil, err
+	} else if transaction == nil {
+		return nil, ErrMissingTransaction
+	} else if transaction.MerkleProof.IsEmpty() {
+		return nil, ErrMissingMerkleProof
+	}
+
+	return &transaction.MerkleProof, nil
+}
+
 // GetTransactions will get all transactions for a given xpub from the Datastore
 //
 // ctx is the context
 // rawXpubKey is the raw xPub key
 // metadata is added to the request for searching
+// conditions is added to the request for searching
+// queryParams is the page, page size and sorting of the results (nil is all results)
 func (c *Client) GetTransactions(ctx context.Context, rawXpubKey string, metadata *Metadata,
-	conditions *map[string]interface{}) ([]*Transaction, error) {
+	conditions *map[string]interface{}, queryParams *datastore.QueryParams) ([]*Transaction, error) {
 
 	// Check for existing NewRelic transaction
 	ctx = c.GetOrStartTxn(ctx, "get_transaction")
 
-	// Get the transaction by ID
-	// todo: add params for: page size and page (right now it is unlimited)
+	// Get the transactions
 	transactions, err := getTransactionsByXpubID(
-		ctx, rawXpubKey, metadata, conditions, 0, 0,
+		ctx, rawXpubKey, metadata, conditions, queryParams,
 		c.DefaultModelOptions(WithXPub(rawXpubKey))...,
 	)
 	if err != nil {
@@ -174,3 +572,61 @@ func (c *Client) GetTransactions(ctx context.Context, rawXpubKey string, metadat
 
 	return transactions, nil
 }
+
+// GetTransactionsCount will get a count of all the transactions for a given xpub from the Datastore
+//
+// ctx is the context
+// rawXpubKey is the raw xPub key
+// metadata is added to the request for searching
+// conditions is added to the request for searching
+func (c *Client) GetTransactionsCount(ctx context.Context, rawXpubKey string, metadata *Metadata,
+	conditions *map[string]interface{}) (int64, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_transactions_count")
+
+	// Get the count
+	return getTransactionsCountByXpubID(
+		ctx, rawXpubKey, metadata, conditions,
+		c.DefaultModelOptions(WithXPub(rawXpubKey))...,
+	)
+}
+
+// DeleteXpubMetadata will delete the metadata of the xPub from a transaction
+//
+// ctx is the context
+// rawXpubKey is the raw xPub key
+// txID is the transaction ID
+// keys are the metadata keys to delete (all the metadata of the xPub if empty)
+func (c *Client) DeleteXpubMetadata(ctx context.Context, rawXpubKey, txID string,
+	keys []string) (*Transaction, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "delete_xpub_metadata")
+
+	// Get the transaction (without the xPub, the global metadata is not merged into the xPub metadata)
+	transaction, err := getTransactionByID(ctx, "", txID, c.DefaultModelOptions()...)
+	if err != nil {
+		return nil, err
+	} else if transaction == nil || !transaction.IsXpubAssociated(rawXpubKey) {
+		return nil, ErrMissingTransaction
+	}
+
+	// Remove the keys (or all the metadata) of the xPub
+	xPubID := utils.Hash(rawXpubKey)
+	if metadata, ok := transaction.XpubMetadata[xPubID]; ok {
+		for _, key := range keys {
+			delete(metadata, key)
+		}
+		if len(keys) == 0 || len(metadata) == 0 {
+			delete(transaction.XpubMetadata, xPubID)
+		}
+		if err = transaction.Save(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Return the transaction for the xPub
+	transaction.rawXpubKey = rawXpubKey
+	return transaction, nil
+}
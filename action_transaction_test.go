@@ -0,0 +1,397 @@
+package bux
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestDraftTransaction will create the xPub, the utxos and a draft transaction (reserves utxos)
+func createTestDraftTransaction(ctx context.Context, t *testing.T, client ClientInterface) *DraftTransaction {
+	_, err := client.NewXpub(ctx, testXPub)
+	require.NoError(t, err)
+
+	destination := newDestination(testXPubID, testLockingScript, append(client.DefaultModelOptions(), New())...)
+	require.NoError(t, destination.Save(ctx))
+
+	createTestUtxos(ctx, client)
+
+	var draftTransaction *DraftTransaction
+	draftTransaction, err = client.NewTransaction(ctx, testXPub, &TransactionConfig{
+		Outputs: []*TransactionOutput{{
+			Satoshis: 1000,
+			To:       "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND",
+		}},
+	}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, draftTransaction)
+
+	return draftTransaction
+}
+
+// TestClient_CancelDraftTransaction will test the method CancelDraftTransaction()
+func TestClient_CancelDraftTransaction(t *testing.T) {
+
+	t.Run("cancel and release utxos", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		draftTransaction := createTestDraftTransaction(ctx, t, client)
+
+		utxos, err := GetSpendableUtxos(ctx, testXPubID, utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.Len(t, utxos, 4)
+
+		var canceled *DraftTransaction
+		canceled, err = client.CancelDraftTransaction(ctx, testXPub, draftTransaction.ID)
+		require.NoError(t, err)
+		require.NotNil(t, canceled)
+		assert.Equal(t, DraftStatusCanceled, canceled.Status)
+
+		utxos, err = GetSpendableUtxos(ctx, testXPubID, utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Len(t, utxos, 5)
+
+		// Can only be canceled once
+		_, err = client.CancelDraftTransaction(ctx, testXPub, draftTransaction.ID)
+		require.ErrorIs(t, err, ErrDraftNotActive)
+	})
+
+	t.Run("draft of another xpub", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		draftTransaction := createTestDraftTransaction(ctx, t, client)
+
+		masterKey, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		var rawXPub string
+		rawXPub, err = bitcoin.GetExtendedPublicKey(masterKey)
+		require.NoError(t, err)
+
+		_, err = client.CancelDraftTransaction(ctx, rawXPub, draftTransaction.ID)
+		require.ErrorIs(t, err, ErrDraftNotFound)
+	})
+}
+
+// TestClient_ExtendDraftTransaction will test the method ExtendDraftTransaction()
+func TestClient_ExtendDraftTransaction(t *testing.T) {
+
+	t.Run("extend the expiration", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		draftTransaction := createTestDraftTransaction(ctx, t, client)
+
+		extended, err := client.ExtendDraftTransaction(ctx, testXPub, draftTransaction.ID, 10*time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, extended)
+		assert.Equal(t, DraftStatusDraft, extended.Status)
+		assert.True(t, extended.ExpiresAt.After(draftTransaction.ExpiresAt))
+
+		var saved *DraftTransaction
+		saved, err = getDraftTransactionID(ctx, testXPubID, draftTransaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, saved)
+		assert.True(t, saved.ExpiresAt.After(time.Now().Add(5*time.Minute)))
+
+		// Utxos are still reserved
+		var utxos []*Utxo
+		utxos, err = GetSpendableUtxos(ctx, testXPubID, utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Len(t, utxos, 4)
+	})
+
+	t.Run("canceled draft", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		draftTransaction := createTestDraftTransaction(ctx, t, client)
+
+		_, err := client.CancelDraftTransaction(ctx, testXPub, draftTransaction.ID)
+		require.NoError(t, err)
+
+		_, err = client.ExtendDraftTransaction(ctx, testXPub, draftTransaction.ID, 10*time.Minute)
+		require.ErrorIs(t, err, ErrDraftNotActive)
+	})
+
+	t.Run("unknown draft", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, err := client.ExtendDraftTransaction(ctx, testXPub, testDraftID, 10*time.Minute)
+		require.ErrorIs(t, err, ErrDraftNotFound)
+	})
+}
+
+// createTestChildPaysForParent will create a parent (rejected for the fee) and a recorded child paying its fee
+func createTestChildPaysForParent(t *testing.T, chainState *chainStateRejectBroadcast) (context.Context,
+	ClientInterface, func(), *Transaction, *Transaction) {
+
+	masterKey, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	var signer Signer
+	signer, err = NewXPrivSigner(masterKey.String())
+	require.NoError(t, err)
+
+	ctx, client, deferMe := CreateTestSQLiteClient(
+		t, false, true,
+		WithCustomTaskManager(&taskManagerMockBase{}),
+		WithCustomChainstate(chainState),
+		WithSigner(signer),
+	)
+
+	var rawXPub string
+	rawXPub, err = bitcoin.GetExtendedPublicKey(masterKey)
+	require.NoError(t, err)
+	_, err = client.NewXpub(ctx, rawXPub)
+	require.NoError(t, err)
+
+	var destination *Destination
+	destination, err = client.NewDestination(
+		ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+	)
+	require.NoError(t, err)
+	_, err = client.RecordTransaction(ctx, rawXPub,
+		CreateFakeFundingTransaction(t, masterKey, []*Destination{destination}, 10000), "",
+	)
+	require.NoError(t, err)
+
+	chainState.reject = true
+	var parent *Transaction
+	parent, err = client.SendTransaction(ctx, rawXPub, &TransactionConfig{
+		FeeUnit: &utils.FeeUnit{Satoshis: 1, Bytes: 1000},
+		Outputs: []*TransactionOutput{{
+			Satoshis: 5000,
+			To:       "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND",
+		}},
+	}, nil)
+	require.NoError(t, err)
+	chainState.reject = false
+
+	var draft *DraftTransaction
+	draft, err = client.BumpTransactionFee(ctx, rawXPub, parent.ID, &utils.FeeUnit{Satoshis: 500, Bytes: 1000}, nil)
+	require.NoError(t, err)
+	var txHex string
+	txHex, err = signer.SignDraftTransaction(ctx, draft)
+	require.NoError(t, err)
+	var child *Transaction
+	child, err = client.RecordTransaction(ctx, rawXPub, txHex, draft.ID)
+	require.NoError(t, err)
+
+	chainState.broadcasts = nil
+	return ctx, client, deferMe, parent, child
+}
+
+// TestClient_BumpTransactionFee will test the method BumpTransactionFee()
+func TestClient_BumpTransactionFee(t *testing.T) {
+
+	t.Run("unknown transaction", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, err := client.BumpTransactionFee(ctx, testXPub, testTxID, nil, nil)
+		require.ErrorIs(t, err, ErrMissingTransaction)
+	})
+
+	t.Run("child pays for the stuck parent", func(t *testing.T) {
+		masterKey, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		var signer Signer
+		signer, err = NewXPrivSigner(masterKey.String())
+		require.NoError(t, err)
+
+		chainState := &chainStateRejectBroadcast{}
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(chainState),
+			WithSigner(signer),
+		)
+		defer deferMe()
+
+		var rawXPub string
+		rawXPub, err = bitcoin.GetExtendedPublicKey(masterKey)
+		require.NoError(t, err)
+		_, err = client.NewXpub(ctx, rawXPub)
+		require.NoError(t, err)
+
+		var destination *Destination
+		destination, err = client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+		_, err = client.RecordTransaction(ctx, rawXPub,
+			CreateFakeFundingTransaction(t, masterKey, []*Destination{destination}, 10000), "",
+		)
+		require.NoError(t, err)
+
+		// Parent is rejected (fee too low), the broadcast keeps being retried
+		chainState.reject = true
+		var parent *Transaction
+		parent, err = client.SendTransaction(ctx, rawXPub, &TransactionConfig{
+			FeeUnit: &utils.FeeUnit{Satoshis: 1, Bytes: 1000},
+			Outputs: []*TransactionOutput{{
+				Satoshis: 5000,
+				To:       "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND",
+			}},
+		}, nil)
+		require.NoError(t, err)
+		var syncTx *SyncTransaction
+		syncTx, err = getSyncTransactionByID(ctx, parent.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, SyncStatusReady, syncTx.BroadcastStatus)
+		assert.Equal(t, SyncStatusError, syncTx.SyncStatus)
+
+		// Child spends the change of the parent, paying the missing fee of the parent
+		chainState.reject = false
+		feeUnit := &utils.FeeUnit{Satoshis: 500, Bytes: 1000}
+		var draft *DraftTransaction
+		draft, err = client.BumpTransactionFee(ctx, rawXPub, parent.ID, feeUnit, nil)
+		require.NoError(t, err)
+		require.NotNil(t, draft)
+		assert.Equal(t, parent.ID, draft.Configuration.ParentID)
+		require.Len(t, draft.Configuration.Inputs, 1)
+		assert.Equal(t, parent.ID, draft.Configuration.Inputs[0].TransactionID)
+		assert.Equal(t, draft.Configuration.Inputs[0].Destination.Address, draft.Configuration.SendAllTo)
+		parentFee := uint64(len(parent.Hex)/2)/2 + uint64(len(parent.Hex)/2)%2 - parent.Fee
+		assert.Equal(t, parentFee, draft.parentFee)
+		assert.Equal(t, draft.estimateFee(feeUnit), draft.Configuration.Fee)
+		assert.Greater(t, draft.Configuration.Fee, parentFee)
+
+		// No other change to spend
+		_, err = client.BumpTransactionFee(ctx, rawXPub, parent.ID, feeUnit, nil)
+		require.ErrorIs(t, err, ErrMissingChangeUtxos)
+
+		// Record the child (linked to the parent)
+		var txHex string
+		txHex, err = signer.SignDraftTransaction(ctx, draft)
+		require.NoError(t, err)
+		var child *Transaction
+		child, err = client.RecordTransaction(ctx, rawXPub, txHex, draft.ID)
+		require.NoError(t, err)
+		assert.Equal(t, parent.ID, child.ParentID)
+
+		// The parent is broadcast with the child
+		chainState.broadcasts = nil
+		syncTx, err = getSyncTransactionByID(ctx, child.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NoError(t, processBroadcastTransaction(ctx, syncTx))
+		assert.Equal(t, []string{parent.ID, child.ID}, chainState.broadcasts)
+		assert.Equal(t, SyncStatusComplete, syncTx.BroadcastStatus)
+		syncTx, err = getSyncTransactionByID(ctx, parent.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, SyncStatusComplete, syncTx.BroadcastStatus)
+	})
+
+	t.Run("parent rejected for the fee - known after the child", func(t *testing.T) {
+		chainState := &chainStateRejectBroadcast{}
+		ctx, client, deferMe, parent, child := createTestChildPaysForParent(t, chainState)
+		defer deferMe()
+
+		chainState.failures = map[string]error{parent.ID: chainstate.ErrFeeTooLow}
+		syncTx, err := getSyncTransactionByID(ctx, child.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NoError(t, processBroadcastTransaction(ctx, syncTx))
+		assert.Equal(t, []string{parent.ID, child.ID}, chainState.broadcasts)
+		assert.Equal(t, SyncStatusComplete, syncTx.BroadcastStatus)
+
+		syncTx, err = getSyncTransactionByID(ctx, parent.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, SyncStatusComplete, syncTx.BroadcastStatus)
+	})
+
+	t.Run("parent broadcast failure - child is not broadcast", func(t *testing.T) {
+		chainState := &chainStateRejectBroadcast{}
+		ctx, client, deferMe, parent, child := createTestChildPaysForParent(t, chainState)
+		defer deferMe()
+
+		chainState.failures = map[string]error{parent.ID: errors.New("broadcast failed on all providers")}
+		syncTx, err := getSyncTransactionByID(ctx, child.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NoError(t, processBroadcastTransaction(ctx, syncTx))
+		assert.Equal(t, []string{parent.ID}, chainState.broadcasts)
+
+		syncTx, err = getSyncTransactionByID(ctx, child.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, SyncStatusReady, syncTx.BroadcastStatus)
+		assert.Equal(t, SyncStatusError, syncTx.SyncStatus)
+		assert.Contains(t, syncTx.Results.LastMessage, "parent broadcast error")
+		syncTx, err = getSyncTransactionByID(ctx, parent.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.NotEqual(t, SyncStatusComplete, syncTx.BroadcastStatus)
+	})
+
+	t.Run("parent double spent - child is conflicted", func(t *testing.T) {
+		chainState := &chainStateRejectBroadcast{}
+		ctx, client, deferMe, parent, child := createTestChildPaysForParent(t, chainState)
+		defer deferMe()
+
+		chainState.failures = map[string]error{parent.ID: chainstate.ErrDoubleSpend}
+		syncTx, err := getSyncTransactionByID(ctx, child.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NoError(t, processBroadcastTransaction(ctx, syncTx))
+		assert.Equal(t, []string{parent.ID}, chainState.broadcasts)
+
+		assertTestTransactionConflicted(ctx, t, client, parent.ID)
+		assertTestTransactionConflicted(ctx, t, client, child.ID)
+	})
+}
+
+// TestClient_DeleteXpubMetadata will test the method DeleteXpubMetadata()
+func TestClient_DeleteXpubMetadata(t *testing.T) {
+
+	// addTestXpubMetadata will add metadata for the xPub to the transaction
+	addTestXpubMetadata := func(ctx context.Context, t *testing.T, client ClientInterface,
+		rawXPub, txID string) {
+		transaction, err := getTransactionByID(ctx, rawXPub, txID, client.DefaultModelOptions(WithXPub(rawXPub))...)
+		require.NoError(t, err)
+		require.NotNil(t, transaction)
+		transaction.Metadata = Metadata{"key-1": "value-1", "key-2": "value-2"}
+		require.NoError(t, transaction.Save(ctx))
+	}
+
+	t.Run("delete keys", func(t *testing.T) {
+		ctx, client, deferMe, _, rawXPub, transaction := createTestDoubleSpendClient(t, &chainStateDoubleSpend{})
+		defer deferMe()
+
+		addTestXpubMetadata(ctx, t, client, rawXPub, transaction.ID)
+
+		updated, err := client.DeleteXpubMetadata(ctx, rawXPub, transaction.ID, []string{"key-1"})
+		require.NoError(t, err)
+		assert.Equal(t, Metadata{"key-2": "value-2"}, updated.XpubMetadata[utils.Hash(rawXPub)])
+
+		updated, err = client.GetTransaction(ctx, rawXPub, transaction.ID)
+		require.NoError(t, err)
+		assert.Equal(t, Metadata{"key-2": "value-2"}, updated.XpubMetadata[utils.Hash(rawXPub)])
+	})
+
+	t.Run("delete all the metadata", func(t *testing.T) {
+		ctx, client, deferMe, _, rawXPub, transaction := createTestDoubleSpendClient(t, &chainStateDoubleSpend{})
+		defer deferMe()
+
+		addTestXpubMetadata(ctx, t, client, rawXPub, transaction.ID)
+
+		_, err := client.DeleteXpubMetadata(ctx, rawXPub, transaction.ID, nil)
+		require.NoError(t, err)
+
+		var updated *Transaction
+		updated, err = client.GetTransaction(ctx, rawXPub, transaction.ID)
+		require.NoError(t, err)
+		assert.NotContains(t, updated.XpubMetadata, utils.Hash(rawXPub))
+	})
+
+	t.Run("transaction of another xPub", func(t *testing.T) {
+		ctx, client, deferMe, _, rawXPub, transaction := createTestDoubleSpendClient(t, &chainStateDoubleSpend{})
+		defer deferMe()
+
+		addTestXpubMetadata(ctx, t, client, rawXPub, transaction.ID)
+		_, _, otherXPub := CreateNewXPub(ctx, t, client)
+
+		_, err := client.DeleteXpubMetadata(ctx, otherXPub, transaction.ID, nil)
+		require.ErrorIs(t, err, ErrMissingTransaction)
+	})
+}
@@ -3,21 +3,22 @@ package bux
 import (
 	"context"
 
+	"github.com/BuxOrg/bux/datastore"
 	"github.com/BuxOrg/bux/utils"
 )
 
 // GetUtxos will get utxos based on an xPub
-func (c *Client) GetUtxos(ctx context.Context, xPubKey string) ([]*Utxo, error) {
+//
+// queryParams is the page, page size and sorting of the results (nil is all results)
+func (c *Client) GetUtxos(ctx context.Context, xPubKey string, queryParams *datastore.QueryParams) ([]*Utxo, error) {
 
 	// Check for existing NewRelic transaction
 	ctx = c.GetOrStartTxn(ctx, "get_utxos")
 
 	// Get the utxos
-	// todo: add params for: page size, page, orderByField, sortDirection (right now it is unlimited)
 	utxos, err := getUtxosByXpubID(
 		ctx, utils.Hash(xPubKey),
-		0, 0,
-		"", "",
+		queryParams,
 		c.DefaultModelOptions()...,
 	)
 	if err != nil {
@@ -27,6 +28,18 @@ func (c *Client) GetUtxos(ctx context.Context, xPubKey string) ([]*Utxo, error)
 	return utxos, nil
 }
 
+// GetUtxosCount will get a count of the utxos based on an xPub
+func (c *Client) GetUtxosCount(ctx context.Context, xPubKey string) (int64, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "get_utxos_count")
+
+	// Get the count
+	return getUtxosCountByXpubID(
+		ctx, utils.Hash(xPubKey), c.DefaultModelOptions()...,
+	)
+}
+
 // GetUtxo will get a single utxo based on an xPub, the tx ID and the outputIndex
 func (c *Client) GetUtxo(ctx context.Context, xPubKey, txID string, outputIndex uint32) (*Utxo, error) {
 
@@ -77,3 +77,51 @@ func (c *Client) GetXpubByID(ctx context.Context, xPubID string) (*Xpub, error)
 	// Return the model
 	return xPub, nil
 }
+
+// DeleteXpub will (soft) delete an xPub
+//
+// The destinations, access keys and paymail addresses of the xPub are deleted as well
+func (c *Client) DeleteXpub(ctx context.Context, xPubKey string) error {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "delete_xpub")
+
+	// Get the xPub (by key - converts to id)
+	xPub, err := getXpub(
+		ctx, xPubKey, c.DefaultModelOptions()...,
+	)
+	if err != nil {
+		return err
+	} else if xPub == nil {
+		return ErrMissingXpub
+	}
+
+	// Mark the xPub (and related models) as deleted
+	return xPub.Delete(ctx)
+}
+
+// RestoreXpub will restore a (soft) deleted xPub
+//
+// The destinations, access keys and paymail addresses that were deleted with the xPub are restored as well
+func (c *Client) RestoreXpub(ctx context.Context, xPubKey string) (*Xpub, error) {
+
+	// Check for existing NewRelic transaction
+	ctx = c.GetOrStartTxn(ctx, "restore_xpub")
+
+	// Get the xPub (including deleted)
+	xPub, err := getXpub(
+		ctx, xPubKey, c.DefaultModelOptions(WithDeleted())...,
+	)
+	if err != nil {
+		return nil, err
+	} else if xPub == nil {
+		return nil, ErrMissingXpub
+	}
+
+	// Remove the deleted mark (and restore the related models)
+	if err = xPub.Restore(ctx); err != nil {
+		return nil, err
+	}
+
+	return xPub, nil
+}
@@ -3,13 +3,16 @@ package bux
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/BuxOrg/bux/cachestore"
 	"github.com/BuxOrg/bux/utils"
 	"github.com/bitcoinschema/go-bitcoin/v2"
 	"github.com/libsv/go-bk/bip32"
@@ -19,6 +22,12 @@ import (
 // AuthenticateRequest will parse the incoming request for the associated authentication header,
 // and it will check the Key/Signature
 //
+// Access keys are checked for expiration and for the scope required by the request (see SetRequiredScope)
+//
+// Admins are the adminXPubs and the admins in the Datastore (see AddAdmin)
+//
+// Requests are rate limited per xPub or access key using the required scope (see WithRateLimit)
+//
 // Sets req.Context(xpub) and req.Context(xpub_hash)
 func (c *Client) AuthenticateRequest(ctx context.Context, req *http.Request, adminXPubs []string,
 	adminRequired, requireSigning, signingDisabled bool) (*http.Request, error) {
@@ -29,14 +38,17 @@ func (c *Client) AuthenticateRequest(ctx context.Context, req *http.Request, adm
 		return req, ErrMissingAuthHeader
 	}
 
-	// Check for admin key
-	if adminRequired {
-		if !utils.StringInSlice(xPubOrAccessKey, adminXPubs) {
+	// Check for admin key (access keys are checked after loading the key)
+	if adminRequired && isXPubKey(xPubOrAccessKey) {
+		if isAdmin, err := c.isAdmin(ctx, utils.Hash(xPubOrAccessKey), adminXPubs); err != nil {
+			return req, err
+		} else if !isAdmin {
 			return req, ErrNotAdminKey
 		}
 	}
 
 	// adminRequired will always force checking of a signature
+	var accessKey *AccessKey
 	if (requireSigning || adminRequired) && !signingDisabled {
 		if req.Body == nil {
 			return req, ErrMissingBody
@@ -59,7 +71,7 @@ func (c *Client) AuthenticateRequest(ctx context.Context, req *http.Request, adm
 			BodyContents: string(b),
 			Signature:    req.Header.Get(AuthSignature),
 		}
-		if err = c.checkSignature(ctx, xPubOrAccessKey, authData); err != nil {
+		if accessKey, err = c.checkSignature(ctx, xPubOrAccessKey, authData); err != nil {
 			return req, err
 		}
 	} else {
@@ -70,27 +82,101 @@ func (c *Client) AuthenticateRequest(ctx context.Context, req *http.Request, adm
 		}
 	}
 
+	// Access keys are limited to their scopes, unless the access key is an admin itself
+	// (admin scoped access keys need to belong to an admin xPub)
+	if accessKey != nil {
+		if err := c.checkAccessKeyScope(ctx, req, accessKey, adminXPubs, adminRequired); err != nil {
+			return req, err
+		} else if req, err = c.allowRequest(ctx, req, accessKey.ID, adminRequired); err != nil {
+			return req, err
+		}
+		req = setOnRequest(req, accessKeyIDKey, accessKey.ID)
+		return setOnRequest(setOnRequest(req, xPubKey, xPubOrAccessKey), xPubHashKey, accessKey.XpubID), nil
+	}
+
+	// Rate limits of the xPub
+	var err error
+	if req, err = c.allowRequest(ctx, req, utils.Hash(xPubOrAccessKey), adminRequired); err != nil {
+		return req, err
+	}
+
 	// Set the data back onto the request
 	return setOnRequest(setOnRequest(req, xPubKey, xPubOrAccessKey), xPubHashKey, utils.Hash(xPubOrAccessKey)), nil
 }
 
 // checkSignature check the signature for the provided auth payload
-func (c *Client) checkSignature(ctx context.Context, xPubOrAccessKey string, auth *AuthPayload) error {
+//
+// Returns the access key if the request was signed with an access key
+func (c *Client) checkSignature(ctx context.Context, xPubOrAccessKey string, auth *AuthPayload) (*AccessKey, error) {
 
 	// Check that we have the basic signature components
-	if err := checkSignatureRequirements(auth); err != nil {
-		return err
+	if err := checkSignatureRequirements(auth, c.options.authSignatureTTL); err != nil {
+		return nil, err
 	}
 
 	// Check xPub vs Access Key
-	if strings.Contains(xPubOrAccessKey, "xpub") && len(xPubOrAccessKey) > 64 {
-		return verifyKeyXPub(xPubOrAccessKey, auth)
+	var accessKey *AccessKey
+	if isXPubKey(xPubOrAccessKey) {
+		if err := verifyKeyXPub(xPubOrAccessKey, auth); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if accessKey, err = verifyAccessKey(ctx, xPubOrAccessKey, auth, c.DefaultModelOptions()...); err != nil {
+			return nil, err
+		}
+	}
+
+	// A valid signature can only be used once (replay protection)
+	if err := c.checkAuthNonce(ctx, xPubOrAccessKey, auth); err != nil {
+		return nil, err
 	}
-	return verifyAccessKey(ctx, xPubOrAccessKey, auth, c.DefaultModelOptions()...)
+	return accessKey, nil
+}
+
+// checkAccessKeyScope will check that the access key has access to the request
+func (c *Client) checkAccessKeyScope(ctx context.Context, req *http.Request, accessKey *AccessKey,
+	adminXPubs []string, adminRequired bool) error {
+
+	// Access key admins have access to everything
+	if adminRequired {
+		if isAdmin, err := c.isAdmin(ctx, accessKey.ID, nil); err != nil || isAdmin {
+			return err
+		}
+	}
+
+	if !accessKey.HasScope(getRequiredScope(req, adminRequired)) {
+		return ErrAccessKeyScope
+	} else if adminRequired {
+		if isAdmin, err := c.isAdmin(ctx, accessKey.XpubID, adminXPubs); err != nil {
+			return err
+		} else if !isAdmin {
+			return ErrNotAdminKey
+		}
+	}
+	return nil
+}
+
+// isAdmin will return true if the ID (hash of the xPub or access key) is an admin
+//
+// Admins are the given admin xPubs and the admins in the Datastore
+func (c *Client) isAdmin(ctx context.Context, id string, adminXPubs []string) (bool, error) {
+	if isAdminXpubID(id, adminXPubs) {
+		return true, nil
+	}
+
+	// todo: add caching in the future, faster than DB
+	admin, err := getAdmin(ctx, id, c.DefaultModelOptions()...)
+	if err != nil {
+		return false, err
+	}
+	return admin != nil, nil
 }
 
 // checkSignatureRequirements will check the payload for basic signature requirements
-func checkSignatureRequirements(auth *AuthPayload) error {
+//
+// ttl is the time window of the signature (from the auth time)
+func checkSignatureRequirements(auth *AuthPayload, ttl time.Duration) error {
 
 	// Check that we have a signature
 	if auth == nil || auth.Signature == "" {
@@ -103,9 +189,38 @@ func checkSignatureRequirements(auth *AuthPayload) error {
 	}
 
 	// Check the auth timestamp
-	if time.Now().UTC().After(time.UnixMilli(auth.AuthTime).Add(AuthSignatureTTL)) {
+	if time.Now().UTC().After(time.UnixMilli(auth.AuthTime).Add(ttl)) {
 		return ErrSignatureExpired
 	}
+
+	// Check that we have a nonce (used for the replay protection)
+	if auth.AuthNonce == "" {
+		return ErrMissingAuthNonce
+	}
+	return nil
+}
+
+// checkAuthNonce will record the nonce of the signed request, a (key, nonce) pair can only be used once
+//
+// The nonce is remembered in the Cachestore until the signature expires
+func (c *Client) checkAuthNonce(ctx context.Context, xPubOrAccessKey string, auth *AuthPayload) error {
+
+	// Remaining time of the signature (in seconds, rounded up)
+	remaining := time.Until(time.UnixMilli(auth.AuthTime).Add(c.options.authSignatureTTL))
+	ttl := int64(math.Ceil(remaining.Seconds()))
+	if ttl < 1 {
+		ttl = 1
+	}
+
+	// The lock fails if the nonce was already used (the secret is random)
+	if _, err := c.Cachestore().WriteLock(
+		ctx, cacheKeyAuthNonce+utils.Hash(xPubOrAccessKey+auth.AuthNonce), ttl,
+	); err != nil {
+		if errors.Is(err, cachestore.ErrLockExists) {
+			return ErrAuthNonceUsed
+		}
+		return err
+	}
 	return nil
 }
 
@@ -150,17 +265,19 @@ func verifyKeyXPub(xPub string, auth *AuthPayload) error {
 }
 
 // verifyAccessKey will verify the access key and the signature payload
-func verifyAccessKey(ctx context.Context, key string, auth *AuthPayload, opts ...ModelOps) error {
+func verifyAccessKey(ctx context.Context, key string, auth *AuthPayload, opts ...ModelOps) (*AccessKey, error) {
 
 	// Get access key from DB
 	// todo: add caching in the future, faster than DB
 	accessKey, err := GetAccessKey(ctx, utils.Hash(key), opts...)
 	if err != nil {
-		return err
+		return nil, err
 	} else if accessKey == nil {
-		return ErrUnknownAccessKey
+		return nil, ErrUnknownAccessKey
 	} else if accessKey.RevokedAt.Valid {
-		return ErrAccessKeyRevoked
+		return nil, ErrAccessKeyRevoked
+	} else if accessKey.IsExpired() {
+		return nil, ErrAccessKeyExpired
 	}
 
 	// todo: should this be the access key or the xpub id?
@@ -168,7 +285,7 @@ func verifyAccessKey(ctx context.Context, key string, auth *AuthPayload, opts ..
 	if address, err = bitcoin.GetAddressFromPubKeyString(
 		key, true,
 	); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Return the error if verification fails
@@ -177,9 +294,9 @@ func verifyAccessKey(ctx context.Context, key string, auth *AuthPayload, opts ..
 		auth.Signature,
 		getSigningMessage(key, auth),
 	); err != nil {
-		return ErrSignatureInvalid
+		return nil, ErrSignatureInvalid
 	}
-	return nil
+	return accessKey, nil
 }
 
 // SetSignature will set the signature on the header for the request
@@ -232,3 +349,16 @@ func GetXpubFromRequest(req *http.Request) (string, bool) {
 func GetXpubHashFromRequest(req *http.Request) (string, bool) {
 	return getFromRequest(req, xPubHashKey)
 }
+
+// GetAccessKeyIDFromRequest gets the stored access key ID from the request if found (signed with an access key)
+func GetAccessKeyIDFromRequest(req *http.Request) (string, bool) {
+	return getFromRequest(req, accessKeyIDKey)
+}
+
+// SetRequiredScope will set the access key scope required by the request (checked in AuthenticateRequest)
+//
+// Admin requests always need the admin scope. Without a required scope, read (GET) requests need the
+// read only scope and all the other requests need an access key with full access
+func SetRequiredScope(req *http.Request, scope string) *http.Request {
+	return setOnRequest(req, requiredScopeKey, scope)
+}
@@ -30,7 +30,7 @@ const (
 	// AuthHeaderTime the time of the request, only valid for 30 seconds
 	AuthHeaderTime = "auth_time"
 
-	// AuthSignatureTTL is the max TTL for a signature to be valid
+	// AuthSignatureTTL is the default max TTL for a signature to be valid (see WithAuthSignatureTTL)
 	AuthSignatureTTL = 20 * time.Second
 )
 
@@ -48,10 +48,42 @@ type AuthPayload struct {
 type paramRequestKey string
 
 const (
-	xPubKey     paramRequestKey = "xpub"
-	xPubHashKey paramRequestKey = "xpub_hash"
+	accessKeyIDKey   paramRequestKey = "access_key_id"
+	rateLimitIDKey   paramRequestKey = "rate_limit_id"
+	requiredScopeKey paramRequestKey = "required_scope"
+	xPubKey          paramRequestKey = "xpub"
+	xPubHashKey      paramRequestKey = "xpub_hash"
 )
 
+// isXPubKey will return true if the key is an xPub (not an access key)
+func isXPubKey(xPubOrAccessKey string) bool {
+	return strings.Contains(xPubOrAccessKey, "xpub") && len(xPubOrAccessKey) > 64
+}
+
+// isAdminXpubID will return true if the xPub ID belongs to one of the (given) admin xPubs
+func isAdminXpubID(xPubID string, adminXPubs []string) bool {
+	for _, adminXPub := range adminXPubs {
+		if len(adminXPub) > 0 && utils.Hash(adminXPub) == xPubID {
+			return true
+		}
+	}
+	return false
+}
+
+// getRequiredScope will get the access key scope required by the request
+func getRequiredScope(req *http.Request, adminRequired bool) string {
+	if adminRequired {
+		return AccessKeyScopeAdmin
+	} else if scope, ok := getFromRequest(req, requiredScopeKey); ok {
+		return scope
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return AccessKeyScopeReadOnly
+	}
+	return ""
+}
+
 // createBodyHash will create the hash of the body, removing any carriage returns
 func createBodyHash(bodyContents string) string {
 	return utils.Hash(strings.TrimSuffix(bodyContents, "\n"))
@@ -111,6 +143,12 @@ func setOnRequest(req *http.Request, keyName paramRequestKey, value interface{})
 	return req.WithContext(context.WithValue(req.Context(), keyName, value))
 }
 
+// getAccessKeyIDFromContext gets the access key ID of an authenticated request from the context (req.Context())
+func getAccessKeyIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(accessKeyIDKey).(string)
+	return id
+}
+
 // getFromRequest gets the stored value from the request if found
 func getFromRequest(req *http.Request, key paramRequestKey) (v string, ok bool) {
 	v, ok = req.Context().Value(key).(string)
@@ -3,13 +3,17 @@ package bux
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/BuxOrg/bux/utils"
 	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/bip32"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -142,6 +146,96 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 		require.NotNil(t, req)
 	})
 
+	t.Run("admin from the Datastore", func(t *testing.T) {
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+
+		var rawXPub string
+		rawXPub, err = bitcoin.GetExtendedPublicKey(key)
+		require.NoError(t, err)
+
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		// authenticateAdmin will authenticate a new signed admin request
+		authenticateAdmin := func() error {
+			req, reqErr := http.NewRequestWithContext(
+				ctx, http.MethodPost, "", bytes.NewReader([]byte(testBodyContents)),
+			)
+			require.NoError(t, reqErr)
+			require.NoError(t, SetSignature(&req.Header, key, testBodyContents))
+			_, reqErr = client.AuthenticateRequest(ctx, req, nil, true, true, false)
+			return reqErr
+		}
+
+		require.ErrorIs(t, authenticateAdmin(), ErrNotAdminKey)
+
+		_, err = client.AddAdmin(ctx, rawXPub)
+		require.NoError(t, err)
+		require.NoError(t, authenticateAdmin())
+
+		require.NoError(t, client.RemoveAdmin(ctx, rawXPub))
+		require.ErrorIs(t, authenticateAdmin(), ErrNotAdminKey)
+	})
+
+	t.Run("error - replayed request", func(t *testing.T) {
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+
+		header := http.Header{}
+		require.NoError(t, SetSignature(&header, key, testBodyContents))
+
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		// newSignedRequest will create a request using the same signature (headers)
+		newSignedRequest := func() *http.Request {
+			req, reqErr := http.NewRequestWithContext(
+				context.Background(), http.MethodPost, "", bytes.NewReader([]byte(testBodyContents)),
+			)
+			require.NoError(t, reqErr)
+			req.Header = header.Clone()
+			return req
+		}
+
+		_, err = client.AuthenticateRequest(context.Background(), newSignedRequest(), nil, false, true, false)
+		require.NoError(t, err)
+
+		_, err = client.AuthenticateRequest(context.Background(), newSignedRequest(), nil, false, true, false)
+		require.ErrorIs(t, err, ErrAuthNonceUsed)
+
+		// A new signature (nonce) is valid
+		require.NoError(t, SetSignature(&header, key, testBodyContents))
+		_, err = client.AuthenticateRequest(context.Background(), newSignedRequest(), nil, false, true, false)
+		require.NoError(t, err)
+	})
+
+	t.Run("custom signature time window", func(t *testing.T) {
+		key, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+
+		var authData *AuthPayload
+		authData, err = createSignature(key, testBodyContents)
+		require.NoError(t, err)
+
+		// Signed 30 seconds ago (expired using the default window)
+		authData.BodyContents = testBodyContents
+		authData.AuthTime = time.Now().Add(-30 * time.Second).UnixMilli()
+		authData.Signature = signTestXPubPayload(t, key, authData)
+
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		_, err = client.(*Client).checkSignature(context.Background(), authData.xPub, authData)
+		require.ErrorIs(t, err, ErrSignatureExpired)
+
+		_, client, deferMe = CreateTestSQLiteClient(t, false, false, WithAuthSignatureTTL(time.Minute))
+		defer deferMe()
+
+		_, err = client.(*Client).checkSignature(context.Background(), authData.xPub, authData)
+		require.NoError(t, err)
+	})
+
 	t.Run("admin key - signing disabled", func(t *testing.T) {
 		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "", bytes.NewReader([]byte(`{}`)))
 		require.NoError(t, err)
@@ -210,6 +304,192 @@ func TestClient_AuthenticateRequest(t *testing.T) {
 	})
 }
 
+// testAccessKeyPublicKey will return the public key (auth header) of a new access key
+func testAccessKeyPublicKey(t *testing.T, accessKey *AccessKey) string {
+	privateKey, err := bitcoin.PrivateKeyFromString(accessKey.Key)
+	require.NoError(t, err)
+	return hex.EncodeToString(privateKey.PubKey().SerialiseCompressed())
+}
+
+// setTestAccessKeySignature will sign the request (headers) using the private key of the access key
+func setTestAccessKeySignature(t *testing.T, header *http.Header, accessKey *AccessKey, bodyString string) {
+	publicKey := testAccessKeyPublicKey(t, accessKey)
+
+	var err error
+	auth := &AuthPayload{
+		AuthHash: utils.Hash(bodyString),
+		AuthTime: time.Now().UnixMilli(),
+	}
+	auth.AuthNonce, err = utils.RandomHex(32)
+	require.NoError(t, err)
+	auth.Signature, err = bitcoin.SignMessage(accessKey.Key, getSigningMessage(publicKey, auth), true)
+	require.NoError(t, err)
+
+	header.Set(AuthHeader, publicKey)
+	header.Set(AuthHeaderHash, auth.AuthHash)
+	header.Set(AuthHeaderNonce, auth.AuthNonce)
+	header.Set(AuthHeaderTime, fmt.Sprintf("%d", auth.AuthTime))
+	header.Set(AuthSignature, auth.Signature)
+}
+
+// signTestXPubPayload will sign the auth payload using the (nonce derived) key of the xPriv
+func signTestXPubPayload(t *testing.T, xPriv *bip32.ExtendedKey, auth *AuthPayload) string {
+	key, err := utils.DeriveChildKeyFromHex(xPriv, auth.AuthNonce)
+	require.NoError(t, err)
+
+	var privateKey *bec.PrivateKey
+	privateKey, err = bitcoin.GetPrivateKeyFromHDKey(key)
+	require.NoError(t, err)
+
+	var signature string
+	signature, err = bitcoin.SignMessage(
+		hex.EncodeToString(privateKey.Serialise()), getSigningMessage(auth.xPub, auth), true,
+	)
+	require.NoError(t, err)
+	return signature
+}
+
+// TestClient_AuthenticateRequest_AccessKey will test the method AuthenticateRequest() using access keys
+func TestClient_AuthenticateRequest_AccessKey(t *testing.T) {
+
+	// authenticateTestAccessKey will authenticate a signed request of the access key
+	authenticateTestAccessKey := func(ctx context.Context, t *testing.T, client ClientInterface,
+		accessKey *AccessKey, method, scope string, adminXPubs []string, adminRequired bool) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, "", bytes.NewReader([]byte(testBodyContents)))
+		require.NoError(t, err)
+		setTestAccessKeySignature(t, &req.Header, accessKey, testBodyContents)
+		if len(scope) > 0 {
+			req = SetRequiredScope(req, scope)
+		}
+		return client.AuthenticateRequest(ctx, req, adminXPubs, adminRequired, true, false)
+	}
+
+	t.Run("full access", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, xPub, rawXPub := CreateNewXPub(ctx, t, client)
+		accessKey, err := client.NewAccessKey(ctx, rawXPub, nil, 0)
+		require.NoError(t, err)
+
+		var req *http.Request
+		req, err = authenticateTestAccessKey(ctx, t, client, accessKey, http.MethodPost, "", nil, false)
+		require.NoError(t, err)
+
+		xPubID, ok := GetXpubHashFromRequest(req)
+		assert.True(t, ok)
+		assert.Equal(t, xPub.ID, xPubID)
+
+		var id string
+		id, ok = GetAccessKeyIDFromRequest(req)
+		assert.True(t, ok)
+		assert.Equal(t, accessKey.ID, id)
+
+		// Not an admin key
+		_, err = authenticateTestAccessKey(ctx, t, client, accessKey, http.MethodPost, "", []string{rawXPub}, true)
+		require.ErrorIs(t, err, ErrAccessKeyScope)
+	})
+
+	t.Run("read only", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		accessKey, err := client.NewAccessKey(ctx, rawXPub, []string{AccessKeyScopeReadOnly}, 0)
+		require.NoError(t, err)
+
+		_, err = authenticateTestAccessKey(ctx, t, client, accessKey, http.MethodGet, "", nil, false)
+		require.NoError(t, err)
+
+		_, err = authenticateTestAccessKey(ctx, t, client, accessKey, http.MethodPost, "", nil, false)
+		require.ErrorIs(t, err, ErrAccessKeyScope)
+
+		_, err = authenticateTestAccessKey(
+			ctx, t, client, accessKey, http.MethodPost, AccessKeyScopeCreateDraft, nil, false,
+		)
+		require.ErrorIs(t, err, ErrAccessKeyScope)
+	})
+
+	t.Run("create draft", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		accessKey, err := client.NewAccessKey(ctx, rawXPub, []string{AccessKeyScopeCreateDraft}, 0)
+		require.NoError(t, err)
+
+		_, err = authenticateTestAccessKey(ctx, t, client, accessKey, http.MethodGet, "", nil, false)
+		require.NoError(t, err)
+
+		_, err = authenticateTestAccessKey(
+			ctx, t, client, accessKey, http.MethodPost, AccessKeyScopeCreateDraft, nil, false,
+		)
+		require.NoError(t, err)
+
+		_, err = authenticateTestAccessKey(
+			ctx, t, client, accessKey, http.MethodPost, AccessKeyScopeRecordTransaction, nil, false,
+		)
+		require.ErrorIs(t, err, ErrAccessKeyScope)
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		accessKey, err := client.NewAccessKey(ctx, rawXPub, []string{AccessKeyScopeAdmin}, 0)
+		require.NoError(t, err)
+
+		_, err = authenticateTestAccessKey(ctx, t, client, accessKey, http.MethodPost, "", []string{rawXPub}, true)
+		require.NoError(t, err)
+
+		// The xPub of the key is not an admin
+		_, err = authenticateTestAccessKey(ctx, t, client, accessKey, http.MethodPost, "", []string{testXpub}, true)
+		require.ErrorIs(t, err, ErrNotAdminKey)
+	})
+
+	t.Run("access key admin", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		accessKey, err := client.NewAccessKey(ctx, rawXPub, []string{AccessKeyScopeReadOnly}, 0)
+		require.NoError(t, err)
+
+		_, err = authenticateTestAccessKey(ctx, t, client, accessKey, http.MethodPost, "", nil, true)
+		require.ErrorIs(t, err, ErrAccessKeyScope)
+
+		// The access key itself is an admin
+		_, err = client.AddAdmin(ctx, testAccessKeyPublicKey(t, accessKey))
+		require.NoError(t, err)
+
+		_, err = authenticateTestAccessKey(ctx, t, client, accessKey, http.MethodPost, "", nil, true)
+		require.NoError(t, err)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		accessKey, err := client.NewAccessKey(ctx, rawXPub, nil, time.Minute)
+		require.NoError(t, err)
+
+		_, err = authenticateTestAccessKey(ctx, t, client, accessKey, http.MethodGet, "", nil, false)
+		require.NoError(t, err)
+
+		// Expire the key
+		var savedKey *AccessKey
+		savedKey, err = GetAccessKey(ctx, accessKey.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		savedKey.ExpiresAt.Time = time.Now().UTC().Add(-time.Minute)
+		require.NoError(t, savedKey.Save(ctx))
+
+		_, err = authenticateTestAccessKey(ctx, t, client, accessKey, http.MethodGet, "", nil, false)
+		require.ErrorIs(t, err, ErrAccessKeyExpired)
+	})
+}
+
 // Test_verifyKeyXPub will test the method verifyKeyXPub()
 func Test_verifyKeyXPub(t *testing.T) {
 	t.Parallel()
@@ -222,7 +502,7 @@ func Test_verifyKeyXPub(t *testing.T) {
 	})
 
 	t.Run("error - missing auth signature", func(t *testing.T) {
-		err := checkSignatureRequirements(&AuthPayload{})
+		err := checkSignatureRequirements(&AuthPayload{}, AuthSignatureTTL)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrMissingSignature)
 	})
@@ -232,7 +512,7 @@ func Test_verifyKeyXPub(t *testing.T) {
 			AuthHash:     "bad-hash",
 			BodyContents: testBodyContents,
 			Signature:    testSignature,
-		})
+		}, AuthSignatureTTL)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrAuhHashMismatch)
 	})
@@ -243,11 +523,21 @@ func Test_verifyKeyXPub(t *testing.T) {
 			BodyContents: testBodyContents,
 			Signature:    testSignature,
 			AuthTime:     1643828414038,
-		})
+		}, AuthSignatureTTL)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrSignatureExpired)
 	})
 
+	t.Run("error - missing nonce", func(t *testing.T) {
+		err := checkSignatureRequirements(&AuthPayload{
+			AuthHash:     testSignatureAuthHash,
+			BodyContents: testBodyContents,
+			Signature:    testSignature,
+			AuthTime:     time.Now().UnixMilli(),
+		}, AuthSignatureTTL)
+		require.ErrorIs(t, err, ErrMissingAuthNonce)
+	})
+
 	t.Run("error - bad xpub", func(t *testing.T) {
 		err := verifyKeyXPub("invalid-key", &AuthPayload{
 			AuthHash:     testSignatureAuthHash,
@@ -264,7 +554,7 @@ func Test_verifyKeyXPub(t *testing.T) {
 			BodyContents: testBodyContents,
 			Signature:    testSignature,
 			AuthTime:     0,
-		})
+		}, AuthSignatureTTL)
 		require.Error(t, err)
 		assert.ErrorIs(t, err, ErrSignatureExpired)
 	})
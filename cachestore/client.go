@@ -2,6 +2,7 @@ package cachestore
 
 import (
 	"context"
+	"sync"
 
 	"github.com/OrlovEvgeny/go-mcache"
 	"github.com/dgraph-io/ristretto"
@@ -13,7 +14,8 @@ type (
 
 	// Client is the client (configuration)
 	Client struct {
-		options *clientOptions
+		bucketLock sync.Mutex // Lock for the in-memory token buckets (mCache/ristretto)
+		options    *clientOptions
 	}
 
 	// clientOptions holds all the configuration for the client
@@ -34,6 +34,9 @@ var ErrFailedToSet = errors.New("failed to set value in cache")
 // ErrTTWCannotBeEmpty is when the TTW field is empty
 var ErrTTWCannotBeEmpty = errors.New("the TTW value cannot be empty")
 
+// ErrTTLCannotBeEmpty is when the TTL field is empty
+var ErrTTLCannotBeEmpty = errors.New("the TTL value cannot be empty")
+
 // ErrInvalidRedisConfig is when the redis config is missing or invalid
 var ErrInvalidRedisConfig = errors.New("invalid redis config")
 
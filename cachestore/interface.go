@@ -22,6 +22,7 @@ type CacheService interface {
 	GetModel(ctx context.Context, key string, model interface{}) error
 	Set(ctx context.Context, key string, value interface{}, dependencies ...string) error
 	SetModel(ctx context.Context, key string, model interface{}, ttl time.Duration, dependencies ...string) error
+	TakeToken(ctx context.Context, key string, capacity int64, period time.Duration) (bool, error)
 }
 
 // ClientInterface is the cachestore interface
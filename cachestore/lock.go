@@ -31,7 +31,9 @@ func (c *Client) WriteLock(ctx context.Context, lockKey string, ttl int64) (stri
 		}
 		if locked, err = cache.WriteLock(
 			ctx, c.options.redis, lockKey, secret, ttl,
-		); err != nil {
+		); errors.Is(err, cache.ErrLockMismatch) {
+			return "", ErrLockExists
+		} else if err != nil {
 			return "", errors.Wrap(ErrLockCreateFailed, err.Error())
 		} else if !locked {
 			return "", ErrLockExists
@@ -39,7 +41,9 @@ func (c *Client) WriteLock(ctx context.Context, lockKey string, ttl int64) (stri
 	} else if c.Engine() == MCache { // Lock using MCache
 		if locked, err = writeLockMcache(
 			c.options.mCache, lockKey, secret, ttl,
-		); err != nil {
+		); errors.Is(err, cache.ErrLockMismatch) {
+			return "", ErrLockExists
+		} else if err != nil {
 			return "", errors.Wrap(ErrLockCreateFailed, err.Error())
 		} else if !locked {
 			return "", ErrLockExists
@@ -47,7 +51,9 @@ func (c *Client) WriteLock(ctx context.Context, lockKey string, ttl int64) (stri
 	} else if c.Engine() == Ristretto { // Lock using Ristretto
 		if locked, err = writeLockRistretto(
 			c.options.ristretto, lockKey, secret, baseCostPerKey, ttl,
-		); err != nil {
+		); errors.Is(err, cache.ErrLockMismatch) {
+			return "", ErrLockExists
+		} else if err != nil {
 			return "", errors.Wrap(ErrLockCreateFailed, err.Error())
 		} else if !locked {
 			return "", ErrLockExists
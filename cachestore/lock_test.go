@@ -59,7 +59,7 @@ func TestClient_WriteLock(t *testing.T) {
 			// Lock exists with different secret
 			secret, err = c.WriteLock(context.Background(), testKey, 30)
 			assert.Equal(t, "", secret)
-			assert.ErrorAs(t, err, &ErrLockExists)
+			assert.ErrorIs(t, err, ErrLockExists)
 		})
 
 		/*t.Run(testCase.name+" - engine not supported", func(t *testing.T) {
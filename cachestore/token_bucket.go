@@ -0,0 +1,140 @@
+package cachestore
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/OrlovEvgeny/go-mcache"
+	"github.com/dgraph-io/ristretto"
+	"github.com/gomodule/redigo/redis"
+)
+
+// takeTokenScript will refill the bucket (tokens and the last refill time in milliseconds) and take a token
+//
+// ARGV: capacity, period (milliseconds), now (milliseconds). Returns 1 if a token was taken.
+var takeTokenScript = redis.NewScript(1, `
+local capacity = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "refilled_at")
+local tokens = tonumber(bucket[1])
+local refilledAt = tonumber(bucket[2])
+if tokens == nil or refilledAt == nil then
+	tokens = capacity
+	refilledAt = now
+end
+if now > refilledAt then
+	tokens = math.min(capacity, tokens + (now - refilledAt) * capacity / period)
+	refilledAt = now
+end
+local taken = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	taken = 1
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "refilled_at", tostring(refilledAt))
+redis.call("PEXPIRE", KEYS[1], period)
+return taken
+`)
+
+// tokenBucket is an in-memory token bucket (mCache/ristretto)
+type tokenBucket struct {
+	refilledAt time.Time
+	tokens     float64
+}
+
+// TakeToken will (atomically) refill the token bucket of the given key and take a token
+//
+// The bucket holds up to capacity tokens and is refilled with capacity tokens per period (a new bucket is full).
+// Returns false if the bucket is empty, so no more than capacity tokens are taken in any burst.
+func (c *Client) TakeToken(ctx context.Context, key string, capacity int64, period time.Duration) (bool, error) {
+
+	// Sanitize the key (trailing or leading spaces)
+	key = strings.TrimSpace(key)
+
+	// Require a key to be present
+	if len(key) == 0 {
+		return false, ErrKeyRequired
+	} else if period <= 0 {
+		return false, ErrTTLCannotBeEmpty
+	} else if capacity <= 0 {
+		return false, nil
+	}
+
+	// Redis (script is atomic)
+	if c.Engine() == Redis {
+		conn, err := c.options.redis.GetConnectionWithContext(ctx)
+		if err != nil {
+			return false, err
+		}
+		defer c.options.redis.CloseConnection(conn)
+		return redis.Bool(takeTokenScript.Do(
+			conn, key, capacity, period.Milliseconds(), time.Now().UnixMilli(),
+		))
+	} else if c.Engine() == MCache {
+		return takeTokenMcache(c, c.options.mCache, key, capacity, period)
+	} else if c.Engine() == Ristretto {
+		return takeTokenRistretto(c, c.options.ristretto, key, capacity, period)
+	}
+
+	// Engine is not supported
+	return false, ErrEngineNotSupported
+}
+
+// takeTokenMcache will take a token from a bucket in mCache
+func takeTokenMcache(c *Client, mCache *mcache.CacheDriver, key string, capacity int64,
+	period time.Duration) (bool, error) {
+	c.bucketLock.Lock()
+	defer c.bucketLock.Unlock()
+
+	bucket, taken := getTokenBucket(mCache.Get(key)).take(capacity, period)
+	if err := mCache.Set(key, bucket, period); err != nil {
+		return false, err
+	}
+	return taken, nil
+}
+
+// takeTokenRistretto will take a token from a bucket in ristretto
+func takeTokenRistretto(c *Client, ristrettoClient *ristretto.Cache, key string, capacity int64,
+	period time.Duration) (bool, error) {
+	c.bucketLock.Lock()
+	defer c.bucketLock.Unlock()
+
+	bucket, taken := getTokenBucket(ristrettoClient.Get(key)).take(capacity, period)
+	if !ristrettoClient.SetWithTTL(key, bucket, baseCostPerKey, period) {
+		return false, ErrRistrettoSetFailed
+	}
+	ristrettoClient.Wait()
+	return taken, nil
+}
+
+// getTokenBucket will return the existing bucket (nil if not found)
+func getTokenBucket(data interface{}, found bool) *tokenBucket {
+	if existing, ok := data.(*tokenBucket); found && ok {
+		return existing
+	}
+	return nil
+}
+
+// take will return the refilled bucket (a full bucket if nil) after taking a token, and if a token was taken
+func (b *tokenBucket) take(capacity int64, period time.Duration) (*tokenBucket, bool) {
+	now := time.Now()
+	bucket := &tokenBucket{refilledAt: now, tokens: float64(capacity)}
+	if b != nil {
+		bucket.refilledAt = b.refilledAt
+		bucket.tokens = b.tokens
+		if elapsed := now.Sub(b.refilledAt); elapsed > 0 {
+			bucket.tokens += float64(capacity) * float64(elapsed) / float64(period)
+			if bucket.tokens > float64(capacity) {
+				bucket.tokens = float64(capacity)
+			}
+			bucket.refilledAt = now
+		}
+	}
+	if bucket.tokens < 1 {
+		return bucket, false
+	}
+	bucket.tokens--
+	return bucket, true
+}
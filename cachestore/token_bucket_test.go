@@ -0,0 +1,109 @@
+//go:build !race
+// +build !race
+
+package cachestore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient_TakeToken will test the method TakeToken()
+func TestClient_TakeToken(t *testing.T) {
+
+	for _, testCase := range cacheTestCases {
+		t.Run(testCase.name+" - missing key or period", func(t *testing.T) {
+			c, err := NewClient(context.Background(), testCase.opts)
+			require.NotNil(t, c)
+			require.NoError(t, err)
+			defer c.Close(context.Background())
+
+			_, err = c.TakeToken(context.Background(), "", 1, time.Minute)
+			assert.ErrorIs(t, err, ErrKeyRequired)
+
+			_, err = c.TakeToken(context.Background(), testKey, 1, 0)
+			assert.ErrorIs(t, err, ErrTTLCannotBeEmpty)
+		})
+
+		t.Run(testCase.name+" - take and refill", func(t *testing.T) {
+			c, err := NewClient(context.Background(), testCase.opts)
+			require.NotNil(t, c)
+			require.NoError(t, err)
+			defer c.Close(context.Background())
+
+			var taken bool
+			for i := 0; i < 3; i++ {
+				taken, err = c.TakeToken(context.Background(), testKey, 3, 300*time.Millisecond)
+				require.NoError(t, err)
+				assert.True(t, taken)
+			}
+			taken, err = c.TakeToken(context.Background(), testKey, 3, 300*time.Millisecond)
+			require.NoError(t, err)
+			assert.False(t, taken)
+
+			// One token is refilled every 100 milliseconds
+			time.Sleep(150 * time.Millisecond)
+			taken, err = c.TakeToken(context.Background(), testKey, 3, 300*time.Millisecond)
+			require.NoError(t, err)
+			assert.True(t, taken)
+			taken, err = c.TakeToken(context.Background(), testKey, 3, 300*time.Millisecond)
+			require.NoError(t, err)
+			assert.False(t, taken)
+		})
+
+		t.Run(testCase.name+" - burst across the period cannot exceed the capacity", func(t *testing.T) {
+			c, err := NewClient(context.Background(), testCase.opts)
+			require.NotNil(t, c)
+			require.NoError(t, err)
+			defer c.Close(context.Background())
+
+			// Burst at the end of the period and right after it
+			var taken int
+			for i := 0; i < 10; i++ {
+				ok, takeErr := c.TakeToken(context.Background(), testKey, 5, time.Second)
+				require.NoError(t, takeErr)
+				if ok {
+					taken++
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+			for i := 0; i < 10; i++ {
+				ok, takeErr := c.TakeToken(context.Background(), testKey, 5, time.Second)
+				require.NoError(t, takeErr)
+				if ok {
+					taken++
+				}
+			}
+			assert.Equal(t, 5, taken)
+		})
+
+		t.Run(testCase.name+" - concurrent takes", func(t *testing.T) {
+			c, err := NewClient(context.Background(), testCase.opts)
+			require.NotNil(t, c)
+			require.NoError(t, err)
+			defer c.Close(context.Background())
+
+			var taken int64
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ok, takeErr := c.TakeToken(context.Background(), testKey, 10, time.Minute)
+					assert.NoError(t, takeErr)
+					if ok {
+						atomic.AddInt64(&taken, 1)
+					}
+				}()
+			}
+			wg.Wait()
+			assert.Equal(t, int64(10), taken)
+		})
+	}
+}
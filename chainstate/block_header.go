@@ -0,0 +1,169 @@
+package chainstate
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+)
+
+// BlockHeader is a parsed block header (hashes are in display hex, big endian)
+type BlockHeader struct {
+	Bits       uint32 `json:"bits"`        // Difficulty target (compact format)
+	Hash       string `json:"hash"`        // Hash of the block
+	Height     uint64 `json:"height"`      // Height of the block
+	MerkleRoot string `json:"merkle_root"` // Merkle root of all transactions in the block
+	Nonce      uint32 `json:"nonce"`       // Nonce used for the proof-of-work
+	PrevHash   string `json:"prev_hash"`   // Hash of the previous block
+	Time       uint32 `json:"time"`        // Time of the block (unix)
+	Version    uint32 `json:"version"`     // Block version
+}
+
+// NewBlockHeaderFromHex will parse a serialized block header (hex)
+func NewBlockHeaderFromHex(header string, height uint64) (*BlockHeader, error) {
+	b, err := hex.DecodeString(strings.TrimSpace(header))
+	if err != nil || len(b) != blockHeaderLength {
+		return nil, ErrInvalidBlockHeader
+	}
+	return &BlockHeader{
+		Bits:       binary.LittleEndian.Uint32(b[72:76]),
+		Hash:       hex.EncodeToString(bt.ReverseBytes(crypto.Sha256d(b))),
+		Height:     height,
+		MerkleRoot: hex.EncodeToString(bt.ReverseBytes(b[36:68])),
+		Nonce:      binary.LittleEndian.Uint32(b[76:80]),
+		PrevHash:   hex.EncodeToString(bt.ReverseBytes(b[4:36])),
+		Time:       binary.LittleEndian.Uint32(b[68:72]),
+		Version:    binary.LittleEndian.Uint32(b[0:4]),
+	}, nil
+}
+
+// NewBlockHeadersFromReader will parse consecutive block headers (one hex header per line)
+//
+// Empty lines and lines starting with "#" are skipped, the first header is at startHeight
+func NewBlockHeadersFromReader(r io.Reader, startHeight uint64) ([]*BlockHeader, error) {
+	var headers []*BlockHeader
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		header, err := NewBlockHeaderFromHex(line, startHeight+uint64(len(headers)))
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, header)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// Bytes will return the serialized block header (nil if the hashes are invalid)
+func (h *BlockHeader) Bytes() []byte {
+	prevHash, _ := hex.DecodeString(h.PrevHash)
+	merkleRoot, _ := hex.DecodeString(h.MerkleRoot)
+
+	if len(prevHash) != 32 || len(merkleRoot) != 32 {
+		return nil
+	}
+
+	b := make([]byte, blockHeaderLength)
+	binary.LittleEndian.PutUint32(b[0:4], h.Version)
+	copy(b[4:36], bt.ReverseBytes(prevHash))
+	copy(b[36:68], bt.ReverseBytes(merkleRoot))
+	binary.LittleEndian.PutUint32(b[68:72], h.Time)
+	binary.LittleEndian.PutUint32(b[72:76], h.Bits)
+	binary.LittleEndian.PutUint32(b[76:80], h.Nonce)
+	return b
+}
+
+// String will return the serialized block header (hex)
+func (h *BlockHeader) String() string {
+	return hex.EncodeToString(h.Bytes())
+}
+
+// Validate will check the hash of the header and the proof-of-work
+func (h *BlockHeader) Validate() error {
+	b := h.Bytes()
+	if len(b) != blockHeaderLength {
+		return ErrInvalidBlockHeader
+	} else if hex.EncodeToString(bt.ReverseBytes(crypto.Sha256d(b))) != h.Hash {
+		return ErrInvalidBlockHeader
+	}
+
+	// The hash must be below the target
+	target := compactToBig(h.Bits)
+	if target.Sign() <= 0 {
+		return ErrInvalidProofOfWork
+	}
+	hash, ok := new(big.Int).SetString(h.Hash, 16)
+	if !ok || hash.Cmp(target) > 0 {
+		return ErrInvalidProofOfWork
+	}
+	return nil
+}
+
+// ValidateNext will validate the next header and check it links to this header
+func (h *BlockHeader) ValidateNext(next *BlockHeader) error {
+	if err := next.Validate(); err != nil {
+		return err
+	} else if next.PrevHash != h.Hash || next.Height != h.Height+1 {
+		return ErrBlockHeaderChainBroken
+	}
+	return nil
+}
+
+// newBlockHeaderFromInfo will create a block header from the provider block information
+func newBlockHeaderFromInfo(version int64, prevHash, merkleRoot string, blockTime int64,
+	bits string, nonce int64, hash string, height int64) (*BlockHeader, error) {
+	b, err := strconv.ParseUint(bits, 16, 32)
+	if err != nil {
+		return nil, ErrInvalidBlockHeader
+	}
+
+	// The genesis block has no previous hash
+	if len(prevHash) == 0 {
+		prevHash = strings.Repeat("0", 64)
+	}
+	header := &BlockHeader{
+		Bits:       uint32(b),
+		Hash:       hash,
+		Height:     uint64(height),
+		MerkleRoot: merkleRoot,
+		Nonce:      uint32(nonce),
+		PrevHash:   prevHash,
+		Time:       uint32(blockTime),
+		Version:    uint32(version),
+	}
+	if len(header.Bytes()) != blockHeaderLength {
+		return nil, ErrInvalidBlockHeader
+	}
+	return header, nil
+}
+
+// compactToBig will convert the compact (bits) format to the target
+func compactToBig(compact uint32) *big.Int {
+	mantissa := compact & 0x007fffff
+	exponent := uint(compact >> 24)
+
+	var target *big.Int
+	if exponent <= 3 {
+		target = big.NewInt(int64(mantissa >> (8 * (3 - exponent))))
+	} else {
+		target = new(big.Int).Lsh(big.NewInt(int64(mantissa)), 8*(exponent-3))
+	}
+
+	// Negative targets are invalid
+	if compact&0x00800000 != 0 {
+		return target.Neg(target)
+	}
+	return target
+}
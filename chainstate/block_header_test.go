@@ -0,0 +1,115 @@
+package chainstate
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Block #100000 (mainnet)
+const (
+	testBlockHash     = "000000000003ba27aa200b1cecaad478d2b00432346c3f1f3986da1afd33e506"
+	testBlockHeight   = uint64(100000)
+	testBlockPrevHash = "000000000002d01c1fccc21636b607dfd930d31d01c3a62104612a1719011250"
+)
+
+// loadTestBlockHeaders will load the fixture block headers (regtest difficulty, starting at height 0)
+func loadTestBlockHeaders(t *testing.T) []*BlockHeader {
+	f, err := os.Open("testdata/block_headers.txt")
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var headers []*BlockHeader
+	headers, err = NewBlockHeadersFromReader(f, 0)
+	require.NoError(t, err)
+	require.Len(t, headers, 21)
+	return headers
+}
+
+// TestNewBlockHeaderFromHex will test the method NewBlockHeaderFromHex()
+func TestNewBlockHeaderFromHex(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid header", func(t *testing.T) {
+		header, err := NewBlockHeaderFromHex(testBlockHeader, testBlockHeight)
+		require.NoError(t, err)
+		require.NotNil(t, header)
+		assert.Equal(t, testBlockHash, header.Hash)
+		assert.Equal(t, testBlockHeight, header.Height)
+		assert.Equal(t, testBlockPrevHash, header.PrevHash)
+		assert.Equal(t, testBlockMerkleRoot, header.MerkleRoot)
+		assert.Equal(t, uint32(0x1b04864c), header.Bits)
+		assert.Equal(t, uint32(274148111), header.Nonce)
+		assert.Equal(t, uint32(1293623863), header.Time)
+		assert.Equal(t, uint32(1), header.Version)
+		assert.Equal(t, testBlockHeader, header.String())
+		assert.NoError(t, header.Validate())
+	})
+
+	t.Run("invalid header", func(t *testing.T) {
+		header, err := NewBlockHeaderFromHex("0100", 0)
+		assert.ErrorIs(t, err, ErrInvalidBlockHeader)
+		assert.Nil(t, header)
+
+		header, err = NewBlockHeaderFromHex("invalid", 0)
+		assert.ErrorIs(t, err, ErrInvalidBlockHeader)
+		assert.Nil(t, header)
+	})
+}
+
+// TestBlockHeader_Validate will test the method Validate()
+func TestBlockHeader_Validate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("hash mismatch", func(t *testing.T) {
+		header, err := NewBlockHeaderFromHex(testBlockHeader, testBlockHeight)
+		require.NoError(t, err)
+		header.Nonce++
+		assert.ErrorIs(t, header.Validate(), ErrInvalidBlockHeader)
+	})
+
+	t.Run("invalid proof-of-work", func(t *testing.T) {
+		header, err := NewBlockHeaderFromHex(testBlockHeader, testBlockHeight)
+		require.NoError(t, err)
+		header.Nonce++
+
+		// Re-hash the modified header (it will not meet the target)
+		var modified *BlockHeader
+		modified, err = NewBlockHeaderFromHex(header.String(), testBlockHeight)
+		require.NoError(t, err)
+		assert.ErrorIs(t, modified.Validate(), ErrInvalidProofOfWork)
+	})
+
+	t.Run("invalid hashes", func(t *testing.T) {
+		header := &BlockHeader{Hash: testBlockHash, PrevHash: "invalid"}
+		assert.ErrorIs(t, header.Validate(), ErrInvalidBlockHeader)
+	})
+}
+
+// TestBlockHeader_ValidateNext will test the method ValidateNext()
+func TestBlockHeader_ValidateNext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid fixture chain", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		require.NoError(t, headers[0].Validate())
+		for i := 1; i < len(headers); i++ {
+			assert.NoError(t, headers[i-1].ValidateNext(headers[i]))
+		}
+	})
+
+	t.Run("broken link", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		assert.ErrorIs(t, headers[0].ValidateNext(headers[2]), ErrBlockHeaderChainBroken)
+	})
+
+	t.Run("wrong height", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		headers[1].Height = 5
+		assert.ErrorIs(t, headers[0].ValidateNext(headers[1]), ErrBlockHeaderChainBroken)
+	})
+}
@@ -22,6 +22,23 @@ var (
 		"txn_already_in_mempool", // TXN_ALREADY_IN_MEMPOOL
 	}
 
+	// doubleSpendErrors are a list of errors (broadcast or query) for a transaction that conflicts with
+	// another transaction spending the same inputs
+	doubleSpendErrors = []string{
+		"txn-mempool-conflict",  // { "error": "-26: 258: txn-mempool-conflict"}
+		"txn_mempool_conflict",  // TXN_MEMPOOL_CONFLICT
+		"bad-txns-inputs-spent", // { "error": "-26: 18: bad-txns-inputs-spent"}
+		"bad_txns_inputs_spent", // BAD_TXNS_INPUTS_SPENT
+	}
+
+	// lowFeeErrors are a list of errors for a transaction that was rejected because the fee is too low
+	lowFeeErrors = []string{
+		"tx_fee_too_low",          // TX_FEE_TOO_LOW
+		"mempool min fee not met", // { "error": "-26: 66: mempool min fee not met"}
+		"min relay fee not met",   // { "error": "-26: 66: min relay fee not met"}
+		"insufficient priority",   // { "error": "-26: 66: insufficient priority"}
+	}
+
 	// broadcastQuestionableErrors are a list of errors that are not good broadcast responses,
 	// but need to be checked differently
 	broadcastQuestionableErrors = []string{
@@ -59,12 +76,15 @@ func doesErrorContain(err string, messages []string) bool {
 // broadcast will broadcast using a standard strategy
 //
 // NOTE: if successful (in-mempool), no error will be returned
+// ErrFeeTooLow is returned if all providers failed and a provider rejected the fee
 func (c *Client) broadcast(ctx context.Context, id, hex string, timeout time.Duration) error {
 
 	// Create a context (to cancel or timeout)
 	ctxWithCancel, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	var lowFee bool
+
 	// First: try all mAPI miners (Only supported on main and test right now)
 	if c.Network() == MainNet || c.Network() == TestNet {
 		for index := range c.options.config.mAPI.broadcastMiners {
@@ -79,6 +99,12 @@ func (c *Client) broadcast(ctx context.Context, id, hex string, timeout time.Dur
 					return nil
 				}
 
+				// Double spend (no need to try the other providers)
+				if doesErrorContain(err.Error(), doubleSpendErrors) {
+					c.DebugLog("broadcast error: " + err.Error() + " from provider: " + c.options.config.mAPI.broadcastMiners[index].Name)
+					return ErrDoubleSpend
+				}
+
 				// Check error response for "questionable errors"
 				if doesErrorContain(err.Error(), broadcastQuestionableErrors) {
 					if err = checkInMempool(ctx, c, id, err.Error(), timeout); err != nil {
@@ -88,6 +114,7 @@ func (c *Client) broadcast(ctx context.Context, id, hex string, timeout time.Dur
 				}
 
 				// Provider error?
+				lowFee = lowFee || doesErrorContain(err.Error(), lowFeeErrors)
 				c.DebugLog("broadcast error: " + err.Error() + " from provider: " + c.options.config.mAPI.broadcastMiners[index].Name)
 			}
 		}
@@ -96,6 +123,12 @@ func (c *Client) broadcast(ctx context.Context, id, hex string, timeout time.Dur
 	// Try next provider: MatterCloud
 	if err := broadcastMatterCloud(ctx, c, c.MatterCloud(), id, hex); err != nil {
 
+		// Double spend (no need to try the other providers)
+		if doesErrorContain(err.Error(), doubleSpendErrors) {
+			c.DebugLog("broadcast error: " + err.Error() + " from provider: " + providerMatterCloud)
+			return ErrDoubleSpend
+		}
+
 		// Check error response for (TX FAILURE)
 		if doesErrorContain(err.Error(), broadcastQuestionableErrors) {
 			if err = checkInMempool(ctx, c, id, err.Error(), timeout); err != nil {
@@ -105,6 +138,7 @@ func (c *Client) broadcast(ctx context.Context, id, hex string, timeout time.Dur
 		}
 
 		// Provider error?
+		lowFee = lowFee || doesErrorContain(err.Error(), lowFeeErrors)
 		c.DebugLog("broadcast error: " + err.Error() + " from provider: " + providerMatterCloud)
 	} else { // Success!
 		return nil
@@ -113,6 +147,12 @@ func (c *Client) broadcast(ctx context.Context, id, hex string, timeout time.Dur
 	// Try next provider: WhatsOnChain
 	if err := broadcastWhatsOnChain(ctx, c, c.WhatsOnChain(), id, hex); err != nil {
 
+		// Double spend (no need to try the other providers)
+		if doesErrorContain(err.Error(), doubleSpendErrors) {
+			c.DebugLog("broadcast error: " + err.Error() + " from provider: " + providerWhatsOnChain)
+			return ErrDoubleSpend
+		}
+
 		// Check error response for (TX FAILURE)
 		if doesErrorContain(err.Error(), broadcastQuestionableErrors) {
 			if err = checkInMempool(ctx, c, id, err.Error(), timeout); err != nil {
@@ -122,6 +162,7 @@ func (c *Client) broadcast(ctx context.Context, id, hex string, timeout time.Dur
 		}
 
 		// Provider error?
+		lowFee = lowFee || doesErrorContain(err.Error(), lowFeeErrors)
 		c.DebugLog("broadcast error: " + err.Error() + " from provider: " + providerWhatsOnChain)
 	} else { // Success!
 		return nil
@@ -130,6 +171,12 @@ func (c *Client) broadcast(ctx context.Context, id, hex string, timeout time.Dur
 	// Try next provider: NowNodes
 	if err := broadcastNowNodes(ctx, c, c.NowNodes(), id, id, hex); err != nil {
 
+		// Double spend (no need to try the other providers)
+		if doesErrorContain(err.Error(), doubleSpendErrors) {
+			c.DebugLog("broadcast error: " + err.Error() + " from provider: " + providerNowNodes)
+			return ErrDoubleSpend
+		}
+
 		// Check error response for (TX FAILURE)
 		if doesErrorContain(err.Error(), broadcastQuestionableErrors) {
 			if err = checkInMempool(ctx, c, id, err.Error(), timeout); err != nil {
@@ -139,12 +186,16 @@ func (c *Client) broadcast(ctx context.Context, id, hex string, timeout time.Dur
 		}
 
 		// Provider error?
+		lowFee = lowFee || doesErrorContain(err.Error(), lowFeeErrors)
 		c.DebugLog("broadcast error: " + err.Error() + " from provider: " + providerNowNodes)
 	} else { // Success!
 		return nil
 	}
 
 	// Final error?
+	if lowFee {
+		return ErrFeeTooLow
+	}
 	return errors.New("broadcast failed on all providers")
 }
 
@@ -245,7 +245,33 @@ func TestClient_Broadcast(t *testing.T) {
 	})
 
 	t.Run("broadcast - bad tx - error mempool conflict?", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithNowNodes(&nowNodesTxNotFound{}),         // Not found
+			WithWhatsOnChain(&whatsOnChainTxNotFound{}), // Not found
+			WithMatterCloud(&matterCloudTxNotFound{}),   // Not Found
+			WithMinercraft(&minerCraftDoubleSpend{}),    // Double spend
+		)
+		err := c.Broadcast(
+			context.Background(), broadcastExample1TxID, broadcastExample1TxHex, defaultBroadcastTimeOut,
+		)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrDoubleSpend)
+	})
 
+	t.Run("broadcast - bad tx - error fee too low", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithNowNodes(&nowNodesTxNotFound{}),         // Not found
+			WithWhatsOnChain(&whatsOnChainTxNotFound{}), // Not found
+			WithMatterCloud(&matterCloudTxNotFound{}),   // Not Found
+			WithMinercraft(&minerCraftLowFee{}),         // Fee too low
+		)
+		err := c.Broadcast(
+			context.Background(), broadcastExample1TxID, broadcastExample1TxHex, defaultBroadcastTimeOut,
+		)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrFeeTooLow)
 	})
 
 	t.Run("broadcast - server errors", func(t *testing.T) {
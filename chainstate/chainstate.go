@@ -44,10 +44,13 @@ func (c *Client) QueryTransaction(
 	}
 
 	// Try all providers and return the "first" valid response
-	info := c.query(ctx, id, requiredIn, timeout)
-	if info == nil {
-		return nil, ErrTransactionNotFound
+	info, err := c.query(ctx, id, requiredIn, timeout)
+	if err != nil {
+		return nil, err
 	}
+
+	// Add the merkle proof (if mined)
+	c.addMerkleProof(ctx, info, timeout)
 	return info, nil
 }
 
@@ -66,9 +69,12 @@ func (c *Client) QueryTransactionFastest(
 	}
 
 	// Try all providers and return the "fastest" valid response
-	info := c.fastestQuery(ctx, id, requiredIn, timeout)
-	if info == nil {
-		return nil, ErrTransactionNotFound
+	info, err := c.fastestQuery(ctx, id, requiredIn, timeout)
+	if err != nil {
+		return nil, err
 	}
+
+	// Add the merkle proof (if mined)
+	c.addMerkleProof(ctx, info, timeout)
 	return info, nil
 }
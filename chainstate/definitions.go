@@ -1,11 +1,16 @@
 package chainstate
 
-import "time"
+import (
+	"time"
+
+	"github.com/libsv/go-bt/v2"
+)
 
 // Chainstate configuration defaults
 const (
 	defaultBroadcastTimeOut = 15 * time.Second
 	defaultQueryTimeOut     = 15 * time.Second
+	defaultMaxReorgDepth    = 100 // Maximum number of orphaned block headers in a chain reorg
 	defaultUserAgent        = "go-chainstate: " + version
 	version                 = "v0.1.0"
 )
@@ -30,12 +35,20 @@ const (
 	requiredOnChain      = "on-chain"     // Requirement for tx query (has to be == on-chain)
 )
 
+// FeeQuote is the mining fee (standard) quoted by a miner
+type FeeQuote struct {
+	ExpiresAt time.Time   `json:"expires_at"` // When the quote expires (empty if unknown)
+	FeeUnit   *bt.FeeUnit `json:"fee_unit"`   // Mining fee for standard transaction parts
+	Miner     string      `json:"miner"`      // Name of the miner that quoted the fee
+}
+
 // TransactionInfo is the universal information about the transaction found from a chain provider
 type TransactionInfo struct {
-	BlockHash     string `json:"block_hash,omitempty"`    // mAPI, WOC
-	BlockHeight   int64  `json:"block_height"`            // mAPI, WOC
-	Confirmations int64  `json:"confirmations,omitempty"` // mAPI, WOC
-	ID            string `json:"id"`                      // Transaction ID (Hex)
-	MinerID       string `json:"miner_id,omitempty"`      // mAPI ONLY - miner_id found
-	Provider      string `json:"provider,omitempty"`      // Provider is our internal source
+	BlockHash     string       `json:"block_hash,omitempty"`    // mAPI, WOC
+	BlockHeight   int64        `json:"block_height"`            // mAPI, WOC
+	Confirmations int64        `json:"confirmations,omitempty"` // mAPI, WOC
+	ID            string       `json:"id"`                      // Transaction ID (Hex)
+	MerkleProof   *MerkleProof `json:"merkle_proof,omitempty"`  // WOC ONLY - TSC merkle proof (if mined)
+	MinerID       string       `json:"miner_id,omitempty"`      // mAPI ONLY - miner_id found
+	Provider      string       `json:"provider,omitempty"`      // Provider is our internal source
 }
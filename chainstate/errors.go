@@ -22,3 +22,39 @@ var ErrMissingBroadcastMiners = errors.New("missing: broadcasting miners")
 
 // ErrMissingQueryMiners is when query miners are missing
 var ErrMissingQueryMiners = errors.New("missing: query miners")
+
+// ErrInvalidMerkleProof is when the merkle proof (or path) is malformed or unsupported
+var ErrInvalidMerkleProof = errors.New("invalid merkle proof")
+
+// ErrMerkleRootMismatch is when the calculated merkle root does not match the expected root
+var ErrMerkleRootMismatch = errors.New("merkle root does not match the target")
+
+// ErrTransactionNotInProof is when the transaction was not found in the merkle path
+var ErrTransactionNotInProof = errors.New("transaction not found in merkle path")
+
+// ErrInvalidBlockHeader is when the block header is missing or invalid
+var ErrInvalidBlockHeader = errors.New("invalid block header")
+
+// ErrMerkleProofNotFound is when the merkle proof was not found for a transaction
+var ErrMerkleProofNotFound = errors.New("merkle proof not found")
+
+// ErrInvalidProofOfWork is when the block hash does not meet the target of the header
+var ErrInvalidProofOfWork = errors.New("block header has invalid proof-of-work")
+
+// ErrBlockHeaderChainBroken is when the block header does not link to the previous block header
+var ErrBlockHeaderChainBroken = errors.New("block header does not link to the previous block header")
+
+// ErrBlockHeaderNotFound is when a block header was not found
+var ErrBlockHeaderNotFound = errors.New("block header not found")
+
+// ErrChainReorgTooDeep is when the fork point of a chain reorg was not found within the max depth
+var ErrChainReorgTooDeep = errors.New("chain reorg is deeper than the max reorg depth")
+
+// ErrFeeQuoteNotFound is when a fee quote was not found using any of the miners
+var ErrFeeQuoteNotFound = errors.New("fee quote not found using all miners")
+
+// ErrDoubleSpend is when the inputs of a transaction are spent by a conflicting transaction (double spend)
+var ErrDoubleSpend = errors.New("transaction inputs are spent by a conflicting transaction")
+
+// ErrFeeTooLow is when the transaction was rejected by the providers because the fee is too low
+var ErrFeeTooLow = errors.New("transaction fee is too low")
@@ -0,0 +1,89 @@
+package chainstate
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/tonicpow/go-minercraft"
+)
+
+// FeeQuote will get the fee (standard mining fee) quoted by a miner using mAPI
+//
+// The given miner (by name) is tried first, then the broadcast miners are used as a fallback.
+// The timeout is per miner, so a slow miner does not use up the time of the fallback miners.
+func (c *Client) FeeQuote(ctx context.Context, minerName string, timeout time.Duration) (*FeeQuote, error) {
+
+	// mAPI is only supported on main and test right now
+	if c.Network() != MainNet && c.Network() != TestNet {
+		return nil, ErrFeeQuoteNotFound
+	}
+
+	for _, miner := range c.feeQuoteMiners(minerName) {
+		quote, err := feeQuoteMAPIWithTimeout(ctx, c, miner, timeout)
+		if err == nil {
+			return quote, nil
+		}
+		c.DebugLog("error executing fee quote request in mAPI using " + miner.Name + ": " + err.Error())
+	}
+	return nil, ErrFeeQuoteNotFound
+}
+
+// feeQuoteMiners will return the miners for a fee quote (requested miner first, then the broadcast miners)
+func (c *Client) feeQuoteMiners(minerName string) []*minercraft.Miner {
+	miners := make([]*minercraft.Miner, 0, len(c.BroadcastMiners())+1)
+	if len(minerName) > 0 {
+		for _, miner := range c.Miners() {
+			if miner != nil && strings.EqualFold(miner.Name, minerName) {
+				miners = append(miners, miner)
+				break
+			}
+		}
+	}
+	for _, miner := range c.BroadcastMiners() {
+		if miner != nil && (len(miners) == 0 || miners[0].MinerID != miner.MinerID) {
+			miners = append(miners, miner)
+		}
+	}
+	return miners
+}
+
+// feeQuoteMAPIWithTimeout will get the standard mining fee from a miner using mAPI (with its own timeout)
+func feeQuoteMAPIWithTimeout(ctx context.Context, client ClientInterface, miner *minercraft.Miner,
+	timeout time.Duration) (*FeeQuote, error) {
+
+	// Create a context (to cancel or timeout)
+	ctxWithCancel, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return feeQuoteMAPI(ctxWithCancel, client, miner)
+}
+
+// feeQuoteMAPI will get the standard mining fee from a miner using mAPI
+func feeQuoteMAPI(ctx context.Context, client ClientInterface, miner *minercraft.Miner) (*FeeQuote, error) {
+	client.DebugLog("executing fee quote request in mAPI using " + miner.Name)
+	response, err := client.Minercraft().FeeQuote(ctx, miner)
+	if err != nil {
+		return nil, err
+	} else if response == nil || response.Quote == nil {
+		return nil, ErrFeeQuoteNotFound
+	}
+
+	fee := response.Quote.GetFee(string(bt.FeeTypeStandard))
+	if fee == nil || fee.MiningFee.Satoshis <= 0 || fee.MiningFee.Bytes <= 0 {
+		return nil, ErrFeeQuoteNotFound
+	}
+
+	quote := &FeeQuote{
+		FeeUnit: &bt.FeeUnit{
+			Satoshis: fee.MiningFee.Satoshis,
+			Bytes:    fee.MiningFee.Bytes,
+		},
+		Miner: miner.Name,
+	}
+	if expiresAt, parseErr := time.Parse(time.RFC3339, response.Quote.ExpirationTime); parseErr == nil {
+		quote.ExpiresAt = expiresAt.UTC()
+	}
+	return quote, nil
+}
@@ -0,0 +1,98 @@
+package chainstate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tonicpow/go-minercraft"
+)
+
+// TestClient_FeeQuote will test the method FeeQuote()
+func TestClient_FeeQuote(t *testing.T) {
+	t.Parallel()
+
+	miners := []*minercraft.Miner{minerTaal, minerMempool, minerGorillaPool}
+
+	t.Run("first broadcast miner", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithMinercraft(&minerCraftFeeQuote{}),
+			WithBroadcastMiners(miners),
+			WithCustomMiners(allMiners),
+		)
+		quote, err := c.FeeQuote(context.Background(), "", defaultQueryTimeOut)
+		require.NoError(t, err)
+		require.NotNil(t, quote)
+		assert.Equal(t, minerTaal.Name, quote.Miner)
+		assert.Equal(t, len(minerTaal.Name), quote.FeeUnit.Satoshis)
+		assert.Equal(t, 1000, quote.FeeUnit.Bytes)
+	})
+
+	t.Run("requested miner", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithMinercraft(&minerCraftFeeQuote{}),
+			WithBroadcastMiners(miners),
+			WithCustomMiners(allMiners),
+		)
+		quote, err := c.FeeQuote(context.Background(), "matterpool", defaultQueryTimeOut)
+		require.NoError(t, err)
+		require.NotNil(t, quote)
+		assert.Equal(t, minerMatterPool.Name, quote.Miner)
+		assert.Equal(t, len(minerMatterPool.Name), quote.FeeUnit.Satoshis)
+	})
+
+	t.Run("fallback to the broadcast miners", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithMinercraft(&minerCraftFeeQuote{failing: []string{minerMatterPool.Name, minerTaal.Name}}),
+			WithBroadcastMiners(miners),
+			WithCustomMiners(allMiners),
+		)
+		quote, err := c.FeeQuote(context.Background(), minerMatterPool.Name, defaultQueryTimeOut)
+		require.NoError(t, err)
+		require.NotNil(t, quote)
+		assert.Equal(t, minerMempool.Name, quote.Miner)
+	})
+
+	t.Run("timeout per miner", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithMinercraft(&minerCraftFeeQuote{slow: []string{minerTaal.Name}}),
+			WithBroadcastMiners(miners),
+			WithCustomMiners(allMiners),
+		)
+		quote, err := c.FeeQuote(context.Background(), "", 50*time.Millisecond)
+		require.NoError(t, err)
+		require.NotNil(t, quote)
+		assert.Equal(t, minerMempool.Name, quote.Miner)
+	})
+
+	t.Run("all miners failed", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithMinercraft(&minerCraftFeeQuote{
+				failing: []string{minerTaal.Name, minerMempool.Name, minerGorillaPool.Name},
+			}),
+			WithBroadcastMiners(miners),
+			WithCustomMiners(allMiners),
+		)
+		quote, err := c.FeeQuote(context.Background(), "", defaultQueryTimeOut)
+		require.ErrorIs(t, err, ErrFeeQuoteNotFound)
+		assert.Nil(t, quote)
+	})
+
+	t.Run("unsupported network", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithMinercraft(&minerCraftFeeQuote{}),
+			WithNetwork(StressTestNet),
+		)
+		quote, err := c.FeeQuote(context.Background(), "", defaultQueryTimeOut)
+		require.ErrorIs(t, err, ErrFeeQuoteNotFound)
+		assert.Nil(t, quote)
+	})
+}
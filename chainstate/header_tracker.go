@@ -0,0 +1,166 @@
+package chainstate
+
+import (
+	"context"
+	"time"
+)
+
+// ReorgHandler is called with the orphaned block headers (highest first) when a chain reorg is detected
+type ReorgHandler func(ctx context.Context, orphaned []*BlockHeader) error
+
+// HeaderTracker will sync block headers from a provider into a store and track the chain tip
+//
+// Each synced header is checked for a valid proof-of-work and must link to the previous header
+type HeaderTracker struct {
+	onReorg  ReorgHandler  // Called when block headers are orphaned (optional)
+	provider HeaderService // Source of the block headers (providers)
+	store    HeaderStore   // Local storage of the block headers
+	timeout  time.Duration // Timeout for each provider request
+}
+
+// NewHeaderTracker will create a new header tracker
+func NewHeaderTracker(provider HeaderService, store HeaderStore, timeout time.Duration) *HeaderTracker {
+	if timeout <= 0 {
+		timeout = defaultQueryTimeOut
+	}
+	return &HeaderTracker{
+		provider: provider,
+		store:    store,
+		timeout:  timeout,
+	}
+}
+
+// SetReorgHandler will set the handler that is called when a chain reorg is detected
+func (t *HeaderTracker) SetReorgHandler(handler ReorgHandler) {
+	t.onReorg = handler
+}
+
+// Tip will return the local chain tip (nil if no headers have been synced)
+func (t *HeaderTracker) Tip(ctx context.Context) (*BlockHeader, error) {
+	return t.store.GetChainTip(ctx)
+}
+
+// Sync will sync up to maxHeaders new block headers and return the (new) local chain tip
+//
+// If no headers have been synced, the sync starts maxHeaders below the provider's chain tip.
+// If the local chain tip is no longer in the provider's chain (reorg), the orphaned headers are
+// passed to the reorg handler and removed before syncing the new chain from the fork point.
+func (t *HeaderTracker) Sync(ctx context.Context, maxHeaders uint64) (*BlockHeader, error) {
+
+	// Get the local tip
+	localTip, err := t.store.GetChainTip(ctx)
+	if err != nil {
+		return nil, err
+	} else if maxHeaders == 0 {
+		return localTip, nil
+	}
+
+	// Get the provider tip
+	var remoteTip *BlockHeader
+	if remoteTip, err = t.provider.QueryChainTip(ctx, t.timeout); err != nil {
+		return localTip, err
+	}
+
+	// Check for a chain reorg
+	if localTip != nil {
+		if localTip, err = t.handleReorg(ctx, localTip, remoteTip); err != nil {
+			return localTip, err
+		}
+	}
+	if localTip != nil && localTip.Height >= remoteTip.Height {
+		return localTip, nil
+	}
+
+	// Find the range of headers to sync
+	var startHeight uint64
+	if localTip != nil {
+		startHeight = localTip.Height + 1
+	} else if remoteTip.Height >= maxHeaders {
+		startHeight = remoteTip.Height - maxHeaders + 1
+	}
+	endHeight := startHeight + maxHeaders - 1
+	if endHeight > remoteTip.Height {
+		endHeight = remoteTip.Height
+	}
+
+	// Sync, validate and save each header
+	previous := localTip
+	for height := startHeight; height <= endHeight; height++ {
+		header := remoteTip
+		if height != remoteTip.Height {
+			if header, err = t.provider.QueryBlockHeader(ctx, height, t.timeout); err != nil {
+				return previous, err
+			}
+		}
+		if previous != nil {
+			err = previous.ValidateNext(header)
+		} else {
+			err = header.Validate()
+		}
+		if err != nil {
+			return previous, err
+		}
+		if err = t.store.SaveBlockHeader(ctx, header); err != nil {
+			return previous, err
+		}
+		previous = header
+	}
+
+	return previous, nil
+}
+
+// handleReorg will check that the local tip is in the provider's chain, otherwise the orphaned
+// headers (down to the fork point) are handled and removed, and the new local tip is returned
+func (t *HeaderTracker) handleReorg(ctx context.Context, localTip, remoteTip *BlockHeader) (*BlockHeader, error) {
+
+	// Provider is behind (cannot check yet)
+	if remoteTip.Height < localTip.Height {
+		return localTip, nil
+	}
+
+	// Walk back until the local header matches the provider's header (fork point)
+	var err error
+	var orphaned []*BlockHeader
+	local := localTip
+	for local != nil {
+		remote := remoteTip
+		if local.Height != remoteTip.Height {
+			if remote, err = t.provider.QueryBlockHeader(ctx, local.Height, t.timeout); err != nil {
+				return localTip, err
+			}
+		}
+		if remote.Hash == local.Hash {
+			break
+		} else if len(orphaned) >= defaultMaxReorgDepth {
+			return localTip, ErrChainReorgTooDeep
+		}
+		orphaned = append(orphaned, local)
+
+		// Reached the genesis block (everything is orphaned)
+		if local.Height == 0 {
+			local = nil
+			break
+		}
+
+		// Below the synced headers, local is nil (everything is orphaned)
+		if local, err = t.store.GetBlockHeaderByHeight(ctx, local.Height-1); err != nil {
+			return localTip, err
+		}
+	}
+
+	// No reorg
+	if len(orphaned) == 0 {
+		return localTip, nil
+	}
+
+	// Handle the orphaned headers before removing them (the handler is retried on failure)
+	if t.onReorg != nil {
+		if err = t.onReorg(ctx, orphaned); err != nil {
+			return localTip, err
+		}
+	}
+	if err = t.store.RemoveBlockHeaders(ctx, orphaned); err != nil {
+		return localTip, err
+	}
+	return local, nil
+}
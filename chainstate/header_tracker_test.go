@@ -0,0 +1,308 @@
+package chainstate
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libsv/go-bk/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headerFixtureProvider is a header provider using the fixture headers
+type headerFixtureProvider struct {
+	headers []*BlockHeader
+	tip     uint64
+}
+
+func (p *headerFixtureProvider) QueryBlockHeader(_ context.Context, height uint64, _ time.Duration) (*BlockHeader, error) {
+	if height > p.tip {
+		return nil, ErrBlockHeaderNotFound
+	}
+	header := *p.headers[height]
+	return &header, nil
+}
+
+func (p *headerFixtureProvider) QueryChainTip(ctx context.Context, timeout time.Duration) (*BlockHeader, error) {
+	return p.QueryBlockHeader(ctx, p.tip, timeout)
+}
+
+// headerMemoryStore is an in-memory header store
+type headerMemoryStore struct {
+	sync.Mutex
+	headers map[uint64]*BlockHeader
+}
+
+func newHeaderMemoryStore() *headerMemoryStore {
+	return &headerMemoryStore{headers: make(map[uint64]*BlockHeader)}
+}
+
+func (s *headerMemoryStore) GetBlockHeaderByHeight(_ context.Context, height uint64) (*BlockHeader, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.headers[height], nil
+}
+
+func (s *headerMemoryStore) GetChainTip(_ context.Context) (tip *BlockHeader, err error) {
+	s.Lock()
+	defer s.Unlock()
+	for _, header := range s.headers {
+		if tip == nil || header.Height > tip.Height {
+			tip = header
+		}
+	}
+	return
+}
+
+func (s *headerMemoryStore) RemoveBlockHeaders(_ context.Context, headers []*BlockHeader) error {
+	s.Lock()
+	defer s.Unlock()
+	for _, header := range headers {
+		if s.headers[header.Height] != nil && s.headers[header.Height].Hash == header.Hash {
+			delete(s.headers, header.Height)
+		}
+	}
+	return nil
+}
+
+func (s *headerMemoryStore) SaveBlockHeader(_ context.Context, header *BlockHeader) error {
+	s.Lock()
+	defer s.Unlock()
+	s.headers[header.Height] = header
+	return nil
+}
+
+// createTestForkHeaders will mine (regtest difficulty) a fork of headers on top of the base header
+func createTestForkHeaders(t *testing.T, base *BlockHeader, count int) []*BlockHeader {
+	headers := make([]*BlockHeader, 0, count)
+	previous := base
+	for i := 0; i < count; i++ {
+		b := previous.Bytes()
+		require.Len(t, b, blockHeaderLength)
+		copy(b[4:36], crypto.Sha256d(previous.Bytes()))
+		binary.LittleEndian.PutUint32(b[68:72], previous.Time+1)
+		for nonce := uint32(0); ; nonce++ {
+			binary.LittleEndian.PutUint32(b[76:80], nonce)
+			if hash := crypto.Sha256d(b); hash[31] < 0x7f {
+				break
+			}
+		}
+
+		header, err := NewBlockHeaderFromHex(hex.EncodeToString(b), previous.Height+1)
+		require.NoError(t, err)
+		require.NoError(t, previous.ValidateNext(header))
+		headers = append(headers, header)
+		previous = header
+	}
+	return headers
+}
+
+// TestHeaderTracker_Sync will test the method Sync()
+func TestHeaderTracker_Sync(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sync from empty store", func(t *testing.T) {
+		provider := &headerFixtureProvider{headers: loadTestBlockHeaders(t), tip: 20}
+		store := newHeaderMemoryStore()
+		tracker := NewHeaderTracker(provider, store, 0)
+
+		tip, err := tracker.Sync(context.Background(), 5)
+		require.NoError(t, err)
+		require.NotNil(t, tip)
+		assert.Equal(t, uint64(20), tip.Height)
+		assert.Equal(t, provider.headers[20].Hash, tip.Hash)
+		assert.Len(t, store.headers, 5)
+		assert.Nil(t, store.headers[15])
+		assert.NotNil(t, store.headers[16])
+
+		tip, err = tracker.Tip(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, uint64(20), tip.Height)
+	})
+
+	t.Run("sync all from genesis", func(t *testing.T) {
+		provider := &headerFixtureProvider{headers: loadTestBlockHeaders(t), tip: 20}
+		store := newHeaderMemoryStore()
+		tracker := NewHeaderTracker(provider, store, 0)
+
+		tip, err := tracker.Sync(context.Background(), 100)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(20), tip.Height)
+		assert.Len(t, store.headers, 21)
+	})
+
+	t.Run("follow the chain tip", func(t *testing.T) {
+		provider := &headerFixtureProvider{headers: loadTestBlockHeaders(t), tip: 10}
+		store := newHeaderMemoryStore()
+		tracker := NewHeaderTracker(provider, store, 0)
+
+		tip, err := tracker.Sync(context.Background(), 3)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10), tip.Height)
+
+		// Nothing new
+		tip, err = tracker.Sync(context.Background(), 3)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10), tip.Height)
+		assert.Len(t, store.headers, 3)
+
+		// New blocks (limited by max headers)
+		provider.tip = 20
+		tip, err = tracker.Sync(context.Background(), 3)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(13), tip.Height)
+
+		tip, err = tracker.Sync(context.Background(), 10)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(20), tip.Height)
+		assert.Len(t, store.headers, 13)
+	})
+
+	t.Run("broken chain", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		headers[15] = headers[14]
+		provider := &headerFixtureProvider{headers: headers, tip: 20}
+		store := newHeaderMemoryStore()
+		tracker := NewHeaderTracker(provider, store, 0)
+
+		tip, err := tracker.Sync(context.Background(), 10)
+		require.ErrorIs(t, err, ErrBlockHeaderChainBroken)
+		assert.Equal(t, uint64(14), tip.Height)
+		assert.Len(t, store.headers, 4)
+	})
+
+	t.Run("tampered header", func(t *testing.T) {
+		provider := &headerFixtureProvider{headers: loadTestBlockHeaders(t), tip: 20}
+		provider.headers[18].Nonce++
+		store := newHeaderMemoryStore()
+		tracker := NewHeaderTracker(provider, store, 0)
+
+		tip, err := tracker.Sync(context.Background(), 10)
+		require.ErrorIs(t, err, ErrInvalidBlockHeader)
+		assert.Equal(t, uint64(17), tip.Height)
+	})
+}
+
+// TestHeaderTracker_Reorg will test the reorg handling of the method Sync()
+func TestHeaderTracker_Reorg(t *testing.T) {
+	t.Parallel()
+
+	t.Run("longer fork", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		provider := &headerFixtureProvider{headers: headers, tip: 20}
+		store := newHeaderMemoryStore()
+		tracker := NewHeaderTracker(provider, store, 0)
+
+		var orphaned []*BlockHeader
+		tracker.SetReorgHandler(func(_ context.Context, headers []*BlockHeader) error {
+			orphaned = headers
+			return nil
+		})
+
+		_, err := tracker.Sync(context.Background(), 100)
+		require.NoError(t, err)
+		assert.Nil(t, orphaned)
+
+		// Blocks 18-20 are replaced by a longer chain (18-22)
+		fork := createTestForkHeaders(t, headers[17], 5)
+		provider.headers = append(headers[:18:18], fork...)
+		provider.tip = 22
+
+		var tip *BlockHeader
+		tip, err = tracker.Sync(context.Background(), 100)
+		require.NoError(t, err)
+		assert.Equal(t, fork[4].Hash, tip.Hash)
+		require.Len(t, orphaned, 3)
+		assert.Equal(t, headers[20].Hash, orphaned[0].Hash)
+		assert.Equal(t, headers[18].Hash, orphaned[2].Hash)
+		assert.Equal(t, fork[0].Hash, store.headers[18].Hash)
+		assert.Equal(t, headers[17].Hash, store.headers[17].Hash)
+		assert.Len(t, store.headers, 23)
+	})
+
+	t.Run("same height fork", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		provider := &headerFixtureProvider{headers: headers, tip: 20}
+		store := newHeaderMemoryStore()
+		tracker := NewHeaderTracker(provider, store, 0)
+
+		var orphaned []*BlockHeader
+		tracker.SetReorgHandler(func(_ context.Context, headers []*BlockHeader) error {
+			orphaned = headers
+			return nil
+		})
+
+		_, err := tracker.Sync(context.Background(), 5)
+		require.NoError(t, err)
+
+		// Block 20 is replaced
+		fork := createTestForkHeaders(t, headers[19], 1)
+		provider.headers = append(headers[:20:20], fork...)
+
+		var tip *BlockHeader
+		tip, err = tracker.Sync(context.Background(), 5)
+		require.NoError(t, err)
+		assert.Equal(t, fork[0].Hash, tip.Hash)
+		require.Len(t, orphaned, 1)
+		assert.Equal(t, headers[20].Hash, orphaned[0].Hash)
+		assert.Len(t, store.headers, 5)
+	})
+
+	t.Run("fork below the synced headers", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		provider := &headerFixtureProvider{headers: headers, tip: 20}
+		store := newHeaderMemoryStore()
+		tracker := NewHeaderTracker(provider, store, 0)
+
+		var orphaned []*BlockHeader
+		tracker.SetReorgHandler(func(_ context.Context, headers []*BlockHeader) error {
+			orphaned = headers
+			return nil
+		})
+
+		_, err := tracker.Sync(context.Background(), 5)
+		require.NoError(t, err)
+
+		// Blocks 11-20 are replaced
+		fork := createTestForkHeaders(t, headers[10], 10)
+		provider.headers = append(headers[:11:11], fork...)
+
+		var tip *BlockHeader
+		tip, err = tracker.Sync(context.Background(), 5)
+		require.NoError(t, err)
+		assert.Equal(t, fork[9].Hash, tip.Hash)
+		assert.Len(t, orphaned, 5)
+		assert.Len(t, store.headers, 5)
+		assert.Equal(t, fork[5].Hash, store.headers[16].Hash)
+	})
+
+	t.Run("reorg handler error", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		provider := &headerFixtureProvider{headers: headers, tip: 20}
+		store := newHeaderMemoryStore()
+		tracker := NewHeaderTracker(provider, store, 0)
+
+		handlerErr := errors.New("handler error")
+		tracker.SetReorgHandler(func(_ context.Context, _ []*BlockHeader) error {
+			return handlerErr
+		})
+
+		_, err := tracker.Sync(context.Background(), 5)
+		require.NoError(t, err)
+
+		fork := createTestForkHeaders(t, headers[18], 2)
+		provider.headers = append(headers[:19:19], fork...)
+
+		// The orphaned headers are kept until the handler succeeds
+		var tip *BlockHeader
+		tip, err = tracker.Sync(context.Background(), 5)
+		require.ErrorIs(t, err, handlerErr)
+		assert.Equal(t, headers[20].Hash, tip.Hash)
+		assert.Equal(t, headers[19].Hash, store.headers[19].Hash)
+	})
+}
@@ -0,0 +1,64 @@
+package chainstate
+
+import (
+	"context"
+	"time"
+
+	"github.com/mrz1836/go-whatsonchain"
+)
+
+// QueryBlockHeader will get the block header at the given height from the provider(s)
+//
+// Note: only WhatsOnChain is supported for block headers
+func (c *Client) QueryBlockHeader(ctx context.Context, height uint64, timeout time.Duration) (*BlockHeader, error) {
+
+	// Create a context (to cancel or timeout)
+	ctxWithCancel, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c.DebugLog("executing block header request in whatsonchain")
+	info, err := c.WhatsOnChain().GetBlockByHeight(ctxWithCancel, int64(height))
+	if err != nil {
+		c.DebugLog("error executing block header request in whatsonchain: " + err.Error())
+		return nil, err
+	} else if info == nil || len(info.Hash) == 0 || uint64(info.Height) != height {
+		return nil, ErrBlockHeaderNotFound
+	}
+	return blockHeaderFromWhatsOnChain(info)
+}
+
+// QueryChainTip will get the block header of the current chain tip from the provider(s)
+//
+// Note: only WhatsOnChain is supported for block headers
+func (c *Client) QueryChainTip(ctx context.Context, timeout time.Duration) (*BlockHeader, error) {
+
+	// Create a context (to cancel or timeout)
+	ctxWithCancel, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c.DebugLog("executing chain tip request in whatsonchain")
+	chainInfo, err := c.WhatsOnChain().GetChainInfo(ctxWithCancel)
+	if err != nil {
+		c.DebugLog("error executing chain tip request in whatsonchain: " + err.Error())
+		return nil, err
+	} else if chainInfo == nil || len(chainInfo.BestBlockHash) == 0 {
+		return nil, ErrBlockHeaderNotFound
+	}
+
+	var info *whatsonchain.BlockInfo
+	if info, err = c.WhatsOnChain().GetHeaderByHash(ctxWithCancel, chainInfo.BestBlockHash); err != nil {
+		c.DebugLog("error executing block header request in whatsonchain: " + err.Error())
+		return nil, err
+	} else if info == nil || info.Hash != chainInfo.BestBlockHash {
+		return nil, ErrBlockHeaderNotFound
+	}
+	return blockHeaderFromWhatsOnChain(info)
+}
+
+// blockHeaderFromWhatsOnChain will convert the WhatsOnChain block information to a block header
+func blockHeaderFromWhatsOnChain(info *whatsonchain.BlockInfo) (*BlockHeader, error) {
+	return newBlockHeaderFromInfo(
+		info.Version, info.PreviousBlockHash, info.MerkleRoot, info.Time,
+		info.Bits, info.Nonce, info.Hash, info.Height,
+	)
+}
@@ -0,0 +1,57 @@
+package chainstate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient_QueryBlockHeader will test the method QueryBlockHeader()
+func TestClient_QueryBlockHeader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid header", func(t *testing.T) {
+		c := NewTestClient(context.Background(), t, WithWhatsOnChain(&whatsOnChainBlockHeaders{}))
+
+		header, err := c.QueryBlockHeader(context.Background(), testBlockHeight, defaultQueryTimeOut)
+		require.NoError(t, err)
+		require.NotNil(t, header)
+		assert.Equal(t, testBlockHash, header.Hash)
+		assert.Equal(t, testBlockHeader, header.String())
+		assert.NoError(t, header.Validate())
+	})
+
+	t.Run("header not found", func(t *testing.T) {
+		c := NewTestClient(context.Background(), t, WithWhatsOnChain(&whatsOnChainBlockHeaders{}))
+
+		header, err := c.QueryBlockHeader(context.Background(), testBlockHeight+1, defaultQueryTimeOut)
+		require.ErrorIs(t, err, ErrBlockHeaderNotFound)
+		require.Nil(t, header)
+	})
+}
+
+// TestClient_QueryChainTip will test the method QueryChainTip()
+func TestClient_QueryChainTip(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid tip", func(t *testing.T) {
+		c := NewTestClient(context.Background(), t, WithWhatsOnChain(&whatsOnChainBlockHeaders{}))
+
+		header, err := c.QueryChainTip(context.Background(), defaultQueryTimeOut)
+		require.NoError(t, err)
+		require.NotNil(t, header)
+		assert.Equal(t, testBlockHash, header.Hash)
+		assert.Equal(t, testBlockHeight, header.Height)
+		assert.NoError(t, header.Validate())
+	})
+
+	t.Run("tip not found", func(t *testing.T) {
+		c := NewTestClient(context.Background(), t, WithWhatsOnChain(&whatsOnChainTxNotFound{}))
+
+		header, err := c.QueryChainTip(context.Background(), defaultQueryTimeOut)
+		require.ErrorIs(t, err, ErrBlockHeaderNotFound)
+		require.Nil(t, header)
+	})
+}
@@ -30,6 +30,28 @@ type ChainService interface {
 	QueryTransactionFastest(
 		ctx context.Context, id string, requiredIn RequiredIn, timeout time.Duration,
 	) (*TransactionInfo, error)
+	QueryUtxoUnspent(
+		ctx context.Context, txID string, outputIndex uint32, lockingScript string, timeout time.Duration,
+	) (bool, error)
+}
+
+// FeeService is the fee related methods
+type FeeService interface {
+	FeeQuote(ctx context.Context, minerName string, timeout time.Duration) (*FeeQuote, error)
+}
+
+// HeaderService is the block header related methods
+type HeaderService interface {
+	QueryBlockHeader(ctx context.Context, height uint64, timeout time.Duration) (*BlockHeader, error)
+	QueryChainTip(ctx context.Context, timeout time.Duration) (*BlockHeader, error)
+}
+
+// HeaderStore is the storage for the synced block headers
+type HeaderStore interface {
+	GetBlockHeaderByHeight(ctx context.Context, height uint64) (*BlockHeader, error)
+	GetChainTip(ctx context.Context) (*BlockHeader, error)
+	RemoveBlockHeaders(ctx context.Context, headers []*BlockHeader) error
+	SaveBlockHeader(ctx context.Context, header *BlockHeader) error
 }
 
 // ProviderServices is the chainstate providers interface
@@ -43,6 +65,8 @@ type ProviderServices interface {
 // ClientInterface is the chainstate client interface
 type ClientInterface interface {
 	ChainService
+	FeeService
+	HeaderService
 	ProviderServices
 	BroadcastMiners() []*minercraft.Miner
 	Close(ctx context.Context)
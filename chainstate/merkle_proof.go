@@ -0,0 +1,268 @@
+package chainstate
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+)
+
+// Merkle proof target types (TSC)
+const (
+	MerkleTargetTypeHash       = "hash"       // Target is the block hash
+	MerkleTargetTypeHeader     = "header"     // Target is the full block header (hex)
+	MerkleTargetTypeMerkleRoot = "merkleRoot" // Target is the merkle root
+)
+
+// Merkle path (BUMP) leaf flags
+const (
+	merklePathFlagHash      byte = 0 // Leaf is a hash
+	merklePathFlagDuplicate byte = 1 // Leaf is a duplicate of the working hash
+	merklePathFlagTxID      byte = 2 // Leaf is a client txid
+)
+
+// blockHeaderLength is the length of a serialized block header (in bytes)
+const blockHeaderLength = 80
+
+// MerkleProof is a merkle proof in the TSC format
+//
+// Specs: https://tsc.bitcoinassociation.net/standards/merkle-proof-standardised-format/
+type MerkleProof struct {
+	Index      uint64   `json:"index"`                // Index of the transaction in the block
+	TxOrID     string   `json:"txOrId"`               // Transaction ID (or the full transaction hex)
+	Target     string   `json:"target"`               // Block hash, block header or merkle root
+	TargetType string   `json:"targetType,omitempty"` // Type of target (default: hash)
+	Nodes      []string `json:"nodes"`                // Hashes of the merkle branch ("*" for duplicate)
+	ProofType  string   `json:"proofType,omitempty"`  // Only "branch" is supported
+	Composite  bool     `json:"composite,omitempty"`  // Only non-composite proofs are supported
+}
+
+// TxID will return the transaction id of the proof
+func (m *MerkleProof) TxID() (string, error) {
+	if len(m.TxOrID) == 64 {
+		return m.TxOrID, nil
+	}
+	tx, err := bt.NewTxFromString(m.TxOrID)
+	if err != nil {
+		return "", ErrInvalidTransactionHex
+	}
+	return tx.TxID(), nil
+}
+
+// MerkleRoot will calculate the merkle root (hex) from the proof
+func (m *MerkleProof) MerkleRoot() (string, error) {
+
+	// Only branches are supported
+	if m.Composite || (len(m.ProofType) > 0 && m.ProofType != "branch") {
+		return "", ErrInvalidMerkleProof
+	}
+
+	// Get the tx id
+	txID, err := m.TxID()
+	if err != nil {
+		return "", err
+	}
+
+	// Start with the tx id (internal byte order)
+	var current []byte
+	if current, err = hexToInternal(txID); err != nil {
+		return "", ErrInvalidMerkleProof
+	}
+
+	// Walk the branch
+	index := m.Index
+	for _, node := range m.Nodes {
+		sibling := current
+		if node != "*" {
+			if sibling, err = hexToInternal(node); err != nil {
+				return "", ErrInvalidMerkleProof
+			}
+		}
+		if index%2 == 1 {
+			current = merkleHash(sibling, current)
+		} else {
+			current = merkleHash(current, sibling)
+		}
+		index >>= 1
+	}
+
+	return hex.EncodeToString(bt.ReverseBytes(current)), nil
+}
+
+// Verify will calculate the merkle root and check it against the target (merkleRoot or header)
+//
+// If the target is a block hash, the calculated root is returned to be checked against a block header
+func (m *MerkleProof) Verify() (string, error) {
+
+	// Calculate the root
+	root, err := m.MerkleRoot()
+	if err != nil {
+		return "", err
+	}
+
+	// Check the target
+	switch m.TargetType {
+	case MerkleTargetTypeMerkleRoot:
+		if root != m.Target {
+			return "", ErrMerkleRootMismatch
+		}
+	case MerkleTargetTypeHeader:
+		var headerRoot string
+		if headerRoot, err = MerkleRootFromHeader(m.Target); err != nil {
+			return "", err
+		} else if root != headerRoot {
+			return "", ErrMerkleRootMismatch
+		}
+	}
+
+	return root, nil
+}
+
+// MerklePathLeaf is a single leaf in a merkle path (BUMP)
+type MerklePathLeaf struct {
+	Offset uint64 `json:"offset"`              // Offset of the leaf in the tree level
+	Hash   string `json:"hash,omitempty"`      // Hash of the leaf (hex)
+	TxID   bool   `json:"txid,omitempty"`      // True if the leaf is a client txid
+	Dup    bool   `json:"duplicate,omitempty"` // True if the leaf duplicates the working hash
+}
+
+// MerklePath is a merkle path in the BUMP format (BRC-74)
+//
+// Specs: https://brc.dev/74
+type MerklePath struct {
+	BlockHeight uint64             `json:"block_height"` // Height of the block
+	Path        [][]MerklePathLeaf `json:"path"`         // Leaves for each level of the tree
+}
+
+// NewMerklePathFromReader will parse a BUMP from a binary reader
+func NewMerklePathFromReader(r *bytes.Reader) (*MerklePath, error) {
+
+	// Block height
+	var height bt.VarInt
+	if _, err := height.ReadFrom(r); err != nil {
+		return nil, ErrInvalidMerkleProof
+	}
+
+	// Tree height
+	treeHeight, err := r.ReadByte()
+	if err != nil {
+		return nil, ErrInvalidMerkleProof
+	}
+
+	path := &MerklePath{
+		BlockHeight: uint64(height),
+		Path:        make([][]MerklePathLeaf, treeHeight),
+	}
+
+	// Read each level
+	for level := 0; level < int(treeHeight); level++ {
+		var numLeaves bt.VarInt
+		if _, err = numLeaves.ReadFrom(r); err != nil {
+			return nil, ErrInvalidMerkleProof
+		}
+		for i := uint64(0); i < uint64(numLeaves); i++ {
+			var offset bt.VarInt
+			if _, err = offset.ReadFrom(r); err != nil {
+				return nil, ErrInvalidMerkleProof
+			}
+			var flags byte
+			if flags, err = r.ReadByte(); err != nil {
+				return nil, ErrInvalidMerkleProof
+			}
+			leaf := MerklePathLeaf{Offset: uint64(offset)}
+			if flags == merklePathFlagDuplicate {
+				leaf.Dup = true
+			} else {
+				hash := make([]byte, 32)
+				if _, err = io.ReadFull(r, hash); err != nil {
+					return nil, ErrInvalidMerkleProof
+				}
+				leaf.Hash = hex.EncodeToString(bt.ReverseBytes(hash))
+				leaf.TxID = flags == merklePathFlagTxID
+			}
+			path.Path[level] = append(path.Path[level], leaf)
+		}
+	}
+
+	return path, nil
+}
+
+// MerkleRoot will calculate the merkle root (hex) for the given tx id using the path
+func (m *MerklePath) MerkleRoot(txID string) (string, error) {
+
+	// Find the tx in the first level
+	if len(m.Path) == 0 {
+		return "", ErrInvalidMerkleProof
+	}
+	offset, found := uint64(0), false
+	for _, leaf := range m.Path[0] {
+		if leaf.Hash == txID {
+			offset, found = leaf.Offset, true
+			break
+		}
+	}
+	if !found {
+		return "", ErrTransactionNotInProof
+	}
+
+	// Walk the path
+	current, err := hexToInternal(txID)
+	if err != nil {
+		return "", ErrInvalidMerkleProof
+	}
+	for level := range m.Path {
+		leaf := m.findLeaf(level, offset^1)
+		if leaf == nil {
+			return "", ErrInvalidMerkleProof
+		}
+		sibling := current
+		if !leaf.Dup {
+			if sibling, err = hexToInternal(leaf.Hash); err != nil {
+				return "", ErrInvalidMerkleProof
+			}
+		}
+		if offset%2 == 1 {
+			current = merkleHash(sibling, current)
+		} else {
+			current = merkleHash(current, sibling)
+		}
+		offset >>= 1
+	}
+
+	return hex.EncodeToString(bt.ReverseBytes(current)), nil
+}
+
+// findLeaf will find a leaf in the given level by offset
+func (m *MerklePath) findLeaf(level int, offset uint64) *MerklePathLeaf {
+	for index := range m.Path[level] {
+		if m.Path[level][index].Offset == offset {
+			return &m.Path[level][index]
+		}
+	}
+	return nil
+}
+
+// MerkleRootFromHeader will return the merkle root (hex) from a serialized block header (hex)
+func MerkleRootFromHeader(header string) (string, error) {
+	b, err := hex.DecodeString(header)
+	if err != nil || len(b) != blockHeaderLength {
+		return "", ErrInvalidBlockHeader
+	}
+	return hex.EncodeToString(bt.ReverseBytes(b[36:68])), nil
+}
+
+// merkleHash will hash two nodes together (internal byte order)
+func merkleHash(left, right []byte) []byte {
+	return crypto.Sha256d(append(append([]byte{}, left...), right...))
+}
+
+// hexToInternal will decode a hash (hex) into internal byte order
+func hexToInternal(hash string) ([]byte, error) {
+	b, err := hex.DecodeString(hash)
+	if err != nil || len(b) != 32 {
+		return nil, ErrInvalidMerkleProof
+	}
+	return bt.ReverseBytes(b), nil
+}
@@ -0,0 +1,177 @@
+package chainstate
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/libsv/go-bt/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Block #100000 (mainnet) has 4 transactions
+const (
+	testBlockHeader     = "0100000050120119172a610421a6c3011dd330d9df07b63616c2cc1f1cd00200000000006657a9252aacd5c0b2940996ecff952228c3067cc38d4885efb5a4ac4247e9f337221b4d4c86041b0f2b5710"
+	testBlockMerkleRoot = "f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766"
+	testBlockNode01     = "ccdafb73d8dcd0173d5d5c3c9a0770d0b3953db889dab99ef05b1907518cb815"
+	testBlockTxID2      = "6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4"
+	testBlockTxID3      = "e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d"
+)
+
+// TestMerkleProof_MerkleRoot will test the method MerkleRoot()
+func TestMerkleProof_MerkleRoot(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid proof", func(t *testing.T) {
+		proof := &MerkleProof{
+			Index:  2,
+			TxOrID: testBlockTxID2,
+			Nodes:  []string{testBlockTxID3, testBlockNode01},
+		}
+		root, err := proof.MerkleRoot()
+		require.NoError(t, err)
+		assert.Equal(t, testBlockMerkleRoot, root)
+	})
+
+	t.Run("wrong index", func(t *testing.T) {
+		proof := &MerkleProof{
+			Index:  3,
+			TxOrID: testBlockTxID2,
+			Nodes:  []string{testBlockTxID3, testBlockNode01},
+		}
+		root, err := proof.MerkleRoot()
+		require.NoError(t, err)
+		assert.NotEqual(t, testBlockMerkleRoot, root)
+	})
+
+	t.Run("invalid node", func(t *testing.T) {
+		proof := &MerkleProof{
+			Index:  2,
+			TxOrID: testBlockTxID2,
+			Nodes:  []string{"invalid"},
+		}
+		_, err := proof.MerkleRoot()
+		assert.ErrorIs(t, err, ErrInvalidMerkleProof)
+	})
+
+	t.Run("composite not supported", func(t *testing.T) {
+		proof := &MerkleProof{
+			Composite: true,
+			TxOrID:    testBlockTxID2,
+		}
+		_, err := proof.MerkleRoot()
+		assert.ErrorIs(t, err, ErrInvalidMerkleProof)
+	})
+}
+
+// TestMerkleProof_Verify will test the method Verify()
+func TestMerkleProof_Verify(t *testing.T) {
+	t.Parallel()
+
+	t.Run("merkle root target", func(t *testing.T) {
+		proof := &MerkleProof{
+			Index:      2,
+			TxOrID:     testBlockTxID2,
+			Nodes:      []string{testBlockTxID3, testBlockNode01},
+			Target:     testBlockMerkleRoot,
+			TargetType: MerkleTargetTypeMerkleRoot,
+		}
+		root, err := proof.Verify()
+		require.NoError(t, err)
+		assert.Equal(t, testBlockMerkleRoot, root)
+	})
+
+	t.Run("header target", func(t *testing.T) {
+		proof := &MerkleProof{
+			Index:      2,
+			TxOrID:     testBlockTxID2,
+			Nodes:      []string{testBlockTxID3, testBlockNode01},
+			Target:     testBlockHeader,
+			TargetType: MerkleTargetTypeHeader,
+		}
+		_, err := proof.Verify()
+		require.NoError(t, err)
+	})
+
+	t.Run("root mismatch", func(t *testing.T) {
+		proof := &MerkleProof{
+			Index:      2,
+			TxOrID:     testBlockTxID2,
+			Nodes:      []string{testBlockNode01, testBlockTxID3},
+			Target:     testBlockMerkleRoot,
+			TargetType: MerkleTargetTypeMerkleRoot,
+		}
+		_, err := proof.Verify()
+		assert.ErrorIs(t, err, ErrMerkleRootMismatch)
+	})
+}
+
+// TestNewMerklePathFromReader will test the method NewMerklePathFromReader()
+func TestNewMerklePathFromReader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid path", func(t *testing.T) {
+		path, err := NewMerklePathFromReader(bytes.NewReader(testMerklePathBytes(t)))
+		require.NoError(t, err)
+		require.NotNil(t, path)
+		assert.Equal(t, uint64(100000), path.BlockHeight)
+		require.Len(t, path.Path, 2)
+		assert.True(t, path.Path[0][0].TxID)
+
+		var root string
+		root, err = path.MerkleRoot(testBlockTxID2)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockMerkleRoot, root)
+	})
+
+	t.Run("tx not in path", func(t *testing.T) {
+		path, err := NewMerklePathFromReader(bytes.NewReader(testMerklePathBytes(t)))
+		require.NoError(t, err)
+
+		_, err = path.MerkleRoot(testBlockNode01)
+		assert.ErrorIs(t, err, ErrTransactionNotInProof)
+	})
+
+	t.Run("truncated path", func(t *testing.T) {
+		b := testMerklePathBytes(t)
+		_, err := NewMerklePathFromReader(bytes.NewReader(b[:len(b)-10]))
+		assert.ErrorIs(t, err, ErrInvalidMerkleProof)
+	})
+}
+
+// TestMerkleRootFromHeader will test the method MerkleRootFromHeader()
+func TestMerkleRootFromHeader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid header", func(t *testing.T) {
+		root, err := MerkleRootFromHeader(testBlockHeader)
+		require.NoError(t, err)
+		assert.Equal(t, testBlockMerkleRoot, root)
+	})
+
+	t.Run("invalid header", func(t *testing.T) {
+		_, err := MerkleRootFromHeader("0100")
+		assert.ErrorIs(t, err, ErrInvalidBlockHeader)
+	})
+}
+
+// testMerklePathBytes will return a BUMP (binary) for tx #2 in block #100000
+func testMerklePathBytes(t *testing.T) []byte {
+	hash := func(h string) []byte {
+		b, err := hex.DecodeString(h)
+		require.NoError(t, err)
+		return bt.ReverseBytes(b)
+	}
+
+	b := bt.VarInt(100000).Bytes()
+	b = append(b, 2)                           // tree height
+	b = append(b, 2)                           // level 0: two leaves
+	b = append(b, 2, merklePathFlagTxID)       // offset 2: client txid
+	b = append(b, hash(testBlockTxID2)...)     //
+	b = append(b, 3, merklePathFlagHash)       // offset 3: hash
+	b = append(b, hash(testBlockTxID3)...)     //
+	b = append(b, 1)                           // level 1: one leaf
+	b = append(b, 0, merklePathFlagHash)       // offset 0: hash
+	return append(b, hash(testBlockNode01)...) //
+}
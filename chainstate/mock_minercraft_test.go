@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/libsv/go-bk/envelope"
+	"github.com/libsv/go-bt/v2"
 	"github.com/tonicpow/go-minercraft"
 )
 
@@ -652,3 +653,111 @@ func (m *minerCraftTxNotFound) QueryTransaction(_ context.Context, miner *minerc
 
 	return nil, nil
 }
+
+type minerCraftDoubleSpend struct {
+	minerCraftBase
+}
+
+func (m *minerCraftDoubleSpend) SubmitTransaction(_ context.Context, miner *minercraft.Miner,
+	_ *minercraft.Transaction) (*minercraft.SubmitTransactionResponse, error) {
+	return &minercraft.SubmitTransactionResponse{
+		JSONEnvelope: minercraft.JSONEnvelope{
+			Miner:     miner,
+			Validated: true,
+			JSONEnvelope: envelope.JSONEnvelope{
+				Payload:  "{\"apiVersion\":\"\",\"timestamp\":\"2022-02-01T17:47:52.518Z\",\"txid\":\"15d31d00ed7533a83d7ab206115d7642812ec04a2cbae4248365febb82576ff3\",\"returnResult\":\"failure\",\"resultDescription\":\"ERROR: 258: txn-mempool-conflict\",\"minerId\":null,\"currentHighestBlockHash\":\"0000000000000000064c900b1fceb316302426aedb2242852530b5e78144f2c1\",\"currentHighestBlockHeight\":724816,\"txSecondMempoolExpiry\":0}",
+				Encoding: utf8Type,
+				MimeType: applicationJSONType,
+			},
+		},
+		Results: &minercraft.SubmissionPayload{
+			APIVersion:                "",
+			CurrentHighestBlockHash:   "0000000000000000064c900b1fceb316302426aedb2242852530b5e78144f2c1",
+			CurrentHighestBlockHeight: 724816,
+			MinerID:                   miner.MinerID,
+			ResultDescription:         "ERROR: 258: txn-mempool-conflict",
+			ReturnResult:              mAPIFailure,
+			Timestamp:                 "2022-02-01T17:47:52.518Z",
+			TxID:                      broadcastExample1TxID,
+		},
+	}, nil
+}
+
+func (m *minerCraftDoubleSpend) QueryTransaction(_ context.Context, miner *minercraft.Miner,
+	id string) (*minercraft.QueryTransactionResponse, error) {
+	return &minercraft.QueryTransactionResponse{
+		JSONEnvelope: minercraft.JSONEnvelope{
+			Miner:     miner,
+			Validated: true,
+		},
+		Query: &minercraft.QueryPayload{
+			Timestamp:         "2022-02-01T17:47:52.518Z",
+			TxID:              id,
+			ReturnResult:      mAPIFailure,
+			ResultDescription: "ERROR: 18: bad-txns-inputs-spent",
+			MinerID:           miner.MinerID,
+		},
+	}, nil
+}
+
+type minerCraftFeeQuote struct {
+	minerCraftBase
+	failing []string // Names of the miners that return an error
+	slow    []string // Names of the miners that do not respond (until the context is done)
+}
+
+func (m *minerCraftFeeQuote) FeeQuote(ctx context.Context, miner *minercraft.Miner) (*minercraft.FeeQuoteResponse, error) {
+	for _, name := range m.failing {
+		if strings.EqualFold(name, miner.Name) {
+			return nil, errors.New("fee quote failed for " + miner.Name)
+		}
+	}
+	for _, name := range m.slow {
+		if strings.EqualFold(name, miner.Name) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+	}
+
+	// Each miner quotes a different fee (based on the length of the name)
+	return &minercraft.FeeQuoteResponse{
+		JSONEnvelope: minercraft.JSONEnvelope{
+			Miner:     miner,
+			Validated: true,
+		},
+		Quote: &minercraft.FeePayload{
+			Fees: []*bt.Fee{{
+				FeeType:   bt.FeeTypeData,
+				MiningFee: bt.FeeUnit{Satoshis: 1, Bytes: 1000},
+				RelayFee:  bt.FeeUnit{Satoshis: 1, Bytes: 1000},
+			}, {
+				FeeType:   bt.FeeTypeStandard,
+				MiningFee: bt.FeeUnit{Satoshis: len(miner.Name), Bytes: 1000},
+				RelayFee:  bt.FeeUnit{Satoshis: 1, Bytes: 1000},
+			}},
+		},
+	}, nil
+}
+
+type minerCraftLowFee struct {
+	minerCraftTxNotFound
+}
+
+func (m *minerCraftLowFee) SubmitTransaction(_ context.Context, miner *minercraft.Miner,
+	_ *minercraft.Transaction) (*minercraft.SubmitTransactionResponse, error) {
+	return &minercraft.SubmitTransactionResponse{
+		JSONEnvelope: minercraft.JSONEnvelope{
+			Miner:     miner,
+			Validated: true,
+		},
+		Results: &minercraft.SubmissionPayload{
+			CurrentHighestBlockHash:   "0000000000000000064c900b1fceb316302426aedb2242852530b5e78144f2c1",
+			CurrentHighestBlockHeight: 724816,
+			MinerID:                   miner.MinerID,
+			ResultDescription:         "ERROR: 66: mempool min fee not met",
+			ReturnResult:              mAPIFailure,
+			Timestamp:                 "2022-02-01T17:47:52.518Z",
+			TxID:                      broadcastExample1TxID,
+		},
+	}, nil
+}
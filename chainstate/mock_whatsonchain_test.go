@@ -206,6 +206,19 @@ func (w *whatsOnChainTxOnChain) GetTxByHash(_ context.Context, hash string) (txI
 	return
 }
 
+func (w *whatsOnChainTxOnChain) GetMerkleProofTSC(_ context.Context, hash string) (merkleResults whatsonchain.MerkleTSCResults, err error) {
+
+	if hash == onChainExample1TxID {
+		merkleResults = whatsonchain.MerkleTSCResults{{
+			Index:  onChainExample1ProofIndex,
+			Nodes:  []string{onChainExample1ProofNode, "*"},
+			Target: onChainExample1BlockHash,
+			TxOrID: onChainExample1TxID,
+		}}
+	}
+	return
+}
+
 type whatsOnChainBroadcastSuccess struct {
 	whatsOnChainBase
 }
@@ -238,3 +251,62 @@ func (w *whatsOnChainTxNotFound) GetTxByHash(context.Context, string) (txInfo *w
 func (w *whatsOnChainTxNotFound) BroadcastTx(context.Context, string) (string, error) {
 	return "", errors.New("unexpected response code 500: mempool conflict")
 }
+
+type whatsOnChainBlockHeaders struct {
+	whatsOnChainBase
+}
+
+func (w *whatsOnChainBlockHeaders) GetChainInfo(context.Context) (*whatsonchain.ChainInfo, error) {
+	return &whatsonchain.ChainInfo{
+		BestBlockHash: testBlockHash,
+		Blocks:        int64(testBlockHeight),
+		Chain:         "main",
+	}, nil
+}
+
+func (w *whatsOnChainBlockHeaders) GetHeaderByHash(_ context.Context, hash string) (*whatsonchain.BlockInfo, error) {
+	if hash == testBlockHash {
+		return testBlockInfo(), nil
+	}
+	return nil, nil
+}
+
+func (w *whatsOnChainBlockHeaders) GetBlockByHeight(_ context.Context, height int64) (*whatsonchain.BlockInfo, error) {
+	if height == int64(testBlockHeight) {
+		return testBlockInfo(), nil
+	}
+	return nil, nil
+}
+
+// testBlockInfo will return the block information of block #100000
+func testBlockInfo() *whatsonchain.BlockInfo {
+	return &whatsonchain.BlockInfo{
+		Bits:              "1b04864c",
+		Hash:              testBlockHash,
+		Height:            int64(testBlockHeight),
+		MerkleRoot:        testBlockMerkleRoot,
+		Nonce:             274148111,
+		PreviousBlockHash: testBlockPrevHash,
+		Time:              1293623863,
+		TxCount:           4,
+		Version:           1,
+	}
+}
+
+type whatsOnChainUnspent struct {
+	whatsOnChainBase
+	scriptHash string
+}
+
+func (w *whatsOnChainUnspent) GetScriptUnspentTransactions(_ context.Context,
+	scriptHash string) (whatsonchain.ScriptList, error) {
+	if scriptHash != w.scriptHash {
+		return whatsonchain.ScriptList{}, nil
+	}
+	return whatsonchain.ScriptList{{
+		Height: int64(testBlockHeight),
+		TxHash: testBlockTxID2,
+		TxPos:  1,
+		Value:  1000,
+	}}, nil
+}
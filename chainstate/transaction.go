@@ -2,8 +2,10 @@ package chainstate
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mrz1836/go-mattercloud"
@@ -13,21 +15,26 @@ import (
 )
 
 // query will try ALL providers in order and return the first "valid" response based on requirements
+//
+// ErrDoubleSpend is returned if no valid response was found and a provider reported a double spend
 func (c *Client) query(ctx context.Context, id string, requiredIn RequiredIn,
-	timeout time.Duration) *TransactionInfo {
+	timeout time.Duration) (*TransactionInfo, error) {
 
 	// Create a context (to cancel or timeout)
 	ctxWithCancel, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	// First: try all mAPI miners (Only supported on main and test right now)
+	notFoundErr := ErrTransactionNotFound
 	if c.Network() == MainNet || c.Network() == TestNet {
 		for index := range c.options.config.mAPI.queryMiners {
 			if c.options.config.mAPI.queryMiners[index] != nil {
 				if res, err := queryMAPI(
 					ctxWithCancel, c, c.Minercraft(), c.options.config.mAPI.queryMiners[index], id,
 				); err == nil && checkRequirement(requiredIn, id, res) {
-					return res
+					return res, nil
+				} else if errors.Is(err, ErrDoubleSpend) {
+					notFoundErr = ErrDoubleSpend
 				}
 			}
 		}
@@ -37,14 +44,14 @@ func (c *Client) query(ctx context.Context, id string, requiredIn RequiredIn,
 	if resp, err := queryWhatsOnChain(
 		ctxWithCancel, c, c.WhatsOnChain(), id,
 	); err == nil && checkRequirement(requiredIn, id, resp) {
-		return resp
+		return resp, nil
 	}
 
 	// Next: try MatterCloud
 	if resp, err := queryMatterCloud(
 		ctxWithCancel, c, c.MatterCloud(), id,
 	); err == nil && checkRequirement(requiredIn, id, resp) {
-		return resp
+		return resp, nil
 	}
 
 	// Next: try NowNodes (if loaded)
@@ -53,17 +60,19 @@ func (c *Client) query(ctx context.Context, id string, requiredIn RequiredIn,
 		if resp, err := queryNowNodes(
 			ctxWithCancel, c, nn, id,
 		); err == nil && checkRequirement(requiredIn, id, resp) {
-			return resp
+			return resp, nil
 		}
 	}
 
 	// No transaction information found
-	return nil
+	return nil, notFoundErr
 }
 
 // fastestQuery will try ALL providers on once and return the fastest "valid" response based on requirements
+//
+// ErrDoubleSpend is returned if no valid response was found and a provider reported a double spend
 func (c *Client) fastestQuery(ctx context.Context, id string, requiredIn RequiredIn,
-	timeout time.Duration) *TransactionInfo {
+	timeout time.Duration) (*TransactionInfo, error) {
 
 	// The channel for the internal results
 	resultsChannel := make(
@@ -77,6 +86,7 @@ func (c *Client) fastestQuery(ctx context.Context, id string, requiredIn Require
 
 	// Loop each miner (break into a Go routine for each query)
 	var wg sync.WaitGroup
+	var doubleSpend int32
 	if c.Network() == MainNet || c.Network() == TestNet {
 		for index := range c.options.config.mAPI.queryMiners {
 			wg.Add(1)
@@ -90,6 +100,8 @@ func (c *Client) fastestQuery(ctx context.Context, id string, requiredIn Require
 					ctx, client, client.Minercraft(), miner, id,
 				); err == nil && checkRequirement(requiredIn, id, res) {
 					resultsChannel <- res
+				} else if errors.Is(err, ErrDoubleSpend) {
+					atomic.StoreInt32(&doubleSpend, 1)
 				}
 			}(ctxWithCancel, c, &wg, c.options.config.mAPI.queryMiners[index], id, requiredIn)
 		}
@@ -136,7 +148,12 @@ func (c *Client) fastestQuery(ctx context.Context, id string, requiredIn Require
 		close(resultsChannel)
 	}()
 
-	return <-resultsChannel
+	if info := <-resultsChannel; info != nil {
+		return info, nil
+	} else if atomic.LoadInt32(&doubleSpend) == 1 {
+		return nil, ErrDoubleSpend
+	}
+	return nil, ErrTransactionNotFound
 }
 
 // queryMAPI will submit a query transaction request to a miner using mAPI
@@ -146,6 +163,9 @@ func queryMAPI(ctx context.Context, client ClientInterface, minerCraft minercraf
 	if resp, err := minerCraft.QueryTransaction(ctx, miner, id); err != nil {
 		client.DebugLog("error executing request in mapi using miner: " + miner.Name + " failed: " + err.Error())
 		return nil, err
+	} else if resp != nil && resp.Query.ReturnResult == mAPIFailure &&
+		doesErrorContain(resp.Query.ResultDescription, doubleSpendErrors) {
+		return nil, ErrDoubleSpend
 	} else if resp != nil && resp.Query.ReturnResult == mAPISuccess && strings.EqualFold(resp.Query.TxID, id) {
 		return &TransactionInfo{
 			BlockHash:     resp.Query.BlockHash,
@@ -179,6 +199,48 @@ func queryWhatsOnChain(ctx context.Context, client ClientInterface,
 	return nil, ErrTransactionIDMismatch
 }
 
+// addMerkleProof will add the TSC merkle proof to a mined transaction (if not already found)
+//
+// Note: a missing proof is not an error, the transaction info is still valid
+func (c *Client) addMerkleProof(ctx context.Context, info *TransactionInfo, timeout time.Duration) {
+	if info.MerkleProof != nil || len(info.BlockHash) == 0 {
+		return
+	}
+
+	// Create a context (to cancel or timeout)
+	ctxWithCancel, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if proof, err := queryMerkleProofWhatsOnChain(
+		ctxWithCancel, c, c.WhatsOnChain(), info.ID,
+	); err == nil {
+		info.MerkleProof = proof
+	}
+}
+
+// queryMerkleProofWhatsOnChain will request WhatsOnChain for the TSC merkle proof of a transaction
+func queryMerkleProofWhatsOnChain(ctx context.Context, client ClientInterface,
+	whatsOnChain whatsonchain.TransactionService, id string) (*MerkleProof, error) {
+	client.DebugLog("executing merkle proof request in whatsonchain")
+	resp, err := whatsOnChain.GetMerkleProofTSC(ctx, id)
+	if err != nil {
+		client.DebugLog("error executing merkle proof request in whatsonchain: " + err.Error())
+		return nil, err
+	}
+	for _, proof := range resp {
+		if proof != nil && strings.EqualFold(proof.TxOrID, id) {
+			return &MerkleProof{
+				Index:      uint64(proof.Index),
+				Nodes:      proof.Nodes,
+				Target:     proof.Target,
+				TargetType: MerkleTargetTypeHash,
+				TxOrID:     proof.TxOrID,
+			}, nil
+		}
+	}
+	return nil, ErrMerkleProofNotFound
+}
+
 // queryMatterCloud will request MatterCloud for transaction information
 func queryMatterCloud(ctx context.Context, client ClientInterface,
 	matterCloud mattercloud.TransactionService, id string) (*TransactionInfo, error) {
@@ -56,6 +56,8 @@ func TestClient_Transaction(t *testing.T) {
 		assert.Equal(t, onChainExample1Confirmations, info.Confirmations)
 		assert.Equal(t, minerTaal.Name, info.Provider)
 		assert.Equal(t, "030d1fe5c1b560efe196ba40540ce9017c20daa9504c4c4cec6184fc702d9f274e", info.MinerID)
+		require.NotNil(t, info.MerkleProof)
+		assert.Equal(t, onChainExample1TxID, info.MerkleProof.TxOrID)
 	})
 
 	t.Run("mAPI not found - woc, mattercloud, nownodes", func(t *testing.T) {
@@ -78,6 +80,12 @@ func TestClient_Transaction(t *testing.T) {
 		assert.Equal(t, onChainExample1BlockHeight, info.BlockHeight)
 		assert.Equal(t, onChainExample1Confirmations, info.Confirmations)
 		assert.Equal(t, providerWhatsOnChain, info.Provider)
+		require.NotNil(t, info.MerkleProof)
+		assert.Equal(t, onChainExample1TxID, info.MerkleProof.TxOrID)
+		assert.Equal(t, uint64(onChainExample1ProofIndex), info.MerkleProof.Index)
+		assert.Equal(t, onChainExample1BlockHash, info.MerkleProof.Target)
+		assert.Equal(t, MerkleTargetTypeHash, info.MerkleProof.TargetType)
+		assert.Len(t, info.MerkleProof.Nodes, 2)
 	})
 
 	t.Run("mAPI, WOC not found - mattercloud, nownodes", func(t *testing.T) {
@@ -100,6 +108,7 @@ func TestClient_Transaction(t *testing.T) {
 		assert.Equal(t, onChainExample1BlockHeight, info.BlockHeight)
 		assert.Equal(t, onChainExample1Confirmations, info.Confirmations)
 		assert.Equal(t, providerMatterCloud, info.Provider)
+		assert.Nil(t, info.MerkleProof)
 	})
 
 	t.Run("mAPI, WOC, mattercloud not found - nownodes", func(t *testing.T) {
@@ -142,6 +151,24 @@ func TestClient_Transaction(t *testing.T) {
 		assert.ErrorIs(t, err, ErrTransactionNotFound)
 	})
 
+	t.Run("error - double spend", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithMinercraft(&minerCraftDoubleSpend{}),    // Inputs are spent by another TX
+			WithWhatsOnChain(&whatsOnChainTxNotFound{}), // NOT going to find the TX
+			WithMatterCloud(&matterCloudTxNotFound{}),   // NOT going to find the TX
+			WithNowNodes(&nowNodesTxNotFound{}),         // NOT going to find the TX
+		)
+
+		info, err := c.QueryTransaction(
+			context.Background(), onChainExample1TxID,
+			RequiredOnChain, defaultQueryTimeOut,
+		)
+		require.Error(t, err)
+		require.Nil(t, info)
+		assert.ErrorIs(t, err, ErrDoubleSpend)
+	})
+
 	t.Run("valid - stn network", func(t *testing.T) {
 		c := NewTestClient(
 			context.Background(), t,
@@ -273,6 +300,24 @@ func TestClient_TransactionFastest(t *testing.T) {
 		assert.ErrorIs(t, err, ErrTransactionNotFound)
 	})
 
+	t.Run("error - double spend", func(t *testing.T) {
+		c := NewTestClient(
+			context.Background(), t,
+			WithMinercraft(&minerCraftDoubleSpend{}),    // Inputs are spent by another TX
+			WithWhatsOnChain(&whatsOnChainTxNotFound{}), // NOT going to find the TX
+			WithMatterCloud(&matterCloudTxNotFound{}),   // NOT going to find the TX
+			WithNowNodes(&nowNodesTxNotFound{}),         // NOT going to find the TX
+		)
+
+		info, err := c.QueryTransactionFastest(
+			context.Background(), onChainExample1TxID,
+			RequiredOnChain, defaultQueryTimeOut,
+		)
+		require.Error(t, err)
+		require.Nil(t, info)
+		assert.ErrorIs(t, err, ErrDoubleSpend)
+	})
+
 	t.Run("valid - stn network", func(t *testing.T) {
 		c := NewTestClient(
 			context.Background(), t,
@@ -0,0 +1,42 @@
+package chainstate
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+)
+
+// QueryUtxoUnspent will check if the output (txID:outputIndex) is unspent according to the provider(s)
+//
+// Note: only WhatsOnChain is supported for utxos (queried by the script hash of the locking script)
+func (c *Client) QueryUtxoUnspent(ctx context.Context, txID string, outputIndex uint32,
+	lockingScript string, timeout time.Duration) (bool, error) {
+
+	script, err := hex.DecodeString(lockingScript)
+	if err != nil {
+		return false, err
+	}
+
+	// Create a context (to cancel or timeout)
+	ctxWithCancel, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c.DebugLog("executing unspent request in whatsonchain")
+	list, err := c.WhatsOnChain().GetScriptUnspentTransactions(
+		ctxWithCancel, hex.EncodeToString(bt.ReverseBytes(crypto.Sha256(script))),
+	)
+	if err != nil {
+		c.DebugLog("error executing unspent request in whatsonchain: " + err.Error())
+		return false, err
+	}
+	for _, record := range list {
+		if record != nil && strings.EqualFold(record.TxHash, txID) && record.TxPos == int64(outputIndex) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,47 @@
+package chainstate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLockingScript     = "76a9147ff514e6ae3deb46e6644caac5cdd0bf2388906588ac"
+	testLockingScriptHash = "9da6098afb7c4a1d92dea27100845e261d34309b7bd2bcc3662b35639d1104f7"
+)
+
+// TestClient_QueryUtxoUnspent will test the method QueryUtxoUnspent()
+func TestClient_QueryUtxoUnspent(t *testing.T) {
+	t.Parallel()
+
+	c := NewTestClient(context.Background(), t, WithWhatsOnChain(&whatsOnChainUnspent{
+		scriptHash: testLockingScriptHash,
+	}))
+
+	t.Run("unspent output", func(t *testing.T) {
+		unspent, err := c.QueryUtxoUnspent(
+			context.Background(), testBlockTxID2, 1, testLockingScript, defaultQueryTimeOut,
+		)
+		require.NoError(t, err)
+		assert.True(t, unspent)
+	})
+
+	t.Run("spent output", func(t *testing.T) {
+		unspent, err := c.QueryUtxoUnspent(
+			context.Background(), testBlockTxID2, 0, testLockingScript, defaultQueryTimeOut,
+		)
+		require.NoError(t, err)
+		assert.False(t, unspent)
+	})
+
+	t.Run("invalid locking script", func(t *testing.T) {
+		unspent, err := c.QueryUtxoUnspent(
+			context.Background(), testBlockTxID2, 1, "invalid", defaultQueryTimeOut,
+		)
+		require.Error(t, err)
+		assert.False(t, unspent)
+	})
+}
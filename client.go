@@ -2,6 +2,8 @@ package bux
 
 import (
 	"context"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/BuxOrg/bux/cachestore"
@@ -25,17 +27,25 @@ type (
 
 	// clientOptions holds all the configuration for the client
 	clientOptions struct {
-		cacheStore  *cacheStoreOptions  // Configuration options for Cachestore (ristretto, redis, etc.)
-		chainstate  *chainstateOptions  // Configuration options for Chainstate (broadcast, sync, etc.)
-		dataStore   *dataStoreOptions   // Configuration options for the DataStore (MySQL, etc.)
-		debug       bool                // If the client is in debug mode
-		itc         bool                // (Incoming Transactions Check) True will check incoming transactions via Miners (real-world)
-		logger      glogger.Interface   // Internal logging
-		models      *modelOptions       // Configuration options for the loaded models
-		newRelic    *newRelicOptions    // Configuration options for NewRelic
-		paymail     *paymailOptions     // Paymail options & client
-		taskManager *taskManagerOptions // Configuration options for the TaskManager (TaskQ, etc.)
-		userAgent   string              // User agent for all outgoing requests
+		adminKeys        []string              // Admin keys (xPubs or access keys) added to the Datastore on start-up
+		aipSigner        AipSigner             // Signs the AIP (author identity) of op_return outputs in drafts
+		authSignatureTTL time.Duration         // Time window of the signed requests (the used nonces are remembered)
+		cacheStore       *cacheStoreOptions    // Configuration options for Cachestore (ristretto, redis, etc.)
+		chainstate       *chainstateOptions    // Configuration options for Chainstate (broadcast, sync, etc.)
+		dataStore        *dataStoreOptions     // Configuration options for the DataStore (MySQL, etc.)
+		debug            bool                  // If the client is in debug mode
+		events           *eventOptions         // Event subscriptions (handlers & webhooks)
+		itc              bool                  // (Incoming Transactions Check) True will check incoming transactions via Miners (real-world)
+		logger           glogger.Interface     // Internal logging
+		models           *modelOptions         // Configuration options for the loaded models
+		newRelic         *newRelicOptions      // Configuration options for NewRelic
+		paymail          *paymailOptions       // Paymail options & client
+		rateLimits       map[string]*RateLimit // Rate limits of the actions per xPub or access key (see Allow)
+		signer           Signer                // Server-side signing of draft transactions (custodial xPubs)
+		taskManager      *taskManagerOptions   // Configuration options for the TaskManager (TaskQ, etc.)
+		userAgent        string                // User agent for all outgoing requests
+		utxos            *utxoOptions          // Configuration options for the utxos (coin selection, etc.)
+		xPubResolver     XPubResolver          // Resolves the raw xPubs of the paymail addresses (server-side key source)
 	}
 
 	// chainstateOptions holds the chainstate configuration and client
@@ -56,6 +66,13 @@ type (
 		options                   []datastore.ClientOps // List of options
 	}
 
+	// eventOptions holds the event subscriptions and the webhook configuration
+	eventOptions struct {
+		sync.RWMutex
+		httpClient    *http.Client         // Client for delivering the webhooks
+		subscriptions []*eventSubscription // List of subscriptions
+	}
+
 	// modelOptions holds the model configuration
 	modelOptions struct {
 		migrateModelNames []string      // List of models for migration
@@ -83,6 +100,12 @@ type (
 		DefaultNote           string // IE: some note for address resolution
 	}
 
+	// utxoOptions holds the configuration for the utxos
+	utxoOptions struct {
+		coinSelectors map[CoinSelectionStrategy]CoinSelector // Custom coin selection strategies (overrides the built-in strategies)
+		consolidation *UtxoConsolidationConfig               // Utxo consolidation task (disabled if not set)
+	}
+
 	// taskManagerOptions holds the configuration for taskmanager
 	taskManagerOptions struct {
 		taskmanager.ClientInterface                          // Client for TaskManager
@@ -150,6 +173,11 @@ func NewClient(ctx context.Context, opts ...ClientOps) (ClientInterface, error)
 		client.options.logger = logger.NewLogger(client.IsDebug())
 	}
 
+	// Add the admins (from the options, the models need the logger)
+	if err := client.loadAdmins(ctx); err != nil {
+		return nil, err
+	}
+
 	// Return the client
 	return client, nil
 }
@@ -307,54 +335,32 @@ func (c *Client) GetOrStartTxn(ctx context.Context, name string) context.Context
 	return ctx
 }
 
-// GetFeeUnit get the fee from a miner
-// todo: move into it's own Service / package
-func (c *Client) GetFeeUnit(_ context.Context, _ string) *utils.FeeUnit {
-	return defaultFee
-	/*
-		func (c *Client) GetFeeUnit(ctx context.Context, useMiner string) *utils.FeeUnit {
-			if useMiner == "" {
-				useMiner = minercraft.MinerTaal
-			}
-			cacheKey := "draft-transaction-fee-" + useMiner
-
-			var fee *utils.FeeUnit
-			err := c.Cachestore().GetModel(ctx, cacheKey, fee)
-			if err == nil && fee != nil {
-				return fee
-			}
-
-			var client *minercraft.Client
-			if client, err = minercraft.NewClient(nil, nil, nil); err != nil {
-				log.Printf("error occurred creating minercraft client: %s", err.Error())
-				return defaultFee
-			}
-
-			miner := client.MinerByName(useMiner)
-			var response *minercraft.FeeQuoteResponse
-			if response, err = client.FeeQuote(context.Background(), miner); err != nil {
-				log.Printf("error occurred getting fee quote: %s", err.Error())
-				return defaultFee
-			}
-
-			for _, quote := range response.Quote.Fee {
-				if quote.FeeType == "standard" && quote.MiningFee.Satoshis > 0 {
-					fee = &utils.FeeUnit{
-						Satoshis: quote.MiningFee.Satoshis,
-						Bytes:    quote.MiningFee.Bytes,
-					}
-					break
-				}
-			}
-
-			err = c.Cachestore().SetModel(ctx, cacheKey, fee)
-			if err != nil {
-				log.Printf("error occurred caching fee quote: %s", err.Error())
-			}
-
-			return fee
-		}
-	*/
+// AipSigner will return the AIP signer (nil if not set)
+func (c *Client) AipSigner() AipSigner {
+	return c.options.aipSigner
+}
+
+// CoinSelector will return the coin selector for the strategy (custom or built-in), nil if not found
+func (c *Client) CoinSelector(strategy CoinSelectionStrategy) CoinSelector {
+	if selector, ok := c.options.utxos.coinSelectors[strategy]; ok {
+		return selector
+	}
+	return newCoinSelector(strategy)
+}
+
+// GetFeeUnit get the fee from a miner (mAPI fee quote), defaults to the broadcast miners if useMiner is empty
+//
+// The default fee is returned if a fee quote was not found
+func (c *Client) GetFeeUnit(ctx context.Context, useMiner string) *utils.FeeUnit {
+	if c.Chainstate() == nil {
+		return defaultFee
+	}
+	feeUnit, err := getFeeUnit(ctx, c.Cachestore(), c.Chainstate(), useMiner)
+	if err != nil {
+		c.Logger().Warn(ctx, "error getting fee quote, using the default fee: "+err.Error())
+		return defaultFee
+	}
+	return feeUnit
 }
 
 // GetTaskPeriod will return the period for a given task name
@@ -424,6 +430,16 @@ func (c *Client) UserAgent() string {
 	return c.options.userAgent
 }
 
+// XPubResolver will return the resolver of the raw xPubs (the signer if not set, nil if neither is set)
+func (c *Client) XPubResolver() XPubResolver {
+	if c.options.xPubResolver != nil {
+		return c.options.xPubResolver
+	} else if c.options.signer != nil {
+		return c.options.signer
+	}
+	return nil
+}
+
 // Version will return the version
 func (c *Client) Version() string {
 	return version
@@ -0,0 +1,230 @@
+package bux
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/BuxOrg/bux/taskmanager"
+	"github.com/BuxOrg/bux/utils"
+	"github.com/pkg/errors"
+)
+
+// Subscribe will add an in-process event handler for the given event types (empty is all events)
+//
+// Returns the subscription id (used to unsubscribe)
+func (c *Client) Subscribe(handler EventHandler, eventTypes ...EventType) (string, error) {
+	if handler == nil {
+		return "", ErrMissingEventHandler
+	}
+	return c.options.events.addSubscription(&eventSubscription{
+		eventTypes: eventTypes,
+		handler:    handler,
+	})
+}
+
+// SubscribeWebhook will add a webhook for the given event types (webhook.EventTypes, empty is all events)
+//
+// The subscription id is derived from the URL (the same on every server and after a restart), subscribing
+// the URL again replaces the webhook. Webhooks are kept in memory: subscribe them on every server at startup,
+// so queued deliveries (retries) can be signed by any server.
+//
+// Returns the subscription id (used to unsubscribe)
+func (c *Client) SubscribeWebhook(webhook *Webhook) (string, error) {
+	if webhook == nil || len(webhook.Secret) == 0 {
+		return "", ErrInvalidWebhook
+	}
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return "", ErrInvalidWebhook
+	}
+	return c.options.events.setSubscription(&eventSubscription{
+		eventTypes: webhook.EventTypes,
+		id:         utils.Hash(webhook.URL),
+		webhook:    webhook,
+	}), nil
+}
+
+// Unsubscribe will remove an event subscription (handler or webhook)
+func (c *Client) Unsubscribe(id string) error {
+	c.options.events.Lock()
+	defer c.options.events.Unlock()
+	for index, subscription := range c.options.events.subscriptions {
+		if subscription.id == id {
+			c.options.events.subscriptions = append(
+				c.options.events.subscriptions[:index], c.options.events.subscriptions[index+1:]...,
+			)
+			return nil
+		}
+	}
+	return ErrEventSubscriptionNotFound
+}
+
+// PublishEvent will publish an event to all the subscribers of the event type
+//
+// In-process handlers are called directly, webhooks are delivered (and retried) using the taskmanager
+func (c *Client) PublishEvent(ctx context.Context, eventType EventType, data interface{}) {
+
+	// No subscribers
+	subscriptions := c.options.events.getSubscriptions(eventType)
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	event, err := newEvent(eventType, data)
+	if err != nil {
+		c.Logger().Error(ctx, "error creating event: "+err.Error())
+		return
+	}
+
+	var payload []byte
+	for _, subscription := range subscriptions {
+		if subscription.handler != nil {
+			subscription.handler(ctx, event)
+			continue
+		}
+
+		// Only marshal the event once (for all webhooks)
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				c.Logger().Error(ctx, "error marshaling event: "+err.Error())
+				return
+			}
+		}
+		if err = c.queueWebhook(ctx, &webhookDelivery{
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			SubscriptionID: subscription.id,
+			URL:            subscription.webhook.URL,
+		}); err != nil {
+			c.Logger().Error(ctx, "error queueing webhook: "+err.Error())
+		}
+	}
+}
+
+// queueWebhook will run the webhook task (or deliver the webhook directly if there is no taskmanager)
+func (c *Client) queueWebhook(ctx context.Context, delivery *webhookDelivery) error {
+	tm := c.Taskmanager()
+	if tm == nil {
+		return c.deliverWebhook(ctx, delivery)
+	}
+	return tm.RunTask(ctx, &taskmanager.TaskOptions{
+		Arguments: []interface{}{c, delivery},
+		TaskName:  webhookTaskName,
+	})
+}
+
+// deliverWebhook will post the event to the webhook, an error is returned if the delivery failed (retry)
+func (c *Client) deliverWebhook(ctx context.Context, delivery *webhookDelivery) error {
+
+	// The webhook was removed (or is not subscribed on this server), the delivery is dropped
+	subscription := c.options.events.getSubscription(delivery.SubscriptionID)
+	if subscription == nil || subscription.webhook == nil || subscription.webhook.URL != delivery.URL {
+		c.Logger().Warn(ctx, "webhook "+delivery.URL+" is not subscribed, dropping event "+delivery.EventID)
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, subscription.webhook.URL, bytes.NewReader(delivery.Payload),
+	)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().UTC().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.UserAgent())
+	req.Header.Set(webhookHeaderEvent, string(delivery.EventType))
+	req.Header.Set(webhookHeaderEventID, delivery.EventID)
+	req.Header.Set(webhookHeaderSignature, WebhookSignature(subscription.webhook.Secret, timestamp, delivery.Payload))
+	req.Header.Set(webhookHeaderTimestamp, timestamp)
+
+	var resp *http.Response
+	if resp, err = c.options.events.httpClient.Do(req); err != nil {
+		return errors.Wrap(ErrWebhookDeliveryFailed, err.Error())
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return errors.Wrap(ErrWebhookDeliveryFailed, "status code "+strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
+
+// registerEventTasks will register the webhook delivery task
+func (c *Client) registerEventTasks() error {
+
+	// No task manager loaded?
+	tm := c.Taskmanager()
+	if tm == nil {
+		return nil
+	}
+
+	return tm.RegisterTask(&taskmanager.Task{
+		Name:       webhookTaskName,
+		RetryLimit: defaultWebhookRetryLimit,
+		MinBackoff: defaultWebhookMinBackoff,
+		Handler: func(client *Client, delivery *webhookDelivery) error {
+			return client.deliverWebhook(context.Background(), delivery)
+		},
+	})
+}
+
+// addSubscription will add the subscription and return the new subscription id
+func (e *eventOptions) addSubscription(subscription *eventSubscription) (string, error) {
+	id, err := utils.RandomHex(16)
+	if err != nil {
+		return "", err
+	}
+	subscription.id = id
+
+	e.Lock()
+	defer e.Unlock()
+	e.subscriptions = append(e.subscriptions, subscription)
+	return id, nil
+}
+
+// setSubscription will add the subscription (or replace the subscription with the same id) and return the id
+func (e *eventOptions) setSubscription(subscription *eventSubscription) string {
+	e.Lock()
+	defer e.Unlock()
+	for index := range e.subscriptions {
+		if e.subscriptions[index].id == subscription.id {
+			e.subscriptions[index] = subscription
+			return subscription.id
+		}
+	}
+	e.subscriptions = append(e.subscriptions, subscription)
+	return subscription.id
+}
+
+// getSubscription will get the subscription by id
+func (e *eventOptions) getSubscription(id string) *eventSubscription {
+	e.RLock()
+	defer e.RUnlock()
+	for _, subscription := range e.subscriptions {
+		if subscription.id == id {
+			return subscription
+		}
+	}
+	return nil
+}
+
+// getSubscriptions will get the subscriptions for the event type
+func (e *eventOptions) getSubscriptions(eventType EventType) (subscriptions []*eventSubscription) {
+	e.RLock()
+	defer e.RUnlock()
+	for _, subscription := range e.subscriptions {
+		if subscription.matches(eventType) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return
+}
@@ -0,0 +1,277 @@
+package bux
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/BuxOrg/bux/taskmanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// taskManagerMockWebhooks will record the webhook tasks that are run
+type taskManagerMockWebhooks struct {
+	taskManagerMockBase
+	sync.Mutex
+	tasks []*taskmanager.TaskOptions
+}
+
+func (tm *taskManagerMockWebhooks) RunTask(_ context.Context, options *taskmanager.TaskOptions) error {
+	tm.Lock()
+	defer tm.Unlock()
+	if options.TaskName == webhookTaskName {
+		tm.tasks = append(tm.tasks, options)
+	}
+	return nil
+}
+
+// TestClient_Subscribe will test the method Subscribe()
+func TestClient_Subscribe(t *testing.T) {
+
+	t.Run("missing handler", func(t *testing.T) {
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		id, err := client.Subscribe(nil)
+		require.ErrorIs(t, err, ErrMissingEventHandler)
+		assert.Empty(t, id)
+	})
+
+	t.Run("transaction events", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+		)
+		defer deferMe()
+
+		var events, spentEvents []*Event
+		id, err := client.Subscribe(func(_ context.Context, event *Event) {
+			events = append(events, event)
+		}, EventTypeTransactionCreated)
+		require.NoError(t, err)
+		require.NotEmpty(t, id)
+
+		_, err = client.Subscribe(func(_ context.Context, event *Event) {
+			spentEvents = append(spentEvents, event)
+		}, EventTypeUtxoSpent)
+		require.NoError(t, err)
+
+		transaction, _ := createTestSyncTransaction(ctx, t, client)
+		require.Len(t, events, 1)
+		assert.Equal(t, EventTypeTransactionCreated, events[0].Type)
+		assert.NotEmpty(t, events[0].ID)
+		require.IsType(t, &Transaction{}, events[0].Data)
+		assert.Equal(t, transaction.ID, events[0].Data.(*Transaction).ID)
+
+		// The funding transaction does not spend any known utxos
+		assert.Empty(t, spentEvents)
+
+		// No more events after unsubscribing
+		require.NoError(t, client.Unsubscribe(id))
+		_, _ = createTestSyncTransaction(ctx, t, client)
+		assert.Len(t, events, 1)
+
+		require.ErrorIs(t, client.Unsubscribe(id), ErrEventSubscriptionNotFound)
+	})
+}
+
+// TestClient_SubscribeWebhook will test the method SubscribeWebhook()
+func TestClient_SubscribeWebhook(t *testing.T) {
+
+	t.Run("invalid webhooks", func(t *testing.T) {
+		_, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		for _, webhook := range []*Webhook{
+			nil,
+			{URL: "https://example.com/webhook"},
+			{URL: "", Secret: "secret"},
+			{URL: "ftp://example.com/webhook", Secret: "secret"},
+			{URL: "https://", Secret: "secret"},
+		} {
+			_, err := client.SubscribeWebhook(webhook)
+			assert.ErrorIs(t, err, ErrInvalidWebhook)
+		}
+	})
+
+	t.Run("webhook is queued", func(t *testing.T) {
+		tm := &taskManagerMockWebhooks{}
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(tm))
+		defer deferMe()
+
+		id, err := client.SubscribeWebhook(&Webhook{
+			EventTypes: []EventType{EventTypeChainReorg},
+			Secret:     "secret",
+			URL:        "https://example.com/webhook",
+		})
+		require.NoError(t, err)
+
+		client.PublishEvent(ctx, EventTypeTransactionCreated, &Transaction{})
+		assert.Empty(t, tm.tasks)
+
+		client.PublishEvent(ctx, EventTypeChainReorg, &ChainReorg{BlockHash: testTxID})
+		require.Len(t, tm.tasks, 1)
+		assert.Equal(t, webhookTaskName, tm.tasks[0].TaskName)
+		require.Len(t, tm.tasks[0].Arguments, 2)
+
+		delivery, ok := tm.tasks[0].Arguments[1].(*webhookDelivery)
+		require.True(t, ok)
+		assert.Equal(t, id, delivery.SubscriptionID)
+		assert.Equal(t, "https://example.com/webhook", delivery.URL)
+		assert.Equal(t, EventTypeChainReorg, delivery.EventType)
+
+		var event struct {
+			Data *ChainReorg `json:"data"`
+			ID   string      `json:"id"`
+			Type EventType   `json:"type"`
+		}
+		require.NoError(t, json.Unmarshal(delivery.Payload, &event))
+		assert.Equal(t, delivery.EventID, event.ID)
+		assert.Equal(t, testTxID, event.Data.BlockHash)
+	})
+
+	t.Run("same url replaces the webhook", func(t *testing.T) {
+		tm := &taskManagerMockWebhooks{}
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(tm))
+		defer deferMe()
+
+		id, err := client.SubscribeWebhook(&Webhook{
+			EventTypes: []EventType{EventTypeChainReorg},
+			Secret:     "secret",
+			URL:        "https://example.com/webhook",
+		})
+		require.NoError(t, err)
+
+		var otherID string
+		otherID, err = client.SubscribeWebhook(&Webhook{
+			EventTypes: []EventType{EventTypeTransactionCreated},
+			Secret:     "other-secret",
+			URL:        "https://example.com/webhook",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, id, otherID)
+
+		client.PublishEvent(ctx, EventTypeChainReorg, &ChainReorg{BlockHash: testTxID})
+		assert.Empty(t, tm.tasks)
+		client.PublishEvent(ctx, EventTypeTransactionCreated, &Transaction{})
+		assert.Len(t, tm.tasks, 1)
+	})
+}
+
+// TestClient_deliverWebhook will test the method deliverWebhook()
+func TestClient_deliverWebhook(t *testing.T) {
+
+	t.Run("signed delivery", func(t *testing.T) {
+		payload := []byte(`{"id":"123","type":"chain_reorg"}`)
+
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, payload, body)
+			assert.Equal(t, string(EventTypeChainReorg), r.Header.Get(webhookHeaderEvent))
+			assert.Equal(t, "123", r.Header.Get(webhookHeaderEventID))
+			assert.Equal(t,
+				WebhookSignature("secret", r.Header.Get(webhookHeaderTimestamp), body),
+				r.Header.Get(webhookHeaderSignature),
+			)
+			assert.NotEqual(t,
+				WebhookSignature("other-secret", r.Header.Get(webhookHeaderTimestamp), body),
+				r.Header.Get(webhookHeaderSignature),
+			)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		id, err := client.SubscribeWebhook(&Webhook{Secret: "secret", URL: server.URL})
+		require.NoError(t, err)
+
+		delivery := &webhookDelivery{
+			EventID:        "123",
+			EventType:      EventTypeChainReorg,
+			Payload:        payload,
+			SubscriptionID: id,
+			URL:            server.URL,
+		}
+		require.NoError(t, client.(*Client).deliverWebhook(ctx, delivery))
+		assert.Equal(t, 1, requests)
+
+		// Removed webhooks are not delivered
+		require.NoError(t, client.Unsubscribe(id))
+		require.NoError(t, client.(*Client).deliverWebhook(ctx, delivery))
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("failed delivery", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		id, err := client.SubscribeWebhook(&Webhook{Secret: "secret", URL: server.URL})
+		require.NoError(t, err)
+
+		err = client.(*Client).deliverWebhook(ctx, &webhookDelivery{
+			EventID:        "123",
+			EventType:      EventTypeChainReorg,
+			Payload:        []byte(`{}`),
+			SubscriptionID: id,
+			URL:            server.URL,
+		})
+		require.ErrorIs(t, err, ErrWebhookDeliveryFailed)
+	})
+
+	t.Run("queued delivery on another server", func(t *testing.T) {
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.Equal(t,
+				WebhookSignature("secret", r.Header.Get(webhookHeaderTimestamp), body),
+				r.Header.Get(webhookHeaderSignature),
+			)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		// The event is queued by the first server
+		tm := &taskManagerMockWebhooks{}
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(tm))
+		defer deferMe()
+		_, err := client.SubscribeWebhook(&Webhook{Secret: "secret", URL: server.URL})
+		require.NoError(t, err)
+		client.PublishEvent(ctx, EventTypeChainReorg, &ChainReorg{BlockHash: testTxID})
+		require.Len(t, tm.tasks, 1)
+		delivery, ok := tm.tasks[0].Arguments[1].(*webhookDelivery)
+		require.True(t, ok)
+
+		// Another server (or the same server after a restart) without the webhook drops the delivery
+		var otherClient ClientInterface
+		var otherDeferMe func()
+		_, otherClient, otherDeferMe = CreateTestSQLiteClient(
+			t, false, false, WithCustomTaskManager(&taskManagerMockBase{}),
+		)
+		defer otherDeferMe()
+		require.NoError(t, otherClient.(*Client).deliverWebhook(ctx, delivery))
+		assert.Equal(t, 0, requests)
+
+		// The webhook is subscribed at startup, the delivery is signed with its secret
+		_, err = otherClient.SubscribeWebhook(&Webhook{Secret: "secret", URL: server.URL})
+		require.NoError(t, err)
+		require.NoError(t, otherClient.(*Client).deliverWebhook(ctx, delivery))
+		assert.Equal(t, 1, requests)
+	})
+}
@@ -7,9 +7,31 @@ import (
 	"github.com/BuxOrg/bux/chainstate"
 	"github.com/BuxOrg/bux/datastore"
 	"github.com/BuxOrg/bux/taskmanager"
+	"github.com/BuxOrg/bux/utils"
 	"github.com/tonicpow/go-paymail"
 )
 
+// loadAdmins will add the admin keys (from the options) to the Datastore
+//
+// Admins that already exist (or were removed) are skipped, removing an admin is not reverted on start-up
+func (c *Client) loadAdmins(ctx context.Context) error {
+	for _, key := range c.options.adminKeys {
+		if err := validateAdminKey(key); err != nil {
+			return err
+		}
+		admin, err := getAdmin(ctx, utils.Hash(key), c.DefaultModelOptions(WithDeleted())...)
+		if err != nil {
+			return err
+		} else if admin != nil {
+			continue
+		}
+		if err = newAdmin(key, c.DefaultModelOptions(New())...).Save(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // loadCache will load caching configuration and start the Cachestore client
 func (c *Client) loadCache(ctx context.Context) (err error) {
 
@@ -93,8 +115,11 @@ func (c *Client) runModelRegisterTasks(models ...interface{}) (err error) {
 	return
 }
 
-// registerAllTasks will register all tasks for all models
+// registerAllTasks will register all tasks for all models (and the event tasks)
 func (c *Client) registerAllTasks() error {
 	c.Taskmanager().ResetCron()
-	return c.runModelRegisterTasks(c.options.models.models...)
+	if err := c.runModelRegisterTasks(c.options.models.models...); err != nil {
+		return err
+	}
+	return c.registerEventTasks()
 }
@@ -2,6 +2,7 @@ package bux
 
 import (
 	"database/sql"
+	"net/http"
 	"strings"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/BuxOrg/bux/chainstate"
 	"github.com/BuxOrg/bux/datastore"
 	"github.com/BuxOrg/bux/taskmanager"
+	"github.com/BuxOrg/bux/utils"
 	"github.com/OrlovEvgeny/go-mcache"
 	"github.com/dgraph-io/ristretto"
 	"github.com/go-redis/redis/v8"
@@ -33,6 +35,9 @@ func defaultClientOptions() *clientOptions {
 	// Set the default options
 	return &clientOptions{
 
+		// Time window of the signed requests
+		authSignatureTTL: AuthSignatureTTL,
+
 		// Incoming Transaction Checker (lookup external tx via miner for validity)
 		itc: true,
 
@@ -54,6 +59,11 @@ func defaultClientOptions() *clientOptions {
 			options:         []datastore.ClientOps{},
 		},
 
+		// Blank event config (no subscriptions)
+		events: &eventOptions{
+			httpClient: &http.Client{Timeout: defaultWebhookTimeout},
+		},
+
 		// Blank model options (use the Base models)
 		models: &modelOptions{
 			modelNames:        modelNames(BaseModels...),
@@ -65,6 +75,9 @@ func defaultClientOptions() *clientOptions {
 		// Blank NewRelic config
 		newRelic: &newRelicOptions{},
 
+		// No rate limits
+		rateLimits: make(map[string]*RateLimit),
+
 		// Blank Paymail config
 		paymail: &paymailOptions{
 			client: nil,
@@ -77,15 +90,22 @@ func defaultClientOptions() *clientOptions {
 		taskManager: &taskManagerOptions{
 			ClientInterface: nil,
 			cronTasks: map[string]time.Duration{
+				ModelBlockHeader.String() + "_sync":            60 * time.Second,
 				ModelDraftTransaction.String() + "_clean_up":   60 * time.Second,
 				ModelIncomingTransaction.String() + "_process": 30 * time.Second,
 				ModelSyncTransaction.String() + "_broadcast":   30 * time.Second,
 				ModelSyncTransaction.String() + "_sync":        30 * time.Second,
+				ModelUtxo.String() + "_consolidate":            10 * time.Minute,
 			},
 		},
 
 		// Default user agent
 		userAgent: defaultUserAgent,
+
+		// Blank utxo config (built-in coin selection strategies)
+		utxos: &utxoOptions{
+			coinSelectors: make(map[CoinSelectionStrategy]CoinSelector),
+		},
 	}
 }
 
@@ -224,6 +244,90 @@ func WithITCDisabled() ClientOps {
 	}
 }
 
+// WithCoinSelector will add a custom coin selection strategy (or override a built-in strategy)
+func WithCoinSelector(strategy CoinSelectionStrategy, selector CoinSelector) ClientOps {
+	return func(c *clientOptions) {
+		if len(strategy) > 0 && selector != nil {
+			c.utxos.coinSelectors[strategy] = selector
+		}
+	}
+}
+
+// WithUtxoConsolidation will enable the utxo consolidation task (resolver is required)
+func WithUtxoConsolidation(config *UtxoConsolidationConfig) ClientOps {
+	return func(c *clientOptions) {
+		if config != nil && config.Resolver != nil {
+			config.setDefaults()
+			c.utxos.consolidation = config
+		}
+	}
+}
+
+// WithSigner will enable server-side signing (SendTransaction) using the signer
+func WithSigner(signer Signer) ClientOps {
+	return func(c *clientOptions) {
+		if signer != nil {
+			c.signer = signer
+		}
+	}
+}
+
+// WithXPubResolver will set the resolver of the raw xPubs (used by the paymail service provider)
+//
+// The raw xPubs of the paymail addresses are not stored, the signer is used if no resolver is set
+func WithXPubResolver(resolver XPubResolver) ClientOps {
+	return func(c *clientOptions) {
+		if resolver != nil {
+			c.xPubResolver = resolver
+		}
+	}
+}
+
+// WithAipSigner will enable signing the AIP (author identity) of op_return outputs in drafts
+func WithAipSigner(signer AipSigner) ClientOps {
+	return func(c *clientOptions) {
+		if signer != nil {
+			c.aipSigner = signer
+		}
+	}
+}
+
+// WithAdminKeys will add the admin keys (xPubs or public keys of access keys) on start-up
+//
+// The admins are saved in the Datastore and can be managed at runtime (AddAdmin, RemoveAdmin)
+func WithAdminKeys(keys ...string) ClientOps {
+	return func(c *clientOptions) {
+		for _, key := range keys {
+			if len(key) > 0 && !utils.StringInSlice(key, c.adminKeys) {
+				c.adminKeys = append(c.adminKeys, key)
+			}
+		}
+	}
+}
+
+// WithAuthSignatureTTL will set the time window of the signed requests (default: AuthSignatureTTL)
+//
+// The nonces of the signed requests are remembered (in the Cachestore) for the length of the window
+func WithAuthSignatureTTL(ttl time.Duration) ClientOps {
+	return func(c *clientOptions) {
+		if ttl > 0 {
+			c.authSignatureTTL = ttl
+		}
+	}
+}
+
+// WithRateLimit will limit an action (RateLimitDraft, RateLimitRecord, RateLimitRead) to a number of
+// requests per period for each xPub or access key
+//
+// The token buckets are stored in the Cachestore, use Redis to share the limits between servers
+func WithRateLimit(action string, limit int64, period time.Duration) ClientOps {
+	return func(c *clientOptions) {
+		if len(action) > 0 && limit > 0 && period > 0 {
+			c.rateLimits[action] = &RateLimit{Limit: limit, Period: period}
+		}
+	}
+}
+
 // WithLogger will set the custom logger interface
 func WithLogger(customLogger logger.Interface) ClientOps {
 	return func(c *clientOptions) {
@@ -3,6 +3,7 @@ package bux
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/BuxOrg/bux/cachestore"
 	"github.com/BuxOrg/bux/datastore"
@@ -482,3 +483,65 @@ func TestWithCustomChainstate(t *testing.T) {
 func TestWithModels(t *testing.T) {
 	// finish this!
 }
+
+// TestWithAuthSignatureTTL will test the method WithAuthSignatureTTL()
+func TestWithAuthSignatureTTL(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default time window", func(t *testing.T) {
+		opts := DefaultClientOpts(t, false, true)
+		opts = append(opts, WithAuthSignatureTTL(0))
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, AuthSignatureTTL, tc.(*Client).options.authSignatureTTL)
+	})
+
+	t.Run("custom time window", func(t *testing.T) {
+		opts := DefaultClientOpts(t, false, true)
+		opts = append(opts, WithAuthSignatureTTL(2*time.Minute))
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, 2*time.Minute, tc.(*Client).options.authSignatureTTL)
+	})
+}
+
+// TestWithRateLimit will test the method WithRateLimit()
+func TestWithRateLimit(t *testing.T) {
+	t.Run("check type", func(t *testing.T) {
+		opt := WithRateLimit(RateLimitDraft, 10, time.Minute)
+		assert.IsType(t, *new(ClientOps), opt)
+	})
+
+	t.Run("invalid rate limits", func(t *testing.T) {
+		options := defaultClientOptions()
+		WithRateLimit("", 10, time.Minute)(options)
+		WithRateLimit(RateLimitDraft, 0, time.Minute)(options)
+		WithRateLimit(RateLimitDraft, 10, 0)(options)
+		assert.Len(t, options.rateLimits, 0)
+	})
+
+	t.Run("set rate limits", func(t *testing.T) {
+		opts := DefaultClientOpts(t, false, true)
+		opts = append(opts,
+			WithRateLimit(RateLimitDraft, 10, time.Minute),
+			WithRateLimit(RateLimitRead, 100, time.Second),
+		)
+
+		tc, err := NewClient(tester.GetNewRelicCtx(t, defaultNewRelicApp, defaultNewRelicTx), opts...)
+		require.NoError(t, err)
+		require.NotNil(t, tc)
+		defer CloseClient(context.Background(), t, tc)
+
+		assert.Equal(t, &RateLimit{Limit: 10, Period: time.Minute}, tc.(*Client).options.rateLimits[RateLimitDraft])
+		assert.Equal(t, &RateLimit{Limit: 100, Period: time.Second}, tc.(*Client).options.rateLimits[RateLimitRead])
+		assert.Nil(t, tc.(*Client).options.rateLimits[RateLimitRecord])
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/BuxOrg/bux/cachestore"
 	"github.com/BuxOrg/bux/datastore"
 	"github.com/BuxOrg/bux/tester"
+	"github.com/libsv/go-bt/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tonicpow/go-paymail"
@@ -147,7 +148,55 @@ func TestClient_Close(t *testing.T) {
 
 // TestClient_GetFeeUnit will test the method GetFeeUnit()
 func TestClient_GetFeeUnit(t *testing.T) {
-	// finish test
+
+	t.Run("no fee quote - default fee", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, false,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateBase{}),
+		)
+		defer deferMe()
+
+		assert.Equal(t, defaultFee, client.GetFeeUnit(ctx, ""))
+	})
+
+	t.Run("fee quote is cached per miner", func(t *testing.T) {
+		chain := &chainStateFeeQuote{feeUnit: bt.FeeUnit{Satoshis: 5, Bytes: 10}}
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, false,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(chain),
+		)
+		defer deferMe()
+
+		feeUnit := client.GetFeeUnit(ctx, "Taal")
+		require.NotNil(t, feeUnit)
+		assert.Equal(t, 5, feeUnit.Satoshis)
+		assert.Equal(t, 10, feeUnit.Bytes)
+
+		// The second request uses the cached quote
+		feeUnit = client.GetFeeUnit(ctx, "Taal")
+		require.NotNil(t, feeUnit)
+		assert.Equal(t, 5, feeUnit.Satoshis)
+		assert.Equal(t, []string{"Taal"}, chain.requests)
+
+		_ = client.GetFeeUnit(ctx, "")
+		assert.Equal(t, []string{"Taal", ""}, chain.requests)
+	})
+
+	t.Run("failed fee quote is cached", func(t *testing.T) {
+		chain := &chainStateFeeQuote{failing: true}
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, false,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(chain),
+		)
+		defer deferMe()
+
+		assert.Equal(t, defaultFee, client.GetFeeUnit(ctx, "Taal"))
+		assert.Equal(t, defaultFee, client.GetFeeUnit(ctx, "Taal"))
+		assert.Equal(t, []string{"Taal"}, chain.requests)
+	})
 }
 
 // TestClient_PaymailClient will test the method PaymailClient()
@@ -0,0 +1,166 @@
+package bux
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sort"
+)
+
+// CoinSelector will select the utxos (inputs) to spend for the given amount of satoshis
+//
+// The fee of each selected input (feePerInput) is added to the satoshis needed,
+// if the utxos do not cover the satoshis needed, all the utxos are returned
+type CoinSelector interface {
+	SelectCoins(utxos []*Utxo, satoshis, feePerInput uint64) ([]*Utxo, error)
+}
+
+// newCoinSelector will return the built-in coin selector for the strategy (nil if not found)
+func newCoinSelector(strategy CoinSelectionStrategy) CoinSelector {
+	switch strategy {
+	case CoinSelectionStrategyDefault, "":
+		return &defaultCoinSelector{}
+	case CoinSelectionStrategyLargestFirst:
+		return &largestFirstCoinSelector{}
+	case CoinSelectionStrategySmallestFirst:
+		return &smallestFirstCoinSelector{}
+	case CoinSelectionStrategyBranchAndBound:
+		return &branchAndBoundCoinSelector{
+			costOfChange: dustLimit,
+			maxTries:     defaultCoinSelectionTries,
+		}
+	case CoinSelectionStrategyRandom:
+		return &randomCoinSelector{}
+	}
+	return nil
+}
+
+// defaultCoinSelector will select the utxos in the given (database) order
+type defaultCoinSelector struct{}
+
+// SelectCoins will select the utxos in the given order
+func (s *defaultCoinSelector) SelectCoins(utxos []*Utxo, satoshis, feePerInput uint64) ([]*Utxo, error) {
+	return selectCoinsInOrder(utxos, satoshis, feePerInput), nil
+}
+
+// largestFirstCoinSelector will select the largest utxos first (the least amount of inputs)
+type largestFirstCoinSelector struct{}
+
+// SelectCoins will select the largest utxos first
+func (s *largestFirstCoinSelector) SelectCoins(utxos []*Utxo, satoshis, feePerInput uint64) ([]*Utxo, error) {
+	return selectCoinsInOrder(sortUtxosBySatoshis(utxos, true), satoshis, feePerInput), nil
+}
+
+// smallestFirstCoinSelector will select the smallest utxos first (consolidates the wallet)
+type smallestFirstCoinSelector struct{}
+
+// SelectCoins will select the smallest utxos first
+func (s *smallestFirstCoinSelector) SelectCoins(utxos []*Utxo, satoshis, feePerInput uint64) ([]*Utxo, error) {
+	return selectCoinsInOrder(sortUtxosBySatoshis(utxos, false), satoshis, feePerInput), nil
+}
+
+// randomCoinSelector will select the utxos in a random order (privacy)
+type randomCoinSelector struct{}
+
+// SelectCoins will select the utxos in a random order
+func (s *randomCoinSelector) SelectCoins(utxos []*Utxo, satoshis, feePerInput uint64) ([]*Utxo, error) {
+	shuffled := make([]*Utxo, len(utxos))
+	copy(shuffled, utxos)
+	for i := len(shuffled) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, err
+		}
+		shuffled[i], shuffled[j.Int64()] = shuffled[j.Int64()], shuffled[i]
+	}
+	return selectCoinsInOrder(shuffled, satoshis, feePerInput), nil
+}
+
+// branchAndBoundCoinSelector will search for a set of utxos that matches the satoshis needed (no change)
+//
+// The value of the set (minus the input fees) has to be between the satoshis needed and the cost of a
+// change output, if no set was found (within the max tries) the largest utxos are selected first
+type branchAndBoundCoinSelector struct {
+	costOfChange uint64 // Excess satoshis that are allowed (cheaper than adding change)
+	maxTries     int    // Max number of branches to search
+}
+
+// SelectCoins will select an exact match of utxos (or the largest utxos first)
+func (s *branchAndBoundCoinSelector) SelectCoins(utxos []*Utxo, satoshis, feePerInput uint64) ([]*Utxo, error) {
+
+	// Only utxos that are worth more than their fee (largest first)
+	candidates := make([]*Utxo, 0, len(utxos))
+	for _, utxo := range sortUtxosBySatoshis(utxos, true) {
+		if utxo.Satoshis > feePerInput {
+			candidates = append(candidates, utxo)
+		}
+	}
+
+	// Remaining (effective) value from each candidate to the end
+	remaining := make([]uint64, len(candidates)+1)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + candidates[i].Satoshis - feePerInput
+	}
+
+	var (
+		best   []*Utxo
+		search func(index int, selected []*Utxo, value uint64) bool
+		tries  int
+	)
+	search = func(index int, selected []*Utxo, value uint64) bool {
+		if tries >= s.maxTries {
+			return true
+		}
+		tries++
+
+		// Enough satoshis, exact match if the excess is below the cost of change
+		if value >= satoshis {
+			if value <= satoshis+s.costOfChange {
+				best = append([]*Utxo{}, selected...)
+				return true
+			}
+			return false
+		} else if value+remaining[index] < satoshis {
+			return false
+		}
+
+		// Include the utxo, then try without it
+		if search(index+1, append(selected, candidates[index]), value+candidates[index].Satoshis-feePerInput) {
+			return true
+		}
+		return search(index+1, selected, value)
+	}
+
+	if search(0, make([]*Utxo, 0, len(candidates)), 0); len(best) > 0 {
+		return best, nil
+	}
+	return selectCoinsInOrder(candidates, satoshis, feePerInput), nil
+}
+
+// selectCoinsInOrder will select the utxos in order until the satoshis (and the input fees) are covered
+func selectCoinsInOrder(utxos []*Utxo, satoshis, feePerInput uint64) []*Utxo {
+	selected := make([]*Utxo, 0)
+	reservedSatoshis := uint64(0)
+	feeNeeded := uint64(0)
+	for _, utxo := range utxos {
+		selected = append(selected, utxo)
+		reservedSatoshis += utxo.Satoshis
+		feeNeeded += feePerInput
+		if reservedSatoshis >= satoshis+feeNeeded {
+			break
+		}
+	}
+	return selected
+}
+
+// sortUtxosBySatoshis will return a copy of the utxos sorted by satoshis
+func sortUtxosBySatoshis(utxos []*Utxo, descending bool) []*Utxo {
+	sorted := make([]*Utxo, len(utxos))
+	copy(sorted, utxos)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if descending {
+			return sorted[i].Satoshis > sorted[j].Satoshis
+		}
+		return sorted[i].Satoshis < sorted[j].Satoshis
+	})
+	return sorted
+}
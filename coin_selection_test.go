@@ -0,0 +1,120 @@
+package bux
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCoinSelectionUtxos will return utxos with the given satoshis (the output index is the position)
+func testCoinSelectionUtxos(satoshis ...uint64) []*Utxo {
+	utxos := make([]*Utxo, 0, len(satoshis))
+	for index, value := range satoshis {
+		utxos = append(utxos, newUtxo(testXPubID, testTxID, testLockingScript, uint32(index), value, New()))
+	}
+	return utxos
+}
+
+// testSelectedSatoshis will return the satoshis of the selected utxos
+func testSelectedSatoshis(utxos []*Utxo) (satoshis []uint64) {
+	for _, utxo := range utxos {
+		satoshis = append(satoshis, utxo.Satoshis)
+	}
+	return
+}
+
+// Test_newCoinSelector will test the method newCoinSelector()
+func Test_newCoinSelector(t *testing.T) {
+	t.Parallel()
+
+	for _, strategy := range []CoinSelectionStrategy{
+		"",
+		CoinSelectionStrategyDefault,
+		CoinSelectionStrategyBranchAndBound,
+		CoinSelectionStrategyLargestFirst,
+		CoinSelectionStrategyRandom,
+		CoinSelectionStrategySmallestFirst,
+	} {
+		assert.NotNil(t, newCoinSelector(strategy), strategy)
+	}
+	assert.Nil(t, newCoinSelector("unknown"))
+}
+
+// TestCoinSelector_SelectCoins will test the method SelectCoins() of the built-in strategies
+func TestCoinSelector_SelectCoins(t *testing.T) {
+	t.Parallel()
+
+	utxos := testCoinSelectionUtxos(3000, 1000, 5000, 2000)
+
+	t.Run("default - database order", func(t *testing.T) {
+		selected, err := newCoinSelector(CoinSelectionStrategyDefault).SelectCoins(utxos, 3500, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{3000, 1000}, testSelectedSatoshis(selected))
+	})
+
+	t.Run("largest first", func(t *testing.T) {
+		selected, err := newCoinSelector(CoinSelectionStrategyLargestFirst).SelectCoins(utxos, 3500, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{5000}, testSelectedSatoshis(selected))
+	})
+
+	t.Run("smallest first", func(t *testing.T) {
+		selected, err := newCoinSelector(CoinSelectionStrategySmallestFirst).SelectCoins(utxos, 3500, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{1000, 2000, 3000}, testSelectedSatoshis(selected))
+	})
+
+	t.Run("input fees are covered", func(t *testing.T) {
+		selected, err := newCoinSelector(CoinSelectionStrategySmallestFirst).SelectCoins(utxos, 2990, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{1000, 2000, 3000}, testSelectedSatoshis(selected))
+	})
+
+	t.Run("not enough - all utxos", func(t *testing.T) {
+		selected, err := newCoinSelector(CoinSelectionStrategyLargestFirst).SelectCoins(utxos, 20000, 10)
+		require.NoError(t, err)
+		assert.Len(t, selected, 4)
+	})
+
+	t.Run("random", func(t *testing.T) {
+		selected, err := newCoinSelector(CoinSelectionStrategyRandom).SelectCoins(utxos, 10960, 10)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{3000, 1000, 5000, 2000}, testSelectedSatoshis(selected))
+
+		// The given utxos are not modified
+		assert.Equal(t, []uint64{3000, 1000, 5000, 2000}, testSelectedSatoshis(utxos))
+	})
+
+	t.Run("branch and bound - exact match", func(t *testing.T) {
+		selector := &branchAndBoundCoinSelector{costOfChange: 0, maxTries: defaultCoinSelectionTries}
+		selected, err := selector.SelectCoins(
+			testCoinSelectionUtxos(6000, 3000, 2500, 2000, 1000), 4980, 10,
+		)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{3000, 2000}, testSelectedSatoshis(selected))
+	})
+
+	t.Run("branch and bound - match within the cost of change", func(t *testing.T) {
+		selected, err := newCoinSelector(CoinSelectionStrategyBranchAndBound).SelectCoins(
+			testCoinSelectionUtxos(10000, 4000, 1200), 5000, 10,
+		)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{4000, 1200}, testSelectedSatoshis(selected))
+	})
+
+	t.Run("branch and bound - no match, largest first", func(t *testing.T) {
+		selected, err := newCoinSelector(CoinSelectionStrategyBranchAndBound).SelectCoins(
+			testCoinSelectionUtxos(1000, 10000), 5000, 10,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{10000}, testSelectedSatoshis(selected))
+	})
+
+	t.Run("branch and bound - max tries", func(t *testing.T) {
+		selector := &branchAndBoundCoinSelector{costOfChange: 0, maxTries: 1}
+		selected, err := selector.SelectCoins(testCoinSelectionUtxos(5000, 3000, 2010), 2000, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{5000}, testSelectedSatoshis(selected))
+	})
+}
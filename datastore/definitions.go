@@ -33,6 +33,20 @@ const (
 	SortAsc = "asc"
 )
 
+// Fields of the (soft) deleted models
+const (
+	deletedAtField = "deleted_at"
+	updatedAtField = "updated_at"
+)
+
+// QueryParams is the paging and sorting of a list of models
+type QueryParams struct {
+	Page          int    `json:"page,omitempty"`           // Page number (starts at 1, 0 is all results)
+	PageSize      int    `json:"page_size,omitempty"`      // Results per page (default is 20 if a page is set)
+	OrderByField  string `json:"order_by_field,omitempty"` // Field to sort the results by
+	SortDirection string `json:"sort_direction,omitempty"` // Sort direction (asc or desc)
+}
+
 // CommonConfig is the common configuration fields between engines
 type CommonConfig struct {
 	Debug                 bool          `json:"debug" mapstructure:"debug"`                                       // flag for debugging sql queries in logs
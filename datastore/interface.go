@@ -10,10 +10,13 @@ import (
 // StorageService is the storage related methods
 type StorageService interface {
 	AutoMigrateDatabase(ctx context.Context, models ...interface{}) error
+	DeleteModel(ctx context.Context, model interface{}, tx *Transaction, deletedAt time.Time, commitTx bool) error
 	Execute(query string) *gorm.DB
 	GetModel(ctx context.Context, model interface{}, conditions map[string]interface{}, timeout time.Duration) error
 	GetModels(ctx context.Context, models interface{}, conditions map[string]interface{}, pageSize, page int,
 		orderByField, sortDirection string, timeout time.Duration) error
+	GetModelCount(ctx context.Context, model interface{}, conditions map[string]interface{},
+		timeout time.Duration) (int64, error)
 	HasMigratedModel(modelType string) bool
 	IncrementModel(ctx context.Context, model interface{},
 		fieldName string, increment int64) (newValue int64, err error)
@@ -21,6 +24,7 @@ type StorageService interface {
 	IndexMetadata(tableName, field string) error
 	NewTx(ctx context.Context, fn func(*Transaction) error) error
 	Raw(query string) *gorm.DB
+	RestoreModel(ctx context.Context, model interface{}, tx *Transaction, restoredAt time.Time, commitTx bool) error
 	SaveModel(ctx context.Context, model interface{}, tx *Transaction, newRecord, commitTx bool) error
 }
 
@@ -80,6 +80,91 @@ func (c *Client) SaveModel(
 	return nil
 }
 
+// DeleteModel will mark a model as deleted (soft delete) at the given time (primary key based)
+//
+// Only the deleted_at and updated_at fields are changed, value is a pointer to the model, IE: &Transaction{}
+func (c *Client) DeleteModel(
+	ctx context.Context,
+	model interface{},
+	tx *Transaction,
+	deletedAt time.Time,
+	commitTx bool,
+) error {
+	return c.setModelDeletedAt(ctx, model, tx, &deletedAt, deletedAt, commitTx)
+}
+
+// RestoreModel will restore a (soft) deleted model at the given time (primary key based)
+//
+// Only the deleted_at (removed) and updated_at fields are changed, value is a pointer to the model
+func (c *Client) RestoreModel(
+	ctx context.Context,
+	model interface{},
+	tx *Transaction,
+	restoredAt time.Time,
+	commitTx bool,
+) error {
+	return c.setModelDeletedAt(ctx, model, tx, nil, restoredAt, commitTx)
+}
+
+// setModelDeletedAt will set (or remove if nil) the deleted_at field of the model
+func (c *Client) setModelDeletedAt(
+	ctx context.Context,
+	model interface{},
+	tx *Transaction,
+	deletedAt *time.Time,
+	updatedAt time.Time,
+	commitTx bool,
+) error {
+
+	// MongoDB (does not support transactions at this time)
+	if c.Engine() == MongoDB {
+		sessionContext := ctx //nolint:contextcheck // we need to overwrite the ctx for transaction support
+		if tx.mongoTx != nil {
+			// set the context to the session context -> mongo transaction
+			sessionContext = *tx.mongoTx
+		}
+		return c.setDeletedAtWithMongo(sessionContext, model, deletedAt, updatedAt)
+	} else if !IsSQLEngine(c.Engine()) {
+		return ErrUnsupportedEngine
+	}
+
+	// Set the NewRelic txn
+	c.options.db = nrgorm.SetTxnToGorm(newrelic.FromContext(ctx), c.options.db)
+
+	// Capture any panics
+	defer func() {
+		if r := recover(); r != nil {
+			c.DebugLog(fmt.Sprintf("panic recovered: %v", r))
+			_ = tx.Rollback()
+		}
+	}()
+	if err := tx.sqlTx.Error; err != nil {
+		return err
+	}
+
+	// Only update the fields (no hooks or other fields)
+	columns := map[string]interface{}{
+		deletedAtField: nil,
+		updatedAtField: updatedAt,
+	}
+	if deletedAt != nil {
+		columns[deletedAtField] = *deletedAt
+	}
+	if err := tx.sqlTx.Model(model).UpdateColumns(columns).Error; err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// Commit & check for errors
+	if commitTx {
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // IncrementModel will increment the given field atomically in the database and return the new value
 func (c *Client) IncrementModel(
 	ctx context.Context,
@@ -165,7 +250,7 @@ func (c *Client) GetModel(
 
 	// Switch on the datastore engines
 	if c.Engine() == MongoDB { // Get using Mongo
-		return c.getWithMongo(ctx, model, conditions)
+		return c.getWithMongo(ctx, model, conditions, nil)
 	} else if !IsSQLEngine(c.Engine()) {
 		return ErrUnsupportedEngine
 	}
@@ -199,14 +284,52 @@ func (c *Client) GetModels(
 
 	// Switch on the datastore engines
 	if c.Engine() == MongoDB { // Get using Mongo
-		// todo: add page/size for mongo
-		return c.getWithMongo(ctx, models, conditions)
+		return c.getWithMongo(ctx, models, conditions, &QueryParams{
+			Page:          page,
+			PageSize:      pageSize,
+			OrderByField:  orderByField,
+			SortDirection: sortDirection,
+		})
 	} else if !IsSQLEngine(c.Engine()) {
 		return ErrUnsupportedEngine
 	}
 	return c.find(ctx, models, conditions, pageSize, page, orderByField, sortDirection, timeout)
 }
 
+// GetModelCount will return a count of the models matching the given conditions
+func (c *Client) GetModelCount(
+	ctx context.Context,
+	model interface{},
+	conditions map[string]interface{},
+	timeout time.Duration,
+) (int64, error) {
+
+	// Switch on the datastore engines
+	if c.Engine() == MongoDB { // Count using Mongo
+		return c.countWithMongo(ctx, model, conditions)
+	} else if !IsSQLEngine(c.Engine()) {
+		return 0, ErrUnsupportedEngine
+	}
+
+	// Set the NewRelic txn
+	c.options.db = nrgorm.SetTxnToGorm(newrelic.FromContext(ctx), c.options.db)
+
+	// Create a new context, and new db tx
+	ctxDB, cancel := createCtx(ctx, c.options.db, timeout, c.IsDebug(), c.options.logger)
+	defer cancel()
+
+	var count int64
+	tx := ctxDB.Model(model)
+	if len(conditions) > 0 {
+		gtx := gormWhere{tx: tx}
+		tx = BuxWhere(&gtx, conditions, c.Engine()).(*gorm.DB)
+	}
+	if err := tx.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 // find will get records and return
 func (c *Client) find(ctx context.Context, result interface{}, conditions map[string]interface{},
 	pageSize, page int, orderByField, sortDirection string, timeout time.Duration) error {
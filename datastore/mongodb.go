@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/BuxOrg/bux/utils"
 	"github.com/newrelic/go-agent/v3/integrations/nrmongo"
@@ -68,6 +71,43 @@ func (c *Client) saveWithMongo(
 	return
 }
 
+// setDeletedAtWithMongo will set (or unset if nil) the deleted_at field of a given struct in MongoDB
+func (c *Client) setDeletedAtWithMongo(
+	ctx context.Context,
+	model interface{},
+	deletedAt *time.Time,
+	updatedAt time.Time,
+) (err error) {
+	collectionName := utils.GetModelTableName(model)
+	if collectionName == nil {
+		return ErrUnknownCollection
+	}
+
+	// Set the collection
+	collection := c.options.mongoDB.Collection(
+		setPrefix(c.options.mongoDBConfig.TablePrefix, *collectionName),
+	)
+
+	id := utils.GetModelStringAttribute(model, "ID")
+	if id == nil {
+		return errors.New("can only delete by id")
+	}
+	operation := "delete"
+	update := bson.M{"$set": bson.M{deletedAtField: deletedAt, updatedAtField: updatedAt}}
+	if deletedAt == nil {
+		operation = "restore"
+		update = bson.M{"$set": bson.M{updatedAtField: updatedAt}, "$unset": bson.M{deletedAtField: ""}}
+	}
+
+	c.DebugLog(fmt.Sprintf(logLine, operation, *collectionName, update))
+
+	if _, err = collection.UpdateOne(ctx, bson.M{"_id": *id}, update); err != nil {
+		c.DebugLog(fmt.Sprintf(logErrorLine, "error", *collectionName, err, model))
+	}
+
+	return
+}
+
 // incrementWithMongo will save a given struct to MongoDB
 func (c *Client) incrementWithMongo(
 	ctx context.Context,
@@ -120,6 +160,7 @@ func (c *Client) getWithMongo(
 	ctx context.Context,
 	model interface{},
 	conditions map[string]interface{},
+	queryParams *QueryParams,
 ) error {
 	queryConditions := getMongoQueryConditions(model, conditions)
 	collectionName := utils.GetModelTableName(model)
@@ -135,7 +176,7 @@ func (c *Client) getWithMongo(
 	if utils.IsModelSlice(model) {
 		c.DebugLog(fmt.Sprintf(logLine, "findMany", *collectionName, queryConditions))
 
-		cursor, err := collection.Find(ctx, queryConditions)
+		cursor, err := collection.Find(ctx, queryConditions, getMongoFindOptions(queryParams))
 		if err != nil {
 			return err
 		}
@@ -169,6 +210,62 @@ func (c *Client) getWithMongo(
 	return nil
 }
 
+// countWithMongo will count the models matching the conditions in MongoDB
+func (c *Client) countWithMongo(
+	ctx context.Context,
+	model interface{},
+	conditions map[string]interface{},
+) (int64, error) {
+	queryConditions := getMongoQueryConditions(model, conditions)
+	collectionName := utils.GetModelTableName(model)
+	if collectionName == nil {
+		return 0, ErrUnknownCollection
+	}
+
+	// Set the collection
+	collection := c.options.mongoDB.Collection(
+		setPrefix(c.options.mongoDBConfig.TablePrefix, *collectionName),
+	)
+
+	c.DebugLog(fmt.Sprintf(logLine, "count", *collectionName, queryConditions))
+
+	return collection.CountDocuments(ctx, queryConditions)
+}
+
+// getMongoFindOptions will set the limit, offset and sort of a find (same as the SQL engines)
+func getMongoFindOptions(queryParams *QueryParams) *options.FindOptions {
+	opts := options.Find()
+	if queryParams == nil {
+		return opts
+	}
+
+	// Set default page size
+	pageSize := queryParams.PageSize
+	if queryParams.Page > 0 && pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+
+	// Use the limit and offset
+	if queryParams.Page > 0 {
+		opts.SetLimit(int64(pageSize))
+		opts.SetSkip(int64((queryParams.Page - 1) * pageSize))
+	}
+
+	// Use an order field/sort
+	if len(queryParams.OrderByField) > 0 {
+		field := queryParams.OrderByField
+		if field == "id" {
+			field = "_id"
+		}
+		direction := 1
+		if strings.ToLower(queryParams.SortDirection) == SortDesc {
+			direction = -1
+		}
+		opts.SetSort(bson.D{{Key: field, Value: direction}})
+	}
+	return opts
+}
+
 // setPrefix will automatically append the table prefix if found
 func setPrefix(prefix, collection string) string {
 	if len(prefix) > 0 {
@@ -207,15 +304,15 @@ func getMongoQueryConditions(
 
 func processMongoConditions(conditions *map[string]interface{}) {
 
-	// transform the map of metadata to key / value query
-	_, ok := (*conditions)["metadata"]
-	if ok {
+	// transform the map of metadata to key / value query (unless already transformed)
+	metadata, ok := (*conditions)["metadata"]
+	if ok && !isMongoOperatorCondition(metadata) {
 		processMetadataConditions(conditions)
 	}
 
-	// transform the map of xpub_metadata to key / value query
-	_, ok = (*conditions)["xpub_metadata"]
-	if ok {
+	// transform the map of xpub_metadata to key / value query (unless already transformed)
+	xPubMetadata, ok := (*conditions)["xpub_metadata"]
+	if ok && !isMongoOperatorCondition(xPubMetadata) {
 		processXpubMetadataConditions(conditions)
 	}
 
@@ -262,6 +359,12 @@ func processXpubMetadataConditions(conditions *map[string]interface{}) {
 	for xPub, xr := range r {
 		xPubMetadata := make([]map[string]interface{}, 0)
 		for key, value := range xr.(map[string]interface{}) {
+			if operators, ok := getObjectOperators(value); ok {
+				xPubMetadata = append(xPubMetadata, getMongoObjectOperators(
+					"xpub_metadata", map[string]interface{}{"x": xPub, "k": key}, operators,
+				)...)
+				continue
+			}
 			xPubMetadata = append(xPubMetadata, map[string]interface{}{
 				"xpub_metadata.x": xPub,
 				"xpub_metadata.k": key,
@@ -316,6 +419,12 @@ func processMetadataConditions(conditions *map[string]interface{}) {
 
 	metadata := make([]map[string]interface{}, 0)
 	for key, value := range r {
+		if operators, ok := getObjectOperators(value); ok {
+			metadata = append(metadata, getMongoObjectOperators(
+				"metadata", map[string]interface{}{"k": key}, operators,
+			)...)
+			continue
+		}
 		metadata = append(metadata, map[string]interface{}{
 			"metadata.k": key,
 			"metadata.v": value,
@@ -334,6 +443,63 @@ func processMetadataConditions(conditions *map[string]interface{}) {
 	delete(*conditions, "metadata")
 }
 
+// getMongoObjectOperators will return the conditions of the object operators (see objectOperators) on the
+// key / value elements of the field, the element is matched using the given keys (e.g. {"k": key})
+func getMongoObjectOperators(field string, element map[string]interface{},
+	operators map[string]interface{}) []map[string]interface{} {
+
+	conditions := make([]map[string]interface{}, 0)
+	value := make(map[string]interface{})
+	for operator, operand := range operators {
+		switch operator {
+		case "$exists":
+			if exists, _ := operand.(bool); exists {
+				conditions = append(conditions, map[string]interface{}{
+					field: map[string]interface{}{"$elemMatch": element},
+				})
+			} else {
+				conditions = append(conditions, map[string]interface{}{
+					field: map[string]interface{}{"$not": map[string]interface{}{"$elemMatch": element}},
+				})
+			}
+		case "$prefix":
+			if prefix, ok := operand.(string); ok {
+				value["$regex"] = "^" + regexp.QuoteMeta(prefix)
+			} else {
+				value["$in"] = []interface{}{} // never matches
+			}
+		default:
+			value[operator] = operand
+		}
+	}
+
+	if len(value) > 0 {
+		elemMatch := map[string]interface{}{"v": value}
+		for key, match := range element {
+			elemMatch[key] = match
+		}
+		conditions = append(conditions, map[string]interface{}{
+			field: map[string]interface{}{"$elemMatch": elemMatch},
+		})
+	}
+
+	return conditions
+}
+
+// isMongoOperatorCondition will return true if the condition is a mongo query (e.g. {"$elemMatch": ...})
+func isMongoOperatorCondition(condition interface{}) bool {
+	c, ok := condition.(map[string]interface{})
+	if !ok || len(c) == 0 {
+		return false
+	}
+	for key := range c {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+	return true
+}
+
 // openMongoDatabase will open a new database or use an existing connection
 func openMongoDatabase(ctx context.Context, config *MongoDBConfig) (*mongo.Database, error) {
 
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 // todo: finish unit tests!
@@ -25,6 +26,35 @@ func TestClient_getWithMongo(t *testing.T) {
 	// finish test
 }
 
+// Test_getMongoFindOptions will test the method getMongoFindOptions()
+func Test_getMongoFindOptions(t *testing.T) {
+	t.Run("nil params", func(t *testing.T) {
+		opts := getMongoFindOptions(nil)
+		assert.Nil(t, opts.Limit)
+		assert.Nil(t, opts.Skip)
+		assert.Nil(t, opts.Sort)
+	})
+
+	t.Run("page with default size", func(t *testing.T) {
+		opts := getMongoFindOptions(&QueryParams{Page: 3})
+		assert.Equal(t, int64(defaultPageSize), *opts.Limit)
+		assert.Equal(t, int64(2*defaultPageSize), *opts.Skip)
+		assert.Nil(t, opts.Sort)
+	})
+
+	t.Run("sort by id", func(t *testing.T) {
+		opts := getMongoFindOptions(&QueryParams{
+			Page:          1,
+			PageSize:      10,
+			OrderByField:  "id",
+			SortDirection: SortDesc,
+		})
+		assert.Equal(t, int64(10), *opts.Limit)
+		assert.Equal(t, int64(0), *opts.Skip)
+		assert.Equal(t, bson.D{{Key: "_id", Value: -1}}, opts.Sort)
+	})
+}
+
 // TestClient_setPrefix will test the method setPrefix()
 func TestClient_setPrefix(t *testing.T) {
 	// finish test
@@ -277,6 +307,90 @@ func TestClient_getMongoQueryConditions(t *testing.T) {
 		assert.Contains(t, expected, queryConditions["$and"].([]map[string]interface{})[0])
 		assert.Contains(t, expected, queryConditions["$and"].([]map[string]interface{})[1])
 	})
+
+	t.Run("metadata operators", func(t *testing.T) {
+		condition := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"order_total": map[string]interface{}{
+					"$gte": 500,
+					"$lt":  2000,
+				},
+			},
+		}
+		queryConditions := getMongoQueryConditions(mockModel{}, condition)
+		expected := map[string]interface{}{
+			"$and": []map[string]interface{}{{
+				"metadata": map[string]interface{}{
+					"$elemMatch": map[string]interface{}{
+						"k": "order_total",
+						"v": map[string]interface{}{
+							"$gte": float64(500),
+							"$lt":  float64(2000),
+						},
+					},
+				},
+			}},
+		}
+		assert.Equal(t, expected, queryConditions)
+	})
+
+	t.Run("metadata $exists $prefix", func(t *testing.T) {
+		condition := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"paid": map[string]interface{}{
+					"$exists": false,
+				},
+				"order_id": map[string]interface{}{
+					"$prefix": "ORD.",
+				},
+			},
+		}
+		queryConditions := getMongoQueryConditions(mockModel{}, condition)
+		expected := []map[string]interface{}{{
+			"metadata": map[string]interface{}{
+				"$not": map[string]interface{}{
+					"$elemMatch": map[string]interface{}{"k": "paid"},
+				},
+			},
+		}, {
+			"metadata": map[string]interface{}{
+				"$elemMatch": map[string]interface{}{
+					"k": "order_id",
+					"v": map[string]interface{}{"$regex": "^ORD\\."},
+				},
+			},
+		}}
+		assert.Len(t, queryConditions["$and"], 2)
+		assert.Contains(t, expected, queryConditions["$and"].([]map[string]interface{})[0])
+		assert.Contains(t, expected, queryConditions["$and"].([]map[string]interface{})[1])
+	})
+
+	t.Run("xpub_metadata $in", func(t *testing.T) {
+		condition := map[string]interface{}{
+			"xpub_metadata": map[string]interface{}{
+				"xPubID": map[string]interface{}{
+					"order_id": map[string]interface{}{
+						"$in": []string{"ORD-001", "ORD-002"},
+					},
+				},
+			},
+		}
+		queryConditions := getMongoQueryConditions(mockModel{}, condition)
+		expected := map[string]interface{}{
+			"$and": []map[string]interface{}{{
+				"xpub_metadata": map[string]interface{}{
+					"$elemMatch": map[string]interface{}{
+						"x": "xPubID",
+						"k": "order_id",
+						"v": map[string]interface{}{
+							"$in": []interface{}{"ORD-001", "ORD-002"},
+						},
+					},
+				},
+			}},
+		}
+		assert.Equal(t, expected, queryConditions)
+	})
 }
 
 // TestClient_openMongoDatabase will test the method openMongoDatabase()
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/BuxOrg/bux/utils"
 	"gorm.io/gorm"
@@ -14,6 +15,9 @@ import (
 var arrayFields = []string{"xpub_in_ids", "xpub_out_ids"}
 var objectFields = []string{"metadata", "xpub_metadata", "xpub_output_value"}
 
+// objectOperators are the operators supported on the keys of the object fields (e.g. {"order_total": {"$gt": 1000}})
+var objectOperators = []string{"$exists", "$gt", "$gte", "$in", "$lt", "$lte", "$prefix"}
+
 type buxWhereInterface interface {
 	Where(query interface{}, args ...interface{})
 	getGormTx() *gorm.DB
@@ -76,7 +80,7 @@ func processConditions(tx buxWhereInterface, conditions map[string]interface{},
 		} else if utils.StringInSlice(key, arrayFields) {
 			tx.Where(whereSlice(engine, key, condition))
 		} else if utils.StringInSlice(key, objectFields) {
-			tx.Where(whereObject(engine, key, condition))
+			processObjectConditions(tx, key, condition, engine, varNum)
 		} else {
 			if condition == nil {
 				tx.Where(key + " IS NULL")
@@ -144,6 +148,195 @@ func processWhereOr(tx buxWhereInterface, condition interface{}, engine Engine,
 	}
 }
 
+// processObjectConditions will add the conditions of an object field, the keys with operators are added
+// separately from the keys with (equal) values
+func processObjectConditions(tx buxWhereInterface, k string, condition interface{}, engine Engine, varNum *int) {
+
+	// we don't know the type, we handle the condition as a map[string]interface{}
+	vJSON, _ := json.Marshal(condition) // nolint: errchkjson // this check might break the current code
+	var object map[string]interface{}
+	_ = json.Unmarshal(vJSON, &object)
+
+	equals := make(map[string]interface{})
+	for key, value := range object {
+		if operators, ok := getObjectOperators(value); ok {
+			whereObjectOperators(tx, engine, k, []string{key}, operators, varNum)
+			continue
+		}
+
+		// Nested objects (xpub_metadata: {xpub_id: {key: value}})
+		nested, ok := value.(map[string]interface{})
+		if !ok || len(nested) == 0 {
+			equals[key] = value
+			continue
+		}
+		nestedEquals := make(map[string]interface{})
+		for nestedKey, nestedValue := range nested {
+			if operators, isOperator := getObjectOperators(nestedValue); isOperator {
+				whereObjectOperators(tx, engine, k, []string{key, nestedKey}, operators, varNum)
+			} else {
+				nestedEquals[nestedKey] = nestedValue
+			}
+		}
+		if len(nestedEquals) > 0 {
+			equals[key] = nestedEquals
+		}
+	}
+
+	if len(equals) > 0 {
+		tx.Where(whereObject(engine, k, equals))
+	}
+}
+
+// getObjectOperators will return the operators if the value only consists of object operators
+func getObjectOperators(value interface{}) (map[string]interface{}, bool) {
+	operators, ok := value.(map[string]interface{})
+	if !ok || len(operators) == 0 {
+		return nil, false
+	}
+	for operator := range operators {
+		if !utils.StringInSlice(operator, objectOperators) {
+			return nil, false
+		}
+	}
+	return operators, true
+}
+
+// whereObjectOperators will add the conditions of the operators on a key (path) of an object field
+//
+// Comparisons only match values of the same JSON type (number, string or boolean) on all engines,
+// $exists matches keys with a null value and $prefix is case-sensitive
+func whereObjectOperators(tx buxWhereInterface, engine Engine, k string, path []string,
+	operators map[string]interface{}, varNum *int) {
+
+	vars := make(map[string]interface{})
+	newVar := func(value interface{}) string {
+		varName := "var" + strconv.Itoa(*varNum)
+		vars[varName] = value
+		*varNum++
+		return "@" + varName
+	}
+
+	// The value and the JSON type of the key (path)
+	var valueExpr, typeExpr string
+	if engine == PostgreSQL {
+		pathVars := make([]string, 0, len(path))
+		for _, key := range path {
+			pathVars = append(pathVars, newVar(key))
+		}
+		valueExpr = "jsonb_extract_path(" + k + "::jsonb, " + strings.Join(pathVars, ", ") + ")"
+		typeExpr = "jsonb_typeof(" + valueExpr + ")"
+	} else {
+		jsonPath := "$"
+		for _, key := range path {
+			jsonPath += ".\"" + key + "\""
+		}
+		pathVar := newVar(jsonPath)
+		valueExpr = "JSON_EXTRACT(" + k + ", " + pathVar + ")"
+		if engine == MySQL {
+			typeExpr = "JSON_TYPE(" + valueExpr + ")"
+		} else {
+			typeExpr = "JSON_TYPE(" + k + ", " + pathVar + ")"
+		}
+	}
+
+	// compare will compare the value of the key to the given value (of the same JSON type)
+	compare := func(operator string, value interface{}) string {
+		guard := whereJSONType(engine, typeExpr, value)
+		if len(guard) == 0 {
+			return "1 = 0"
+		}
+		if engine == SQLite {
+			return "(" + guard + " AND " + valueExpr + " " + operator + " " + newVar(value) + ")"
+		}
+		jsonValue, _ := json.Marshal(value) // nolint: errchkjson // value is a string, number or boolean
+		if engine == MySQL {
+			return "(" + guard + " AND " + valueExpr + " " + operator + " CAST(" + newVar(string(jsonValue)) + " AS JSON))"
+		}
+		return "(" + guard + " AND " + valueExpr + " " + operator + " CAST(" + newVar(string(jsonValue)) + " AS jsonb))"
+	}
+
+	queryParts := make([]string, 0)
+	for _, operator := range objectOperators {
+		value, ok := operators[operator]
+		if !ok {
+			continue
+		}
+		switch operator {
+		case "$exists":
+			if exists, _ := value.(bool); exists {
+				queryParts = append(queryParts, typeExpr+" IS NOT NULL")
+			} else {
+				queryParts = append(queryParts, typeExpr+" IS NULL")
+			}
+		case "$gt":
+			queryParts = append(queryParts, compare(">", value))
+		case "$gte":
+			queryParts = append(queryParts, compare(">=", value))
+		case "$in":
+			values, _ := value.([]interface{})
+			or := make([]string, 0, len(values))
+			for _, v := range values {
+				or = append(or, compare("=", v))
+			}
+			if len(or) == 0 {
+				queryParts = append(queryParts, "1 = 0")
+			} else {
+				queryParts = append(queryParts, "("+strings.Join(or, " OR ")+")")
+			}
+		case "$lt":
+			queryParts = append(queryParts, compare("<", value))
+		case "$lte":
+			queryParts = append(queryParts, compare("<=", value))
+		case "$prefix":
+			prefix, isString := value.(string)
+			if !isString {
+				queryParts = append(queryParts, "1 = 0")
+				continue
+			}
+			stringExpr := valueExpr
+			if engine == MySQL {
+				stringExpr = "JSON_UNQUOTE(" + valueExpr + ")"
+			} else if engine == PostgreSQL {
+				stringExpr = "(" + valueExpr + " #>> '{}')"
+			}
+			queryParts = append(queryParts, "("+whereJSONType(engine, typeExpr, prefix)+" AND SUBSTR("+
+				stringExpr+", 1, "+strconv.Itoa(utf8.RuneCountInString(prefix))+") = "+newVar(prefix)+")")
+		}
+	}
+
+	query := queryParts[0]
+	if len(queryParts) > 1 {
+		query = "(" + strings.Join(queryParts, " AND ") + ")"
+	}
+	tx.Where(query, vars)
+}
+
+// whereJSONType will return the condition for the JSON type of the value (empty if not a string, number or boolean)
+func whereJSONType(engine Engine, typeExpr string, value interface{}) string {
+	var types []string
+	switch value.(type) {
+	case string:
+		types = map[Engine][]string{
+			MySQL: {"STRING"}, PostgreSQL: {"string"}, SQLite: {"text"},
+		}[engine]
+	case bool:
+		types = map[Engine][]string{
+			MySQL: {"BOOLEAN"}, PostgreSQL: {"boolean"}, SQLite: {"true", "false"},
+		}[engine]
+	case float64, float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		types = map[Engine][]string{
+			MySQL: {"INTEGER", "UNSIGNED INTEGER", "DOUBLE", "DECIMAL"}, PostgreSQL: {"number"}, SQLite: {"integer", "real"},
+		}[engine]
+	}
+	if len(types) == 0 {
+		return ""
+	} else if len(types) == 1 {
+		return typeExpr + " = '" + types[0] + "'"
+	}
+	return typeExpr + " IN ('" + strings.Join(types, "', '") + "')"
+}
+
 func escapeDBString(s string) string {
 	rs := strings.Replace(s, "'", "\\'", -1)
 	return strings.Replace(rs, "\"", "\\\"", -1)
@@ -164,6 +357,9 @@ func whereObject(engine Engine, k string, v interface{}) string {
 			case string:
 				rangeValue = "\"" + escapeDBString(rangeValue.(string)) + "\""
 				queryParts = append(queryParts, "JSON_EXTRACT("+k+", '$."+rangeKey+"') = "+rangeValue.(string))
+			case bool, float64:
+				valueJSON, _ := json.Marshal(vv) // nolint: errchkjson // this check might break the current code
+				queryParts = append(queryParts, "JSON_EXTRACT("+k+", '$."+rangeKey+"') = "+string(valueJSON))
 			default:
 				metadataJSON, _ := json.Marshal(vv) // nolint: errchkjson // this check might break the current code
 				var metadata map[string]interface{}
@@ -134,6 +134,13 @@ func Test_whereObject(t *testing.T) {
 		expected = "JSON_EXTRACT(metadata, '$.test_key') = \"test-\\'value\\'\""
 		assert.Equal(t, expected, query)
 
+		metadata = map[string]interface{}{
+			"test_key": 1500,
+		}
+		query = whereObject(SQLite, "metadata", metadata)
+		expected = "JSON_EXTRACT(metadata, '$.test_key') = 1500"
+		assert.Equal(t, expected, query)
+
 		metadata = map[string]interface{}{
 			"test_key1": "test-value",
 			"test_key2": "test-value2",
@@ -539,6 +546,90 @@ func TestBuxWhere(t *testing.T) {
 		assert.Equal(t, 3203, tx.Vars["var4"])
 		assert.Equal(t, 4203, tx.Vars["var5"])
 	})
+
+	t.Run("SQLite metadata $gt", func(t *testing.T) {
+		tx := mockSQLCtx{
+			WhereClauses: make([]interface{}, 0),
+			Vars:         make(map[string]interface{}),
+		}
+		conditions := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"order_total": map[string]interface{}{
+					"$gt": 1000,
+				},
+			},
+		}
+		_ = BuxWhere(&tx, conditions, SQLite)
+		assert.Len(t, tx.WhereClauses, 1)
+		assert.Equal(t, "(JSON_TYPE(metadata, @var0) IN ('integer', 'real') AND JSON_EXTRACT(metadata, @var0) > @var1)", tx.WhereClauses[0])
+		assert.Equal(t, "$.\"order_total\"", tx.Vars["var0"])
+		assert.Equal(t, float64(1000), tx.Vars["var1"])
+	})
+
+	t.Run("MySQL metadata $in", func(t *testing.T) {
+		tx := mockSQLCtx{
+			WhereClauses: make([]interface{}, 0),
+			Vars:         make(map[string]interface{}),
+		}
+		conditions := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"order_id": map[string]interface{}{
+					"$in": []interface{}{"ORD-001", 3},
+				},
+			},
+		}
+		_ = BuxWhere(&tx, conditions, MySQL)
+		assert.Len(t, tx.WhereClauses, 1)
+		assert.Equal(t, "((JSON_TYPE(JSON_EXTRACT(metadata, @var0)) = 'STRING' AND JSON_EXTRACT(metadata, @var0) = CAST(@var1 AS JSON)) OR "+
+			"(JSON_TYPE(JSON_EXTRACT(metadata, @var0)) IN ('INTEGER', 'UNSIGNED INTEGER', 'DOUBLE', 'DECIMAL') AND JSON_EXTRACT(metadata, @var0) = CAST(@var2 AS JSON)))", tx.WhereClauses[0])
+		assert.Equal(t, "$.\"order_id\"", tx.Vars["var0"])
+		assert.Equal(t, "\"ORD-001\"", tx.Vars["var1"])
+		assert.Equal(t, "3", tx.Vars["var2"])
+	})
+
+	t.Run("PostgreSQL metadata $prefix $exists", func(t *testing.T) {
+		tx := mockSQLCtx{
+			WhereClauses: make([]interface{}, 0),
+			Vars:         make(map[string]interface{}),
+		}
+		conditions := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"order_id": map[string]interface{}{
+					"$exists": true,
+					"$prefix": "ORD-",
+				},
+			},
+		}
+		_ = BuxWhere(&tx, conditions, PostgreSQL)
+		assert.Len(t, tx.WhereClauses, 1)
+		assert.Equal(t, "(jsonb_typeof(jsonb_extract_path(metadata::jsonb, @var0)) IS NOT NULL AND "+
+			"(jsonb_typeof(jsonb_extract_path(metadata::jsonb, @var0)) = 'string' AND "+
+			"SUBSTR((jsonb_extract_path(metadata::jsonb, @var0) #>> '{}'), 1, 4) = @var1))", tx.WhereClauses[0])
+		assert.Equal(t, "order_id", tx.Vars["var0"])
+		assert.Equal(t, "ORD-", tx.Vars["var1"])
+	})
+
+	t.Run("xpub_metadata $lt and equal", func(t *testing.T) {
+		tx := mockSQLCtx{
+			WhereClauses: make([]interface{}, 0),
+			Vars:         make(map[string]interface{}),
+		}
+		conditions := map[string]interface{}{
+			"xpub_metadata": map[string]interface{}{
+				"xPubID": map[string]interface{}{
+					"order_total": map[string]interface{}{
+						"$lt": 10,
+					},
+					"order_id": "ORD-001",
+				},
+			},
+		}
+		_ = BuxWhere(&tx, conditions, SQLite)
+		assert.Len(t, tx.WhereClauses, 2)
+		assert.Equal(t, "(JSON_TYPE(xpub_metadata, @var0) IN ('integer', 'real') AND JSON_EXTRACT(xpub_metadata, @var0) < @var1)", tx.WhereClauses[0])
+		assert.Equal(t, "JSON_EXTRACT(xpub_metadata, '$.xPubID.order_id') = \"ORD-001\"", tx.WhereClauses[1])
+		assert.Equal(t, "$.\"xPubID\".\"order_total\"", tx.Vars["var0"])
+	})
 }
 
 // Test_escapeDBString will test the method escapeDBString()
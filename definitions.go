@@ -9,26 +9,45 @@ import (
 // Defaults for engine functionality
 const (
 	databaseLongReadTimeout    = 30 * time.Second  // For all "GET" or "SELECT" methods
+	defaultBlockHeaderSyncMax  = uint64(10)        // Max block headers to sync per task run
+	defaultCoinSelectionTries  = 100000            // Max branches to search for an exact match of utxos
+	defaultConsolidateInputs   = 1000              // Max utxos (inputs) in a consolidation draft transaction
+	defaultConsolidateMinUtxos = 100               // Consolidate when an xPub has more small utxos than this
+	defaultConsolidatePageSize = 100               // Number of xPubs loaded per page by the consolidation task
+	defaultConsolidateSatoshis = uint64(1000)      // Utxos of this value (or less) are consolidated
 	defaultCacheLockTTL        = 20                // in Seconds
 	defaultCacheLockTTW        = 10                // in Seconds
 	defaultDatabaseReadTimeout = 10 * time.Second  // For all "GET" or "SELECT" methods
 	defaultDraftTxExpiresIn    = 30 * time.Second  // Default TTL for draft transactions
 	defaultOverheadSize        = uint64(10)        // 10 bytes is the default overhead in a transaction
+	defaultQueryTimeout        = 10 * time.Second  // Default timeout for chain provider queries
 	defaultUserAgent           = "bux: " + version // Default user agent
+	defaultWebhookMinBackoff   = 10 * time.Second  // Min backoff between webhook delivery retries
+	defaultWebhookRetryLimit   = 5                 // Max webhook delivery attempts
+	defaultWebhookTimeout      = 10 * time.Second  // Timeout for each webhook delivery
 	dustLimit                  = uint64(512)       // Dust limit
+	lockTimeThreshold          = uint32(500000000) // Lock times below are block heights, above are unix timestamps
 	mongoTestVersion           = "4.2.1"           // Mongo Testing Version
 	sqliteTestVersion          = "3.37.0"          // SQLite Testing Version (dummy version for now)
 	version                    = "v0.0.1"          // bux version
+	webhookTaskName            = "event_webhook"   // Name of the webhook delivery task
 )
 
+// unknownSpendingTxID is set on utxos that were spent by an unknown transaction (the winner of a double spend)
+const unknownSpendingTxID = "0000000000000000000000000000000000000000000000000000000000000000"
+
 // All the base models
 const (
 	ModelAccessKey           ModelName = "access_key"
+	ModelAdmin               ModelName = "admin"
+	ModelBlockHeader         ModelName = "block_header"
 	ModelDestination         ModelName = "destination"
 	ModelDraftTransaction    ModelName = "draft_transaction"
 	ModelIncomingTransaction ModelName = "incoming_transaction"
 	ModelMetadata            ModelName = "metadata"
 	ModelNameEmpty           ModelName = "empty"
+	ModelPaymailAddress      ModelName = "paymail_address"
+	ModelSpendingPolicy      ModelName = "spending_policy"
 	ModelSyncTransaction     ModelName = "sync_transaction"
 	ModelTransaction         ModelName = "transaction"
 	ModelUtxo                ModelName = "utxo"
@@ -39,9 +58,13 @@ var (
 	// AllModelNames is a list of all models
 	AllModelNames = []ModelName{
 		ModelAccessKey,
+		ModelAdmin,
+		ModelBlockHeader,
 		ModelDestination,
 		ModelIncomingTransaction,
 		ModelMetadata,
+		ModelPaymailAddress,
+		ModelSpendingPolicy,
 		ModelSyncTransaction,
 		ModelTransaction,
 		ModelUtxo,
@@ -52,9 +75,13 @@ var (
 // Internal table names
 const (
 	tableAccessKeys           = "access_keys"
+	tableAdmins               = "admins"
+	tableBlockHeaders         = "block_headers"
 	tableDestinations         = "destinations"
 	tableDraftTransactions    = "draft_transactions"
 	tableIncomingTransactions = "incoming_transactions"
+	tablePaymailAddresses     = "paymail_addresses"
+	tableSpendingPolicies     = "spending_policies"
 	tableSyncTransactions     = "sync_transactions"
 	tableTransactions         = "transactions"
 	tableUTXOs                = "utxos"
@@ -66,16 +93,23 @@ const (
 	ReferenceIDField = "reference_id"
 
 	// Internal field names
+	blockHashField       = "block_hash"
 	broadcastStatusField = "broadcast_status"
+	consolidationField   = "utxo_consolidation"
 	currentBalanceField  = "current_balance"
+	deletedAtField       = "deleted_at"
 	draftIDField         = "draft_id"
+	heightField          = "height"
 	idField              = "id"
 	metadataField        = "metadata"
 	nextExternalNumField = "next_external_num"
 	nextInternalNumField = "next_internal_num"
+	paymailField         = "paymail"
+	satoshisField        = "satoshis"
 	spendingTxIDField    = "spending_tx_id"
 	statusField          = "status"
 	syncStatusField      = "sync_status"
+	transactionIDField   = "transaction_id"
 	typeField            = "type"
 	xPubIDField          = "xpub_id"
 	xPubMetadataField    = "xpub_metadata"
@@ -83,6 +117,7 @@ const (
 	// Universal statuses
 	statusCanceled   = "canceled"
 	statusComplete   = "complete"
+	statusConflicted = "conflicted"
 	statusDraft      = "draft"
 	statusError      = "error"
 	statusExpired    = "expired"
@@ -91,16 +126,31 @@ const (
 	statusReady      = "ready"
 	statusSkipped    = "skipped"
 
+	// Authentication
+	cacheKeyAuthNonce = "auth-nonce-"
+	cacheKeyRateLimit = "rate-limit-"
+
+	// Fees
+	cacheKeyFeeQuote       = "fee-quote-"
+	cacheKeyFeeQuoteFailed = "fee-quote-failed-"
+	cacheTTLFeeQuote       = 10 * time.Minute
+	cacheTTLFeeQuoteFailed = 30 * time.Second
+
 	// Paymail / Handles
 	cacheKeyAddressResolution       = "paymail-address-resolution-"
 	cacheKeyCapabilities            = "paymail-capabilities-"
 	cacheTTLAddressResolution       = 2 * time.Minute
 	cacheTTLCapabilities            = 60 * time.Minute
 	defaultAddressResolutionPurpose = "bux Address Resolution"
+	defaultP2PTransactionNote       = "bux P2P Transaction"
+	defaultReferenceIDLength        = 16
 	defaultSenderPaymail            = "bitcoinschema@moneybutton.com"
 	handleHandcashPrefix            = "$"
 	handleMaxLength                 = 25
 	handleRelayPrefix               = "1"
+	p2pMetadataField                = "p2p_tx_metadata"
+	paymailIdentityChain            = uint32(2) // Chain of the identity key (PKI), not used by destinations
+	paymailRequestField             = "paymail_request"
 
 	// Misc
 	gormTypeText = "text"
@@ -157,5 +207,25 @@ var (
 		&Utxo{
 			Model: *NewBaseModel(ModelUtxo),
 		},
+
+		// Paymail addresses (alias@domain.com) related to an xPub
+		&PaymailAddress{
+			Model: *NewBaseModel(ModelPaymailAddress),
+		},
+
+		// Block headers (synced from the chain providers)
+		&BlockHeader{
+			Model: *NewBaseModel(ModelBlockHeader),
+		},
+
+		// Admins (xPubs & access keys) of the engine
+		&Admin{
+			Model: *NewBaseModel(ModelAdmin),
+		},
+
+		// Spending policies of the xPubs & access keys
+		&SpendingPolicy{
+			Model: *NewBaseModel(ModelSpendingPolicy),
+		},
 	}
 )
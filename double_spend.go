@@ -0,0 +1,215 @@
+package bux
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+)
+
+// processDoubleSpend will mark a transaction that lost a double spend (and its descendants) as conflicted
+//
+// The utxos spent by the transaction are released (if unspent on-chain), the utxos created by the transaction are removed and the
+// balances of the xPubs are restored. The sync transaction is optional (loaded if nil).
+func processDoubleSpend(ctx context.Context, transaction *Transaction, syncTx *SyncTransaction,
+	reason string, opts ...ModelOps) error {
+	return processConflictedTransaction(ctx, transaction, syncTx, reason, make(map[string]bool), opts...)
+}
+
+// processConflictedTransaction will mark the transaction as conflicted after its descendants (visited stops loops)
+//
+// The utxos, the sync transaction and the conflict mark are saved together (one datastore transaction),
+// a failure before that leaves the transaction unmarked so a retry runs the whole cleanup again
+func processConflictedTransaction(ctx context.Context, transaction *Transaction, syncTx *SyncTransaction,
+	reason string, visited map[string]bool, opts ...ModelOps) error {
+
+	// Already processed (or being processed)
+	if transaction.ConflictedAt.Valid || visited[transaction.ID] {
+		return nil
+	}
+	visited[transaction.ID] = true
+
+	// Descendants (spending the outputs of this transaction) are conflicted first
+	createdUtxos, err := getUtxosByConditions(
+		ctx, map[string]interface{}{transactionIDField: transaction.ID}, 0, 0, "", "", opts...,
+	)
+	if err != nil {
+		return err
+	}
+	for _, utxo := range createdUtxos {
+		if !utxo.SpendingTxID.Valid {
+			continue
+		}
+		var child *Transaction
+		if child, err = getTransactionByID(ctx, "", utxo.SpendingTxID.String, opts...); err != nil {
+			return err
+		} else if child != nil {
+			if err = processConflictedTransaction(
+				ctx, child, nil, "parent transaction "+transaction.ID+" was double spent", visited, opts...,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Remove the utxos created by this transaction (reloaded, the descendants released them)
+	if createdUtxos, err = getUtxosByConditions(
+		ctx, map[string]interface{}{transactionIDField: transaction.ID}, 0, 0, "", "", opts...,
+	); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	utxos := make([]Utxo, 0)
+	for _, utxo := range createdUtxos {
+		utxo.SetDeletedTime(true, now)
+		utxos = append(utxos, *utxo)
+	}
+
+	// Release the utxos spent by this transaction (disputed utxos stay spent by the unknown winner)
+	var spentUtxos []*Utxo
+	if spentUtxos, err = getUtxosByConditions(
+		ctx, map[string]interface{}{spendingTxIDField: transaction.ID}, 0, 0, "", "", opts...,
+	); err != nil {
+		return err
+	}
+	balances := make(map[string]int64)
+	for xPubID, value := range transaction.XpubOutputValue {
+		balances[xPubID] -= value
+	}
+	for _, utxo := range spentUtxos {
+		var disputed bool
+		if disputed, err = isUtxoDisputed(ctx, transaction.Client(), utxo, opts...); err != nil {
+			return err
+		} else if disputed {
+			utxo.SpendingTxID = utils.NullString{NullString: sql.NullString{String: unknownSpendingTxID, Valid: true}}
+			balances[utxo.XpubID] -= int64(utxo.Satoshis)
+		} else {
+			utxo.SpendingTxID.Valid = false
+		}
+		utxo.DraftID.Valid = false
+		utxo.ReservedAt.Valid = false
+		utxos = append(utxos, *utxo)
+	}
+
+	// Stop the broadcast and sync of the transaction
+	if syncTx == nil {
+		if syncTx, err = getSyncTransactionByID(ctx, transaction.ID, opts...); err != nil {
+			return err
+		}
+	}
+	if syncTx != nil {
+		syncTx.BroadcastStatus = SyncStatusConflicted
+		syncTx.SyncStatus = SyncStatusConflicted
+		syncTx.Results.LastMessage = reason
+		syncTx.Results.Attempts = append(syncTx.Results.Attempts, &SyncAttempt{
+			Action:        "conflict",
+			AttemptedAt:   now,
+			StatusMessage: reason,
+		})
+	}
+
+	// Mark the transaction, saved with the utxos and the sync transaction (child models)
+	transaction.ConflictedAt = utils.NullTime{NullTime: sql.NullTime{Time: now, Valid: true}}
+	transaction.utxos = utxos
+	transaction.syncTransaction = syncTx
+	if err = transaction.Save(ctx); err != nil {
+		transaction.ConflictedAt.Valid = false
+		return err
+	}
+	if syncTx != nil {
+		publishEvent(ctx, syncTx.Client(), EventTypeSyncTransactionStatus, syncTx)
+	}
+
+	// Restore the balances (reverse the value of the transaction for each xPub, minus the disputed utxos)
+	for xPubID, value := range balances {
+		var xPub *Xpub
+		if xPub, err = getXpubByID(ctx, xPubID, opts...); err != nil {
+			return err
+		} else if xPub == nil {
+			continue
+		}
+		if err = xPub.IncrementBalance(ctx, value); err != nil {
+			return err
+		}
+	}
+
+	// Emit the conflict event
+	xPubIDs := append([]string{}, transaction.XpubInIDs...)
+	for _, xPubID := range transaction.XpubOutIDs {
+		if !utils.StringInSlice(xPubID, xPubIDs) {
+			xPubIDs = append(xPubIDs, xPubID)
+		}
+	}
+	transaction.Client().Logger().Warn(ctx, fmt.Sprintf(
+		"transaction %s was double spent: %s", transaction.ID, reason,
+	))
+	publishEvent(ctx, transaction.Client(), EventTypeTransactionConflicted, &TransactionConflict{
+		Reason:        reason,
+		TransactionID: transaction.ID,
+		XpubIDs:       xPubIDs,
+	})
+
+	return nil
+}
+
+// isUtxoDisputed will check if a utxo spent by a conflicted transaction could be spent by the winner
+//
+// Utxos of conflicted transactions are removed anyway, all others are only released if the chain provider
+// confirms that they are unspent (a failed query is treated as disputed)
+func isUtxoDisputed(ctx context.Context, client ClientInterface, utxo *Utxo, opts ...ModelOps) (bool, error) {
+	parent, err := getTransactionByID(ctx, "", utxo.TransactionID, opts...)
+	if err != nil {
+		return false, err
+	} else if parent != nil && parent.ConflictedAt.Valid {
+		return false, nil
+	}
+
+	var unspent bool
+	if unspent, err = client.Chainstate().QueryUtxoUnspent(
+		ctx, utxo.TransactionID, utxo.OutputIndex, utxo.ScriptPubKey, defaultQueryTimeout,
+	); err != nil {
+		client.Logger().Warn(ctx, fmt.Sprintf(
+			"failed checking utxo %s:%d, keeping it spent: %s", utxo.TransactionID, utxo.OutputIndex, err.Error(),
+		))
+		return true, nil // nolint: nilerr // the state is unknown, the utxo must not be spent again
+	}
+	return !unspent, nil
+}
+
+// processIncomingDoubleSpend will mark an incoming transaction that lost a double spend as conflicted
+//
+// The incoming transaction was not recorded yet, the xPubs of the event are the owners of the outputs
+func processIncomingDoubleSpend(ctx context.Context, incomingTx *IncomingTransaction, reason string) error {
+	incomingTx.Status = SyncStatusConflicted
+	incomingTx.StatusMessage = reason
+	if err := incomingTx.Save(ctx); err != nil {
+		return err
+	}
+
+	xPubIDs := make([]string, 0)
+	if incomingTx.TransactionBase.parsedTx != nil {
+		for _, output := range incomingTx.TransactionBase.parsedTx.Outputs {
+			destination, err := getDestinationByLockingScript(
+				ctx, output.LockingScript.String(), incomingTx.GetOptions(false)...,
+			)
+			if err != nil {
+				return err
+			} else if destination != nil && !utils.StringInSlice(destination.XpubID, xPubIDs) {
+				xPubIDs = append(xPubIDs, destination.XpubID)
+			}
+		}
+	}
+
+	incomingTx.Client().Logger().Warn(ctx, fmt.Sprintf(
+		"incoming transaction %s was double spent: %s", incomingTx.ID, reason,
+	))
+	publishEvent(ctx, incomingTx.Client(), EventTypeTransactionConflicted, &TransactionConflict{
+		Reason:        reason,
+		TransactionID: incomingTx.ID,
+		XpubIDs:       xPubIDs,
+	})
+
+	return nil
+}
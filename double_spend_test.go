@@ -0,0 +1,284 @@
+package bux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestDoubleSpendClient will create a client (with a signer) and a funded xPub (10000 satoshis)
+func createTestDoubleSpendClient(t *testing.T, chainState *chainStateDoubleSpend) (context.Context,
+	ClientInterface, func(), *bip32.ExtendedKey, string, *Transaction) {
+
+	masterKey, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	var signer Signer
+	signer, err = NewXPrivSigner(masterKey.String())
+	require.NoError(t, err)
+
+	ctx, client, deferMe := CreateTestSQLiteClient(
+		t, false, true,
+		WithCustomTaskManager(&taskManagerMockBase{}),
+		WithCustomChainstate(chainState),
+		WithSigner(signer),
+	)
+
+	var rawXPub string
+	rawXPub, err = bitcoin.GetExtendedPublicKey(masterKey)
+	require.NoError(t, err)
+	_, err = client.NewXpub(ctx, rawXPub)
+	require.NoError(t, err)
+
+	var destination *Destination
+	destination, err = client.NewDestination(
+		ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+	)
+	require.NoError(t, err)
+	var funding *Transaction
+	funding, err = client.RecordTransaction(ctx, rawXPub,
+		CreateFakeFundingTransaction(t, masterKey, []*Destination{destination}, 10000), "",
+	)
+	require.NoError(t, err)
+
+	return ctx, client, deferMe, masterKey, rawXPub, funding
+}
+
+// sendTestDoubleSpendTransaction will send 1000 satoshis to an external address
+func sendTestDoubleSpendTransaction(ctx context.Context, t *testing.T, client ClientInterface,
+	rawXPub string) *Transaction {
+	transaction, err := client.SendTransaction(ctx, rawXPub, &TransactionConfig{
+		Outputs: []*TransactionOutput{{
+			Satoshis: 1000,
+			To:       "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND",
+		}},
+	}, nil)
+	require.NoError(t, err)
+	return transaction
+}
+
+// assertTestTransactionConflicted will check that the transaction and its sync record are conflicted
+func assertTestTransactionConflicted(ctx context.Context, t *testing.T, client ClientInterface, txID string) {
+	transaction, err := getTransactionByID(ctx, "", txID, client.DefaultModelOptions()...)
+	require.NoError(t, err)
+	require.NotNil(t, transaction)
+	assert.True(t, transaction.ConflictedAt.Valid)
+
+	var syncTx *SyncTransaction
+	syncTx, err = getSyncTransactionByID(ctx, txID, client.DefaultModelOptions()...)
+	require.NoError(t, err)
+	require.NotNil(t, syncTx)
+	assert.Equal(t, SyncStatusConflicted, syncTx.BroadcastStatus)
+	assert.Equal(t, SyncStatusConflicted, syncTx.SyncStatus)
+}
+
+// Test_processDoubleSpend will test the method processDoubleSpend()
+func Test_processDoubleSpend(t *testing.T) {
+
+	t.Run("broadcast - double spend", func(t *testing.T) {
+		chainState := &chainStateDoubleSpend{}
+		ctx, client, deferMe, _, rawXPub, funding := createTestDoubleSpendClient(t, chainState)
+		defer deferMe()
+
+		var events []*Event
+		_, err := client.Subscribe(func(_ context.Context, event *Event) {
+			events = append(events, event)
+		}, EventTypeTransactionConflicted)
+		require.NoError(t, err)
+
+		chainState.doubleSpend = true
+		transaction := sendTestDoubleSpendTransaction(ctx, t, client, rawXPub)
+		assertTestTransactionConflicted(ctx, t, client, transaction.ID)
+
+		// The event is published for the xPub
+		require.Len(t, events, 1)
+		require.IsType(t, &TransactionConflict{}, events[0].Data)
+		conflict := events[0].Data.(*TransactionConflict)
+		assert.Equal(t, transaction.ID, conflict.TransactionID)
+		assert.Equal(t, []string{utils.Hash(rawXPub)}, conflict.XpubIDs)
+		assert.Contains(t, conflict.Reason, "broadcast error")
+
+		// The balance is restored and the funding utxo can be spent again (the change utxo is removed)
+		var xPub *Xpub
+		xPub, err = getXpubByID(ctx, utils.Hash(rawXPub), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10000), xPub.CurrentBalance)
+
+		var utxos []*Utxo
+		utxos, err = GetSpendableUtxos(
+			ctx, utils.Hash(rawXPub), utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+		assert.Equal(t, funding.ID, utxos[0].TransactionID)
+
+		_, err = GetSpendableUtxos(
+			ctx, utils.Hash(rawXPub), utils.ScriptTypePubKeyHash,
+			[]*UtxoPointer{{TransactionID: transaction.ID, OutputIndex: 1}}, client.DefaultModelOptions()...,
+		)
+		require.ErrorIs(t, err, ErrUtxoAlreadySpent)
+	})
+
+	t.Run("broadcast - disputed utxos stay spent", func(t *testing.T) {
+		chainState := &chainStateDoubleSpend{disputed: true}
+		ctx, client, deferMe, _, rawXPub, funding := createTestDoubleSpendClient(t, chainState)
+		defer deferMe()
+
+		chainState.doubleSpend = true
+		transaction := sendTestDoubleSpendTransaction(ctx, t, client, rawXPub)
+		assertTestTransactionConflicted(ctx, t, client, transaction.ID)
+
+		// The funding utxo was spent by the winner of the double spend
+		utxo, err := getUtxo(ctx, funding.ID, 0, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, utxo)
+		assert.Equal(t, unknownSpendingTxID, utxo.SpendingTxID.String)
+		assert.False(t, utxo.DraftID.Valid)
+
+		var utxos []*Utxo
+		utxos, err = GetSpendableUtxos(
+			ctx, utils.Hash(rawXPub), utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		assert.Len(t, utxos, 0)
+
+		var xPub *Xpub
+		xPub, err = getXpubByID(ctx, utils.Hash(rawXPub), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), xPub.CurrentBalance)
+	})
+
+	t.Run("failed cleanup is run again", func(t *testing.T) {
+		chainState := &chainStateDoubleSpend{}
+		ctx, client, deferMe, _, rawXPub, funding := createTestDoubleSpendClient(t, chainState)
+		defer deferMe()
+
+		transaction := sendTestDoubleSpendTransaction(ctx, t, client, rawXPub)
+		xPub, err := getXpubByID(ctx, utils.Hash(rawXPub), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		balance := xPub.CurrentBalance
+
+		// The context is canceled while checking the spent utxos, nothing is saved
+		cancelCtx, cancel := context.WithCancel(ctx)
+		chainState.onQueryUtxo = cancel
+		var loaded *Transaction
+		loaded, err = getTransactionByID(ctx, "", transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.Error(t, processDoubleSpend(cancelCtx, loaded, nil, "double spend", client.DefaultModelOptions()...))
+		chainState.onQueryUtxo = nil
+
+		loaded, err = getTransactionByID(ctx, "", transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.False(t, loaded.ConflictedAt.Valid)
+		xPub, err = getXpubByID(ctx, utils.Hash(rawXPub), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, balance, xPub.CurrentBalance)
+
+		// The retry runs the whole cleanup
+		require.NoError(t, processDoubleSpend(ctx, loaded, nil, "double spend", client.DefaultModelOptions()...))
+		assertTestTransactionConflicted(ctx, t, client, transaction.ID)
+
+		xPub, err = getXpubByID(ctx, utils.Hash(rawXPub), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10000), xPub.CurrentBalance)
+
+		var utxos []*Utxo
+		utxos, err = GetSpendableUtxos(
+			ctx, utils.Hash(rawXPub), utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+		assert.Equal(t, funding.ID, utxos[0].TransactionID)
+	})
+
+	t.Run("sync - descendants are conflicted", func(t *testing.T) {
+		chainState := &chainStateDoubleSpend{}
+		ctx, client, deferMe, _, rawXPub, funding := createTestDoubleSpendClient(t, chainState)
+		defer deferMe()
+
+		// Parent and child (spending the change of the parent)
+		parent := sendTestDoubleSpendTransaction(ctx, t, client, rawXPub)
+		child := sendTestDoubleSpendTransaction(ctx, t, client, rawXPub)
+
+		var events []*Event
+		_, err := client.Subscribe(func(_ context.Context, event *Event) {
+			events = append(events, event)
+		}, EventTypeTransactionConflicted)
+		require.NoError(t, err)
+
+		chainState.doubleSpend = true
+		var syncTx *SyncTransaction
+		syncTx, err = getSyncTransactionByID(ctx, parent.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NoError(t, processSyncTransaction(ctx, syncTx))
+
+		assertTestTransactionConflicted(ctx, t, client, parent.ID)
+		assertTestTransactionConflicted(ctx, t, client, child.ID)
+
+		require.Len(t, events, 2)
+		assert.Equal(t, child.ID, events[0].Data.(*TransactionConflict).TransactionID)
+		assert.Contains(t, events[0].Data.(*TransactionConflict).Reason, parent.ID)
+		assert.Equal(t, parent.ID, events[1].Data.(*TransactionConflict).TransactionID)
+		assert.Contains(t, events[1].Data.(*TransactionConflict).Reason, "sync error")
+
+		var xPub *Xpub
+		xPub, err = getXpubByID(ctx, utils.Hash(rawXPub), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10000), xPub.CurrentBalance)
+
+		var utxos []*Utxo
+		utxos, err = GetSpendableUtxos(
+			ctx, utils.Hash(rawXPub), utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+		assert.Equal(t, funding.ID, utxos[0].TransactionID)
+
+		// Already conflicted (no new events)
+		parent, err = getTransactionByID(ctx, "", parent.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NoError(t, processDoubleSpend(ctx, parent, nil, "test", client.DefaultModelOptions()...))
+		assert.Len(t, events, 2)
+	})
+
+	t.Run("incoming transaction - double spend", func(t *testing.T) {
+		chainState := &chainStateDoubleSpend{}
+		ctx, client, deferMe, masterKey, rawXPub, _ := createTestDoubleSpendClient(t, chainState)
+		defer deferMe()
+
+		var events []*Event
+		_, err := client.Subscribe(func(_ context.Context, event *Event) {
+			events = append(events, event)
+		}, EventTypeTransactionConflicted)
+		require.NoError(t, err)
+
+		var destination *Destination
+		destination, err = client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+		txHex := CreateFakeFundingTransaction(t, masterKey, []*Destination{destination}, 5000)
+		incomingTx := newIncomingTransaction("", txHex, client.DefaultModelOptions(New())...)
+		incomingTx.ID = incomingTx.TransactionBase.parsedTx.TxID()
+
+		// The incoming transaction is processed when saved
+		chainState.doubleSpend = true
+		require.NoError(t, incomingTx.Save(ctx))
+		assert.Equal(t, SyncStatusConflicted, incomingTx.Status)
+
+		require.Len(t, events, 1)
+		conflict := events[0].Data.(*TransactionConflict)
+		assert.Equal(t, incomingTx.ID, conflict.TransactionID)
+		assert.Equal(t, []string{utils.Hash(rawXPub)}, conflict.XpubIDs)
+
+		// Not recorded
+		var transaction *Transaction
+		transaction, err = getTransactionByID(ctx, "", incomingTx.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Nil(t, transaction)
+	})
+}
@@ -52,6 +52,15 @@ var ErrInvalidLockingScript = errors.New("invalid locking script")
 // ErrInvalidOpReturnOutput is when a locking script is not a valid op_return
 var ErrInvalidOpReturnOutput = errors.New("invalid op_return output")
 
+// ErrInvalidAipSignature is when the AIP signature of an op_return is missing or not valid
+var ErrInvalidAipSignature = errors.New("invalid aip signature")
+
+// ErrInvalidBapProtocol is when the BAP protocol of an op_return is not valid (or not signed using AIP)
+var ErrInvalidBapProtocol = errors.New("invalid bap protocol")
+
+// ErrMissingAipSigner is when an op_return needs to be signed using AIP, but no AIP signer is set
+var ErrMissingAipSigner = errors.New("missing aip signer")
+
 // ErrInvalidTransactionID is when a transaction id cannot be decoded
 var ErrInvalidTransactionID = errors.New("invalid transaction id")
 
@@ -67,6 +76,15 @@ var ErrOutputValueUnSpendable = errors.New("output value un-spendable")
 // ErrPaymailAddressIsInvalid is when the paymail address is NOT alias@domain.com
 var ErrPaymailAddressIsInvalid = errors.New("paymail address is invalid")
 
+// ErrMissingPaymail is when the paymail address could not be found
+var ErrMissingPaymail = errors.New("could not find paymail address")
+
+// ErrMissingXPubResolver is when the raw xPub of a paymail address is needed, but no resolver (or signer) is set
+var ErrMissingXPubResolver = errors.New("xpub resolver is required for resolving the paymail xpub")
+
+// ErrPaymailAlreadyExists is when the paymail address is already in use
+var ErrPaymailAlreadyExists = errors.New("paymail address already exists")
+
 // ErrUtxoNotReserved is when the utxo is not reserved, but a transaction tries to spend it
 var ErrUtxoNotReserved = errors.New("transaction utxo has not been reserved for spending")
 
@@ -82,6 +100,15 @@ var ErrUtxoAlreadySpent = errors.New("utxo has already been spent")
 // ErrDraftNotFound is when the requested draft transaction was not found
 var ErrDraftNotFound = errors.New("corresponding draft transaction not found")
 
+// ErrDraftNotActive is when the draft transaction is not a draft anymore (canceled, expired or complete)
+var ErrDraftNotActive = errors.New("draft transaction is canceled, expired or complete")
+
+// ErrLockTimeMismatch is when the lock time of the transaction does not match the draft transaction
+var ErrLockTimeMismatch = errors.New("transaction lock time does not match the draft transaction")
+
+// ErrSequenceMismatch is when an input sequence of the transaction does not match the draft transaction
+var ErrSequenceMismatch = errors.New("transaction input sequence does not match the draft transaction")
+
 // ErrTaskManagerNotLoaded is when the taskmanager was not loaded
 var ErrTaskManagerNotLoaded = errors.New("taskmanager must be loaded")
 
@@ -91,6 +118,42 @@ var ErrTransactionNotParsed = errors.New("transaction is not parsed")
 // ErrNoMatchingOutputs is when the transaction does not match any known destinations
 var ErrNoMatchingOutputs = errors.New("transaction outputs do not match any known destinations")
 
+// ErrSPVInvalidPayload is when the BEEF or SPV envelope payload cannot be parsed
+var ErrSPVInvalidPayload = errors.New("invalid spv payload, expected beef or spv envelope")
+
+// ErrSPVMissingAncestor is when an input spends a transaction that is not found in the spv payload
+var ErrSPVMissingAncestor = errors.New("spv payload is missing an ancestor transaction")
+
+// ErrSPVInvalidInput is when an input spends a missing output or the transaction has no inputs
+var ErrSPVInvalidInput = errors.New("spv transaction input is invalid")
+
+// ErrSPVInvalidScript is when an input unlocking script fails against the ancestor locking script
+var ErrSPVInvalidScript = errors.New("spv transaction input script is invalid")
+
+// ErrSPVInsufficientInputs is when the inputs do not cover the outputs of the transaction
+var ErrSPVInsufficientInputs = errors.New("spv transaction inputs do not cover the outputs")
+
+// ErrSPVUnknownBlock is when the block of an spv merkle proof is not found in the block headers or chain provider(s)
+var ErrSPVUnknownBlock = errors.New("spv merkle proof block is unknown")
+
+// ErrMissingTransaction is when the transaction could not be found
+var ErrMissingTransaction = errors.New("could not find transaction")
+
+// ErrTransactionAlreadyMined is when the fee of a transaction is bumped, but the transaction is already mined
+var ErrTransactionAlreadyMined = errors.New("transaction is already mined")
+
+// ErrMissingChangeUtxos is when the fee of a transaction is bumped, but it has no unspent change of the xPub
+var ErrMissingChangeUtxos = errors.New("transaction has no unspent change outputs")
+
+// ErrBlockHeaderOrphaned is when the block header was orphaned by a chain reorg
+var ErrBlockHeaderOrphaned = errors.New("block header was orphaned by a chain reorg")
+
+// ErrMissingMerkleProof is when the transaction has not been mined or the merkle proof was not found
+var ErrMissingMerkleProof = errors.New("merkle proof not found for transaction")
+
+// ErrMerkleProofTargetMismatch is when the block hash target of the merkle proof is not the block of the transaction
+var ErrMerkleProofTargetMismatch = errors.New("merkle proof target does not match the block of the transaction")
+
 // ErrResolutionFailed is when the paymail resolution failed unexpectedly
 var ErrResolutionFailed = errors.New("failed to return a resolution for paymail address")
 
@@ -127,8 +190,101 @@ var ErrMissingBody = errors.New("missing body")
 // ErrSignatureInvalid is when the signature failed to be valid
 var ErrSignatureInvalid = errors.New("signature invalid")
 
+// ErrMissingAuthNonce is when the nonce of the signature is missing
+var ErrMissingAuthNonce = errors.New("missing auth nonce")
+
+// ErrAuthNonceUsed is when the nonce of the signature was already used (replayed request)
+var ErrAuthNonceUsed = errors.New("auth nonce was already used")
+
+// ErrRateLimitExceeded is when the xPub or access key made too many requests (see WithRateLimit)
+var ErrRateLimitExceeded = errors.New("rate limit exceeded, try again later")
+
 // ErrUnknownAccessKey is when the access key is unknown or not found
 var ErrUnknownAccessKey = errors.New("unknown access key")
 
 // ErrAccessKeyRevoked is when the access key has been revoked
 var ErrAccessKeyRevoked = errors.New("access key has been revoked")
+
+// ErrAccessKeyExpired is when the access key has expired
+var ErrAccessKeyExpired = errors.New("access key has expired")
+
+// ErrAccessKeyScope is when the access key does not have the scope required by the request
+var ErrAccessKeyScope = errors.New("access key does not have the required scope")
+
+// ErrInvalidAccessKeyScope is when the scope of the access key is unknown
+var ErrInvalidAccessKeyScope = errors.New("invalid access key scope")
+
+// ErrMissingEventHandler is when the event handler is missing
+var ErrMissingEventHandler = errors.New("missing event handler")
+
+// ErrInvalidWebhook is when the webhook url or secret is invalid
+var ErrInvalidWebhook = errors.New("invalid webhook, a valid url (http or https) and a secret are required")
+
+// ErrEventSubscriptionNotFound is when the event subscription was not found
+var ErrEventSubscriptionNotFound = errors.New("event subscription not found")
+
+// ErrWebhookDeliveryFailed is when the webhook delivery failed (will be retried)
+var ErrWebhookDeliveryFailed = errors.New("webhook delivery failed")
+
+// ErrUnknownCoinSelectionStrategy is when the coin selection strategy is not found
+var ErrUnknownCoinSelectionStrategy = errors.New("unknown coin selection strategy")
+
+// ErrMissingSigner is when a signer is required (server-side signing) but was not set
+var ErrMissingSigner = errors.New("missing signer, server-side signing is not enabled")
+
+// ErrDraftInputNotFound is when an input of the draft hex was not found in the draft configuration
+var ErrDraftInputNotFound = errors.New("draft transaction input not found")
+
+// ErrInvalidKeystore is when the keystore could not be parsed
+var ErrInvalidKeystore = errors.New("invalid keystore")
+
+// ErrKeystoreDecryption is when the keystore could not be decrypted (wrong passphrase)
+var ErrKeystoreDecryption = errors.New("unable to decrypt the keystore")
+
+// ErrDraftSignaturesMismatch is when the number of signatures does not match the number of inputs of the draft
+var ErrDraftSignaturesMismatch = errors.New("number of signatures does not match the draft transaction inputs")
+
+// ErrNotMultiSigInput is when a multisig signature is given (or needed) for an input that is not multisig
+var ErrNotMultiSigInput = errors.New("draft transaction input is not a multisig input")
+
+// ErrNotMultiSigCosigner is when the xPub is not a cosigner of the multisig input
+var ErrNotMultiSigCosigner = errors.New("xpub is not a cosigner of the multisig input")
+
+// ErrMissingMultiSigSignatures is when a multisig input does not have the required number of signatures (yet)
+var ErrMissingMultiSigSignatures = errors.New("multisig input is missing the required signatures")
+
+// ErrMixedMultiSigInputs is when a draft spends multisig utxos together with other utxos
+var ErrMixedMultiSigInputs = errors.New("multisig utxos cannot be spent together with other utxos")
+
+// ErrDuplicateMultiSigXpub is when the same xPub is given more than once for a multisig destination
+var ErrDuplicateMultiSigXpub = errors.New("multisig xpubs must be unique")
+
+// ErrMissingUtxo is when the utxo could not be found
+var ErrMissingUtxo = errors.New("could not find utxo")
+
+// ErrMissingDestination is when the destination could not be found
+var ErrMissingDestination = errors.New("could not find destination")
+
+// ErrMissingAccessKey is when the access key could not be found
+var ErrMissingAccessKey = errors.New("could not find access key")
+
+// ErrInvalidAdminKey is when the admin key is not an xPub or the public key of an access key
+var ErrInvalidAdminKey = errors.New("admin key is not a valid xpub or access key")
+
+// ErrMissingAdmin is when the admin could not be found
+var ErrMissingAdmin = errors.New("could not find admin")
+
+// ErrMissingSpendingPolicy is when the spending policy could not be found
+var ErrMissingSpendingPolicy = errors.New("could not find spending policy")
+
+// ErrSpendingPolicyViolation is when a transaction violates a spending policy (see SpendingPolicyError)
+var ErrSpendingPolicyViolation = errors.New("transaction violates the spending policy")
+
+// ErrSpendingMaxSatoshis is when a transaction sends more than the maximum satoshis of the spending policy
+var ErrSpendingMaxSatoshis = errors.New("transaction exceeds the maximum satoshis per transaction")
+
+// ErrSpendingDailyLimit is when a transaction exceeds the daily limit of the spending policy
+var ErrSpendingDailyLimit = errors.New("transaction exceeds the daily spend limit")
+
+// ErrSpendingDestination is when a transaction sends to a destination that is not allowed by the spending policy
+var ErrSpendingDestination = errors.New("destination is not allowed")
@@ -0,0 +1,148 @@
+package bux
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+)
+
+// EventType is the type of event published by the engine
+type EventType string
+
+const (
+	// EventTypeChainReorg is when a block was orphaned and the mined transactions are synced again
+	EventTypeChainReorg EventType = "chain_reorg"
+
+	// EventTypeDraftTransactionCanceled is when a draft transaction was canceled
+	EventTypeDraftTransactionCanceled EventType = "draft_transaction_canceled"
+
+	// EventTypeDraftTransactionExpired is when a draft transaction has expired
+	EventTypeDraftTransactionExpired EventType = "draft_transaction_expired"
+
+	// EventTypeSyncTransactionStatus is when the broadcast or sync status of a transaction has changed
+	EventTypeSyncTransactionStatus EventType = "sync_transaction_status"
+
+	// EventTypeTransactionConflicted is when a transaction lost a double spend (inputs spent by a conflicting transaction)
+	EventTypeTransactionConflicted EventType = "transaction_conflicted"
+
+	// EventTypeTransactionCreated is when a transaction was recorded (incoming or outgoing)
+	EventTypeTransactionCreated EventType = "transaction_created"
+
+	// EventTypeUtxoConsolidation is when a consolidation draft transaction needs to be signed by the xPub owner
+	EventTypeUtxoConsolidation EventType = "utxo_consolidation"
+
+	// EventTypeUtxoSpent is when a utxo was spent by a recorded transaction
+	EventTypeUtxoSpent EventType = "utxo_spent"
+)
+
+// Webhook headers
+const (
+	webhookHeaderEvent     = "X-Bux-Event"
+	webhookHeaderEventID   = "X-Bux-Event-Id"
+	webhookHeaderSignature = "X-Bux-Signature"
+	webhookHeaderTimestamp = "X-Bux-Timestamp"
+)
+
+// Event is an event published by the engine
+type Event struct {
+	CreatedAt time.Time   `json:"created_at"` // When the event was published
+	Data      interface{} `json:"data"`       // The related model or event data
+	ID        string      `json:"id"`         // Unique id of the event
+	Type      EventType   `json:"type"`       // Type of event
+}
+
+// EventHandler is an in-process event subscriber
+//
+// Handlers are called synchronously from the model hooks and should not block
+type EventHandler func(ctx context.Context, event *Event)
+
+// Webhook is an HTTP event subscriber
+//
+// Events are posted (JSON) to the URL and signed using the secret, see WebhookSignature()
+type Webhook struct {
+	EventTypes []EventType `json:"event_types"` // Types of events to deliver (empty is all events)
+	Secret     string      `json:"-"`           // Secret for the HMAC signature
+	URL        string      `json:"url"`         // URL of the webhook (http or https)
+}
+
+// ChainReorg is the event data for a chain reorg (EventTypeChainReorg)
+type ChainReorg struct {
+	BlockHash      string   `json:"block_hash"`      // Hash of the orphaned block
+	BlockHeight    uint64   `json:"block_height"`    // Height of the orphaned block
+	TransactionIDs []string `json:"transaction_ids"` // Transactions that were mined in the orphaned block
+}
+
+// TransactionConflict is the event data for a double spent transaction (EventTypeTransactionConflicted)
+type TransactionConflict struct {
+	Reason        string   `json:"reason"`         // Reason of the conflict (broadcast or sync error)
+	TransactionID string   `json:"transaction_id"` // Transaction that lost the double spend
+	XpubIDs       []string `json:"xpub_ids"`       // xPubs of the transaction (inputs and outputs)
+}
+
+// eventSubscription is a subscription for events (in-process handler or webhook)
+type eventSubscription struct {
+	eventTypes []EventType  // Types of events (empty is all events)
+	handler    EventHandler // In-process handler
+	id         string       // Unique id of the subscription
+	webhook    *Webhook     // Webhook configuration
+}
+
+// webhookDelivery is a webhook delivery (task arguments)
+//
+// The secret is not part of the task, it is taken from the webhook subscribed with the same id (URL)
+type webhookDelivery struct {
+	EventID        string    `json:"event_id"`
+	EventType      EventType `json:"event_type"`
+	Payload        []byte    `json:"payload"`
+	SubscriptionID string    `json:"subscription_id"`
+	URL            string    `json:"url"`
+}
+
+// newEvent will start a new event
+func newEvent(eventType EventType, data interface{}) (*Event, error) {
+	id, err := utils.RandomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+		ID:        id,
+		Type:      eventType,
+	}, nil
+}
+
+// matches will return true if the subscription is for the event type
+func (s *eventSubscription) matches(eventType EventType) bool {
+	if len(s.eventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.eventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSignature will return the HMAC-SHA256 signature (hex) of a webhook payload
+//
+// The signature is sent in the X-Bux-Signature header and is computed over the
+// X-Bux-Timestamp header value, a "." and the request body
+func WebhookSignature(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(timestamp + "."))
+	_, _ = mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// publishEvent will publish an event if the client is loaded
+func publishEvent(ctx context.Context, client ClientInterface, eventType EventType, data interface{}) {
+	if client != nil {
+		client.PublishEvent(ctx, eventType, data)
+	}
+}
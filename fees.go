@@ -0,0 +1,66 @@
+package bux
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/bux/cachestore"
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/BuxOrg/bux/utils"
+)
+
+// getFeeUnit is a utility function to retrieve the fee unit quoted by a miner (using mAPI)
+//
+// The quote is cached until it expires (max cacheTTLFeeQuote), miner can be empty (use the broadcast miners).
+// A failed quote is also cached (cacheTTLFeeQuoteFailed), so requests do not wait on mAPI while it is down.
+func getFeeUnit(ctx context.Context, cache cachestore.ClientInterface, chain chainstate.ClientInterface,
+	miner string) (*utils.FeeUnit, error) {
+
+	// Attempt to get from cachestore
+	key := cacheKeyFeeQuote + strings.ToLower(miner)
+	failedKey := cacheKeyFeeQuoteFailed + strings.ToLower(miner)
+	if cache != nil {
+		feeUnit := new(utils.FeeUnit)
+		if err := cache.GetModel(ctx, key, feeUnit); err != nil && !errors.Is(err, cachestore.ErrKeyNotFound) {
+			return nil, err
+		} else if feeUnit.Satoshis > 0 && feeUnit.Bytes > 0 {
+			return feeUnit, nil
+		}
+
+		// The fee quote failed recently
+		if err := cache.GetModel(ctx, failedKey, new(utils.FeeUnit)); err == nil {
+			return nil, chainstate.ErrFeeQuoteNotFound
+		} else if !errors.Is(err, cachestore.ErrKeyNotFound) {
+			return nil, err
+		}
+	}
+
+	// Get the fee quote from the miner(s)
+	quote, err := chain.FeeQuote(ctx, miner, chain.QueryTimeout())
+	if err != nil {
+		if cache != nil {
+			_ = cache.SetModel(ctx, failedKey, &utils.FeeUnit{}, cacheTTLFeeQuoteFailed)
+		}
+		return nil, err
+	}
+	feeUnit := utils.FeeUnit(*quote.FeeUnit)
+
+	// Save to cachestore (until the quote expires)
+	if cache != nil {
+		ttl := cacheTTLFeeQuote
+		if !quote.ExpiresAt.IsZero() {
+			if expiresIn := time.Until(quote.ExpiresAt); expiresIn < ttl {
+				ttl = expiresIn
+			}
+		}
+		if ttl > 0 {
+			if err = cache.SetModel(ctx, key, &feeUnit, ttl); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &feeUnit, nil
+}
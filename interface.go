@@ -15,56 +15,134 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// AccessKeyService is the access key related requests
+type AccessKeyService interface {
+	DeleteAccessKey(ctx context.Context, xPubKey, id string) error
+	GetAccessKeyByID(ctx context.Context, xPubKey, id string) (*AccessKey, error)
+	GetAccessKeys(ctx context.Context, xPubKey string, usingMetadata *Metadata,
+		queryParams *datastore.QueryParams) ([]*AccessKey, error)
+	NewAccessKey(ctx context.Context, xPubKey string, scopes []string, expiresIn time.Duration,
+		opts ...ModelOps) (*AccessKey, error)
+	RevokeAccessKey(ctx context.Context, xPubKey, id string, opts ...ModelOps) (*AccessKey, error)
+}
+
+// AdminService is the admin related requests
+type AdminService interface {
+	AddAdmin(ctx context.Context, key string, opts ...ModelOps) (*Admin, error)
+	ListAdmins(ctx context.Context, queryParams *datastore.QueryParams) ([]*Admin, error)
+	RemoveAdmin(ctx context.Context, key string) error
+}
+
+// SpendingPolicyService is the spending policy related requests
+type SpendingPolicyService interface {
+	DeleteSpendingPolicy(ctx context.Context, xPubKey, accessKeyID string) error
+	GetSpendingPolicy(ctx context.Context, xPubKey, accessKeyID string) (*SpendingPolicy, error)
+	SetSpendingPolicy(ctx context.Context, xPubKey, accessKeyID string, rules *SpendingRules,
+		opts ...ModelOps) (*SpendingPolicy, error)
+}
+
 // TransactionService is the transaction related requests
 type TransactionService interface {
+	AddDraftSignatures(ctx context.Context, rawXpubKey, draftID string,
+		signatures []string) (*DraftTransaction, error)
+	BumpTransactionFee(ctx context.Context, rawXpubKey, txID string, feeUnit *utils.FeeUnit,
+		metadata map[string]interface{}, opts ...ModelOps) (*DraftTransaction, error)
+	CancelDraftTransaction(ctx context.Context, rawXpubKey, draftID string) (*DraftTransaction, error)
+	DeleteXpubMetadata(ctx context.Context, rawXpubKey, txID string, keys []string) (*Transaction, error)
+	ExtendDraftTransaction(ctx context.Context, rawXpubKey, draftID string,
+		expiresIn time.Duration) (*DraftTransaction, error)
 	GetTransaction(ctx context.Context, rawXpubKey, txID string) (*Transaction, error)
-	GetTransactions(ctx context.Context, rawXpubKey string, metadata *Metadata, conditions *map[string]interface{}) ([]*Transaction, error)
+	GetTransactionMerkleProof(ctx context.Context, rawXpubKey, txID string) (*MerkleProof, error)
+	GetTransactions(ctx context.Context, rawXpubKey string, metadata *Metadata, conditions *map[string]interface{},
+		queryParams *datastore.QueryParams) ([]*Transaction, error)
+	GetTransactionsCount(ctx context.Context, rawXpubKey string, metadata *Metadata,
+		conditions *map[string]interface{}) (int64, error)
 	NewTransaction(ctx context.Context, rawXpubKey string, config *TransactionConfig,
 		metadata map[string]interface{}, opts ...ModelOps) (*DraftTransaction, error)
+	RecordMultiSigTransaction(ctx context.Context, rawXpubKey, draftID string,
+		opts ...ModelOps) (*Transaction, error)
+	RecordSPVTransaction(ctx context.Context, xPubKey, spvPayload, draftID string,
+		opts ...ModelOps) (*Transaction, error)
 	RecordTransaction(ctx context.Context, xPubKey, txHex, draftID string,
 		opts ...ModelOps) (*Transaction, error)
+	SendTransaction(ctx context.Context, rawXpubKey string, config *TransactionConfig,
+		metadata map[string]interface{}, opts ...ModelOps) (*Transaction, error)
 }
 
 // DestinationService is the destination related requests
 type DestinationService interface {
+	DeleteDestination(ctx context.Context, xPubKey, id string) error
 	GetDestinationByAddress(ctx context.Context, xPubKey, address string) (*Destination, error)
 	GetDestinationByLockingScript(ctx context.Context, xPubKey, lockingScript string) (*Destination, error)
-	GetDestinations(ctx context.Context, xPubKey string, usingMetadata *Metadata) ([]*Destination, error)
+	GetDestinations(ctx context.Context, xPubKey string, usingMetadata *Metadata,
+		queryParams *datastore.QueryParams) ([]*Destination, error)
+	GetDestinationsCount(ctx context.Context, xPubKey string, usingMetadata *Metadata) (int64, error)
 	NewDestination(ctx context.Context, xPubKey string, chain uint32, destinationType string,
 		metadata *map[string]interface{}) (*Destination, error)
 	NewDestinationForLockingScript(ctx context.Context, xPubID, lockingScript, destinationType string,
 		metadata map[string]interface{}) (*Destination, error)
+	NewMultiSigDestination(ctx context.Context, xPubKeys []string, required int, chain uint32,
+		metadata *map[string]interface{}) (*Destination, error)
+	RestoreDestination(ctx context.Context, xPubKey, id string) (*Destination, error)
+}
+
+// EventService is the event related methods
+type EventService interface {
+	PublishEvent(ctx context.Context, eventType EventType, data interface{})
+	Subscribe(handler EventHandler, eventTypes ...EventType) (string, error)
+	SubscribeWebhook(webhook *Webhook) (string, error)
+	Unsubscribe(id string) error
+}
+
+// PaymailService is the paymail address related requests
+type PaymailService interface {
+	DeletePaymailAddress(ctx context.Context, address string, opts ...ModelOps) error
+	GetPaymailAddress(ctx context.Context, address string) (*PaymailAddress, error)
+	GetPaymailAddressesByXpubID(ctx context.Context, xPubID string, usingMetadata *Metadata) ([]*PaymailAddress, error)
+	NewPaymailAddress(ctx context.Context, xPubKey, address, publicName, avatar string,
+		opts ...ModelOps) (*PaymailAddress, error)
 }
 
 // UTXOService is the utxo related requests
 type UTXOService interface {
 	GetUtxo(ctx context.Context, xPubKey, txID string, outputIndex uint32) (*Utxo, error)
-	GetUtxos(ctx context.Context, xPubKey string) ([]*Utxo, error)
+	GetUtxos(ctx context.Context, xPubKey string, queryParams *datastore.QueryParams) ([]*Utxo, error)
+	GetUtxosCount(ctx context.Context, xPubKey string) (int64, error)
 }
 
 // XPubService is the xPub related requests
 type XPubService interface {
+	DeleteXpub(ctx context.Context, xPubKey string) error
 	GetXpub(ctx context.Context, xPubKey string) (*Xpub, error)
 	GetXpubByID(ctx context.Context, xPubID string) (*Xpub, error)
 	NewXpub(ctx context.Context, xPubKey string, opts ...ModelOps) (*Xpub, error)
+	RestoreXpub(ctx context.Context, xPubKey string) (*Xpub, error)
 }
 
 // ClientInterface is the client (bux engine) interface
 type ClientInterface interface {
+	AccessKeyService
+	AdminService
 	DestinationService
+	EventService
+	PaymailService
+	SpendingPolicyService
 	TransactionService
 	UTXOService
 	XPubService
 	AddModels(ctx context.Context, autoMigrate bool, models ...interface{}) error
+	AipSigner() AipSigner
+	Allow(ctx context.Context, id, action string) error
 	AuthenticateRequest(ctx context.Context, req *http.Request, adminXPubs []string, adminRequired, requireSigning, signingDisabled bool) (*http.Request, error)
 	Cachestore() cachestore.ClientInterface
 	Chainstate() chainstate.ClientInterface
 	Close(ctx context.Context) error
+	CoinSelector(strategy CoinSelectionStrategy) CoinSelector
 	Datastore() datastore.ClientInterface
 	Debug(on bool)
 	DefaultModelOptions(opts ...ModelOps) []ModelOps
 	EnableNewRelic()
-	GetFeeUnit(_ context.Context, _ string) *utils.FeeUnit
+	GetFeeUnit(ctx context.Context, useMiner string) *utils.FeeUnit
 	GetOrStartTxn(ctx context.Context, name string) context.Context
 	GetTaskPeriod(name string) time.Duration
 	IsDebug() bool
@@ -75,7 +153,9 @@ type ClientInterface interface {
 	ModifyTaskPeriod(name string, period time.Duration) error
 	PaymailClient() paymail.ClientInterface
 	PaymailServerConfig() *paymailServerOptions
+	PaymailServiceProvider() server.PaymailServiceProvider
 	Taskmanager() taskmanager.ClientInterface
 	UserAgent() string
 	Version() string
+	XPubResolver() XPubResolver
 }
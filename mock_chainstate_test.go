@@ -6,6 +6,7 @@ import (
 
 	"github.com/BuxOrg/bux/chainstate"
 	"github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bt/v2"
 	"github.com/mrz1836/go-mattercloud"
 	"github.com/mrz1836/go-nownodes"
 	"github.com/mrz1836/go-whatsonchain"
@@ -30,6 +31,22 @@ func (c *chainStateBase) QueryTransactionFastest(context.Context, string, chains
 	return nil, nil
 }
 
+func (c *chainStateBase) QueryBlockHeader(context.Context, uint64, time.Duration) (*chainstate.BlockHeader, error) {
+	return nil, chainstate.ErrBlockHeaderNotFound
+}
+
+func (c *chainStateBase) QueryChainTip(context.Context, time.Duration) (*chainstate.BlockHeader, error) {
+	return nil, chainstate.ErrBlockHeaderNotFound
+}
+
+func (c *chainStateBase) QueryUtxoUnspent(context.Context, string, uint32, string, time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (c *chainStateBase) FeeQuote(context.Context, string, time.Duration) (*chainstate.FeeQuote, error) {
+	return nil, chainstate.ErrFeeQuoteNotFound
+}
+
 func (c *chainStateBase) BroadcastMiners() []*minercraft.Miner {
 	return nil
 }
@@ -133,8 +150,15 @@ func (c *chainStateEverythingOnChain) QueryTransaction(_ context.Context, id str
 		BlockHeight:   600000,
 		Confirmations: 10,
 		ID:            id,
-		MinerID:       "",
-		Provider:      "whatsonchain",
+		MerkleProof: &chainstate.MerkleProof{
+			Index:      1,
+			Nodes:      []string{testTxScriptSigID},
+			Target:     hash,
+			TargetType: chainstate.MerkleTargetTypeHash,
+			TxOrID:     id,
+		},
+		MinerID:  "",
+		Provider: "whatsonchain",
 	}, nil
 }
 
@@ -147,7 +171,136 @@ func (c *chainStateEverythingOnChain) QueryTransactionFastest(_ context.Context,
 		BlockHeight:   600000,
 		Confirmations: 10,
 		ID:            id,
-		MinerID:       "",
-		Provider:      "whatsonchain",
+		MerkleProof: &chainstate.MerkleProof{
+			Index:      1,
+			Nodes:      []string{testTxScriptSigID},
+			Target:     hash,
+			TargetType: chainstate.MerkleTargetTypeHash,
+			TxOrID:     id,
+		},
+		MinerID:  "",
+		Provider: "whatsonchain",
+	}, nil
+}
+
+// chainStateBlockHeaders will return the block headers (and tip) from the given list
+//
+// The target (and type) of the merkle proofs are replaced if set
+type chainStateBlockHeaders struct {
+	chainStateEverythingOnChain
+	headers    []*chainstate.BlockHeader
+	target     string
+	targetType string
+}
+
+func (c *chainStateBlockHeaders) QueryBlockHeader(_ context.Context, height uint64,
+	_ time.Duration) (*chainstate.BlockHeader, error) {
+	for _, header := range c.headers {
+		if header.Height == height {
+			return header, nil
+		}
+	}
+	return nil, chainstate.ErrBlockHeaderNotFound
+}
+
+func (c *chainStateBlockHeaders) QueryChainTip(context.Context, time.Duration) (*chainstate.BlockHeader, error) {
+	if len(c.headers) == 0 {
+		return nil, chainstate.ErrBlockHeaderNotFound
+	}
+	return c.headers[len(c.headers)-1], nil
+}
+
+func (c *chainStateBlockHeaders) QueryTransactionFastest(ctx context.Context, id string, requiredIn chainstate.RequiredIn,
+	timeout time.Duration) (*chainstate.TransactionInfo, error) {
+
+	// The transaction is mined in the chain tip
+	info, _ := c.chainStateEverythingOnChain.QueryTransactionFastest(ctx, id, requiredIn, timeout)
+	tip, err := c.QueryChainTip(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+	info.BlockHash = tip.Hash
+	info.BlockHeight = int64(tip.Height)
+	info.MerkleProof.Target = tip.Hash
+	if len(c.target) > 0 {
+		info.MerkleProof.Target = c.target
+	}
+	if len(c.targetType) > 0 {
+		info.MerkleProof.TargetType = c.targetType
+	}
+	return info, nil
+}
+
+// chainStateFeeQuote will quote a fee for the requested miner (and record the requests)
+type chainStateFeeQuote struct {
+	chainStateBase
+	failing  bool
+	feeUnit  bt.FeeUnit
+	requests []string
+}
+
+func (c *chainStateFeeQuote) FeeQuote(_ context.Context, minerName string, _ time.Duration) (*chainstate.FeeQuote, error) {
+	c.requests = append(c.requests, minerName)
+	if c.failing {
+		return nil, chainstate.ErrFeeQuoteNotFound
+	}
+	feeUnit := c.feeUnit
+	return &chainstate.FeeQuote{
+		ExpiresAt: time.Now().UTC().Add(time.Minute),
+		FeeUnit:   &feeUnit,
+		Miner:     minerName,
 	}, nil
 }
+
+// chainStateRejectBroadcast will keep track of the broadcasts, rejecting them if set (fee too low)
+//
+// The failures are returned for the broadcast of the given transaction ids
+type chainStateRejectBroadcast struct {
+	chainStateEverythingOnChain
+	broadcasts []string
+	failures   map[string]error
+	reject     bool
+}
+
+func (c *chainStateRejectBroadcast) Broadcast(_ context.Context, id, _ string, _ time.Duration) error {
+	c.broadcasts = append(c.broadcasts, id)
+	if err, ok := c.failures[id]; ok {
+		return err
+	} else if c.reject {
+		return chainstate.ErrFeeTooLow
+	}
+	return nil
+}
+
+// chainStateDoubleSpend will report the transactions as double spent (broadcast and query) if set
+//
+// All utxos are unspent, unless disputed is set (onQueryUtxo is called on every utxo query)
+type chainStateDoubleSpend struct {
+	chainStateEverythingOnChain
+	disputed    bool
+	doubleSpend bool
+	onQueryUtxo func()
+}
+
+func (c *chainStateDoubleSpend) Broadcast(context.Context, string, string, time.Duration) error {
+	if c.doubleSpend {
+		return chainstate.ErrDoubleSpend
+	}
+	return nil
+}
+
+func (c *chainStateDoubleSpend) QueryUtxoUnspent(context.Context, string, uint32, string,
+	time.Duration) (bool, error) {
+	if c.onQueryUtxo != nil {
+		c.onQueryUtxo()
+	}
+	return !c.disputed, nil
+}
+
+func (c *chainStateDoubleSpend) QueryTransactionFastest(ctx context.Context, id string,
+	requiredIn chainstate.RequiredIn, timeout time.Duration) (*chainstate.TransactionInfo, error) {
+	if c.doubleSpend {
+		return nil, chainstate.ErrDoubleSpend
+	}
+	return c.chainStateEverythingOnChain.QueryTransactionFastest(ctx, id, requiredIn, timeout)
+}
@@ -5,12 +5,36 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"errors"
+	"time"
 
 	"github.com/BuxOrg/bux/datastore"
 	"github.com/BuxOrg/bux/utils"
 	"github.com/bitcoinschema/go-bitcoin/v2"
 )
 
+// Scopes of the access keys (an access key without scopes has full access to the xPub)
+const (
+	// AccessKeyScopeAdmin gives access to all the requests (including admin requests, if the xPub is an admin)
+	AccessKeyScopeAdmin = "admin"
+
+	// AccessKeyScopeCreateDraft gives access to the read requests and creating draft transactions
+	AccessKeyScopeCreateDraft = "create_draft"
+
+	// AccessKeyScopeReadOnly gives access to the read requests
+	AccessKeyScopeReadOnly = "read_only"
+
+	// AccessKeyScopeRecordTransaction gives access to the read requests and recording transactions
+	AccessKeyScopeRecordTransaction = "record_transaction"
+)
+
+// AccessKeyScopes are all the valid scopes of the access keys
+var AccessKeyScopes = []string{
+	AccessKeyScopeAdmin,
+	AccessKeyScopeCreateDraft,
+	AccessKeyScopeReadOnly,
+	AccessKeyScopeRecordTransaction,
+}
+
 // AccessKey is an object representing the access key
 //
 // An AccessKey is a private key with a corresponding public key
@@ -27,6 +51,8 @@ type AccessKey struct {
 	ID        string         `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the unique access key id" bson:"_id"`
 	XpubID    string         `json:"xpub_id" toml:"xpub_id" yaml:"hash" gorm:"<-:create;type:char(64);index;comment:This is the related xPub id" bson:"xpub_id"`
 	RevokedAt utils.NullTime `json:"revoked_at" toml:"revoked_at" yaml:"revoked_at" gorm:"<-;comment:When the key was revoked" bson:"revoked_at,omitempty"`
+	ExpiresAt utils.NullTime `json:"expires_at" toml:"expires_at" yaml:"expires_at" gorm:"<-;comment:When the key expires" bson:"expires_at,omitempty"`
+	Scopes    IDs            `json:"scopes" toml:"scopes" yaml:"scopes" gorm:"<-;type:json;comment:The scopes of the key (empty is full access)" bson:"scopes,omitempty"`
 
 	// Private fields
 	Key string `gorm:"-" bson:"-"` // Used on "CREATE", shown to the user "once" only
@@ -50,6 +76,23 @@ func newAccessKey(xPubID string, opts ...ModelOps) *AccessKey {
 	}
 }
 
+// newAccessKeyWithScopes will start a new model with the given scopes and expiration (zero never expires)
+func newAccessKeyWithScopes(xPubID string, scopes []string, expiresIn time.Duration,
+	opts ...ModelOps) *AccessKey {
+
+	accessKey := newAccessKey(xPubID, opts...)
+	if len(scopes) > 0 {
+		accessKey.Scopes = scopes
+	}
+	if expiresIn > 0 {
+		accessKey.ExpiresAt = utils.NullTime{NullTime: sql.NullTime{
+			Time:  time.Now().UTC().Add(expiresIn),
+			Valid: true,
+		}}
+	}
+	return accessKey
+}
+
 // GetAccessKey will get the model with a given ID
 func GetAccessKey(ctx context.Context, id string, opts ...ModelOps) (*AccessKey, error) {
 
@@ -69,6 +112,45 @@ func GetAccessKey(ctx context.Context, id string, opts ...ModelOps) (*AccessKey,
 	return key, nil
 }
 
+// getAccessKeysByXpubID will get all the access keys of an xPubID
+func getAccessKeysByXpubID(ctx context.Context, xPubID string, usingMetadata *Metadata,
+	queryParams *datastore.QueryParams, opts ...ModelOps) ([]*AccessKey, error) {
+
+	if queryParams == nil {
+		queryParams = &datastore.QueryParams{}
+	}
+
+	// Construct an empty model
+	var models []AccessKey
+	conditions := map[string]interface{}{
+		xPubIDField: xPubID,
+	}
+	if usingMetadata != nil {
+		conditions[metadataField] = usingMetadata
+	}
+
+	// Get the records
+	if err := getModels(
+		ctx, NewBaseModel(ModelNameEmpty, opts...).Client().Datastore(),
+		&models, conditions, queryParams.PageSize, queryParams.Page,
+		queryParams.OrderByField, queryParams.SortDirection, defaultDatabaseReadTimeout,
+	); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Loop and enrich
+	accessKeys := make([]*AccessKey, 0)
+	for index := range models {
+		models[index].enrich(ModelAccessKey, opts...)
+		accessKeys = append(accessKeys, &models[index])
+	}
+
+	return accessKeys, nil
+}
+
 // GetModelName will get the name of the current model
 func (m *AccessKey) GetModelName() string {
 	return ModelAccessKey.String()
@@ -84,6 +166,16 @@ func (m *AccessKey) Save(ctx context.Context) error {
 	return Save(ctx, m)
 }
 
+// Delete will (soft) delete the model in the Datastore
+func (m *AccessKey) Delete(ctx context.Context) error {
+	return Delete(ctx, m)
+}
+
+// Restore will restore the (soft) deleted model in the Datastore
+func (m *AccessKey) Restore(ctx context.Context) error {
+	return Restore(ctx, m)
+}
+
 // GetID will get the ID
 func (m *AccessKey) GetID() string {
 	return m.ID
@@ -98,10 +190,39 @@ func (m *AccessKey) BeforeCreating(_ context.Context) error {
 		return ErrMissingFieldID
 	}
 
+	// Make sure the scopes are valid
+	for _, scope := range m.Scopes {
+		if !utils.StringInSlice(scope, AccessKeyScopes) {
+			return ErrInvalidAccessKeyScope
+		}
+	}
+
 	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
 	return nil
 }
 
+// IsExpired will return true if the access key has expired
+func (m *AccessKey) IsExpired() bool {
+	return m.ExpiresAt.Valid && !time.Now().UTC().Before(m.ExpiresAt.Time)
+}
+
+// HasScope will return true if the access key has access to the given scope
+//
+// An empty scope is a request that is not covered by any scope (full access is needed)
+func (m *AccessKey) HasScope(scope string) bool {
+
+	// Full access (legacy keys), except for the admin requests
+	if len(m.Scopes) == 0 {
+		return scope != AccessKeyScopeAdmin
+	}
+
+	// Admin has access to everything, every scope includes the read requests
+	if utils.StringInSlice(AccessKeyScopeAdmin, m.Scopes) || scope == AccessKeyScopeReadOnly {
+		return true
+	}
+	return len(scope) > 0 && utils.StringInSlice(scope, m.Scopes)
+}
+
 // RegisterTasks will register the model specific tasks on client initialization
 func (m *AccessKey) RegisterTasks() error {
 	return nil
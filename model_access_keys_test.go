@@ -83,4 +83,110 @@ func Test_newAccessKey(t *testing.T) {
 		assert.Equal(t, id, revokedKey.ID)
 		assert.True(t, revokedKey.RevokedAt.Valid)
 	})
+
+	t.Run("delete", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		key, err := client.NewAccessKey(ctx, rawXPub, nil, 0)
+		require.NoError(t, err)
+
+		// Another xPub can not delete the key
+		_, _, otherXPub := CreateNewXPub(ctx, t, client)
+		err = client.DeleteAccessKey(ctx, otherXPub, key.ID)
+		require.ErrorIs(t, err, utils.ErrXpubNoMatch)
+
+		err = client.DeleteAccessKey(ctx, rawXPub, key.ID)
+		require.NoError(t, err)
+
+		var accessKey *AccessKey
+		accessKey, err = GetAccessKey(ctx, key.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Nil(t, accessKey)
+
+		accessKey, err = GetAccessKey(ctx, key.ID, client.DefaultModelOptions(WithDeleted())...)
+		require.NoError(t, err)
+		require.NotNil(t, accessKey)
+		assert.True(t, accessKey.IsDeleted())
+
+		err = client.DeleteAccessKey(ctx, rawXPub, key.ID)
+		require.ErrorIs(t, err, ErrMissingAccessKey)
+	})
+}
+
+// TestAccessKey_HasScope will test the method HasScope()
+func TestAccessKey_HasScope(t *testing.T) {
+	t.Parallel()
+
+	t.Run("full access", func(t *testing.T) {
+		key := newAccessKey(testXPubID)
+		assert.True(t, key.HasScope(""))
+		assert.True(t, key.HasScope(AccessKeyScopeReadOnly))
+		assert.True(t, key.HasScope(AccessKeyScopeRecordTransaction))
+		assert.False(t, key.HasScope(AccessKeyScopeAdmin))
+	})
+
+	t.Run("scoped", func(t *testing.T) {
+		key := newAccessKeyWithScopes(testXPubID, []string{AccessKeyScopeRecordTransaction}, 0)
+		assert.False(t, key.HasScope(""))
+		assert.True(t, key.HasScope(AccessKeyScopeReadOnly))
+		assert.True(t, key.HasScope(AccessKeyScopeRecordTransaction))
+		assert.False(t, key.HasScope(AccessKeyScopeCreateDraft))
+		assert.False(t, key.HasScope(AccessKeyScopeAdmin))
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		key := newAccessKeyWithScopes(testXPubID, []string{AccessKeyScopeAdmin}, 0)
+		assert.True(t, key.HasScope(""))
+		assert.True(t, key.HasScope(AccessKeyScopeCreateDraft))
+		assert.True(t, key.HasScope(AccessKeyScopeAdmin))
+	})
+}
+
+// TestClient_GetAccessKeys will test the methods GetAccessKeys() and GetAccessKeyByID()
+func TestClient_GetAccessKeys(t *testing.T) {
+
+	t.Run("scoped keys with metadata", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		key, err := client.NewAccessKey(
+			ctx, rawXPub, []string{AccessKeyScopeReadOnly}, time.Hour,
+			WithMetadatas(Metadata{"integration": "reporting"}),
+		)
+		require.NoError(t, err)
+		assert.True(t, key.ExpiresAt.Valid)
+		assert.False(t, key.IsExpired())
+
+		_, err = client.NewAccessKey(ctx, rawXPub, nil, 0)
+		require.NoError(t, err)
+
+		var keys []*AccessKey
+		keys, err = client.GetAccessKeys(ctx, rawXPub, nil, nil)
+		require.NoError(t, err)
+		assert.Len(t, keys, 2)
+
+		var accessKey *AccessKey
+		accessKey, err = client.GetAccessKeyByID(ctx, rawXPub, key.ID)
+		require.NoError(t, err)
+		assert.Equal(t, IDs{AccessKeyScopeReadOnly}, accessKey.Scopes)
+		assert.Equal(t, "reporting", accessKey.Metadata["integration"])
+		assert.True(t, accessKey.ExpiresAt.Valid)
+
+		// Another xPub
+		_, _, otherXPub := CreateNewXPub(ctx, t, client)
+		_, err = client.GetAccessKeyByID(ctx, otherXPub, key.ID)
+		require.ErrorIs(t, err, utils.ErrXpubNoMatch)
+	})
+
+	t.Run("error - invalid scope", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		_, err := client.NewAccessKey(ctx, rawXPub, []string{"unknown"}, 0)
+		require.ErrorIs(t, err, ErrInvalidAccessKeyScope)
+	})
 }
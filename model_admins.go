@@ -0,0 +1,171 @@
+package bux
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/BuxOrg/bux/datastore"
+	"github.com/BuxOrg/bux/utils"
+)
+
+// Types of admin keys
+const (
+	// AdminTypeAccessKey is an admin using an access key (signatures are checked using the access key)
+	AdminTypeAccessKey = "access_key"
+
+	// AdminTypeXPub is an admin using an xPub
+	AdminTypeXPub = "xpub"
+)
+
+// publicKeyRegex is the format of a (compressed) public key of an access key
+var publicKeyRegex = regexp.MustCompile(`^0[23][0-9a-fA-F]{64}$`)
+
+// Admin is an object representing an admin of the engine
+//
+// The admin key is either an xPub or the public key of an access key, only the hash of the key is saved.
+// Removed admins are (soft) deleted and are not restored by the admin keys of the client options.
+//
+// Gorm related models & indexes: https://gorm.io/docs/models.html - https://gorm.io/docs/indexes.html
+type Admin struct {
+	// Base model
+	Model `bson:",inline"`
+
+	// Model specific fields
+	ID   string `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the hash of the admin key" bson:"_id"`
+	Type string `json:"type" toml:"type" yaml:"type" gorm:"<-:create;type:varchar(16);comment:This is the type of the admin key (xpub or access_key)" bson:"type"`
+}
+
+// newAdmin will start a new model (key is an xPub or the public key of an access key)
+func newAdmin(key string, opts ...ModelOps) *Admin {
+	adminType := AdminTypeAccessKey
+	if isXPubKey(key) {
+		adminType = AdminTypeXPub
+	}
+	return &Admin{
+		ID:    utils.Hash(key),
+		Model: *NewBaseModel(ModelAdmin, opts...),
+		Type:  adminType,
+	}
+}
+
+// getAdmin will get the admin with the given ID (hash of the key)
+func getAdmin(ctx context.Context, id string, opts ...ModelOps) (*Admin, error) {
+
+	// Construct an empty model
+	admin := &Admin{
+		ID:    id,
+		Model: *NewBaseModel(ModelAdmin, opts...),
+	}
+
+	// Get the record
+	if err := Get(ctx, admin, nil, false, defaultDatabaseReadTimeout); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return admin, nil
+}
+
+// getAdmins will get all the admins
+func getAdmins(ctx context.Context, queryParams *datastore.QueryParams, opts ...ModelOps) ([]*Admin, error) {
+
+	if queryParams == nil {
+		queryParams = &datastore.QueryParams{}
+	}
+
+	// Construct an empty model
+	var models []Admin
+
+	// Get the records
+	if err := getModels(
+		ctx, NewBaseModel(ModelNameEmpty, opts...).Client().Datastore(),
+		&models, map[string]interface{}{}, queryParams.PageSize, queryParams.Page,
+		queryParams.OrderByField, queryParams.SortDirection, defaultDatabaseReadTimeout,
+	); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Loop and enrich
+	admins := make([]*Admin, 0)
+	for index := range models {
+		models[index].enrich(ModelAdmin, opts...)
+		admins = append(admins, &models[index])
+	}
+
+	return admins, nil
+}
+
+// validateAdminKey will check that the key is a valid xPub or the public key of an access key
+func validateAdminKey(key string) error {
+	if isXPubKey(key) {
+		_, err := utils.ValidateXPub(key)
+		return err
+	} else if !publicKeyRegex.MatchString(key) {
+		return ErrInvalidAdminKey
+	}
+	return nil
+}
+
+// GetModelName will get the name of the current model
+func (m *Admin) GetModelName() string {
+	return ModelAdmin.String()
+}
+
+// GetModelTableName will get the db table name of the current model
+func (m *Admin) GetModelTableName() string {
+	return tableAdmins
+}
+
+// Save will Save the model into the Datastore
+func (m *Admin) Save(ctx context.Context) error {
+	return Save(ctx, m)
+}
+
+// Delete will (soft) delete the model in the Datastore
+func (m *Admin) Delete(ctx context.Context) error {
+	return Delete(ctx, m)
+}
+
+// Restore will restore the (soft) deleted model in the Datastore
+func (m *Admin) Restore(ctx context.Context) error {
+	return Restore(ctx, m)
+}
+
+// GetID will get the ID
+func (m *Admin) GetID() string {
+	return m.ID
+}
+
+// BeforeCreating will fire before the model is being inserted into the Datastore
+func (m *Admin) BeforeCreating(_ context.Context) error {
+	m.DebugLog("starting: [" + m.name.String() + "] BeforeCreating hook...")
+
+	// Make sure ID is valid
+	if len(m.ID) == 0 {
+		return ErrMissingFieldID
+	}
+
+	// Make sure the type is valid
+	if m.Type != AdminTypeAccessKey && m.Type != AdminTypeXPub {
+		return ErrInvalidAdminKey
+	}
+
+	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
+	return nil
+}
+
+// RegisterTasks will register the model specific tasks on client initialization
+func (m *Admin) RegisterTasks() error {
+	return nil
+}
+
+// Migrate model specific migration on startup
+func (m *Admin) Migrate(client datastore.ClientInterface) error {
+	return client.IndexMetadata(client.GetTableName(tableAdmins), metadataField)
+}
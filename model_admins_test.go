@@ -0,0 +1,124 @@
+package bux
+
+import (
+	"testing"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdmin_newAdmin will test the method newAdmin()
+func TestAdmin_newAdmin(t *testing.T) {
+	t.Parallel()
+
+	t.Run("xpub admin", func(t *testing.T) {
+		admin := newAdmin(testXPub)
+		require.NotNil(t, admin)
+		assert.Equal(t, testXPubID, admin.ID)
+		assert.Equal(t, AdminTypeXPub, admin.Type)
+		assert.Equal(t, ModelAdmin.String(), admin.GetModelName())
+	})
+
+	t.Run("access key admin", func(t *testing.T) {
+		publicKey := "02719a5e3623bee13f8116f1db4ee54603c993e020087960f31d2e0b4cbd97d175"
+		admin := newAdmin(publicKey)
+		require.NotNil(t, admin)
+		assert.Equal(t, utils.Hash(publicKey), admin.ID)
+		assert.Equal(t, AdminTypeAccessKey, admin.Type)
+	})
+}
+
+// TestClient_AddAdmin will test the methods AddAdmin(), RemoveAdmin() and ListAdmins()
+func TestClient_AddAdmin(t *testing.T) {
+
+	t.Run("add, remove and restore", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		admin, err := client.AddAdmin(ctx, testXPub)
+		require.NoError(t, err)
+		assert.Equal(t, testXPubID, admin.ID)
+
+		var admins []*Admin
+		admins, err = client.ListAdmins(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, admins, 1)
+		assert.Equal(t, testXPubID, admins[0].ID)
+
+		require.NoError(t, client.RemoveAdmin(ctx, testXPub))
+		require.ErrorIs(t, client.RemoveAdmin(ctx, testXPub), ErrMissingAdmin)
+
+		admins, err = client.ListAdmins(ctx, nil)
+		require.NoError(t, err)
+		assert.Len(t, admins, 0)
+
+		// Adding again restores the admin
+		admin, err = client.AddAdmin(ctx, testXPub)
+		require.NoError(t, err)
+		assert.False(t, admin.IsDeleted())
+
+		admins, err = client.ListAdmins(ctx, nil)
+		require.NoError(t, err)
+		assert.Len(t, admins, 1)
+	})
+
+	t.Run("access key admin", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		accessKey, err := client.NewAccessKey(ctx, rawXPub, nil, 0)
+		require.NoError(t, err)
+
+		var admin *Admin
+		admin, err = client.AddAdmin(ctx, testAccessKeyPublicKey(t, accessKey))
+		require.NoError(t, err)
+		assert.Equal(t, accessKey.ID, admin.ID)
+		assert.Equal(t, AdminTypeAccessKey, admin.Type)
+	})
+
+	t.Run("error - invalid keys", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, err := client.AddAdmin(ctx, "invalid-key")
+		require.ErrorIs(t, err, ErrInvalidAdminKey)
+
+		// Unknown access key
+		_, err = client.AddAdmin(ctx, "02719a5e3623bee13f8116f1db4ee54603c993e020087960f31d2e0b4cbd97d175")
+		require.ErrorIs(t, err, ErrUnknownAccessKey)
+	})
+
+	t.Run("admin keys (options)", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true, WithCustomTaskManager(&taskManagerMockBase{}), WithAdminKeys(testXPub),
+		)
+		defer deferMe()
+
+		admins, err := client.ListAdmins(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, admins, 1)
+		assert.Equal(t, testXPubID, admins[0].ID)
+
+		// Removed admins are not added again
+		require.NoError(t, client.RemoveAdmin(ctx, testXPub))
+		require.NoError(t, client.(*Client).loadAdmins(ctx))
+
+		admins, err = client.ListAdmins(ctx, nil)
+		require.NoError(t, err)
+		assert.Len(t, admins, 0)
+	})
+
+	t.Run("admin keys (options) - debug mode", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, true, true, WithCustomTaskManager(&taskManagerMockBase{}), WithAdminKeys(testXPub),
+		)
+		defer deferMe()
+
+		admins, err := client.ListAdmins(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, admins, 1)
+		assert.Equal(t, testXPubID, admins[0].ID)
+	})
+}
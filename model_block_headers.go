@@ -0,0 +1,381 @@
+package bux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/BuxOrg/bux/datastore"
+	"github.com/BuxOrg/bux/taskmanager"
+)
+
+// BlockHeader is an object representing the block header table
+//
+// Block headers are synced from the chain providers and are used for verifying merkle proofs locally,
+// block headers orphaned by a chain reorg are marked as deleted
+//
+// Gorm related models & indexes: https://gorm.io/docs/models.html - https://gorm.io/docs/indexes.html
+type BlockHeader struct {
+	// Base model
+	Model `bson:",inline"`
+
+	// Model specific fields
+	ID         string `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the block hash" bson:"_id"`
+	Height     uint64 `json:"height" toml:"height" yaml:"height" gorm:"<-:create;type:bigint;index;comment:This is the block height" bson:"height"`
+	PrevHash   string `json:"prev_hash" toml:"prev_hash" yaml:"prev_hash" gorm:"<-:create;type:char(64);index;comment:This is the hash of the previous block" bson:"prev_hash"`
+	MerkleRoot string `json:"merkle_root" toml:"merkle_root" yaml:"merkle_root" gorm:"<-:create;type:char(64);index;comment:This is the merkle root of the block" bson:"merkle_root"`
+	Bits       uint32 `json:"bits" toml:"bits" yaml:"bits" gorm:"<-:create;type:bigint;comment:This is the difficulty target (compact)" bson:"bits"`
+	Nonce      uint32 `json:"nonce" toml:"nonce" yaml:"nonce" gorm:"<-:create;type:bigint;comment:This is the nonce of the proof-of-work" bson:"nonce"`
+	Time       uint32 `json:"time" toml:"time" yaml:"time" gorm:"<-:create;type:bigint;comment:This is the time of the block (unix)" bson:"time"`
+	Version    uint32 `json:"version" toml:"version" yaml:"version" gorm:"<-:create;type:bigint;comment:This is the block version" bson:"version"`
+}
+
+// newBlockHeader will start a new BlockHeader model from a (validated) chainstate block header
+func newBlockHeader(header *chainstate.BlockHeader, opts ...ModelOps) *BlockHeader {
+	return &BlockHeader{
+		Bits:       header.Bits,
+		Height:     header.Height,
+		ID:         header.Hash,
+		MerkleRoot: header.MerkleRoot,
+		Model:      *NewBaseModel(ModelBlockHeader, opts...),
+		Nonce:      header.Nonce,
+		PrevHash:   header.PrevHash,
+		Time:       header.Time,
+		Version:    header.Version,
+	}
+}
+
+// getBlockHeaderByHash will get the block header by the given hash
+//
+// Orphaned block headers are also returned, use IsOrphaned() to check
+func getBlockHeaderByHash(ctx context.Context, hash string, opts ...ModelOps) (*BlockHeader, error) {
+
+	// Construct an empty model
+	blockHeader := &BlockHeader{
+		ID:    hash,
+		Model: *NewBaseModel(ModelBlockHeader, append(opts, WithDeleted())...),
+	}
+
+	// Get the record
+	if err := Get(
+		ctx, blockHeader, nil, false, defaultDatabaseReadTimeout,
+	); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return blockHeader, nil
+}
+
+// getBlockHeaderByHeight will get the (non-orphaned) block header by the given height
+func getBlockHeaderByHeight(ctx context.Context, height uint64, opts ...ModelOps) (*BlockHeader, error) {
+
+	// Construct an empty model
+	blockHeader := &BlockHeader{
+		Model: *NewBaseModel(ModelBlockHeader, opts...),
+	}
+	conditions := map[string]interface{}{
+		deletedAtField: nil,
+		heightField:    height,
+	}
+
+	// Get the record
+	if err := Get(
+		ctx, blockHeader, conditions, false, defaultDatabaseReadTimeout,
+	); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return blockHeader, nil
+}
+
+// getChainTipBlockHeader will get the (non-orphaned) block header with the highest height (the local chain tip)
+func getChainTipBlockHeader(ctx context.Context, opts ...ModelOps) (*BlockHeader, error) {
+
+	// Construct an empty model
+	var models []BlockHeader
+
+	// Get the records
+	if err := getModels(
+		ctx, NewBaseModel(ModelNameEmpty, opts...).Client().Datastore(),
+		&models, map[string]interface{}{deletedAtField: nil}, 1, 1, heightField, datastore.SortDesc, defaultDatabaseReadTimeout,
+	); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	} else if len(models) == 0 {
+		return nil, nil
+	}
+
+	models[0].enrich(ModelBlockHeader, opts...)
+	return &models[0], nil
+}
+
+// getChainTip will get the chain tip from the local block headers, or from the chain provider(s) if not synced
+//
+// Returns nil if the chain tip is unknown (the chain provider(s) failed)
+func getChainTip(ctx context.Context, opts ...ModelOps) (*chainstate.BlockHeader, error) {
+	blockHeader, err := getChainTipBlockHeader(ctx, opts...)
+	if err != nil {
+		return nil, err
+	} else if blockHeader != nil {
+		return blockHeader.chainstateHeader(), nil
+	}
+
+	var tip *chainstate.BlockHeader
+	if tip, err = NewBaseModel(ModelNameEmpty, opts...).Client().Chainstate().QueryChainTip(
+		ctx, defaultQueryTimeout,
+	); err != nil {
+		return nil, nil // nolint: nilerr // the chain tip is checked again on the next run
+	}
+	return tip, nil
+}
+
+// GetModelName will get the name of the current model
+func (m *BlockHeader) GetModelName() string {
+	return ModelBlockHeader.String()
+}
+
+// GetModelTableName will get the db table name of the current model
+func (m *BlockHeader) GetModelTableName() string {
+	return tableBlockHeaders
+}
+
+// Save will Save the model into the Datastore
+func (m *BlockHeader) Save(ctx context.Context) error {
+	return Save(ctx, m)
+}
+
+// Delete will (soft) delete the model in the Datastore
+func (m *BlockHeader) Delete(ctx context.Context) error {
+	return Delete(ctx, m)
+}
+
+// Restore will restore the (soft) deleted model in the Datastore
+func (m *BlockHeader) Restore(ctx context.Context) error {
+	return Restore(ctx, m)
+}
+
+// GetID will get the ID
+func (m *BlockHeader) GetID() string {
+	return m.ID
+}
+
+// IsOrphaned will return true if the block header was orphaned by a chain reorg
+func (m *BlockHeader) IsOrphaned() bool {
+	return m.DeletedAt.Valid
+}
+
+// chainstateHeader will return the chainstate version of the block header
+func (m *BlockHeader) chainstateHeader() *chainstate.BlockHeader {
+	return &chainstate.BlockHeader{
+		Bits:       m.Bits,
+		Hash:       m.ID,
+		Height:     m.Height,
+		MerkleRoot: m.MerkleRoot,
+		Nonce:      m.Nonce,
+		PrevHash:   m.PrevHash,
+		Time:       m.Time,
+		Version:    m.Version,
+	}
+}
+
+// BeforeCreating will fire before the model is being inserted into the Datastore
+func (m *BlockHeader) BeforeCreating(_ context.Context) error {
+	m.DebugLog("starting: [" + m.name.String() + "] BeforeCreating hook...")
+
+	// Make sure ID is valid
+	if len(m.ID) == 0 {
+		return ErrMissingFieldID
+	}
+
+	// Make sure the header is valid (hash & proof-of-work)
+	if err := m.chainstateHeader().Validate(); err != nil {
+		return err
+	}
+
+	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
+	return nil
+}
+
+// RegisterTasks will register the model specific tasks on client initialization
+func (m *BlockHeader) RegisterTasks() error {
+
+	// No task manager loaded?
+	tm := m.Client().Taskmanager()
+	if tm == nil {
+		return nil
+	}
+
+	// Register the task locally (cron task - set the defaults)
+	syncTask := m.Name() + "_sync"
+	ctx := context.Background()
+
+	// Register the task
+	if err := tm.RegisterTask(&taskmanager.Task{
+		Name:       syncTask,
+		RetryLimit: 1,
+		Handler: func(client *Client) error {
+			if taskErr := TaskSyncBlockHeaders(ctx, client.Logger(), WithClient(client)); taskErr != nil {
+				client.Logger().Error(ctx, "error running "+syncTask+" task: "+taskErr.Error())
+			}
+			return nil
+		},
+	}); err != nil {
+		return err
+	}
+
+	// Run the task periodically
+	return tm.RunTask(ctx, &taskmanager.TaskOptions{
+		Arguments:      []interface{}{m.Client()},
+		RunEveryPeriod: m.Client().GetTaskPeriod(syncTask),
+		TaskName:       syncTask,
+	})
+}
+
+// Migrate model specific migration on startup
+func (m *BlockHeader) Migrate(client datastore.ClientInterface) error {
+	return client.IndexMetadata(client.GetTableName(tableBlockHeaders), metadataField)
+}
+
+// blockHeaderStore is the datastore implementation of the chainstate header store
+type blockHeaderStore struct {
+	opts []ModelOps
+}
+
+// GetBlockHeaderByHeight will get the block header by height (nil if not found)
+func (s *blockHeaderStore) GetBlockHeaderByHeight(ctx context.Context, height uint64) (*chainstate.BlockHeader, error) {
+	blockHeader, err := getBlockHeaderByHeight(ctx, height, s.opts...)
+	if err != nil || blockHeader == nil {
+		return nil, err
+	}
+	return blockHeader.chainstateHeader(), nil
+}
+
+// GetChainTip will get the local chain tip (nil if no headers are synced)
+func (s *blockHeaderStore) GetChainTip(ctx context.Context) (*chainstate.BlockHeader, error) {
+	blockHeader, err := getChainTipBlockHeader(ctx, s.opts...)
+	if err != nil || blockHeader == nil {
+		return nil, err
+	}
+	return blockHeader.chainstateHeader(), nil
+}
+
+// RemoveBlockHeaders will mark the block headers as orphaned
+func (s *blockHeaderStore) RemoveBlockHeaders(ctx context.Context, headers []*chainstate.BlockHeader) error {
+	for _, header := range headers {
+		blockHeader, err := getBlockHeaderByHash(ctx, header.Hash, s.opts...)
+		if err != nil {
+			return err
+		} else if blockHeader == nil || blockHeader.IsOrphaned() {
+			continue
+		}
+		if err = blockHeader.Delete(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveBlockHeader will save a (validated) block header, an orphaned block header is restored
+func (s *blockHeaderStore) SaveBlockHeader(ctx context.Context, header *chainstate.BlockHeader) error {
+	blockHeader, err := getBlockHeaderByHash(ctx, header.Hash, s.opts...)
+	if err != nil {
+		return err
+	} else if blockHeader == nil {
+		return newBlockHeader(header, append(s.opts, New())...).Save(ctx)
+	} else if !blockHeader.IsOrphaned() {
+		return nil
+	}
+	return blockHeader.Restore(ctx)
+}
+
+// syncBlockHeaders will sync the block headers from the chain providers and return the local chain tip
+//
+// Transactions mined in orphaned blocks (chain reorg) are moved back to be synced again
+func syncBlockHeaders(ctx context.Context, maxHeaders uint64, opts ...ModelOps) (*chainstate.BlockHeader, error) {
+	tracker := chainstate.NewHeaderTracker(
+		NewBaseModel(ModelNameEmpty, opts...).Client().Chainstate(),
+		&blockHeaderStore{opts: opts},
+		defaultQueryTimeout,
+	)
+	tracker.SetReorgHandler(func(ctx context.Context, orphaned []*chainstate.BlockHeader) error {
+		return processChainReorg(ctx, orphaned, opts...)
+	})
+	return tracker.Sync(ctx, maxHeaders)
+}
+
+// processChainReorg will reset the transactions mined in the orphaned blocks and mark them to be synced again
+func processChainReorg(ctx context.Context, orphaned []*chainstate.BlockHeader, opts ...ModelOps) error {
+	client := NewBaseModel(ModelNameEmpty, opts...).Client()
+	for _, header := range orphaned {
+
+		// Get the transactions mined in the orphaned block
+		transactions, err := _getTransactions(
+			ctx, map[string]interface{}{blockHashField: header.Hash}, nil, opts...,
+		)
+		if err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("block %s at height %d was orphaned by a chain reorg", header.Hash, header.Height)
+		reorg := &ChainReorg{
+			BlockHash:      header.Hash,
+			BlockHeight:    header.Height,
+			TransactionIDs: make([]string, 0, len(transactions)),
+		}
+		for _, transaction := range transactions {
+			if err = resetOrphanedTransaction(ctx, transaction, message, opts...); err != nil {
+				return err
+			}
+			reorg.TransactionIDs = append(reorg.TransactionIDs, transaction.ID)
+		}
+
+		// Emit the reorg event
+		client.Logger().Warn(ctx, fmt.Sprintf(
+			"%s, %d transaction(s) moved back to be synced", message, len(transactions),
+		))
+		publishEvent(ctx, client, EventTypeChainReorg, reorg)
+	}
+	return nil
+}
+
+// resetOrphanedTransaction will remove the block information of a transaction and mark it to be synced again
+func resetOrphanedTransaction(ctx context.Context, transaction *Transaction, message string, opts ...ModelOps) error {
+
+	// Remove the block information
+	transaction.BlockHash = ""
+	transaction.BlockHeight = 0
+	transaction.MerkleProof = MerkleProof{}
+	if err := transaction.Save(ctx); err != nil {
+		return err
+	}
+
+	// Get the sync transaction (not found if the transaction was never synced)
+	syncTx, err := getSyncTransactionByID(ctx, transaction.ID, opts...)
+	if err != nil || syncTx == nil {
+		return err
+	}
+
+	// Move the sync transaction back to be synced
+	syncTx.SyncStatus = SyncStatusReady
+	syncTx.Results.LastMessage = message
+	syncTx.Results.Attempts = append(syncTx.Results.Attempts, &SyncAttempt{
+		Action:        "reorg",
+		AttemptedAt:   time.Now().UTC(),
+		StatusMessage: message,
+	})
+	if err = syncTx.Save(ctx); err != nil {
+		return err
+	}
+	publishEvent(ctx, syncTx.Client(), EventTypeSyncTransactionStatus, syncTx)
+	return nil
+}
@@ -0,0 +1,240 @@
+package bux
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/libsv/go-bk/crypto"
+	"github.com/libsv/go-bt/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlockHeaderForkRoot is a (fake) merkle root for the fork block headers
+const testBlockHeaderForkRoot = "e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d"
+
+// loadTestBlockHeaders will load the chainstate fixture block headers (regtest difficulty, starting at height 0)
+func loadTestBlockHeaders(t *testing.T) []*chainstate.BlockHeader {
+	f, err := os.Open("chainstate/testdata/block_headers.txt")
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var headers []*chainstate.BlockHeader
+	headers, err = chainstate.NewBlockHeadersFromReader(f, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, headers)
+	return headers
+}
+
+// createTestBlockHeader will mine (regtest difficulty) a block header on top of the previous header
+func createTestBlockHeader(t *testing.T, previous *chainstate.BlockHeader, merkleRoot string) *chainstate.BlockHeader {
+	prevHash, err := hex.DecodeString(previous.Hash)
+	require.NoError(t, err)
+
+	var root []byte
+	root, err = hex.DecodeString(merkleRoot)
+	require.NoError(t, err)
+
+	b := make([]byte, 80)
+	binary.LittleEndian.PutUint32(b[0:4], 0x20000000)
+	copy(b[4:36], bt.ReverseBytes(prevHash))
+	copy(b[36:68], bt.ReverseBytes(root))
+	binary.LittleEndian.PutUint32(b[68:72], previous.Time+600)
+	binary.LittleEndian.PutUint32(b[72:76], 0x207fffff)
+	for nonce := uint32(0); ; nonce++ {
+		binary.LittleEndian.PutUint32(b[76:80], nonce)
+		if hash := crypto.Sha256d(b); hash[31] < 0x7f {
+			break
+		}
+	}
+
+	var header *chainstate.BlockHeader
+	header, err = chainstate.NewBlockHeaderFromHex(hex.EncodeToString(b), previous.Height+1)
+	require.NoError(t, err)
+	require.NoError(t, previous.ValidateNext(header))
+	return header
+}
+
+// TestBlockHeader_newBlockHeader will test the method newBlockHeader()
+func TestBlockHeader_newBlockHeader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid block header", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		blockHeader := newBlockHeader(headers[5], New())
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, headers[5].Hash, blockHeader.GetID())
+		assert.Equal(t, uint64(5), blockHeader.Height)
+		assert.Equal(t, headers[5].PrevHash, blockHeader.PrevHash)
+		assert.Equal(t, headers[5].MerkleRoot, blockHeader.MerkleRoot)
+		assert.Equal(t, ModelBlockHeader.String(), blockHeader.GetModelName())
+		assert.Equal(t, tableBlockHeaders, blockHeader.GetModelTableName())
+		assert.Equal(t, headers[5], blockHeader.chainstateHeader())
+	})
+}
+
+// TestBlockHeader_Save will test the method Save()
+func TestBlockHeader_Save(t *testing.T) {
+
+	t.Run("save and get", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		headers := loadTestBlockHeaders(t)
+		for _, header := range headers[:3] {
+			require.NoError(t, newBlockHeader(header, append(client.DefaultModelOptions(), New())...).Save(ctx))
+		}
+
+		blockHeader, err := getBlockHeaderByHash(ctx, headers[1].Hash, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, uint64(1), blockHeader.Height)
+
+		blockHeader, err = getBlockHeaderByHeight(ctx, 2, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, headers[2].Hash, blockHeader.ID)
+
+		blockHeader, err = getChainTipBlockHeader(ctx, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, headers[2].Hash, blockHeader.ID)
+
+		blockHeader, err = getBlockHeaderByHeight(ctx, 3, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Nil(t, blockHeader)
+	})
+
+	t.Run("invalid block header", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		header := *loadTestBlockHeaders(t)[1]
+		header.Nonce++
+		err := newBlockHeader(&header, append(client.DefaultModelOptions(), New())...).Save(ctx)
+		require.ErrorIs(t, err, chainstate.ErrInvalidBlockHeader)
+	})
+}
+
+// Test_syncBlockHeaders will test the method syncBlockHeaders()
+func Test_syncBlockHeaders(t *testing.T) {
+
+	t.Run("sync headers from provider", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, false,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateBlockHeaders{headers: headers}),
+		)
+		defer deferMe()
+
+		tip, err := syncBlockHeaders(ctx, 5, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, tip)
+		assert.Equal(t, headers[len(headers)-1].Hash, tip.Hash)
+
+		var blockHeader *BlockHeader
+		blockHeader, err = getBlockHeaderByHeight(ctx, tip.Height-4, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+
+		blockHeader, err = getBlockHeaderByHeight(ctx, tip.Height-5, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.Nil(t, blockHeader)
+
+		// Nothing new to sync
+		tip, err = syncBlockHeaders(ctx, 5, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, headers[len(headers)-1].Hash, tip.Hash)
+	})
+
+	t.Run("chain reorg - mined transactions are synced again", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		chainState := &chainStateBlockHeaders{headers: headers}
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(chainState),
+		)
+		defer deferMe()
+
+		transaction, rawXPub := createTestSyncTransaction(ctx, t, client)
+
+		// Mine the transaction in the chain tip
+		proof := &chainstate.MerkleProof{Index: 1, Nodes: []string{testTxScriptSigID}, TxOrID: transaction.ID}
+		merkleRoot, err := proof.MerkleRoot()
+		require.NoError(t, err)
+		orphan := createTestBlockHeader(t, headers[len(headers)-1], merkleRoot)
+		chainState.headers = append(headers, orphan)
+
+		_, err = syncBlockHeaders(ctx, 5, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+
+		syncTx := newSyncTransaction(transaction.ID, nil, append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, syncTx.Save(ctx))
+		require.NoError(t, processSyncTransaction(ctx, syncTx))
+		assert.Equal(t, SyncStatusComplete, syncTx.SyncStatus)
+
+		var reorgs []*ChainReorg
+		_, err = client.Subscribe(func(_ context.Context, event *Event) {
+			reorgs = append(reorgs, event.Data.(*ChainReorg))
+		}, EventTypeChainReorg)
+		require.NoError(t, err)
+
+		// The block is replaced by a longer chain
+		fork := createTestBlockHeader(t, headers[len(headers)-1], testBlockHeaderForkRoot)
+		chainState.headers = append(headers, fork, createTestBlockHeader(t, fork, testBlockHeaderForkRoot))
+
+		var tip *chainstate.BlockHeader
+		tip, err = syncBlockHeaders(ctx, 5, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, orphan.Height+1, tip.Height)
+		require.Len(t, reorgs, 1)
+		assert.Equal(t, orphan.Hash, reorgs[0].BlockHash)
+		assert.Equal(t, []string{transaction.ID}, reorgs[0].TransactionIDs)
+
+		var blockHeader *BlockHeader
+		blockHeader, err = getBlockHeaderByHash(ctx, orphan.Hash, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.True(t, blockHeader.IsOrphaned())
+
+		blockHeader, err = getBlockHeaderByHeight(ctx, orphan.Height, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		assert.Equal(t, fork.Hash, blockHeader.ID)
+
+		// The transaction is no longer mined
+		var minedTx *Transaction
+		minedTx, err = client.GetTransaction(ctx, rawXPub, transaction.ID)
+		require.NoError(t, err)
+		assert.Empty(t, minedTx.BlockHash)
+		assert.Equal(t, uint64(0), minedTx.BlockHeight)
+		assert.True(t, minedTx.MerkleProof.IsEmpty())
+
+		syncTx, err = getSyncTransactionByID(ctx, transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, syncTx)
+		assert.Equal(t, SyncStatusReady, syncTx.SyncStatus)
+		assert.Contains(t, syncTx.Results.LastMessage, "orphaned by a chain reorg")
+	})
+
+	t.Run("no provider headers", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, false,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateBlockHeaders{}),
+		)
+		defer deferMe()
+
+		tip, err := syncBlockHeaders(ctx, 5, client.DefaultModelOptions()...)
+		require.ErrorIs(t, err, chainstate.ErrBlockHeaderNotFound)
+		assert.Nil(t, tip)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/BuxOrg/bux/datastore"
 	"github.com/BuxOrg/bux/utils"
 	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bip32"
 )
 
 // Destination is an object representing the BitCoin destination table
@@ -83,6 +84,54 @@ func newAddress(rawXpubKey string, chain, num uint32, opts ...ModelOps) (*Destin
 	return destination, nil
 }
 
+// newMultiSigAddress will start a new m-of-n multisig Destination model for the xPubs (derived at the same chain/num)
+//
+// The destination belongs to the first xPub, the public keys are used in the order of the xPubs
+func newMultiSigAddress(hdKeys []*bip32.ExtendedKey, required int, chain, num uint32,
+	opts ...ModelOps) (*Destination, error) {
+
+	// Derive the public key of each xPub
+	pubKeys := make([][]byte, 0, len(hdKeys))
+	for _, hdKey := range hdKeys {
+		pubKey, err := utils.DerivePublicKey(hdKey, chain, num)
+		if err != nil {
+			return nil, err
+		}
+		pubKeys = append(pubKeys, pubKey.SerialiseCompressed())
+	}
+
+	// Create the locking script
+	lockingScript, err := utils.NewMultiSigLockingScript(required, pubKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the model (multisig destinations have no address)
+	destination := newDestination(utils.Hash(hdKeys[0].String()), lockingScript, opts...)
+	destination.Chain = chain
+	destination.Num = num
+
+	return destination, nil
+}
+
+// getDestinationByID will get the destination by the given ID
+func getDestinationByID(ctx context.Context, id string, opts ...ModelOps) (*Destination, error) {
+
+	// Construct an empty model
+	destination := newDestination("", "", opts...)
+	destination.ID = id
+
+	// Get the record
+	if err := Get(ctx, destination, nil, true, defaultDatabaseReadTimeout); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return destination, nil
+}
+
 // getDestinationByAddress will get the destination by the given address
 func getDestinationByAddress(ctx context.Context, address string, opts ...ModelOps) (*Destination, error) {
 
@@ -122,22 +171,21 @@ func getDestinationByLockingScript(ctx context.Context, lockingScript string, op
 
 // getDestinationsByXpubID will get the destination(s) by the given xPubID
 func getDestinationsByXpubID(ctx context.Context, xPubID string, usingMetadata *Metadata,
-	pageSize, page int, opts ...ModelOps) ([]*Destination, error) {
+	queryParams *datastore.QueryParams, opts ...ModelOps) ([]*Destination, error) {
 
-	// Construct an empty model
-	var models []Destination
-	conditions := map[string]interface{}{
-		xPubIDField: xPubID,
+	if queryParams == nil {
+		queryParams = &datastore.QueryParams{}
 	}
 
-	if usingMetadata != nil {
-		conditions[metadataField] = usingMetadata
-	}
+	// Construct an empty model
+	var models []Destination
+	conditions := getDestinationsConditions(xPubID, usingMetadata)
 
 	// Get the records
 	if err := getModels(
 		ctx, NewBaseModel(ModelNameEmpty, opts...).Client().Datastore(),
-		&models, conditions, pageSize, page, "", "", defaultDatabaseReadTimeout,
+		&models, conditions, queryParams.PageSize, queryParams.Page,
+		queryParams.OrderByField, queryParams.SortDirection, defaultDatabaseReadTimeout,
 	); err != nil {
 		if errors.Is(err, datastore.ErrNoResults) {
 			return nil, nil
@@ -155,6 +203,26 @@ func getDestinationsByXpubID(ctx context.Context, xPubID string, usingMetadata *
 	return destinations, nil
 }
 
+// getDestinationsCountByXpubID will count the destination(s) by the given xPubID
+func getDestinationsCountByXpubID(ctx context.Context, xPubID string, usingMetadata *Metadata,
+	opts ...ModelOps) (int64, error) {
+	return getModelCount(
+		ctx, NewBaseModel(ModelNameEmpty, opts...).Client().Datastore(),
+		&Destination{}, getDestinationsConditions(xPubID, usingMetadata), defaultDatabaseReadTimeout,
+	)
+}
+
+// getDestinationsConditions will get the conditions for the destinations of an xPubID
+func getDestinationsConditions(xPubID string, usingMetadata *Metadata) map[string]interface{} {
+	conditions := map[string]interface{}{
+		xPubIDField: xPubID,
+	}
+	if usingMetadata != nil {
+		conditions[metadataField] = usingMetadata
+	}
+	return conditions
+}
+
 // GetModelName will get the name of the current model
 func (m *Destination) GetModelName() string {
 	return ModelDestination.String()
@@ -170,6 +238,16 @@ func (m *Destination) Save(ctx context.Context) (err error) {
 	return Save(ctx, m)
 }
 
+// Delete will (soft) delete the model in the Datastore
+func (m *Destination) Delete(ctx context.Context) (err error) {
+	return Delete(ctx, m)
+}
+
+// Restore will restore the (soft) deleted model in the Datastore
+func (m *Destination) Restore(ctx context.Context) (err error) {
+	return Restore(ctx, m)
+}
+
 // GetID will get the model ID
 func (m *Destination) GetID() string {
 	return m.ID
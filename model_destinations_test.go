@@ -125,6 +125,75 @@ func TestDestination_BeforeCreating(t *testing.T) {
 	// finish test
 }
 
+// TestClient_DeleteDestination will test the methods DeleteDestination() and RestoreDestination()
+func TestClient_DeleteDestination(t *testing.T) {
+
+	t.Run("delete and restore", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		destination, err := client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+
+		err = client.DeleteDestination(ctx, rawXPub, destination.ID)
+		require.NoError(t, err)
+
+		// Deleted destinations are not returned
+		var found *Destination
+		found, err = client.GetDestinationByAddress(ctx, rawXPub, destination.Address)
+		require.ErrorIs(t, err, ErrMissingDestination)
+		require.Nil(t, found)
+
+		var count int64
+		count, err = client.GetDestinationsCount(ctx, rawXPub, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+
+		// Unless requested
+		found, err = getDestinationByID(ctx, destination.ID, client.DefaultModelOptions(WithDeleted())...)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.True(t, found.IsDeleted())
+
+		// Restore the destination
+		found, err = client.RestoreDestination(ctx, rawXPub, destination.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.False(t, found.IsDeleted())
+
+		found, err = client.GetDestinationByAddress(ctx, rawXPub, destination.Address)
+		require.NoError(t, err)
+		assert.Equal(t, destination.ID, found.ID)
+	})
+
+	t.Run("error - destination of another xPub", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		_, _, otherXPub := CreateNewXPub(ctx, t, client)
+		destination, err := client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+
+		err = client.DeleteDestination(ctx, otherXPub, destination.ID)
+		require.ErrorIs(t, err, ErrXpubIDMisMatch)
+	})
+
+	t.Run("error - missing destination", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		err := client.DeleteDestination(ctx, rawXPub, testDestinationID)
+		require.ErrorIs(t, err, ErrMissingDestination)
+	})
+}
+
 // TestDestination_Save will test the method Save()
 func TestDestination_Save(t *testing.T) {
 	// finish test
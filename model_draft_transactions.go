@@ -28,10 +28,13 @@ type DraftTransaction struct {
 
 	// Model specific fields
 	XpubID        string            `json:"xpub_id" toml:"xpub_id" yaml:"xpub_id" gorm:"<-:create;type:char(64);index;comment:This is the related xPub" bson:"xpub_id"`
-	ExpiresAt     time.Time         `json:"expires_at" toml:"expires_at" yaml:"expires_at" gorm:"<-:create;comment:Time when the draft expires" bson:"expires_at"`
+	ExpiresAt     time.Time         `json:"expires_at" toml:"expires_at" yaml:"expires_at" gorm:"<-;comment:Time when the draft expires" bson:"expires_at"`
 	Configuration TransactionConfig `json:"configuration" toml:"configuration" yaml:"configuration" gorm:"<-;type:text;comment:This is the configuration struct in JSON" bson:"configuration"`
 	Status        DraftStatus       `json:"status" toml:"status" yaml:"status" gorm:"<-;type:varchar(10);index;comment:This is the status of the draft" bson:"status"`
 	FinalTxID     string            `json:"final_tx_id,omitempty" toml:"final_tx_id" yaml:"final_tx_id" gorm:"<-;type:char(64);index;comment:This is the final tx ID" bson:"final_tx_id,omitempty"`
+
+	// Private for internal use
+	parentFee uint64 `gorm:"-" bson:"-"` // Missing fee of the parent transaction (child-pays-for-parent)
 }
 
 // newDraftTransaction will start a new draft tx
@@ -87,6 +90,45 @@ func getDraftTransactionID(ctx context.Context, xPubID, id string,
 	return draftTransaction, nil
 }
 
+// getActiveDraftTransaction will get the draft transaction of the xPub, if it is still a draft (not expired)
+func getActiveDraftTransaction(ctx context.Context, rawXpubKey, id string,
+	opts ...ModelOps) (*DraftTransaction, error) {
+
+	draftTransaction, err := getDraftTransactionID(
+		ctx, utils.Hash(rawXpubKey), id, opts...,
+	)
+	if err != nil {
+		return nil, err
+	} else if draftTransaction == nil {
+		return nil, ErrDraftNotFound
+	} else if !draftTransaction.isActive() {
+		return nil, ErrDraftNotActive
+	}
+
+	return draftTransaction, nil
+}
+
+// getDraftTransactionByID will get the draft transaction by id (of any xPub, ie: multisig cosigners)
+func getDraftTransactionByID(ctx context.Context, id string, opts ...ModelOps) (*DraftTransaction, error) {
+
+	// Get the record
+	draftTransaction := newDraftTransaction("", &TransactionConfig{}, opts...)
+	draftTransaction.ID = id
+	if err := Get(ctx, draftTransaction, nil, false, defaultDatabaseReadTimeout); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return draftTransaction, nil
+}
+
+// isActive will return true if the draft is still a draft (not canceled, complete or expired)
+func (m *DraftTransaction) isActive() bool {
+	return m.Status == DraftStatusDraft && !time.Now().UTC().After(m.ExpiresAt)
+}
+
 // GetModelName will get the name of the current model
 func (m *DraftTransaction) GetModelName() string {
 	return ModelDraftTransaction.String()
@@ -112,6 +154,16 @@ func (m *DraftTransaction) Save(ctx context.Context) (err error) {
 	return
 }
 
+// Delete will (soft) delete the model in the Datastore
+func (m *DraftTransaction) Delete(ctx context.Context) (err error) {
+	return Delete(ctx, m)
+}
+
+// Restore will restore the (soft) deleted model in the Datastore
+func (m *DraftTransaction) Restore(ctx context.Context) (err error) {
+	return Restore(ctx, m)
+}
+
 // GetID will get the model ID
 func (m *DraftTransaction) GetID() string {
 	return m.ID
@@ -147,6 +199,15 @@ func (m *DraftTransaction) processConfigOutputs(ctx context.Context) error {
 				m.Configuration.Outputs[index].Scripts = make([]*ScriptOutput, 0)
 			}
 
+			// Sign the AIP of the op_return using the signer of the client
+			if m.Configuration.Outputs[index].OpReturn != nil {
+				if err := m.Configuration.Outputs[index].OpReturn.signAip(
+					ctx, c.AipSigner(), m.XpubID,
+				); err != nil {
+					return err
+				}
+			}
+
 			// Process the outputs
 			if err := m.Configuration.Outputs[index].processOutput(
 				ctx, c.Cachestore(),
@@ -177,12 +238,30 @@ func (m *DraftTransaction) createTransactionHex(ctx context.Context) (err error)
 	// Set opts
 	opts := m.GetOptions(false)
 
+	// Child-pays-for-parent: the fee also needs to pay for the parent transaction
+	if err = m.setParentFee(ctx); err != nil {
+		return
+	}
+
 	// Process the outputs first
 	// if an error occurs in processing the outputs, we have at least not made any reservations yet
 	if err = m.processConfigOutputs(ctx); err != nil {
 		return
 	}
 
+	// Get the spending policies of the xPub and the access key (of the request)
+	var policies []*SpendingPolicy
+	if policies, err = getSpendingPolicies(
+		ctx, m.XpubID, getAccessKeyIDFromContext(ctx), opts...,
+	); err != nil {
+		return
+	}
+
+	// Multisig inputs are signed by the cosigners, other inputs would stay unsigned
+	if err = m.checkFromUtxoTypes(ctx, opts...); err != nil {
+		return err
+	}
+
 	var inputUtxos *[]*bt.UTXO
 	var satoshisReserved uint64
 	if m.Configuration.SendAllTo != "" {
@@ -192,6 +271,17 @@ func (m *DraftTransaction) createTransactionHex(ctx context.Context) (err error)
 		); err != nil {
 			return err
 		}
+
+		// Check the spending policies before reserving (all the satoshis are sent)
+		for _, utxo := range spendableUtxos {
+			m.Configuration.Outputs[0].Satoshis += utxo.Satoshis
+		}
+		if err = checkSpendingPolicies(
+			ctx, m.XpubID, policies, m.Configuration.Outputs, opts...,
+		); err != nil {
+			return err
+		}
+
 		for _, utxo := range spendableUtxos {
 			// Reserve the utxos
 			utxo.DraftID.Valid = true
@@ -203,8 +293,6 @@ func (m *DraftTransaction) createTransactionHex(ctx context.Context) (err error)
 			if err = utxo.Save(ctx); err != nil {
 				return err
 			}
-
-			m.Configuration.Outputs[0].Satoshis += utxo.Satoshis
 		}
 
 		// Get the inputUtxos (in bt.UTXO format) and the total amount of satoshis from the utxos
@@ -220,13 +308,22 @@ func (m *DraftTransaction) createTransactionHex(ctx context.Context) (err error)
 			return err
 		}
 	} else {
+		// Check the spending policies before reserving
+		if err = checkSpendingPolicies(
+			ctx, m.XpubID, policies, m.Configuration.Outputs, opts...,
+		); err != nil {
+			return err
+		}
+
 		// Reserve and Get utxos for the transaction
 		var reservedUtxos []*Utxo
-		feePerByte := float64(m.Configuration.FeeUnit.Satoshis / m.Configuration.FeeUnit.Bytes)
+		feePerByte := float64(m.Configuration.FeeUnit.Satoshis) / float64(m.Configuration.FeeUnit.Bytes)
 
-		reserveSatoshis := satoshisNeeded + m.estimateFee(m.Configuration.FeeUnit) + dustLimit
+		// The coin selection target is the exact amount (no change), dust change is handled below
+		reserveSatoshis := satoshisNeeded + m.estimateFee(m.Configuration.FeeUnit)
 		if reservedUtxos, err = ReserveUtxos(
-			ctx, m.XpubID, m.ID, reserveSatoshis, feePerByte, m.Configuration.FromUtxos, opts...,
+			ctx, m.XpubID, m.ID, reserveSatoshis, feePerByte, m.Configuration.FromUtxos,
+			m.Configuration.CoinSelectionStrategy, opts...,
 		); err != nil {
 			return
 		}
@@ -251,10 +348,20 @@ func (m *DraftTransaction) createTransactionHex(ctx context.Context) (err error)
 		return
 	}
 
+	// Set the lock time and the input sequences (inputs are in the same order as the utxos)
+	tx.LockTime = m.Configuration.LockTime
+	for index, input := range m.Configuration.Inputs {
+		tx.Inputs[index].SequenceNumber = input.Sequence
+	}
+
 	// Estimate the fee for the transaction
 	fee := m.estimateFee(m.Configuration.FeeUnit)
 	if m.Configuration.SendAllTo != "" {
-		if m.Configuration.Outputs[0].Satoshis <= dustLimit {
+		minSatoshis := dustLimit
+		if len(m.Configuration.ParentID) > 0 {
+			minSatoshis += fee // The child also pays the fee of the parent (child-pays-for-parent)
+		}
+		if m.Configuration.Outputs[0].Satoshis <= minSatoshis {
 			return ErrOutputValueTooLow
 		}
 
@@ -266,8 +373,12 @@ func (m *DraftTransaction) createTransactionHex(ctx context.Context) (err error)
 			return ErrNotEnoughUtxos
 		}
 
-		// if we have a remainder, add that to an output to our own wallet address
+		// if we have a remainder, add that to an output to our own wallet address (dust is added to the fee)
 		satoshisChange := satoshisReserved - satoshisNeeded - fee
+		if satoshisChange > 0 && satoshisChange <= dustLimit {
+			fee += satoshisChange
+			satoshisChange = 0
+		}
 		m.Configuration.Fee = fee
 		if satoshisChange > 0 {
 			if err = m.setChangeDestination(
@@ -289,6 +400,29 @@ func (m *DraftTransaction) createTransactionHex(ctx context.Context) (err error)
 	return
 }
 
+// checkFromUtxoTypes will check that the given utxos are either all multisig or all other types
+//
+// Utxos are only selected by type if no utxos are given (never mixed)
+func (m *DraftTransaction) checkFromUtxoTypes(ctx context.Context, opts ...ModelOps) error {
+	var multiSig, other bool
+	for _, pointer := range m.Configuration.FromUtxos {
+		utxo, err := getUtxo(ctx, pointer.TransactionID, pointer.OutputIndex, opts...)
+		if err != nil {
+			return err
+		} else if utxo == nil {
+			continue // Missing utxos are reported when reserving
+		} else if utxo.Type == utils.ScriptTypeMultiSig {
+			multiSig = true
+		} else {
+			other = true
+		}
+	}
+	if multiSig && other {
+		return ErrMixedMultiSigInputs
+	}
+	return nil
+}
+
 // processUtxos will process the utxos
 func (m *DraftTransaction) processUtxos(ctx context.Context, utxos []*Utxo) error {
 	// Get destinations
@@ -303,16 +437,67 @@ func (m *DraftTransaction) processUtxos(ctx context.Context, utxos []*Utxo) erro
 			m.Configuration.Inputs, &TransactionInput{
 				Utxo:        *utxo,
 				Destination: *destination,
+				Sequence:    m.getInputSequence(utxo),
 			})
 	}
 
 	return nil
 }
 
+// getInputSequence will return the sequence for the input (set in FromUtxos or the default)
+//
+// The default sequence is final, unless a lock time is set (lock time is only enforced on non-final inputs)
+func (m *DraftTransaction) getInputSequence(utxo *Utxo) uint32 {
+	for _, pointer := range m.Configuration.FromUtxos {
+		if pointer.Sequence != nil &&
+			pointer.TransactionID == utxo.TransactionID &&
+			pointer.OutputIndex == utxo.OutputIndex {
+			return *pointer.Sequence
+		}
+	}
+	if m.Configuration.LockTime > 0 {
+		return bt.DefaultSequenceNumber - 1
+	}
+	return bt.DefaultSequenceNumber
+}
+
+// checkLockTime will check that the lock time and input sequences of the tx match the draft
+func (m *DraftTransaction) checkLockTime(tx *bt.Tx) error {
+	if tx.LockTime != m.Configuration.LockTime {
+		return ErrLockTimeMismatch
+	} else if m.Configuration.LockTime == 0 {
+		return nil // sequences are only relevant for a lock time
+	}
+
+	// The draft inputs (by utxo id)
+	sequences := make(map[string]uint32, len(m.Configuration.Inputs))
+	for _, input := range m.Configuration.Inputs {
+		sequences[input.Utxo.GenerateID()] = input.Sequence
+	}
+
+	for _, txInput := range tx.Inputs {
+		utxo := &Utxo{
+			OutputIndex:   txInput.PreviousTxOutIndex,
+			TransactionID: txInput.PreviousTxIDStr(),
+		}
+		if sequence, ok := sequences[utxo.GenerateID()]; ok && sequence != txInput.SequenceNumber {
+			return ErrSequenceMismatch
+		}
+	}
+
+	return nil
+}
+
 // estimateSize will loop the inputs and outputs and estimate the size of the transaction
 func (m *DraftTransaction) estimateSize() uint64 {
 	size := defaultOverheadSize
 	for _, input := range m.Configuration.Inputs {
+		if input.Type == utils.ScriptTypeMultiSig {
+			if required, _, err := utils.ParseMultiSig(input.ScriptPubKey); err == nil {
+				size += utils.GetInputSizeForMultiSig(required)
+				continue
+			}
+		}
 		size += utils.GetInputSizeForType(input.Type)
 	}
 	for _, output := range m.Configuration.Outputs {
@@ -333,7 +518,32 @@ func (m *DraftTransaction) estimateSize() uint64 {
 // estimateFee will loop the inputs and outputs and estimate the required fee
 func (m *DraftTransaction) estimateFee(unit *utils.FeeUnit) uint64 {
 	size := m.estimateSize()
-	return uint64(math.Ceil(float64(size) * (float64(unit.Satoshis) / float64(unit.Bytes))))
+	return uint64(math.Ceil(float64(size)*(float64(unit.Satoshis)/float64(unit.Bytes)))) + m.parentFee
+}
+
+// setParentFee will set the missing fee of the parent transaction, using the fee unit of the draft
+//
+// The parent needs to be unconfirmed, the draft (child) pays the fee for both transactions (child-pays-for-parent)
+func (m *DraftTransaction) setParentFee(ctx context.Context) error {
+	if len(m.Configuration.ParentID) == 0 {
+		return nil
+	}
+
+	parent, err := getTransactionByID(ctx, "", m.Configuration.ParentID, m.GetOptions(false)...)
+	if err != nil {
+		return err
+	} else if parent == nil {
+		return ErrMissingTransaction
+	} else if len(parent.BlockHash) > 0 {
+		return ErrTransactionAlreadyMined
+	}
+
+	unit := m.Configuration.FeeUnit
+	size := uint64(len(parent.Hex) / 2)
+	if fee := uint64(math.Ceil(float64(size) * (float64(unit.Satoshis) / float64(unit.Bytes)))); fee > parent.Fee {
+		m.parentFee = fee - parent.Fee
+	}
+	return nil
 }
 
 // addOutputs will add the given outputs to the bt.Tx
@@ -358,11 +568,20 @@ func (m *DraftTransaction) addOutputsToTx(tx *bt.Tx) (err error) {
 					Satoshis:      0,
 				})
 			} else {
-				// sending to a p2pkh
 				if sc.Satoshis == 0 {
 					return ErrOutputValueTooLow
 				}
 
+				// sending to a multisig
+				if s.IsMultiSigOut() {
+					tx.AddOutput(&bt.Output{
+						LockingScript: s,
+						Satoshis:      sc.Satoshis,
+					})
+					continue
+				}
+
+				// sending to a p2pkh
 				if err = tx.AddP2PKHOutputFromScript(
 					s, sc.Satoshis,
 				); err != nil {
@@ -393,6 +612,17 @@ func (m *DraftTransaction) setChangeDestination(ctx context.Context, satoshisCha
 		numberOfDestinations = 1
 	}
 
+	// Change of multisig inputs is sent back to the multisig destination (stays under m-of-n control)
+	if m.Configuration.ChangeDestinations == nil {
+		for _, input := range m.Configuration.Inputs {
+			if input.Type == utils.ScriptTypeMultiSig {
+				destination := input.Destination
+				m.Configuration.ChangeDestinations = []*Destination{&destination}
+				break
+			}
+		}
+	}
+
 	if m.Configuration.ChangeDestinations == nil {
 		if err := m.setChangeDestinations(
 			ctx, numberOfDestinations,
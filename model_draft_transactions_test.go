@@ -280,6 +280,43 @@ func TestDraftTransaction_createTransaction(t *testing.T) {
 		assert.True(t, gUtxo.ReservedAt.Valid)
 	})
 
+	t.Run("transaction with dust change", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		xPub := newXpub(testXPub, append(client.DefaultModelOptions(), New())...)
+		err := xPub.Save(ctx)
+		require.NoError(t, err)
+
+		destination := newDestination(testXPubID, testLockingScript,
+			append(client.DefaultModelOptions(), New())...)
+		err = destination.Save(ctx)
+		require.NoError(t, err)
+
+		utxo := newUtxo(testXPubID, testTxID, testLockingScript, 0, 1300,
+			append(client.DefaultModelOptions(), New())...)
+		err = utxo.Save(ctx)
+		require.NoError(t, err)
+
+		draftTransaction := newDraftTransaction(testXPub, &TransactionConfig{
+			Outputs: []*TransactionOutput{{
+				To:       testExternalAddress,
+				Satoshis: 1000,
+			}},
+		}, append(client.DefaultModelOptions(), New())...)
+
+		err = draftTransaction.createTransactionHex(ctx)
+		require.NoError(t, err)
+
+		// the change would be dust, so it is added to the fee
+		assert.Equal(t, 0, len(draftTransaction.Configuration.ChangeDestinations))
+		assert.Equal(t, uint64(0), draftTransaction.Configuration.ChangeSatoshis)
+		assert.Equal(t, uint64(300), draftTransaction.Configuration.Fee)
+
+		assert.Equal(t, 1, len(draftTransaction.Configuration.Inputs))
+		assert.Equal(t, 1, len(draftTransaction.Configuration.Outputs))
+		assert.Equal(t, uint64(1000), draftTransaction.Configuration.Outputs[0].Satoshis)
+	})
+
 	t.Run("send to all", func(t *testing.T) {
 		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
 		defer deferMe()
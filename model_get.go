@@ -10,6 +10,7 @@ import (
 // Get will retrieve a model from the Cachestore or Datastore using the provided conditions
 //
 // use bypassCache to skip checking the Cachestore for the record
+// (soft) deleted records are excluded, unless the model uses WithDeleted() or the conditions include deleted_at
 func Get(
 	ctx context.Context,
 	model ModelInterface,
@@ -51,13 +52,18 @@ func Get(
 	// }
 	// }
 
+	// Exclude the (soft) deleted record
+	if m, ok := model.(interface{ includesDeleted() bool }); !ok || !m.includesDeleted() {
+		conditions = excludeDeleted(conditions)
+	}
+
 	// Attempt to Get the model (by model fields & given conditions)
 	return model.Client().Datastore().GetModel(ctx, model, conditions, timeout)
 }
 
 // getModels will retrieve model(s) from the Cachestore or Datastore using the provided conditions
 //
-// use bypassCache to skip checking the Cachestore for the record
+// (soft) deleted records are excluded, unless the conditions include deleted_at
 func getModels(
 	ctx context.Context,
 	datastore datastore.ClientInterface,
@@ -68,5 +74,34 @@ func getModels(
 	timeout time.Duration,
 ) error {
 	// Attempt to Get the model (by model fields & given conditions)
-	return datastore.GetModels(ctx, models, conditions, pageSize, page, orderByField, sortDirection, timeout)
+	return datastore.GetModels(
+		ctx, models, excludeDeleted(conditions), pageSize, page, orderByField, sortDirection, timeout,
+	)
+}
+
+// getModelCount will return a count of the model matching conditions
+//
+// (soft) deleted records are excluded, unless the conditions include deleted_at
+func getModelCount(
+	ctx context.Context,
+	datastore datastore.ClientInterface,
+	model interface{},
+	conditions map[string]interface{},
+	timeout time.Duration,
+) (int64, error) {
+	return datastore.GetModelCount(ctx, model, excludeDeleted(conditions), timeout)
+}
+
+// excludeDeleted will add the condition to exclude the (soft) deleted records (if not set)
+func excludeDeleted(conditions map[string]interface{}) map[string]interface{} {
+	if _, ok := conditions[deletedAtField]; ok {
+		return conditions
+	}
+	newConditions := map[string]interface{}{
+		deletedAtField: nil,
+	}
+	for key, value := range conditions {
+		newConditions[key] = value
+	}
+	return newConditions
 }
@@ -93,6 +93,16 @@ func (m *IncomingTransaction) Save(ctx context.Context) error {
 	return Save(ctx, m)
 }
 
+// Delete will (soft) delete the model in the Datastore
+func (m *IncomingTransaction) Delete(ctx context.Context) error {
+	return Delete(ctx, m)
+}
+
+// Restore will restore the (soft) deleted model in the Datastore
+func (m *IncomingTransaction) Restore(ctx context.Context) error {
+	return Restore(ctx, m)
+}
+
 // GetID will get the ID
 func (m *IncomingTransaction) GetID() string {
 	return m.ID
@@ -249,7 +259,9 @@ func processIncomingTransaction(ctx context.Context, incomingTx *IncomingTransac
 	var txInfo *chainstate.TransactionInfo
 	if txInfo, err = incomingTx.Client().Chainstate().QueryTransactionFastest(
 		ctx, incomingTx.ID, chainstate.RequiredInMempool, 10*time.Second,
-	); err != nil {
+	); errors.Is(err, chainstate.ErrDoubleSpend) {
+		return processIncomingDoubleSpend(ctx, incomingTx, "query error: "+err.Error())
+	} else if err != nil {
 
 		// todo: check the error... try broadcasting the tx, and retry query?
 
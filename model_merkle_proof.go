@@ -0,0 +1,58 @@
+package bux
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/BuxOrg/bux/chainstate"
+)
+
+// MerkleProof is the merkle proof (TSC format) of a mined transaction
+//
+// Specs: https://tsc.bitcoinassociation.net/standards/merkle-proof-standardised-format/
+type MerkleProof chainstate.MerkleProof
+
+// IsEmpty will return true if the proof has not been set
+func (m MerkleProof) IsEmpty() bool {
+	return len(m.TxOrID) == 0
+}
+
+// GormDataType type in gorm
+func (m MerkleProof) GormDataType() string {
+	return gormTypeText
+}
+
+// Scan scan value into Json, implements sql.Scanner interface
+func (m *MerkleProof) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	xType := fmt.Sprintf("%T", value)
+	var byteValue []byte
+	if xType == ValueTypeString {
+		byteValue = []byte(value.(string))
+	} else {
+		byteValue = value.([]byte)
+	}
+	if bytes.Equal(byteValue, []byte("")) || bytes.Equal(byteValue, []byte("\"\"")) {
+		return nil
+	}
+
+	return json.Unmarshal(byteValue, &m)
+}
+
+// Value return json value, implement driver.Valuer interface
+func (m MerkleProof) Value() (driver.Value, error) {
+	if m.IsEmpty() {
+		return nil, nil
+	}
+	marshal, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(marshal), nil
+}
@@ -0,0 +1,63 @@
+package bux
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMerkleProof_Scan will test the method Scan()
+func TestMerkleProof_Scan(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil value", func(t *testing.T) {
+		m := MerkleProof{}
+		err := m.Scan(nil)
+		require.NoError(t, err)
+		assert.True(t, m.IsEmpty())
+	})
+
+	t.Run("empty string", func(t *testing.T) {
+		m := MerkleProof{}
+		err := m.Scan("\"\"")
+		require.NoError(t, err)
+		assert.True(t, m.IsEmpty())
+	})
+
+	t.Run("valid proof", func(t *testing.T) {
+		m := MerkleProof{}
+		err := m.Scan(`{"index":1,"txOrId":"` + testTxID + `","target":"` + testTxScriptSigID + `","nodes":["*"]}`)
+		require.NoError(t, err)
+		assert.False(t, m.IsEmpty())
+		assert.Equal(t, uint64(1), m.Index)
+		assert.Equal(t, testTxID, m.TxOrID)
+		assert.Equal(t, testTxScriptSigID, m.Target)
+		assert.Equal(t, []string{"*"}, m.Nodes)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		m := MerkleProof{}
+		err := m.Scan("{invalid")
+		assert.Error(t, err)
+	})
+}
+
+// TestMerkleProof_Value will test the method Value()
+func TestMerkleProof_Value(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty object", func(t *testing.T) {
+		m := MerkleProof{}
+		value, err := m.Value()
+		require.NoError(t, err)
+		assert.Nil(t, value)
+	})
+
+	t.Run("proof present", func(t *testing.T) {
+		m := MerkleProof{Index: 1, TxOrID: testTxID, Target: testTxScriptSigID, Nodes: []string{"*"}}
+		value, err := m.Value()
+		require.NoError(t, err)
+		assert.Equal(t, `{"index":1,"txOrId":"`+testTxID+`","target":"`+testTxScriptSigID+`","nodes":["*"]}`, value)
+	})
+}
@@ -20,6 +20,13 @@ func WithClient(client ClientInterface) ModelOps {
 	}
 }
 
+// WithDeleted will include the (soft) deleted record when getting the model
+func WithDeleted() ModelOps {
+	return func(m *Model) {
+		m.includeDeleted = true
+	}
+}
+
 // WithXPub will set the xPub key on the model
 func WithXPub(rawXpubKey string) ModelOps {
 	return func(m *Model) {
@@ -0,0 +1,179 @@
+package bux
+
+import (
+	"context"
+	"errors"
+
+	"github.com/BuxOrg/bux/datastore"
+	"github.com/BuxOrg/bux/utils"
+	"github.com/tonicpow/go-paymail"
+)
+
+// PaymailAddress is an object representing the paymail address table
+//
+// A PaymailAddress maps an alias@domain to an xPub, which is used for serving all inbound Paymail requests
+//
+// Gorm related models & indexes: https://gorm.io/docs/models.html - https://gorm.io/docs/indexes.html
+type PaymailAddress struct {
+	// Base model
+	Model `bson:",inline"`
+
+	// Model specific fields
+	ID         string `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the hash of the paymail address" bson:"_id"`
+	Alias      string `json:"alias" toml:"alias" yaml:"alias" gorm:"<-:create;type:varchar(64);index;comment:This is the alias of the paymail address" bson:"alias"`
+	Domain     string `json:"domain" toml:"domain" yaml:"domain" gorm:"<-:create;type:varchar(255);index;comment:This is the domain of the paymail address" bson:"domain"`
+	PublicName string `json:"public_name" toml:"public_name" yaml:"public_name" gorm:"<-;type:varchar(255);comment:This is the public name of the paymail (public profile)" bson:"public_name"`
+	Avatar     string `json:"avatar" toml:"avatar" yaml:"avatar" gorm:"<-;type:text;comment:This is the url of the avatar (public profile)" bson:"avatar"`
+	XpubID     string `json:"xpub_id" toml:"xpub_id" yaml:"xpub_id" gorm:"<-;type:char(64);index;comment:This is the related xPub" bson:"xpub_id"`
+}
+
+// newPaymailAddress will start a new PaymailAddress model for the given xPub
+//
+// The raw xPub is not stored, it is resolved by the XPubResolver of the client when needed
+func newPaymailAddress(address, rawXpubKey string, opts ...ModelOps) *PaymailAddress {
+
+	// Sanitize the paymail address (alias@domain.com)
+	alias, domain, address := paymail.SanitizePaymail(address)
+
+	// Return the model
+	return &PaymailAddress{
+		Alias:  alias,
+		Domain: domain,
+		ID:     utils.Hash(address),
+		Model:  *NewBaseModel(ModelPaymailAddress, append(opts, WithXPub(rawXpubKey))...),
+		XpubID: utils.Hash(rawXpubKey),
+	}
+}
+
+// getPaymailAddress will get the paymail address by the given alias@domain.com
+//
+// Deleted paymail addresses are also returned, use IsDeleted() to check
+func getPaymailAddress(ctx context.Context, address string, opts ...ModelOps) (*PaymailAddress, error) {
+
+	// Sanitize the paymail address (alias@domain.com)
+	_, _, address = paymail.SanitizePaymail(address)
+
+	// Construct an empty model
+	paymailAddress := &PaymailAddress{
+		ID:    utils.Hash(address),
+		Model: *NewBaseModel(ModelPaymailAddress, append(opts, WithDeleted())...),
+	}
+
+	// Get the record
+	if err := Get(
+		ctx, paymailAddress, nil, false, defaultDatabaseReadTimeout,
+	); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return paymailAddress, nil
+}
+
+// getPaymailAddressesByXpubID will get the (active) paymail address(es) by the given xPubID
+func getPaymailAddressesByXpubID(ctx context.Context, xPubID string, usingMetadata *Metadata,
+	pageSize, page int, opts ...ModelOps) ([]*PaymailAddress, error) {
+
+	// Construct an empty model
+	var models []PaymailAddress
+	conditions := map[string]interface{}{
+		xPubIDField: xPubID,
+	}
+
+	if usingMetadata != nil {
+		conditions[metadataField] = usingMetadata
+	}
+
+	// Get the records
+	if err := getModels(
+		ctx, NewBaseModel(ModelNameEmpty, opts...).Client().Datastore(),
+		&models, conditions, pageSize, page, "", "", defaultDatabaseReadTimeout,
+	); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Loop and enrich (skip deleted addresses)
+	paymailAddresses := make([]*PaymailAddress, 0)
+	for index := range models {
+		if models[index].IsDeleted() {
+			continue
+		}
+		models[index].enrich(ModelPaymailAddress, opts...)
+		paymailAddresses = append(paymailAddresses, &models[index])
+	}
+
+	return paymailAddresses, nil
+}
+
+// GetModelName will get the name of the current model
+func (m *PaymailAddress) GetModelName() string {
+	return ModelPaymailAddress.String()
+}
+
+// GetModelTableName will get the db table name of the current model
+func (m *PaymailAddress) GetModelTableName() string {
+	return tablePaymailAddresses
+}
+
+// Save will Save the model into the Datastore
+func (m *PaymailAddress) Save(ctx context.Context) error {
+	return Save(ctx, m)
+}
+
+// Delete will (soft) delete the model in the Datastore
+func (m *PaymailAddress) Delete(ctx context.Context) error {
+	return Delete(ctx, m)
+}
+
+// Restore will restore the (soft) deleted model in the Datastore
+func (m *PaymailAddress) Restore(ctx context.Context) error {
+	return Restore(ctx, m)
+}
+
+// GetID will get the ID
+func (m *PaymailAddress) GetID() string {
+	return m.ID
+}
+
+// Address will return the full paymail address (alias@domain.com)
+func (m *PaymailAddress) Address() string {
+	return m.Alias + "@" + m.Domain
+}
+
+// BeforeCreating will fire before the model is being inserted into the Datastore
+func (m *PaymailAddress) BeforeCreating(_ context.Context) error {
+	m.DebugLog("starting: [" + m.name.String() + "] BeforeCreating hook...")
+
+	// Make sure ID is valid
+	if len(m.ID) == 0 {
+		return ErrMissingFieldID
+	}
+
+	// Make sure the paymail address is valid
+	if err := paymail.ValidatePaymail(m.Address()); err != nil {
+		return ErrPaymailAddressIsInvalid
+	}
+
+	// Make sure the xPub is set
+	if len(m.XpubID) == 0 {
+		return ErrMissingFieldXpubID
+	}
+
+	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
+	return nil
+}
+
+// RegisterTasks will register the model specific tasks on client initialization
+func (m *PaymailAddress) RegisterTasks() error {
+	return nil
+}
+
+// Migrate model specific migration on startup
+func (m *PaymailAddress) Migrate(client datastore.ClientInterface) error {
+	return client.IndexMetadata(client.GetTableName(tablePaymailAddresses), metadataField)
+}
@@ -0,0 +1,80 @@
+package bux
+
+import (
+	"testing"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_newPaymailAddress will test the method newPaymailAddress()
+func Test_newPaymailAddress(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid paymail address", func(t *testing.T) {
+		paymailAddress := newPaymailAddress("Tester@Test.com", testXPub, New())
+		require.NotNil(t, paymailAddress)
+		assert.Equal(t, testPaymailAlias, paymailAddress.Alias)
+		assert.Equal(t, testPaymailDomain, paymailAddress.Domain)
+		assert.Equal(t, testPaymailAlias+"@"+testPaymailDomain, paymailAddress.Address())
+		assert.Equal(t, utils.Hash(testPaymailAlias+"@"+testPaymailDomain), paymailAddress.GetID())
+		assert.Equal(t, testXPubID, paymailAddress.XpubID)
+		assert.Equal(t, ModelPaymailAddress.String(), paymailAddress.GetModelName())
+		assert.Equal(t, tablePaymailAddresses, paymailAddress.GetModelTableName())
+		assert.False(t, paymailAddress.IsDeleted())
+	})
+}
+
+// TestPaymailAddress_Save will test the method Save()
+func TestPaymailAddress_Save(t *testing.T) {
+
+	t.Run("save and get", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		paymailAddress := newPaymailAddress(
+			testPaymailAlias+"@"+testPaymailDomain, testXPub,
+			append(client.DefaultModelOptions(), New())...,
+		)
+		require.NoError(t, paymailAddress.Save(ctx))
+
+		gotAddress, err := getPaymailAddress(ctx, testPaymailAlias+"@"+testPaymailDomain, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, gotAddress)
+		assert.Equal(t, paymailAddress.ID, gotAddress.ID)
+		assert.Equal(t, testXPubID, gotAddress.XpubID)
+
+		var paymailAddresses []*PaymailAddress
+		paymailAddresses, err = getPaymailAddressesByXpubID(ctx, testXPubID, nil, 0, 0, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.Len(t, paymailAddresses, 1)
+	})
+
+	t.Run("invalid paymail address", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		paymailAddress := newPaymailAddress(
+			"invalid-paymail", testXPub,
+			append(client.DefaultModelOptions(), New())...,
+		)
+		require.Error(t, paymailAddress.Save(ctx))
+	})
+
+	t.Run("deleted paymail address", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		paymailAddress := newPaymailAddress(
+			testPaymailAlias+"@"+testPaymailDomain, testXPub,
+			append(client.DefaultModelOptions(), New())...,
+		)
+		paymailAddress.DeletedAt.Valid = true
+		require.NoError(t, paymailAddress.Save(ctx))
+
+		paymailAddresses, err := getPaymailAddressesByXpubID(ctx, testXPubID, nil, 0, 0, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Len(t, paymailAddresses, 0)
+	})
+}
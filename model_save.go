@@ -99,6 +99,76 @@ func Save(ctx context.Context, model ModelInterface) (err error) {
 	})
 }
 
+// Delete will (soft) delete the model in the Datastore and fire the AfterDeleted hook
+//
+// The record is kept (marked as deleted) and excluded from the Get queries, deleting a deleted model does nothing
+func Delete(ctx context.Context, model ModelInterface) (err error) {
+	if model.IsDeleted() {
+		return nil
+	}
+
+	// Create new Datastore transaction
+	if err = model.Client().Datastore().NewTx(ctx, func(tx *datastore.Transaction) error {
+
+		// Mark the record as deleted
+		deletedAt := time.Now().UTC()
+		model.DebugLog("starting to Delete model: " + model.Name())
+		if err = model.Client().Datastore().DeleteModel(ctx, model, tx, deletedAt, false); err != nil {
+			return err
+		}
+
+		// Commit the model if needed
+		if tx.CanCommit() {
+			model.DebugLog("committing db transaction...")
+			if err = tx.Commit(); err != nil {
+				return err
+			}
+		}
+		model.SetDeletedTime(true, deletedAt)
+		return nil
+	}); err != nil {
+		return
+	}
+
+	// Fire after hooks (only on commit success) (cascades to any related models)
+	return model.AfterDeleted(ctx)
+}
+
+// Restore will restore a (soft) deleted model in the Datastore and fire the AfterUpdated hook
+//
+// Restoring a model that is not deleted does nothing
+func Restore(ctx context.Context, model ModelInterface) (err error) {
+	if !model.IsDeleted() {
+		return nil
+	}
+
+	// Create new Datastore transaction
+	if err = model.Client().Datastore().NewTx(ctx, func(tx *datastore.Transaction) error {
+
+		// Remove the deleted mark of the record
+		restoredAt := time.Now().UTC()
+		model.DebugLog("starting to Restore model: " + model.Name())
+		if err = model.Client().Datastore().RestoreModel(ctx, model, tx, restoredAt, false); err != nil {
+			return err
+		}
+
+		// Commit the model if needed
+		if tx.CanCommit() {
+			model.DebugLog("committing db transaction...")
+			if err = tx.Commit(); err != nil {
+				return err
+			}
+		}
+		model.SetDeletedTime(false, restoredAt)
+		return nil
+	}); err != nil {
+		return
+	}
+
+	// Fire after hooks (only on commit success)
+	return model.AfterUpdated(ctx)
+}
+
 // saveToCache will Save the model to the cache using the given key
 //
 // ttl of 0 will cache forever
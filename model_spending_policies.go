@@ -0,0 +1,414 @@
+package bux
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/BuxOrg/bux/datastore"
+	"github.com/BuxOrg/bux/utils"
+)
+
+// SpendingRules are the rules of a spending policy (zero values are not limited)
+type SpendingRules struct {
+	AllowedDestinations   []string `json:"allowed_destinations" toml:"allowed_destinations" yaml:"allowed_destinations"`          // Allowed addresses and paymail addresses
+	AllowedPaymailDomains []string `json:"allowed_paymail_domains" toml:"allowed_paymail_domains" yaml:"allowed_paymail_domains"` // Allowed paymail domains
+	DailyLimit            uint64   `json:"daily_limit" toml:"daily_limit" yaml:"daily_limit"`                                     // Maximum satoshis sent per day (UTC)
+	MaxSatoshis           uint64   `json:"max_satoshis" toml:"max_satoshis" yaml:"max_satoshis"`                                  // Maximum satoshis sent per transaction
+}
+
+// SpendingPolicy is an object representing the spending policy of an xPub or an access key
+//
+// The policy of the xPub applies to all the transactions of the xPub, the policy of an access key applies
+// to the transactions created by the requests of the access key (see AuthenticateRequest).
+// The satoshis sent are the satoshis of the outputs that do not belong to the xPub (fees are not included).
+//
+// Gorm related models & indexes: https://gorm.io/docs/models.html - https://gorm.io/docs/indexes.html
+type SpendingPolicy struct {
+	// Base model
+	Model `bson:",inline"`
+
+	// Model specific fields
+	ID                    string         `json:"id" toml:"id" yaml:"id" gorm:"<-:create;type:char(64);primaryKey;comment:This is the xPub id or the access key id" bson:"_id"`
+	XpubID                string         `json:"xpub_id" toml:"xpub_id" yaml:"xpub_id" gorm:"<-:create;type:char(64);index;comment:This is the related xPub id" bson:"xpub_id"`
+	AccessKeyID           string         `json:"access_key_id,omitempty" toml:"access_key_id" yaml:"access_key_id" gorm:"<-:create;type:char(64);comment:This is the related access key id (empty for the xPub policy)" bson:"access_key_id,omitempty"`
+	MaxSatoshis           uint64         `json:"max_satoshis" toml:"max_satoshis" yaml:"max_satoshis" gorm:"<-;comment:Maximum satoshis sent per transaction (zero is unlimited)" bson:"max_satoshis"`
+	DailyLimit            uint64         `json:"daily_limit" toml:"daily_limit" yaml:"daily_limit" gorm:"<-;comment:Maximum satoshis sent per day (zero is unlimited)" bson:"daily_limit"`
+	AllowedDestinations   IDs            `json:"allowed_destinations" toml:"allowed_destinations" yaml:"allowed_destinations" gorm:"<-;type:json;comment:Allowed addresses and paymail addresses" bson:"allowed_destinations,omitempty"`
+	AllowedPaymailDomains IDs            `json:"allowed_paymail_domains" toml:"allowed_paymail_domains" yaml:"allowed_paymail_domains" gorm:"<-;type:json;comment:Allowed paymail domains" bson:"allowed_paymail_domains,omitempty"`
+	DailySpent            uint64         `json:"daily_spent" toml:"daily_spent" yaml:"daily_spent" gorm:"<-;comment:Satoshis sent on the day of daily_spent_at" bson:"daily_spent"`
+	DailySpentAt          utils.NullTime `json:"daily_spent_at" toml:"daily_spent_at" yaml:"daily_spent_at" gorm:"<-;comment:When the last transaction was recorded" bson:"daily_spent_at,omitempty"`
+}
+
+// SpendingPolicyError is the error when a transaction violates a spending policy
+//
+// errors.Is() matches ErrSpendingPolicyViolation and the violated rule (ErrSpendingMaxSatoshis,
+// ErrSpendingDailyLimit or ErrSpendingDestination)
+type SpendingPolicyError struct {
+	AccessKeyID string // The access key of the policy (empty for the xPub policy)
+	Rule        error  // The violated rule
+	XpubID      string // The xPub of the policy
+}
+
+// Error will return the error message
+func (e *SpendingPolicyError) Error() string {
+	if len(e.AccessKeyID) > 0 {
+		return "spending policy of access key " + e.AccessKeyID + ": " + e.Rule.Error()
+	}
+	return "spending policy of xpub " + e.XpubID + ": " + e.Rule.Error()
+}
+
+// Is will match ErrSpendingPolicyViolation
+func (e *SpendingPolicyError) Is(target error) bool {
+	return target == ErrSpendingPolicyViolation
+}
+
+// Unwrap will return the violated rule
+func (e *SpendingPolicyError) Unwrap() error {
+	return e.Rule
+}
+
+// newSpendingPolicy will start a new model (accessKeyID is empty for the policy of the xPub)
+func newSpendingPolicy(xPubID, accessKeyID string, rules *SpendingRules, opts ...ModelOps) *SpendingPolicy {
+	id := xPubID
+	if len(accessKeyID) > 0 {
+		id = accessKeyID
+	}
+	policy := &SpendingPolicy{
+		AccessKeyID: accessKeyID,
+		ID:          id,
+		Model:       *NewBaseModel(ModelSpendingPolicy, opts...),
+		XpubID:      xPubID,
+	}
+	policy.setRules(rules)
+	return policy
+}
+
+// getSpendingPolicy will get the spending policy with the given ID (xPub id or access key id)
+func getSpendingPolicy(ctx context.Context, id string, opts ...ModelOps) (*SpendingPolicy, error) {
+
+	// Construct an empty model
+	policy := &SpendingPolicy{
+		ID:    id,
+		Model: *NewBaseModel(ModelSpendingPolicy, opts...),
+	}
+
+	// Get the record
+	if err := Get(ctx, policy, nil, false, defaultDatabaseReadTimeout); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// getSpendingPolicies will get the spending policies of the xPub and the access key (if set)
+func getSpendingPolicies(ctx context.Context, xPubID, accessKeyID string,
+	opts ...ModelOps) ([]*SpendingPolicy, error) {
+
+	policies := make([]*SpendingPolicy, 0)
+	for _, id := range []string{xPubID, accessKeyID} {
+		if len(id) == 0 {
+			continue
+		}
+		policy, err := getSpendingPolicy(ctx, id, opts...)
+		if err != nil {
+			return nil, err
+		} else if policy != nil && policy.XpubID == xPubID {
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+// checkSpendingPolicies will check the outputs of a draft and the satoshis sent against all the policies
+//
+// The outputs that belong to the xPub (sent to self) are not checked, the same as when recording
+func checkSpendingPolicies(ctx context.Context, xPubID string, policies []*SpendingPolicy,
+	outputs []*TransactionOutput, opts ...ModelOps) error {
+
+	if len(policies) == 0 {
+		return nil
+	}
+
+	// Get the outputs that are sent to others
+	var satoshis uint64
+	sentOutputs := make([]*TransactionOutput, 0, len(outputs))
+	for _, output := range outputs {
+		isOwn, err := isOwnOutput(ctx, xPubID, output, opts...)
+		if err != nil {
+			return err
+		} else if isOwn {
+			continue
+		}
+		satoshis += output.Satoshis
+		sentOutputs = append(sentOutputs, output)
+	}
+
+	for _, policy := range policies {
+		if err := policy.checkOutputs(sentOutputs); err != nil {
+			return err
+		} else if err = policy.checkSatoshis(satoshis); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isOwnOutput will return true if all the scripts of the output are destinations of the xPub
+func isOwnOutput(ctx context.Context, xPubID string, output *TransactionOutput, opts ...ModelOps) (bool, error) {
+	if len(output.Scripts) == 0 {
+		return false, nil
+	}
+	for _, script := range output.Scripts {
+		destination, err := getDestinationByLockingScript(ctx, script.Script, opts...)
+		if err != nil {
+			return false, err
+		} else if destination == nil || destination.XpubID != xPubID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// checkTransactionSpendingPolicies will check a transaction (of a draft) against the spending policies
+//
+// The outputs that do not belong to the xPub need to be outputs of the draft (allowed by the policies).
+// Returns the policies and the satoshis sent, the satoshis are added to the policies after recording.
+func checkTransactionSpendingPolicies(ctx context.Context, transaction *Transaction, accessKeyID string,
+	opts ...ModelOps) ([]*SpendingPolicy, uint64, error) {
+
+	// Only transactions of drafts can spend the utxos of the xPub
+	if len(transaction.DraftID) == 0 || transaction.TransactionBase.parsedTx == nil {
+		return nil, 0, nil
+	}
+	draftTransaction, err := getDraftTransactionID(ctx, transaction.xPubID, transaction.DraftID, opts...)
+	if err != nil {
+		return nil, 0, err
+	} else if draftTransaction == nil {
+		return nil, 0, nil
+	}
+
+	// Get the policies of the xPub and the access key
+	var policies []*SpendingPolicy
+	if policies, err = getSpendingPolicies(
+		ctx, draftTransaction.XpubID, accessKeyID, opts...,
+	); err != nil || len(policies) == 0 {
+		return nil, 0, err
+	}
+
+	// Check the outputs that are sent to others
+	var satoshis uint64
+	for _, output := range transaction.TransactionBase.parsedTx.Outputs {
+		if output.Satoshis == 0 {
+			continue
+		}
+		lockingScript := output.LockingScript.String()
+		var destination *Destination
+		if destination, err = getDestinationByLockingScript(ctx, lockingScript, opts...); err != nil {
+			return nil, 0, err
+		} else if destination != nil && destination.XpubID == draftTransaction.XpubID {
+			continue
+		}
+		satoshis += output.Satoshis
+		for _, policy := range policies {
+			if !policy.isAllowedScript(draftTransaction.Configuration.Outputs, lockingScript) {
+				return nil, 0, policy.newError(ErrSpendingDestination)
+			}
+		}
+	}
+
+	// Check the satoshis sent
+	for _, policy := range policies {
+		if err = policy.checkSatoshis(satoshis); err != nil {
+			return nil, 0, err
+		}
+	}
+	return policies, satoshis, nil
+}
+
+// setRules will set the rules of the policy
+func (m *SpendingPolicy) setRules(rules *SpendingRules) {
+	if rules == nil {
+		rules = &SpendingRules{}
+	}
+	m.AllowedDestinations = make(IDs, 0)
+	for _, destination := range rules.AllowedDestinations {
+		if destination = strings.TrimSpace(destination); len(destination) > 0 {
+			m.AllowedDestinations = append(m.AllowedDestinations, destination)
+		}
+	}
+	m.AllowedPaymailDomains = make(IDs, 0)
+	for _, domain := range rules.AllowedPaymailDomains {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); len(domain) > 0 {
+			m.AllowedPaymailDomains = append(m.AllowedPaymailDomains, domain)
+		}
+	}
+	m.DailyLimit = rules.DailyLimit
+	m.MaxSatoshis = rules.MaxSatoshis
+}
+
+// getDailySpent will return the satoshis sent today (UTC)
+func (m *SpendingPolicy) getDailySpent() uint64 {
+	if !m.DailySpentAt.Valid {
+		return 0
+	}
+	spentYear, spentMonth, spentDay := m.DailySpentAt.Time.UTC().Date()
+	year, month, day := time.Now().UTC().Date()
+	if spentYear != year || spentMonth != month || spentDay != day {
+		return 0
+	}
+	return m.DailySpent
+}
+
+// checkSatoshis will check the satoshis sent by a transaction against the maximum and the daily limit
+func (m *SpendingPolicy) checkSatoshis(satoshis uint64) error {
+	if m.MaxSatoshis > 0 && satoshis > m.MaxSatoshis {
+		return m.newError(ErrSpendingMaxSatoshis)
+	} else if m.DailyLimit > 0 && m.getDailySpent()+satoshis > m.DailyLimit {
+		return m.newError(ErrSpendingDailyLimit)
+	}
+	return nil
+}
+
+// hasAllowlist will return true if the destinations are limited
+func (m *SpendingPolicy) hasAllowlist() bool {
+	return len(m.AllowedDestinations) > 0 || len(m.AllowedPaymailDomains) > 0
+}
+
+// checkOutputs will check that all the outputs (with satoshis) go to allowed destinations
+func (m *SpendingPolicy) checkOutputs(outputs []*TransactionOutput) error {
+	if !m.hasAllowlist() {
+		return nil
+	}
+	for _, output := range outputs {
+		if output.Satoshis > 0 && !m.isAllowedOutput(output) {
+			return m.newError(ErrSpendingDestination)
+		}
+	}
+	return nil
+}
+
+// isAllowedOutput will return true if the destination of the output is allowed
+//
+// The output is allowed by the paymail address, the paymail domain or the addresses of all the scripts
+func (m *SpendingPolicy) isAllowedOutput(output *TransactionOutput) bool {
+	if len(output.To) > 0 && utils.StringInSlice(output.To, m.AllowedDestinations) {
+		return true
+	} else if output.PaymailP4 != nil && utils.StringInSlice(
+		strings.ToLower(output.PaymailP4.Domain), m.AllowedPaymailDomains,
+	) {
+		return true
+	} else if len(output.Scripts) == 0 {
+		return false
+	}
+	for _, script := range output.Scripts {
+		if len(script.Address) == 0 || !utils.StringInSlice(script.Address, m.AllowedDestinations) {
+			return false
+		}
+	}
+	return true
+}
+
+// isAllowedScript will return true if the locking script is a script of an allowed output (of the draft)
+func (m *SpendingPolicy) isAllowedScript(outputs []*TransactionOutput, lockingScript string) bool {
+	if !m.hasAllowlist() {
+		return true
+	}
+	for _, output := range outputs {
+		for _, script := range output.Scripts {
+			if script.Script == lockingScript && m.isAllowedOutput(output) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addDailySpent will add the satoshis sent by a recorded transaction to the daily spent
+//
+// The policy is loaded again, so only the daily spent is changed (the caller holds the lock of the policies)
+func (m *SpendingPolicy) addDailySpent(ctx context.Context, satoshis uint64) error {
+	policy, err := getSpendingPolicy(ctx, m.ID, m.GetOptions(false)...)
+	if err != nil {
+		return err
+	} else if policy == nil {
+		return nil
+	}
+	policy.DailySpent = policy.getDailySpent() + satoshis
+	policy.DailySpentAt = utils.NullTime{NullTime: sql.NullTime{Time: time.Now().UTC(), Valid: true}}
+	if err = policy.Save(ctx); err != nil {
+		return err
+	}
+	m.DailySpent = policy.DailySpent
+	m.DailySpentAt = policy.DailySpentAt
+	return nil
+}
+
+// newError will return the error of the violated rule
+func (m *SpendingPolicy) newError(rule error) error {
+	return &SpendingPolicyError{
+		AccessKeyID: m.AccessKeyID,
+		Rule:        rule,
+		XpubID:      m.XpubID,
+	}
+}
+
+// GetModelName will get the name of the current model
+func (m *SpendingPolicy) GetModelName() string {
+	return ModelSpendingPolicy.String()
+}
+
+// GetModelTableName will get the db table name of the current model
+func (m *SpendingPolicy) GetModelTableName() string {
+	return tableSpendingPolicies
+}
+
+// Save will Save the model into the Datastore
+func (m *SpendingPolicy) Save(ctx context.Context) error {
+	return Save(ctx, m)
+}
+
+// Delete will (soft) delete the model in the Datastore
+func (m *SpendingPolicy) Delete(ctx context.Context) error {
+	return Delete(ctx, m)
+}
+
+// Restore will restore the (soft) deleted model in the Datastore
+func (m *SpendingPolicy) Restore(ctx context.Context) error {
+	return Restore(ctx, m)
+}
+
+// GetID will get the ID
+func (m *SpendingPolicy) GetID() string {
+	return m.ID
+}
+
+// BeforeCreating will fire before the model is being inserted into the Datastore
+func (m *SpendingPolicy) BeforeCreating(_ context.Context) error {
+	m.DebugLog("starting: [" + m.name.String() + "] BeforeCreating hook...")
+
+	// Make sure ID is valid
+	if len(m.ID) == 0 {
+		return ErrMissingFieldID
+	} else if len(m.XpubID) == 0 {
+		return ErrMissingFieldXpubID
+	}
+
+	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
+	return nil
+}
+
+// RegisterTasks will register the model specific tasks on client initialization
+func (m *SpendingPolicy) RegisterTasks() error {
+	return nil
+}
+
+// Migrate model specific migration on startup
+func (m *SpendingPolicy) Migrate(client datastore.ClientInterface) error {
+	return client.IndexMetadata(client.GetTableName(tableSpendingPolicies), metadataField)
+}
@@ -0,0 +1,415 @@
+package bux
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPolicyAddress      = "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND"
+	testPolicyOtherAddress = "1A1PjKqjWMNBzTVdcBru27EV1PHcXWc63W"
+)
+
+// TestSpendingPolicy_checkSatoshis will test the method checkSatoshis()
+func TestSpendingPolicy_checkSatoshis(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no limits", func(t *testing.T) {
+		policy := newSpendingPolicy(testXPubID, "", nil)
+		require.NoError(t, policy.checkSatoshis(1000000))
+	})
+
+	t.Run("maximum satoshis", func(t *testing.T) {
+		policy := newSpendingPolicy(testXPubID, "", &SpendingRules{MaxSatoshis: 1000})
+		require.NoError(t, policy.checkSatoshis(1000))
+
+		err := policy.checkSatoshis(1001)
+		require.ErrorIs(t, err, ErrSpendingPolicyViolation)
+		require.ErrorIs(t, err, ErrSpendingMaxSatoshis)
+
+		var policyErr *SpendingPolicyError
+		require.True(t, errors.As(err, &policyErr))
+		assert.Equal(t, testXPubID, policyErr.XpubID)
+		assert.Equal(t, "", policyErr.AccessKeyID)
+	})
+
+	t.Run("daily limit", func(t *testing.T) {
+		policy := newSpendingPolicy(testXPubID, "", &SpendingRules{DailyLimit: 1000})
+		policy.DailySpent = 600
+		policy.DailySpentAt = utils.NullTime{NullTime: sql.NullTime{Time: time.Now().UTC(), Valid: true}}
+		require.NoError(t, policy.checkSatoshis(400))
+		require.ErrorIs(t, policy.checkSatoshis(401), ErrSpendingDailyLimit)
+
+		// Spent on another day
+		policy.DailySpentAt.Time = time.Now().UTC().Add(-48 * time.Hour)
+		require.NoError(t, policy.checkSatoshis(1000))
+	})
+}
+
+// TestSpendingPolicy_checkOutputs will test the method checkOutputs()
+func TestSpendingPolicy_checkOutputs(t *testing.T) {
+	t.Parallel()
+
+	addressOutput := func(address string) *TransactionOutput {
+		return &TransactionOutput{
+			Satoshis: 1000,
+			Scripts:  []*ScriptOutput{{Address: address, Satoshis: 1000}},
+			To:       address,
+		}
+	}
+	paymailOutput := &TransactionOutput{
+		PaymailP4: &PaymailP4{Alias: "alias", Domain: "example.com"},
+		Satoshis:  1000,
+		Scripts:   []*ScriptOutput{{Address: testPolicyOtherAddress, Satoshis: 1000}},
+		To:        "alias@example.com",
+	}
+	opReturnOutput := &TransactionOutput{OpReturn: &OpReturn{Hex: "006a"}}
+
+	t.Run("no allowlist", func(t *testing.T) {
+		policy := newSpendingPolicy(testXPubID, "", nil)
+		require.NoError(t, policy.checkOutputs([]*TransactionOutput{addressOutput(testPolicyOtherAddress)}))
+	})
+
+	t.Run("allowed destinations", func(t *testing.T) {
+		policy := newSpendingPolicy(testXPubID, "", &SpendingRules{
+			AllowedDestinations: []string{testPolicyAddress, "alias@example.com"},
+		})
+		require.NoError(t, policy.checkOutputs([]*TransactionOutput{
+			addressOutput(testPolicyAddress), paymailOutput, opReturnOutput,
+		}))
+		require.ErrorIs(t, policy.checkOutputs([]*TransactionOutput{
+			addressOutput(testPolicyAddress), addressOutput(testPolicyOtherAddress),
+		}), ErrSpendingDestination)
+	})
+
+	t.Run("allowed paymail domains", func(t *testing.T) {
+		policy := newSpendingPolicy(testXPubID, "", &SpendingRules{
+			AllowedPaymailDomains: []string{" Example.com "},
+		})
+		require.NoError(t, policy.checkOutputs([]*TransactionOutput{paymailOutput}))
+		require.ErrorIs(t, policy.checkOutputs([]*TransactionOutput{
+			addressOutput(testPolicyAddress),
+		}), ErrSpendingDestination)
+	})
+}
+
+// TestClient_SetSpendingPolicy will test the methods SetSpendingPolicy(), GetSpendingPolicy()
+// and DeleteSpendingPolicy()
+func TestClient_SetSpendingPolicy(t *testing.T) {
+
+	t.Run("set, update and delete", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, xPub, rawXPub := CreateNewXPub(ctx, t, client)
+
+		_, err := client.GetSpendingPolicy(ctx, rawXPub, "")
+		require.ErrorIs(t, err, ErrMissingSpendingPolicy)
+
+		var policy *SpendingPolicy
+		policy, err = client.SetSpendingPolicy(ctx, rawXPub, "", &SpendingRules{MaxSatoshis: 1000})
+		require.NoError(t, err)
+		assert.Equal(t, xPub.ID, policy.ID)
+		assert.Equal(t, uint64(1000), policy.MaxSatoshis)
+
+		policy, err = client.SetSpendingPolicy(ctx, rawXPub, "", &SpendingRules{DailyLimit: 5000})
+		require.NoError(t, err)
+
+		policy, err = client.GetSpendingPolicy(ctx, rawXPub, "")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), policy.MaxSatoshis)
+		assert.Equal(t, uint64(5000), policy.DailyLimit)
+
+		require.NoError(t, client.DeleteSpendingPolicy(ctx, rawXPub, ""))
+		_, err = client.GetSpendingPolicy(ctx, rawXPub, "")
+		require.ErrorIs(t, err, ErrMissingSpendingPolicy)
+
+		// Set again restores the policy
+		_, err = client.SetSpendingPolicy(ctx, rawXPub, "", &SpendingRules{MaxSatoshis: 2000})
+		require.NoError(t, err)
+		policy, err = client.GetSpendingPolicy(ctx, rawXPub, "")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2000), policy.MaxSatoshis)
+	})
+
+	t.Run("access key policy", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, xPub, rawXPub := CreateNewXPub(ctx, t, client)
+		accessKey, err := client.NewAccessKey(ctx, rawXPub, nil, 0)
+		require.NoError(t, err)
+
+		var policy *SpendingPolicy
+		policy, err = client.SetSpendingPolicy(ctx, rawXPub, accessKey.ID, &SpendingRules{MaxSatoshis: 1000})
+		require.NoError(t, err)
+		assert.Equal(t, accessKey.ID, policy.ID)
+		assert.Equal(t, accessKey.ID, policy.AccessKeyID)
+		assert.Equal(t, xPub.ID, policy.XpubID)
+
+		// The xPub has no policy
+		_, err = client.GetSpendingPolicy(ctx, rawXPub, "")
+		require.ErrorIs(t, err, ErrMissingSpendingPolicy)
+
+		// Access key of another xPub
+		_, _, otherXPub := CreateNewXPub(ctx, t, client)
+		_, err = client.SetSpendingPolicy(ctx, otherXPub, accessKey.ID, nil)
+		require.ErrorIs(t, err, utils.ErrXpubNoMatch)
+
+		_, err = client.SetSpendingPolicy(ctx, rawXPub, utils.Hash("unknown"), nil)
+		require.ErrorIs(t, err, ErrMissingAccessKey)
+	})
+}
+
+// TestClient_SpendingPolicy_Transactions will test the spending policies in NewTransaction() and RecordTransaction()
+func TestClient_SpendingPolicy_Transactions(t *testing.T) {
+
+	// newTestPolicyTransaction will create a draft sending the satoshis to the address
+	newTestPolicyTransaction := func(ctx context.Context, client ClientInterface, rawXPub, address string,
+		satoshis uint64) (*DraftTransaction, error) {
+		return client.NewTransaction(ctx, rawXPub, &TransactionConfig{
+			Outputs: []*TransactionOutput{{Satoshis: satoshis, To: address}},
+		}, nil)
+	}
+
+	t.Run("maximum satoshis", func(t *testing.T) {
+		ctx, client, deferMe, _, rawXPub, _ := createTestDoubleSpendClient(t, &chainStateDoubleSpend{})
+		defer deferMe()
+
+		_, err := client.SetSpendingPolicy(ctx, rawXPub, "", &SpendingRules{MaxSatoshis: 1000})
+		require.NoError(t, err)
+
+		_, err = newTestPolicyTransaction(ctx, client, rawXPub, testPolicyAddress, 1001)
+		require.ErrorIs(t, err, ErrSpendingMaxSatoshis)
+
+		// No utxos are reserved
+		var utxos []*Utxo
+		utxos, err = GetSpendableUtxos(
+			ctx, utils.Hash(rawXPub), utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+
+		_, err = newTestPolicyTransaction(ctx, client, rawXPub, testPolicyAddress, 1000)
+		require.NoError(t, err)
+	})
+
+	t.Run("allowed destinations", func(t *testing.T) {
+		ctx, client, deferMe, _, rawXPub, _ := createTestDoubleSpendClient(t, &chainStateDoubleSpend{})
+		defer deferMe()
+
+		_, err := client.SetSpendingPolicy(ctx, rawXPub, "", &SpendingRules{
+			AllowedDestinations: []string{testPolicyAddress},
+		})
+		require.NoError(t, err)
+
+		_, err = newTestPolicyTransaction(ctx, client, rawXPub, testPolicyOtherAddress, 1000)
+		require.ErrorIs(t, err, ErrSpendingDestination)
+
+		_, err = newTestPolicyTransaction(ctx, client, rawXPub, testPolicyAddress, 1000)
+		require.NoError(t, err)
+	})
+
+	t.Run("daily limit", func(t *testing.T) {
+		ctx, client, deferMe, _, rawXPub, _ := createTestDoubleSpendClient(t, &chainStateDoubleSpend{})
+		defer deferMe()
+
+		_, err := client.SetSpendingPolicy(ctx, rawXPub, "", &SpendingRules{DailyLimit: 1500})
+		require.NoError(t, err)
+
+		// The first transaction is recorded (the change is not sent)
+		sendTestDoubleSpendTransaction(ctx, t, client, rawXPub)
+
+		var policy *SpendingPolicy
+		policy, err = client.GetSpendingPolicy(ctx, rawXPub, "")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1000), policy.DailySpent)
+
+		_, err = newTestPolicyTransaction(ctx, client, rawXPub, testPolicyAddress, 1000)
+		require.ErrorIs(t, err, ErrSpendingDailyLimit)
+
+		_, err = newTestPolicyTransaction(ctx, client, rawXPub, testPolicyAddress, 500)
+		require.NoError(t, err)
+	})
+
+	t.Run("access key policy", func(t *testing.T) {
+		ctx, client, deferMe, _, rawXPub, _ := createTestDoubleSpendClient(t, &chainStateDoubleSpend{})
+		defer deferMe()
+
+		accessKey, err := client.NewAccessKey(ctx, rawXPub, nil, 0)
+		require.NoError(t, err)
+		_, err = client.SetSpendingPolicy(ctx, rawXPub, accessKey.ID, &SpendingRules{MaxSatoshis: 500})
+		require.NoError(t, err)
+
+		// Request of the access key (see AuthenticateRequest)
+		accessKeyCtx := context.WithValue(ctx, accessKeyIDKey, accessKey.ID)
+		_, err = newTestPolicyTransaction(accessKeyCtx, client, rawXPub, testPolicyAddress, 1000)
+		require.ErrorIs(t, err, ErrSpendingMaxSatoshis)
+
+		var policyErr *SpendingPolicyError
+		require.True(t, errors.As(err, &policyErr))
+		assert.Equal(t, accessKey.ID, policyErr.AccessKeyID)
+
+		// Requests of the xPub are not limited by the policy of the access key
+		_, err = newTestPolicyTransaction(ctx, client, rawXPub, testPolicyAddress, 1000)
+		require.NoError(t, err)
+	})
+
+	t.Run("record transaction", func(t *testing.T) {
+		ctx, client, deferMe, masterKey, rawXPub, _ := createTestDoubleSpendClient(t, &chainStateDoubleSpend{})
+		defer deferMe()
+
+		draftTransaction, err := newTestPolicyTransaction(ctx, client, rawXPub, testPolicyAddress, 1000)
+		require.NoError(t, err)
+
+		var signer Signer
+		signer, err = NewXPrivSigner(masterKey.String())
+		require.NoError(t, err)
+		var txHex string
+		txHex, err = signer.SignDraftTransaction(ctx, draftTransaction)
+		require.NoError(t, err)
+
+		// The policy is set after creating the draft
+		_, err = client.SetSpendingPolicy(ctx, rawXPub, "", &SpendingRules{MaxSatoshis: 999})
+		require.NoError(t, err)
+
+		_, err = client.RecordTransaction(ctx, rawXPub, txHex, draftTransaction.ID)
+		require.ErrorIs(t, err, ErrSpendingMaxSatoshis)
+
+		_, err = client.SetSpendingPolicy(ctx, rawXPub, "", &SpendingRules{
+			AllowedDestinations: []string{testPolicyOtherAddress},
+		})
+		require.NoError(t, err)
+
+		_, err = client.RecordTransaction(ctx, rawXPub, txHex, draftTransaction.ID)
+		require.ErrorIs(t, err, ErrSpendingDestination)
+
+		_, err = client.SetSpendingPolicy(ctx, rawXPub, "", &SpendingRules{MaxSatoshis: 1000})
+		require.NoError(t, err)
+
+		_, err = client.RecordTransaction(ctx, rawXPub, txHex, draftTransaction.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("concurrent record transactions", func(t *testing.T) {
+		ctx, client, deferMe, masterKey, rawXPub, _ := createTestDoubleSpendClient(t, &chainStateDoubleSpend{})
+		defer deferMe()
+
+		// Second utxo, so both drafts can be created
+		destination, err := client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+		_, err = client.RecordTransaction(ctx, rawXPub,
+			CreateFakeFundingTransaction(t, masterKey, []*Destination{destination}, 10000), "",
+		)
+		require.NoError(t, err)
+
+		_, err = client.SetSpendingPolicy(ctx, rawXPub, "", &SpendingRules{DailyLimit: 1500})
+		require.NoError(t, err)
+
+		var signer Signer
+		signer, err = NewXPrivSigner(masterKey.String())
+		require.NoError(t, err)
+
+		// Each draft is within the daily limit, both are not
+		txHexes := make(map[string]string)
+		for i := 0; i < 2; i++ {
+			var draftTransaction *DraftTransaction
+			draftTransaction, err = newTestPolicyTransaction(ctx, client, rawXPub, testPolicyAddress, 1000)
+			require.NoError(t, err)
+			txHexes[draftTransaction.ID], err = signer.SignDraftTransaction(ctx, draftTransaction)
+			require.NoError(t, err)
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, len(txHexes))
+		for draftID, txHex := range txHexes {
+			wg.Add(1)
+			go func(draftID, txHex string) {
+				defer wg.Done()
+				_, recordErr := client.RecordTransaction(ctx, rawXPub, txHex, draftID)
+				errs <- recordErr
+			}(draftID, txHex)
+		}
+		wg.Wait()
+		close(errs)
+
+		var recorded, limited int
+		for recordErr := range errs {
+			if recordErr == nil {
+				recorded++
+			} else if errors.Is(recordErr, ErrSpendingDailyLimit) {
+				limited++
+			} else {
+				require.NoError(t, recordErr)
+			}
+		}
+		assert.Equal(t, 1, recorded)
+		assert.Equal(t, 1, limited)
+
+		var policy *SpendingPolicy
+		policy, err = client.GetSpendingPolicy(ctx, rawXPub, "")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1000), policy.DailySpent)
+		assert.Equal(t, uint64(1500), policy.DailyLimit)
+	})
+
+	t.Run("child pays for parent is sent to self", func(t *testing.T) {
+		ctx, client, deferMe, _, rawXPub, _ := createTestDoubleSpendClient(t, &chainStateDoubleSpend{})
+		defer deferMe()
+
+		_, err := client.SetSpendingPolicy(ctx, rawXPub, "", &SpendingRules{
+			AllowedDestinations: []string{testPolicyAddress},
+			DailyLimit:          1500,
+			MaxSatoshis:         1500,
+		})
+		require.NoError(t, err)
+
+		// The change of the parent is more than the limits
+		parent := sendTestDoubleSpendTransaction(ctx, t, client, rawXPub)
+
+		var draft *DraftTransaction
+		draft, err = client.BumpTransactionFee(ctx, rawXPub, parent.ID, &utils.FeeUnit{Satoshis: 500, Bytes: 1000}, nil)
+		require.NoError(t, err)
+		require.NotNil(t, draft)
+		assert.Greater(t, draft.Configuration.Outputs[0].Satoshis, uint64(1500))
+	})
+
+	t.Run("consolidation is sent to self", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		createTestConsolidationXPub(ctx, t, client)
+
+		_, err := client.SetSpendingPolicy(ctx, testXPub, "", &SpendingRules{
+			AllowedDestinations: []string{testPolicyAddress},
+			DailyLimit:          1000,
+			MaxSatoshis:         1000,
+		})
+		require.NoError(t, err)
+
+		config := &UtxoConsolidationConfig{
+			MaxInputs:   4,
+			MaxSatoshis: 2000,
+			MinUtxos:    3,
+			Resolver:    &xPubResolverMock{xPubs: map[string]string{testXPubID: testXPub}},
+		}
+		config.setDefaults()
+		err = TaskConsolidateUtxos(ctx, client.Logger(), config, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+
+		// Max inputs were reserved by the consolidation draft
+		var utxos []*Utxo
+		utxos, err = GetSpendableUtxos(ctx, testXPubID, utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Len(t, utxos, 1)
+	})
+}
@@ -29,6 +29,9 @@ const (
 
 	// SyncStatusComplete is when the sync is complete
 	SyncStatusComplete SyncStatus = statusComplete
+
+	// SyncStatusConflicted is when the transaction lost a double spend (inputs spent by a conflicting transaction)
+	SyncStatusConflicted SyncStatus = statusConflicted
 )
 
 // Scan will scan the value into Struct, implements sql.Scanner interface
@@ -54,6 +57,8 @@ func (t *SyncStatus) Scan(value interface{}) error {
 		*t = SyncStatusError
 	case statusComplete:
 		*t = SyncStatusComplete
+	case statusConflicted:
+		*t = SyncStatusConflicted
 	}
 
 	return nil
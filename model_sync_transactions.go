@@ -4,12 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/BuxOrg/bux/chainstate"
 	"github.com/BuxOrg/bux/datastore"
 	"github.com/BuxOrg/bux/taskmanager"
 	"github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bt/v2"
 )
 
 // SyncTransaction is an object representing the chain-state sync configuration and results for a given transaction
@@ -57,6 +59,28 @@ func newSyncTransaction(txID string, config *SyncConfig, opts ...ModelOps) *Sync
 	}
 }
 
+// getSyncTransactionByID will get a sync transaction by the transaction ID
+func getSyncTransactionByID(ctx context.Context, txID string, opts ...ModelOps) (*SyncTransaction, error) {
+
+	// Construct an empty model
+	syncTx := &SyncTransaction{
+		ID:    txID,
+		Model: *NewBaseModel(ModelSyncTransaction, opts...),
+	}
+
+	// Get the record
+	if err := Get(
+		ctx, syncTx, nil, false, defaultDatabaseReadTimeout,
+	); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return syncTx, nil
+}
+
 // getTransactionsToBroadcast will get the sync transactions to broadcast
 func getTransactionsToBroadcast(ctx context.Context, pageSize, page int,
 	opts ...ModelOps) ([]*SyncTransaction, error) {
@@ -75,6 +99,24 @@ func getTransactionsToBroadcast(ctx context.Context, pageSize, page int,
 	return txs, nil
 }
 
+// getPendingBroadcastTransactions will get the sync transactions held from broadcasting (not final)
+func getPendingBroadcastTransactions(ctx context.Context, pageSize, page int,
+	opts ...ModelOps) ([]*SyncTransaction, error) {
+
+	// Get the records by status
+	txs, err := getSyncTransactionsByConditions(
+		ctx,
+		map[string]interface{}{
+			broadcastStatusField: SyncStatusPending.String(),
+		},
+		pageSize, page, opts...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
 // getTransactionsToSync will get the sync transactions to sync
 func getTransactionsToSync(ctx context.Context, pageSize, page int,
 	opts ...ModelOps) ([]*SyncTransaction, error) {
@@ -134,6 +176,16 @@ func (m *SyncTransaction) Save(ctx context.Context) error {
 	return Save(ctx, m)
 }
 
+// Delete will (soft) delete the model in the Datastore
+func (m *SyncTransaction) Delete(ctx context.Context) error {
+	return Delete(ctx, m)
+}
+
+// Restore will restore the (soft) deleted model in the Datastore
+func (m *SyncTransaction) Restore(ctx context.Context) error {
+	return Restore(ctx, m)
+}
+
 // GetID will get the ID
 func (m *SyncTransaction) GetID() string {
 	return m.ID
@@ -143,8 +195,10 @@ func (m *SyncTransaction) GetID() string {
 func (m *SyncTransaction) BeforeCreating(_ context.Context) error {
 	m.DebugLog("starting: [" + m.name.String() + "] BeforeCreating hook...")
 
-	// Set status
-	m.BroadcastStatus = SyncStatusReady
+	// Set status (pending broadcasts are held until the transaction is final)
+	if m.BroadcastStatus != SyncStatusPending {
+		m.BroadcastStatus = SyncStatusReady
+	}
 	m.SyncStatus = SyncStatusPending
 
 	// Make sure ID is valid
@@ -251,8 +305,15 @@ func processSyncTransactions(ctx context.Context, maxTransactions int, opts ...M
 // processBroadcastTransactions will process sync transaction records
 func processBroadcastTransactions(ctx context.Context, maxTransactions int, opts ...ModelOps) error {
 
+	// Release the held transactions that became final
+	err := processPendingBroadcasts(ctx, maxTransactions, opts...)
+	if err != nil {
+		return err
+	}
+
 	// Get x records
-	records, err := getTransactionsToBroadcast(
+	var records []*SyncTransaction
+	records, err = getTransactionsToBroadcast(
 		ctx, maxTransactions, 1, opts...,
 	)
 	if err != nil {
@@ -273,6 +334,94 @@ func processBroadcastTransactions(ctx context.Context, maxTransactions int, opts
 	return nil
 }
 
+// processPendingBroadcasts will set the held (pending) broadcasts to ready once the transaction is final
+func processPendingBroadcasts(ctx context.Context, maxTransactions int, opts ...ModelOps) error {
+
+	// Get x records
+	records, err := getPendingBroadcastTransactions(
+		ctx, maxTransactions, 1, opts...,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, syncTx := range records {
+
+		// Get the transaction
+		var transaction *Transaction
+		if transaction, err = getTransactionByID(
+			ctx, syncTx.rawXpubKey, syncTx.ID, syncTx.GetOptions(false)...,
+		); err != nil {
+			return err
+		} else if transaction == nil {
+			syncTx.BroadcastStatus = SyncStatusError
+			bailAndSaveSyncTransaction(ctx, syncTx, SyncStatusError, "transaction not found")
+			continue
+		}
+
+		var tx *bt.Tx
+		if tx, err = bt.NewTxFromString(transaction.Hex); err != nil {
+			syncTx.BroadcastStatus = SyncStatusError
+			bailAndSaveSyncTransaction(ctx, syncTx, SyncStatusError, "transaction hex error: "+err.Error())
+			continue
+		}
+
+		// Still locked?
+		var final bool
+		if final, err = isTransactionFinal(ctx, tx, opts...); err != nil {
+			return err
+		} else if !final {
+			continue
+		}
+
+		syncTx.BroadcastStatus = SyncStatusReady
+		if err = syncTx.Save(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isTransactionFinal will check if the transaction can be mined (lock time has passed or all inputs are final)
+//
+// The lock time is checked against the chain tip (block height or block time), if no block headers are synced
+// the chain tip of the chain provider(s) is used. If the chain tip is unknown, a height lock is not final (yet)
+// and a time lock is checked against the current time
+func isTransactionFinal(ctx context.Context, tx *bt.Tx, opts ...ModelOps) (bool, error) {
+	if tx.LockTime == 0 {
+		return true, nil
+	}
+
+	// Lock time is only enforced if an input is not final
+	final := true
+	for _, input := range tx.Inputs {
+		if input.SequenceNumber != bt.DefaultSequenceNumber {
+			final = false
+			break
+		}
+	}
+	if final {
+		return true, nil
+	}
+
+	tip, err := getChainTip(ctx, opts...)
+	if err != nil {
+		return false, err
+	}
+
+	// Block height lock
+	if tx.LockTime < lockTimeThreshold {
+		return tip != nil && uint64(tx.LockTime) <= tip.Height, nil
+	}
+
+	// Timestamp lock
+	if tip != nil {
+		return tx.LockTime <= tip.Time, nil
+	}
+	return int64(tx.LockTime) <= time.Now().UTC().Unix(), nil
+}
+
 // processBroadcastTransaction will process the sync transaction record, or save the failure
 func processBroadcastTransaction(ctx context.Context, syncTx *SyncTransaction) error {
 
@@ -293,10 +442,46 @@ func processBroadcastTransaction(ctx context.Context, syncTx *SyncTransaction) e
 		return err
 	}
 
+	// Child-pays-for-parent: broadcast the parent first (if not broadcast yet), the child pays the fee for both
+	var parent *Transaction
+	var parentSyncTx *SyncTransaction
+	parentAccepted := false
+	if len(transaction.ParentID) > 0 {
+		if parent, err = getTransactionByID(
+			ctx, "", transaction.ParentID, syncTx.GetOptions(false)...,
+		); err != nil {
+			return err
+		} else if parentSyncTx, err = getSyncTransactionByID(
+			ctx, transaction.ParentID, syncTx.GetOptions(false)...,
+		); err != nil {
+			return err
+		}
+		if parent != nil && len(parent.BlockHash) == 0 &&
+			(parentSyncTx == nil || parentSyncTx.BroadcastStatus != SyncStatusComplete) {
+			// A parent rejected for the fee is broadcast again with the child, other failures stop the child
+			if err = syncTx.Client().Chainstate().Broadcast(
+				ctx, parent.ID, parent.Hex, 15*time.Second,
+			); errors.Is(err, chainstate.ErrDoubleSpend) {
+				// The child spends the outputs of the parent (conflicted as a descendant)
+				return processDoubleSpend(
+					ctx, parent, parentSyncTx, "broadcast error: "+err.Error(), syncTx.GetOptions(false)...,
+				)
+			} else if err != nil && !errors.Is(err, chainstate.ErrFeeTooLow) {
+				bailAndSaveSyncTransaction(ctx, syncTx, SyncStatusError, "parent broadcast error: "+err.Error())
+				return nil // nolint: nilerr // error is not needed
+			}
+			parentAccepted = err == nil
+		}
+	}
+
 	// Broadcast
 	if err = syncTx.Client().Chainstate().Broadcast(
 		ctx, syncTx.ID, transaction.Hex, 15*time.Second,
-	); err != nil {
+	); errors.Is(err, chainstate.ErrDoubleSpend) {
+		return processDoubleSpend(
+			ctx, transaction, syncTx, "broadcast error: "+err.Error(), syncTx.GetOptions(false)...,
+		)
+	} else if err != nil {
 		bailAndSaveSyncTransaction(ctx, syncTx, SyncStatusError, "broadcast error: "+err.Error())
 		return nil // nolint: nilerr // error is not needed
 	}
@@ -318,11 +503,49 @@ func processBroadcastTransaction(ctx context.Context, syncTx *SyncTransaction) e
 		bailAndSaveSyncTransaction(ctx, syncTx, SyncStatusError, err.Error())
 		return err
 	}
+	publishEvent(ctx, syncTx.Client(), EventTypeSyncTransactionStatus, syncTx)
+
+	// The parent was accepted (before or together with the child)
+	if parent != nil && parentSyncTx != nil && parentSyncTx.BroadcastStatus != SyncStatusComplete {
+		if !parentAccepted {
+			if _, err = syncTx.Client().Chainstate().QueryTransaction(
+				ctx, parent.ID, chainstate.RequiredInMempool, defaultQueryTimeout,
+			); err != nil {
+				return nil // nolint: nilerr // the parent is not known, its own broadcast is retried
+			}
+		}
+		if err = completeParentBroadcast(ctx, parentSyncTx, syncTx.ID); err != nil {
+			return err
+		}
+	}
 
 	// Done!
 	return nil
 }
 
+// completeParentBroadcast will complete the broadcast of the parent, broadcast with the child (child-pays-for-parent)
+func completeParentBroadcast(ctx context.Context, syncTx *SyncTransaction, childID string) error {
+
+	// Create status message
+	message := "transaction was broadcasted with child transaction " + childID
+
+	// Update the sync status
+	syncTx.BroadcastStatus = SyncStatusComplete
+	syncTx.Results.LastMessage = message
+	syncTx.Results.Attempts = append(syncTx.Results.Attempts, &SyncAttempt{
+		Action:        "broadcast",
+		AttemptedAt:   time.Now().UTC(),
+		StatusMessage: message,
+	})
+
+	if err := syncTx.Save(ctx); err != nil {
+		return err
+	}
+	publishEvent(ctx, syncTx.Client(), EventTypeSyncTransactionStatus, syncTx)
+
+	return nil
+}
+
 // processSyncTransaction will process the sync transaction record, or save the failure
 func processSyncTransaction(ctx context.Context, syncTx *SyncTransaction) error {
 
@@ -343,6 +566,18 @@ func processSyncTransaction(ctx context.Context, syncTx *SyncTransaction) error
 		if errors.Is(err, chainstate.ErrTransactionNotFound) {
 			bailAndSaveSyncTransaction(ctx, syncTx, SyncStatusReady, "transaction not found on-chain")
 			return nil
+		} else if errors.Is(err, chainstate.ErrDoubleSpend) {
+			reason := "sync error: " + err.Error()
+			var transaction *Transaction
+			if transaction, err = getTransactionByID(
+				ctx, "", syncTx.ID, syncTx.GetOptions(false)...,
+			); err != nil {
+				return err
+			} else if transaction == nil {
+				bailAndSaveSyncTransaction(ctx, syncTx, SyncStatusError, "transaction not found")
+				return nil
+			}
+			return processDoubleSpend(ctx, transaction, syncTx, reason, syncTx.GetOptions(false)...)
 		}
 		return err
 	}
@@ -358,10 +593,25 @@ func processSyncTransaction(ctx context.Context, syncTx *SyncTransaction) error
 	// Add additional information (if found on-chain)
 	transaction.BlockHash = txInfo.BlockHash
 	transaction.BlockHeight = uint64(txInfo.BlockHeight)
+	if txInfo.MerkleProof != nil {
+		transaction.MerkleProof = MerkleProof(*txInfo.MerkleProof)
+	}
 
 	// Create status message
 	message := "transaction was found on-chain by " + txInfo.Provider
 
+	// Check the merkle proof against the local block header (if synced)
+	var verified bool
+	if verified, err = verifyMerkleProofLocally(ctx, transaction, syncTx.GetOptions(false)...); errors.Is(err, ErrBlockHeaderOrphaned) {
+		bailAndSaveSyncTransaction(ctx, syncTx, SyncStatusReady, "transaction was found in an orphaned block")
+		return nil
+	} else if err != nil {
+		bailAndSaveSyncTransaction(ctx, syncTx, SyncStatusError, "merkle proof error: "+err.Error())
+		return nil // nolint: nilerr // error is not needed
+	} else if verified {
+		message += " and verified with the local block header"
+	}
+
 	// Save the transaction (should NOT error)
 	if err = transaction.Save(ctx); err != nil {
 		bailAndSaveSyncTransaction(ctx, syncTx, SyncStatusError, err.Error())
@@ -382,13 +632,62 @@ func processSyncTransaction(ctx context.Context, syncTx *SyncTransaction) error
 		bailAndSaveSyncTransaction(ctx, syncTx, SyncStatusError, err.Error())
 		return err
 	}
+	publishEvent(ctx, syncTx.Client(), EventTypeSyncTransactionStatus, syncTx)
 
 	// Done!
 	return nil
 }
 
-// bailAndSaveSyncTransaction try to save the error message
+// verifyMerkleProofLocally will check the merkle proof of a mined transaction against the local block header
+//
+// Returns false if the proof or the block header is not found (not verified, but not an error)
+func verifyMerkleProofLocally(ctx context.Context, transaction *Transaction, opts ...ModelOps) (bool, error) {
+	if transaction.MerkleProof.IsEmpty() || len(transaction.BlockHash) == 0 {
+		return false, nil
+	}
+
+	// Get the local block header
+	blockHeader, err := getBlockHeaderByHash(ctx, transaction.BlockHash, opts...)
+	if err != nil {
+		return false, err
+	} else if blockHeader == nil {
+		return false, nil
+	} else if blockHeader.IsOrphaned() {
+		return false, ErrBlockHeaderOrphaned
+	}
+
+	// A block hash target must be the block of the transaction (merkle root and header targets are checked by Verify())
+	proof := chainstate.MerkleProof(transaction.MerkleProof)
+	switch proof.TargetType {
+	case "", chainstate.MerkleTargetTypeHash:
+		if !strings.EqualFold(proof.Target, transaction.BlockHash) {
+			return false, ErrMerkleProofTargetMismatch
+		}
+	case chainstate.MerkleTargetTypeHeader, chainstate.MerkleTargetTypeMerkleRoot:
+	default:
+		return false, chainstate.ErrInvalidMerkleProof
+	}
+
+	// The proof must be for this transaction and must match the merkle root of the block
+	var txID, merkleRoot string
+	if txID, err = proof.TxID(); err != nil {
+		return false, err
+	} else if txID != transaction.ID {
+		return false, chainstate.ErrTransactionIDMismatch
+	} else if merkleRoot, err = proof.Verify(); err != nil {
+		return false, err
+	} else if merkleRoot != blockHeader.MerkleRoot {
+		return false, chainstate.ErrMerkleRootMismatch
+	}
+
+	// Use the local height (trusted)
+	transaction.BlockHeight = blockHeader.Height
+	return true, nil
+}
+
+// bailAndSaveSyncTransaction try to save the error message (the event is published if the status changed)
 func bailAndSaveSyncTransaction(ctx context.Context, syncTx *SyncTransaction, status SyncStatus, message string) {
+	statusChanged := syncTx.SyncStatus != status
 	syncTx.SyncStatus = status
 	syncTx.LastAttempt = utils.NullTime{
 		NullTime: sql.NullTime{
@@ -402,5 +701,7 @@ func bailAndSaveSyncTransaction(ctx context.Context, syncTx *SyncTransaction, st
 		AttemptedAt:   time.Now().UTC(),
 		StatusMessage: message,
 	})
-	_ = syncTx.Save(ctx)
+	if err := syncTx.Save(ctx); err == nil && statusChanged {
+		publishEvent(ctx, syncTx.Client(), EventTypeSyncTransactionStatus, syncTx)
+	}
 }
@@ -1,9 +1,15 @@
 package bux
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bt/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestSyncTransaction_GetModelName will test the method GetModelName()
@@ -13,3 +19,280 @@ func TestSyncTransaction_GetModelName(t *testing.T) {
 	bTx := newSyncTransaction(testTxID, nil, New())
 	assert.Equal(t, ModelSyncTransaction.String(), bTx.GetModelName())
 }
+
+// createTestSyncTransaction will record a new (funding) transaction for a new xPub
+func createTestSyncTransaction(ctx context.Context, t *testing.T, client ClientInterface) (*Transaction, string) {
+	masterKey, _, rawXPub := CreateNewXPub(ctx, t, client)
+	destination, err := client.NewDestination(
+		ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+	)
+	require.NoError(t, err)
+
+	var transaction *Transaction
+	transaction, err = client.RecordTransaction(
+		ctx, rawXPub, CreateFakeFundingTransaction(t, masterKey, []*Destination{destination}, 1000), "",
+	)
+	require.NoError(t, err)
+	return transaction, rawXPub
+}
+
+// Test_processSyncTransaction will test the method processSyncTransaction()
+func Test_processSyncTransaction(t *testing.T) {
+
+	t.Run("mined transaction - merkle proof is saved", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+		)
+		defer deferMe()
+
+		transaction, rawXPub := createTestSyncTransaction(ctx, t, client)
+
+		// Not synced yet
+		proof, err := client.GetTransactionMerkleProof(ctx, rawXPub, transaction.ID)
+		require.ErrorIs(t, err, ErrMissingMerkleProof)
+		require.Nil(t, proof)
+
+		syncTx := newSyncTransaction(transaction.ID, nil, append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, syncTx.Save(ctx))
+		require.NoError(t, processSyncTransaction(ctx, syncTx))
+		assert.Equal(t, SyncStatusComplete, syncTx.SyncStatus)
+
+		proof, err = client.GetTransactionMerkleProof(ctx, rawXPub, transaction.ID)
+		require.NoError(t, err)
+		require.NotNil(t, proof)
+		assert.Equal(t, transaction.ID, proof.TxOrID)
+		assert.Equal(t, chainstate.MerkleTargetTypeHash, proof.TargetType)
+		assert.Equal(t, []string{testTxScriptSigID}, proof.Nodes)
+
+		var minedTx *Transaction
+		minedTx, err = client.GetTransaction(ctx, rawXPub, transaction.ID)
+		require.NoError(t, err)
+		assert.Equal(t, proof.Target, minedTx.BlockHash)
+	})
+
+	t.Run("mined transaction - verified with local block header", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		chainState := &chainStateBlockHeaders{headers: headers}
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(chainState),
+		)
+		defer deferMe()
+
+		transaction, _ := createTestSyncTransaction(ctx, t, client)
+
+		// Mine the transaction in a new block (using the mocked merkle proof)
+		proof := &chainstate.MerkleProof{Index: 1, Nodes: []string{testTxScriptSigID}, TxOrID: transaction.ID}
+		merkleRoot, err := proof.MerkleRoot()
+		require.NoError(t, err)
+		header := createTestBlockHeader(t, headers[len(headers)-1], merkleRoot)
+		chainState.headers = append(chainState.headers, header)
+		require.NoError(t, newBlockHeader(header, append(client.DefaultModelOptions(), New())...).Save(ctx))
+
+		syncTx := newSyncTransaction(transaction.ID, nil, append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, syncTx.Save(ctx))
+		require.NoError(t, processSyncTransaction(ctx, syncTx))
+		assert.Equal(t, SyncStatusComplete, syncTx.SyncStatus)
+		assert.Contains(t, syncTx.Results.LastMessage, "verified with the local block header")
+
+		var minedTx *Transaction
+		minedTx, err = client.GetTransaction(ctx, "", transaction.ID)
+		require.NoError(t, err)
+		assert.Equal(t, header.Hash, minedTx.BlockHash)
+		assert.Equal(t, header.Height, minedTx.BlockHeight)
+	})
+
+	t.Run("mined transaction - merkle root mismatch", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		chainState := &chainStateBlockHeaders{headers: headers}
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(chainState),
+		)
+		defer deferMe()
+
+		transaction, _ := createTestSyncTransaction(ctx, t, client)
+
+		// The block does not contain the transaction
+		header := createTestBlockHeader(t, headers[len(headers)-1], testTxScriptSigID)
+		chainState.headers = append(chainState.headers, header)
+		require.NoError(t, newBlockHeader(header, append(client.DefaultModelOptions(), New())...).Save(ctx))
+
+		syncTx := newSyncTransaction(transaction.ID, nil, append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, syncTx.Save(ctx))
+		require.NoError(t, processSyncTransaction(ctx, syncTx))
+		assert.Equal(t, SyncStatusError, syncTx.SyncStatus)
+		assert.Contains(t, syncTx.Results.LastMessage, chainstate.ErrMerkleRootMismatch.Error())
+	})
+
+	t.Run("mined transaction - merkle proof target mismatch", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		chainState := &chainStateBlockHeaders{headers: headers, target: headers[len(headers)-1].Hash}
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(chainState),
+		)
+		defer deferMe()
+
+		transaction, _ := createTestSyncTransaction(ctx, t, client)
+
+		// The proof is valid for the new block, but the target is the previous block
+		proof := &chainstate.MerkleProof{Index: 1, Nodes: []string{testTxScriptSigID}, TxOrID: transaction.ID}
+		merkleRoot, err := proof.MerkleRoot()
+		require.NoError(t, err)
+		header := createTestBlockHeader(t, headers[len(headers)-1], merkleRoot)
+		chainState.headers = append(chainState.headers, header)
+		require.NoError(t, newBlockHeader(header, append(client.DefaultModelOptions(), New())...).Save(ctx))
+
+		syncTx := newSyncTransaction(transaction.ID, nil, append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, syncTx.Save(ctx))
+		require.NoError(t, processSyncTransaction(ctx, syncTx))
+		assert.Equal(t, SyncStatusError, syncTx.SyncStatus)
+		assert.Contains(t, syncTx.Results.LastMessage, ErrMerkleProofTargetMismatch.Error())
+	})
+
+	t.Run("mined transaction - unsupported merkle proof target type", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		chainState := &chainStateBlockHeaders{headers: headers, targetType: "unknown"}
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(chainState),
+		)
+		defer deferMe()
+
+		transaction, _ := createTestSyncTransaction(ctx, t, client)
+
+		proof := &chainstate.MerkleProof{Index: 1, Nodes: []string{testTxScriptSigID}, TxOrID: transaction.ID}
+		merkleRoot, err := proof.MerkleRoot()
+		require.NoError(t, err)
+		header := createTestBlockHeader(t, headers[len(headers)-1], merkleRoot)
+		chainState.headers = append(chainState.headers, header)
+		require.NoError(t, newBlockHeader(header, append(client.DefaultModelOptions(), New())...).Save(ctx))
+
+		syncTx := newSyncTransaction(transaction.ID, nil, append(client.DefaultModelOptions(), New())...)
+		require.NoError(t, syncTx.Save(ctx))
+		require.NoError(t, processSyncTransaction(ctx, syncTx))
+		assert.Equal(t, SyncStatusError, syncTx.SyncStatus)
+		assert.Contains(t, syncTx.Results.LastMessage, chainstate.ErrInvalidMerkleProof.Error())
+	})
+
+	t.Run("missing transaction", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		proof, err := client.GetTransactionMerkleProof(ctx, testXPub, testTxID)
+		require.ErrorIs(t, err, ErrMissingTransaction)
+		require.Nil(t, proof)
+	})
+}
+
+// Test_isTransactionFinal will test the method isTransactionFinal()
+func Test_isTransactionFinal(t *testing.T) {
+
+	// lockedTx will return a tx with one input (sequence) and the lock time
+	lockedTx := func(lockTime, sequence uint32) *bt.Tx {
+		tx := bt.NewTx()
+		tx.LockTime = lockTime
+		tx.Inputs = append(tx.Inputs, &bt.Input{SequenceNumber: sequence})
+		return tx
+	}
+
+	t.Run("no lock time", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		final, err := isTransactionFinal(ctx, lockedTx(0, 0), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.True(t, final)
+	})
+
+	t.Run("final inputs", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		final, err := isTransactionFinal(ctx, lockedTx(900000, bt.DefaultSequenceNumber), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.True(t, final)
+	})
+
+	t.Run("block height - no block headers", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateBase{}),
+		)
+		defer deferMe()
+
+		final, err := isTransactionFinal(ctx, lockedTx(1, 0), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.False(t, final)
+	})
+
+	t.Run("block height - chain tip of the chain provider", func(t *testing.T) {
+		headers := loadTestBlockHeaders(t)
+		tip := headers[len(headers)-1]
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateBlockHeaders{headers: headers}),
+		)
+		defer deferMe()
+
+		final, err := isTransactionFinal(ctx, lockedTx(uint32(tip.Height), 0), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.True(t, final)
+
+		final, err = isTransactionFinal(ctx, lockedTx(uint32(tip.Height)+1, 0), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.False(t, final)
+	})
+
+	t.Run("block height - chain tip", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		headers := loadTestBlockHeaders(t)
+		tip := headers[len(headers)-1]
+		for _, header := range headers {
+			require.NoError(t, newBlockHeader(header, append(client.DefaultModelOptions(), New())...).Save(ctx))
+		}
+
+		final, err := isTransactionFinal(ctx, lockedTx(uint32(tip.Height), 0), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.True(t, final)
+
+		final, err = isTransactionFinal(ctx, lockedTx(uint32(tip.Height)+1, 0), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.False(t, final)
+
+		// Timestamp lock (checked against the block time)
+		final, err = isTransactionFinal(ctx, lockedTx(tip.Time+1, 0), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.False(t, final)
+	})
+
+	t.Run("timestamp - no block headers", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateBase{}),
+		)
+		defer deferMe()
+
+		final, err := isTransactionFinal(ctx, lockedTx(lockTimeThreshold, 0), client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.True(t, final)
+
+		final, err = isTransactionFinal(
+			ctx, lockedTx(uint32(time.Now().Add(time.Hour).Unix()), 0), client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		assert.False(t, final)
+	})
+}
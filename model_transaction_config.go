@@ -12,7 +12,6 @@ import (
 
 	"github.com/BuxOrg/bux/cachestore"
 	"github.com/BuxOrg/bux/utils"
-	magic "github.com/bitcoinschema/go-map"
 	"github.com/libsv/go-bt/v2/bscript"
 	"github.com/tonicpow/go-paymail"
 )
@@ -20,27 +19,58 @@ import (
 // TransactionConfig is the configuration used to start a transaction
 type TransactionConfig struct {
 	// Conditions (utxo choices)
-	// NlockTime uint32
-	ChangeDestinations         []*Destination       `json:"change_destinations" toml:"change_destinations" yaml:"change_destinations"`
-	ChangeDestinationsStrategy ChangeStrategy       `json:"change_destinations_strategy" toml:"change_destinations_strategy" yaml:"change_destinations_strategy"`
-	ChangeMinimumSatoshis      uint64               `json:"change_minimum_satoshis" toml:"change_minimum_satoshis" yaml:"change_minimum_satoshis"`
-	ChangeNumberOfDestinations int                  `json:"change_number_of_destinations" toml:"change_number_of_destinations" yaml:"change_number_of_destinations"`
-	ChangeSatoshis             uint64               `json:"change_satoshis" toml:"change_satoshis" yaml:"change_satoshis"`
-	ExpiresIn                  time.Duration        `json:"expires_in" toml:"expires_in" yaml:"expires_in"`
-	Fee                        uint64               `json:"fee" toml:"fee" yaml:"fee"`
-	FeeUnit                    *utils.FeeUnit       `json:"fee_unit" toml:"fee_unit" yaml:"fee_unit"`
-	FromUtxos                  []*UtxoPointer       `json:"from_utxos" toml:"from_utxos" yaml:"from_utxos"`
-	Inputs                     []*TransactionInput  `json:"inputs" toml:"inputs" yaml:"inputs"`
-	Miner                      string               `json:"miner" toml:"miner" yaml:"miner"`
-	Outputs                    []*TransactionOutput `json:"outputs" toml:"outputs" yaml:"outputs"`
-	SendAllTo                  string               `json:"send_all_to" toml:"send_all_to" yaml:"send_all_to"`
-	Sync                       *SyncConfig          `json:"sync" toml:"sync" yaml:"sync"`
+	ChangeDestinations         []*Destination        `json:"change_destinations" toml:"change_destinations" yaml:"change_destinations"`
+	ChangeDestinationsStrategy ChangeStrategy        `json:"change_destinations_strategy" toml:"change_destinations_strategy" yaml:"change_destinations_strategy"`
+	ChangeMinimumSatoshis      uint64                `json:"change_minimum_satoshis" toml:"change_minimum_satoshis" yaml:"change_minimum_satoshis"`
+	ChangeNumberOfDestinations int                   `json:"change_number_of_destinations" toml:"change_number_of_destinations" yaml:"change_number_of_destinations"`
+	ChangeSatoshis             uint64                `json:"change_satoshis" toml:"change_satoshis" yaml:"change_satoshis"`
+	CoinSelectionStrategy      CoinSelectionStrategy `json:"coin_selection_strategy" toml:"coin_selection_strategy" yaml:"coin_selection_strategy"`
+	ExpiresIn                  time.Duration         `json:"expires_in" toml:"expires_in" yaml:"expires_in"`
+	Fee                        uint64                `json:"fee" toml:"fee" yaml:"fee"`
+	FeeUnit                    *utils.FeeUnit        `json:"fee_unit" toml:"fee_unit" yaml:"fee_unit"`
+	FromUtxos                  []*UtxoPointer        `json:"from_utxos" toml:"from_utxos" yaml:"from_utxos"`
+	Inputs                     []*TransactionInput   `json:"inputs" toml:"inputs" yaml:"inputs"`
+	LockTime                   uint32                `json:"lock_time" toml:"lock_time" yaml:"lock_time"`
+	Miner                      string                `json:"miner" toml:"miner" yaml:"miner"`
+	Outputs                    []*TransactionOutput  `json:"outputs" toml:"outputs" yaml:"outputs"`
+	ParentID                   string                `json:"parent_id,omitempty" toml:"parent_id" yaml:"parent_id"` // Child-pays-for-parent (fee bump)
+	SendAllTo                  string                `json:"send_all_to" toml:"send_all_to" yaml:"send_all_to"`
+	Sync                       *SyncConfig           `json:"sync" toml:"sync" yaml:"sync"`
 }
 
 // TransactionInput is an input on the transaction config
 type TransactionInput struct {
 	Utxo
-	Destination Destination `json:"destination" toml:"destination" yaml:"destination" bson:"destination"`
+	Destination Destination       `json:"destination" toml:"destination" yaml:"destination" bson:"destination"`
+	Sequence    uint32            `json:"sequence" toml:"sequence" yaml:"sequence" bson:"sequence"`
+	Signatures  map[string]string `json:"signatures,omitempty" toml:"signatures" yaml:"signatures" bson:"signatures,omitempty"` // Multisig: public key (hex) -> signature (hex)
+}
+
+// AipProtocol is the AIP (author identity) protocol for an op_return, signing all the preceding data
+//
+// If the signature is empty, the draft transaction is signed using the AIP signer of the client
+type AipProtocol struct {
+	Address   string `json:"address,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// BProtocol is the B:// (file storage) protocol for an op_return
+type BProtocol struct {
+	Data      []byte `json:"data"`
+	Encoding  string `json:"encoding,omitempty"` // Default is binary
+	Filename  string `json:"filename,omitempty"`
+	MediaType string `json:"media_type"`
+}
+
+// BapProtocol is the BAP (attestation) protocol for an op_return, needs to be signed using AIP
+//
+// ATTEST and REVOKE use the hash and sequence, ID uses the identity key and address
+type BapProtocol struct {
+	Address     string `json:"address,omitempty"`
+	Hash        string `json:"hash,omitempty"`
+	IdentityKey string `json:"identity_key,omitempty"`
+	Sequence    uint64 `json:"sequence,omitempty"`
+	Type        string `json:"type"`
 }
 
 // MapProtocol is a specific MAP protocol interface for an op_return
@@ -52,6 +82,9 @@ type MapProtocol struct {
 
 // OpReturn is the op_return definition for the output
 type OpReturn struct {
+	Aip         *AipProtocol `json:"aip,omitempty"`
+	B           *BProtocol   `json:"b,omitempty"`
+	Bap         *BapProtocol `json:"bap,omitempty"`
 	Hex         string       `json:"hex,omitempty"`
 	HexParts    []string     `json:"hex_parts,omitempty"`
 	Map         *MapProtocol `json:"map,omitempty"`
@@ -103,6 +136,27 @@ const (
 	ChangeStrategyNominations ChangeStrategy = "nominations"
 )
 
+// CoinSelectionStrategy strategy to use for selecting the utxos (inputs)
+type CoinSelectionStrategy string
+
+// Types of coin selection strategies
+const (
+	// CoinSelectionStrategyDefault Coin selection strategy using the utxos in the order they are found
+	CoinSelectionStrategyDefault CoinSelectionStrategy = "default"
+
+	// CoinSelectionStrategyBranchAndBound Coin selection strategy searching for an exact match (no change output)
+	CoinSelectionStrategyBranchAndBound CoinSelectionStrategy = "branch_and_bound"
+
+	// CoinSelectionStrategyLargestFirst Coin selection strategy using the largest utxos first (the least inputs)
+	CoinSelectionStrategyLargestFirst CoinSelectionStrategy = "largest_first"
+
+	// CoinSelectionStrategyRandom Coin selection strategy using the utxos in a random order (privacy)
+	CoinSelectionStrategyRandom CoinSelectionStrategy = "random"
+
+	// CoinSelectionStrategySmallestFirst Coin selection strategy using the smallest utxos first (consolidation)
+	CoinSelectionStrategySmallestFirst CoinSelectionStrategy = "smallest_first"
+)
+
 // ScriptOutput is the actual script record (could be several for one output record)
 type ScriptOutput struct {
 	Address    string `json:"address,omitempty"`  // Hex encoded locking script
@@ -161,6 +215,11 @@ func (t *TransactionOutput) processOutput(ctx context.Context, cacheStore caches
 			return ErrOutputValueTooLow
 		}
 		return t.processPaymailOutput(ctx, cacheStore, paymailClient, defaultFromSender, defaultNote)
+	} else if utils.IsMultiSig(t.To) { // Multisig locking script
+		if checkSats && t.Satoshis <= 0 {
+			return ErrOutputValueTooLow
+		}
+		return t.processMultiSigOutput()
 	} else if len(t.To) > 0 { // Standard Bitcoin Address
 		if checkSats && t.Satoshis <= 0 {
 			return ErrOutputValueTooLow
@@ -319,6 +378,19 @@ func (t *TransactionOutput) processAddressOutput() (err error) {
 	return
 }
 
+// processMultiSigOutput will process an output to a (bare) multisig locking script
+func (t *TransactionOutput) processMultiSigOutput() error {
+	t.Scripts = append(
+		t.Scripts,
+		&ScriptOutput{
+			Satoshis:   t.Satoshis,
+			Script:     t.To,
+			ScriptType: utils.ScriptTypeMultiSig,
+		},
+	)
+	return nil
+}
+
 // processOpReturnOutput will process an op_return output
 func (t *TransactionOutput) processOpReturnOutput() (err error) {
 
@@ -359,21 +431,11 @@ func (t *TransactionOutput) processOpReturnOutput() (err error) {
 			return
 		}
 		script = s.String()
-	} else if t.OpReturn.Map != nil {
-		// strings for the map op_return
-		bytesArray := [][]byte{
-			[]byte(magic.Prefix),
-			[]byte(magic.Set),
-			[]byte(magic.MapAppKey),
-			[]byte(t.OpReturn.Map.App),
-			[]byte(magic.MapTypeKey),
-			[]byte(t.OpReturn.Map.Type),
-		}
-		if len(t.OpReturn.Map.Keys) > 0 {
-			for key, value := range t.OpReturn.Map.Keys {
-				bytesArray = append(bytesArray, []byte(key))
-				bytesArray = append(bytesArray, []byte(value.(string)))
-			}
+	} else {
+		// protocols of the op_return (B, MAP, BAP and AIP)
+		var bytesArray [][]byte
+		if bytesArray, err = t.OpReturn.getAipData(); err != nil {
+			return
 		}
 		s := &bscript.Script{}
 		_ = s.AppendOpcodes(bscript.OpFALSE, bscript.OpRETURN)
@@ -381,8 +443,6 @@ func (t *TransactionOutput) processOpReturnOutput() (err error) {
 			return
 		}
 		script = s.String()
-	} else {
-		return ErrInvalidOpReturnOutput
 	}
 
 	// Append the script
@@ -13,9 +13,9 @@ import (
 )
 
 var (
-	emptyConfigJSON = "{\"change_destinations\":[{\"created_at\":\"0001-01-01T00:00:00Z\",\"updated_at\":\"0001-01-01T00:00:00Z\",\"deleted_at\":null,\"id\":\"c775e7b757ede630cd0aa1113bd102661ab38829ca52a6422ab782862f268646\",\"xpub_id\":\"1a0b10d4eda0636aae1709e7e7080485a4d99af3ca2962c6e677cf5b53d8ab8c\",\"locking_script\":\"76a9147ff514e6ae3deb46e6644caac5cdd0bf2388906588ac\",\"type\":\"pubkeyhash\",\"chain\":1,\"num\":123,\"address\":\"1CfaQw9udYNPccssFJFZ94DN8MqNZm9nGt\",\"draft_id\":\"test-reference\"}],\"change_destinations_strategy\":\"\",\"change_minimum_satoshis\":0,\"change_number_of_destinations\":0,\"change_satoshis\":124,\"expires_in\":30000000000,\"fee\":443,\"fee_unit\":{\"satoshis\":500,\"bytes\":1000},\"from_utxos\":null,\"inputs\":null,\"miner\":\"\",\"outputs\":null,\"send_all_to\":\"\",\"sync\":null}"
+	emptyConfigJSON = "{\"change_destinations\":[{\"created_at\":\"0001-01-01T00:00:00Z\",\"updated_at\":\"0001-01-01T00:00:00Z\",\"deleted_at\":null,\"id\":\"c775e7b757ede630cd0aa1113bd102661ab38829ca52a6422ab782862f268646\",\"xpub_id\":\"1a0b10d4eda0636aae1709e7e7080485a4d99af3ca2962c6e677cf5b53d8ab8c\",\"locking_script\":\"76a9147ff514e6ae3deb46e6644caac5cdd0bf2388906588ac\",\"type\":\"pubkeyhash\",\"chain\":1,\"num\":123,\"address\":\"1CfaQw9udYNPccssFJFZ94DN8MqNZm9nGt\",\"draft_id\":\"test-reference\"}],\"change_destinations_strategy\":\"\",\"change_minimum_satoshis\":0,\"change_number_of_destinations\":0,\"change_satoshis\":124,\"coin_selection_strategy\":\"\",\"expires_in\":30000000000,\"fee\":443,\"fee_unit\":{\"satoshis\":500,\"bytes\":1000},\"from_utxos\":null,\"inputs\":null,\"lock_time\":0,\"miner\":\"\",\"outputs\":null,\"send_all_to\":\"\",\"sync\":null}"
 	opReturn        = "006a2231394878696756345179427633744870515663554551797131707a5a56646f417574324b65657020616e20657965206f6e207468697320706c61636520666f7220736f6d65204a616d696679206c6f76652e2e2e200d746578742f6d61726b646f776e055554462d38"
-	unsetConfigJSON = "{\"change_destinations\":null,\"change_destinations_strategy\":\"\",\"change_minimum_satoshis\":0,\"change_number_of_destinations\":0,\"change_satoshis\":0,\"expires_in\":0,\"fee\":0,\"fee_unit\":null,\"from_utxos\":null,\"inputs\":null,\"miner\":\"\",\"outputs\":null,\"send_all_to\":\"\",\"sync\":null}"
+	unsetConfigJSON = "{\"change_destinations\":null,\"change_destinations_strategy\":\"\",\"change_minimum_satoshis\":0,\"change_number_of_destinations\":0,\"change_satoshis\":0,\"coin_selection_strategy\":\"\",\"expires_in\":0,\"fee\":0,\"fee_unit\":null,\"from_utxos\":null,\"inputs\":null,\"lock_time\":0,\"miner\":\"\",\"outputs\":null,\"send_all_to\":\"\",\"sync\":null}"
 
 	opReturnParts = []string{
 		"31394878696756345179427633744870515663554551797131707a5a56646f417574",
@@ -151,6 +151,32 @@ func TestTransactionConfig_processAddressOutput(t *testing.T) {
 	})
 }
 
+// TestTransactionConfig_processMultiSigOutput will test the method processMultiSigOutput()
+func TestTransactionConfig_processMultiSigOutput(t *testing.T) {
+	// t.Parallel() mocking does not allow parallel tests
+
+	multiSig := "5221039e1cf9bff7c4cebbcab98f22a684a4cab10cfbf5f175c8ae3f955e1085cf1fce2102915dc096441652076efc262bf0f8c47a5988324ad09e7856b16d3b58f3738c4b2103567fcb22500d8f11858c4966d4f9ad3d43fa516525c218ffbce3498d5adae71553ae"
+
+	t.Run("multisig locking script output", func(t *testing.T) {
+		out := &TransactionOutput{
+			Satoshis: 1000,
+			To:       multiSig,
+		}
+
+		err := out.processOutput(
+			context.Background(), nil, nil,
+			defaultSenderPaymail, defaultAddressResolutionPurpose,
+			true,
+		)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(out.Scripts))
+		assert.Equal(t, multiSig, out.Scripts[0].Script)
+		assert.Equal(t, utils.ScriptTypeMultiSig, out.Scripts[0].ScriptType)
+		assert.Equal(t, uint64(1000), out.Scripts[0].Satoshis)
+	})
+}
+
 // TestTransactionConfig_processOutput will test the method processOutput()
 func TestTransactionConfig_processOutput(t *testing.T) {
 	// t.Parallel() mocking does not allow parallel tests
@@ -47,12 +47,15 @@ type Transaction struct {
 	// Model specific fields
 	XpubInIDs       IDs             `json:"xpub_in_ids,omitempty" toml:"xpub_in_ids" yaml:"xpub_in_ids" gorm:"<-:create;type:json" bson:"xpub_in_ids,omitempty"`
 	XpubOutIDs      IDs             `json:"xpub_out_ids,omitempty" toml:"xpub_out_ids" yaml:"xpub_out_ids" gorm:"<-:create;type:json" bson:"xpub_out_ids,omitempty"`
-	BlockHash       string          `json:"block_hash" toml:"block_hash" yaml:"block_hash" gorm:"<-;type:char(64);comment:This is the related block when the transaction was mined" bson:"block_hash,omitempty"`
+	BlockHash       string          `json:"block_hash" toml:"block_hash" yaml:"block_hash" gorm:"<-;type:char(64);index;comment:This is the related block when the transaction was mined" bson:"block_hash,omitempty"`
 	BlockHeight     uint64          `json:"block_height" toml:"block_height" yaml:"block_height" gorm:"<-;type:bigint;comment:This is the related block when the transaction was mined" bson:"block_height,omitempty"`
+	MerkleProof     MerkleProof     `json:"merkle_proof" toml:"merkle_proof" yaml:"merkle_proof" gorm:"<-;type:text;comment:This is the merkle proof (TSC) when the transaction was mined" bson:"merkle_proof,omitempty"`
 	Fee             uint64          `json:"fee" toml:"fee" yaml:"fee" gorm:"<-create;type:bigint" bson:"fee,omitempty"`
 	NumberOfInputs  uint32          `json:"number_of_inputs" toml:"number_of_inputs" yaml:"number_of_inputs" gorm:"<-create;type:int" bson:"number_of_inputs,omitempty"`
 	NumberOfOutputs uint32          `json:"number_of_outputs" toml:"number_of_outputs" yaml:"number_of_outputs" gorm:"<-create;type:int" bson:"number_of_outputs,omitempty"`
 	DraftID         string          `json:"draft_id" toml:"draft_id" yaml:"draft_id" gorm:"<-create;type:varchar(64);index;comment:This is the related draft id" bson:"draft_id,omitempty"`
+	ParentID        string          `json:"parent_id,omitempty" toml:"parent_id" yaml:"parent_id" gorm:"<-create;type:char(64);index;comment:This is the parent tx (child-pays-for-parent) of a fee bump" bson:"parent_id,omitempty"`
+	ConflictedAt    utils.NullTime  `json:"conflicted_at" toml:"conflicted_at" yaml:"conflicted_at" gorm:"<-;comment:When the transaction lost a double spend" bson:"conflicted_at,omitempty"`
 	TotalValue      uint64          `json:"total_value" toml:"total_value" yaml:"total_value" gorm:"<-create;type:bigint" bson:"total_value,omitempty"`
 	XpubMetadata    XpubMetadata    `json:"-" toml:"xpub_metadata" gorm:"<-;type:json;xpub_id specific metadata" bson:"xpub_metadata,omitempty"`
 	XpubOutputValue XpubOutputValue `json:"-" toml:"xpub_output_value" gorm:"<-create;type:json;xpub_id specific value" bson:"xpub_output_value,omitempty"`
@@ -158,7 +161,26 @@ func (m *Transaction) setXPubID() {
 // getTransactionsByXpubID will get all the models for a given xpub ID
 func getTransactionsByXpubID(ctx context.Context, rawXpubKey string,
 	metadata *Metadata, conditions *map[string]interface{},
-	pageSize, page int, opts ...ModelOps) ([]*Transaction, error) {
+	queryParams *datastore.QueryParams, opts ...ModelOps) ([]*Transaction, error) {
+
+	dbConditions := getTransactionsConditions(rawXpubKey, metadata, conditions)
+	return _getTransactions(ctx, dbConditions, queryParams, opts...)
+}
+
+// getTransactionsCountByXpubID will count all the transactions for the given xPub
+func getTransactionsCountByXpubID(ctx context.Context, rawXpubKey string,
+	metadata *Metadata, conditions *map[string]interface{}, opts ...ModelOps) (int64, error) {
+
+	dbConditions := getTransactionsConditions(rawXpubKey, metadata, conditions)
+	return getModelCount(
+		ctx, NewBaseModel(ModelNameEmpty, opts...).Client().Datastore(),
+		&Transaction{}, dbConditions, defaultDatabaseReadTimeout,
+	)
+}
+
+// getTransactionsConditions will build the transaction conditions for the given xPub (direction, metadata and conditions)
+func getTransactionsConditions(rawXpubKey string, metadata *Metadata,
+	conditions *map[string]interface{}) map[string]interface{} {
 
 	xPubID := utils.Hash(rawXpubKey)
 	dbConditions := map[string]interface{}{
@@ -169,9 +191,18 @@ func getTransactionsByXpubID(ctx context.Context, rawXpubKey string,
 		}},
 	}
 
+	// copy the conditions, the direction is removed (the same conditions are used for the count)
+	var txConditions map[string]interface{}
+	if conditions != nil {
+		txConditions = make(map[string]interface{}, len(*conditions))
+		for key, value := range *conditions {
+			txConditions[key] = value
+		}
+	}
+
 	// check for direction query
-	if conditions != nil && (*conditions)["direction"] != nil {
-		direction := (*conditions)["direction"].(string)
+	if txConditions["direction"] != nil {
+		direction := txConditions["direction"].(string)
 		if direction == string(TransactionDirectionIn) {
 			dbConditions["xpub_output_value"] = map[string]interface{}{
 				xPubID: map[string]interface{}{
@@ -189,7 +220,7 @@ func getTransactionsByXpubID(ctx context.Context, rawXpubKey string,
 				xPubID: 0,
 			}
 		}
-		delete(*conditions, "direction")
+		delete(txConditions, "direction")
 	}
 
 	if metadata != nil && len(*metadata) > 0 {
@@ -216,28 +247,32 @@ func getTransactionsByXpubID(ctx context.Context, rawXpubKey string,
 		dbConditions["$and"] = and
 	}
 
-	if conditions != nil && len(*conditions) > 0 {
+	if len(txConditions) > 0 {
 		and := make([]map[string]interface{}, 0)
 		if _, ok := dbConditions["$and"]; ok {
 			and = dbConditions["$and"].([]map[string]interface{})
 		}
-		and = append(and, *conditions)
+		and = append(and, txConditions)
 		dbConditions["$and"] = and
 	}
 
-	return _getTransactions(ctx, dbConditions, page, pageSize, opts...)
+	return dbConditions
 }
 
 // _getTransactions get all transactions for the given conditions
 // NOTE: this function should only be used internally, it allows to query the whole transaction table
-func _getTransactions(ctx context.Context, conditions map[string]interface{}, page, pageSize int, opts ...ModelOps) ([]*Transaction, error) {
+func _getTransactions(ctx context.Context, conditions map[string]interface{},
+	queryParams *datastore.QueryParams, opts ...ModelOps) ([]*Transaction, error) {
+	if queryParams == nil {
+		queryParams = &datastore.QueryParams{}
+	}
 	var models []Transaction
 	if err := getModels(
 		ctx, NewBaseModel(
 			ModelNameEmpty, opts...).Client().Datastore(),
 		&models, conditions,
-		pageSize, page,
-		"", "", defaultDatabaseReadTimeout,
+		queryParams.PageSize, queryParams.Page,
+		queryParams.OrderByField, queryParams.SortDirection, defaultDatabaseReadTimeout,
 	); err != nil {
 		if errors.Is(err, datastore.ErrNoResults) {
 			return nil, nil
@@ -297,6 +332,16 @@ func (m *Transaction) Save(ctx context.Context) (err error) {
 	return Save(ctx, m)
 }
 
+// Delete will (soft) delete the model in the Datastore
+func (m *Transaction) Delete(ctx context.Context) (err error) {
+	return Delete(ctx, m)
+}
+
+// Restore will restore the (soft) deleted model in the Datastore
+func (m *Transaction) Restore(ctx context.Context) (err error) {
+	return Restore(ctx, m)
+}
+
 // GetID will get the ID
 func (m *Transaction) GetID() string {
 	return m.ID
@@ -392,6 +437,14 @@ func (m *Transaction) BeforeCreating(ctx context.Context) error {
 	// Validations and broadcast config check
 	if m.draftTransaction != nil {
 
+		// Lock time and sequences need to match the draft
+		if err = m.draftTransaction.checkLockTime(m.TransactionBase.parsedTx); err != nil {
+			return err
+		}
+
+		// Link the fee bump (child) to the parent transaction
+		m.ParentID = m.draftTransaction.Configuration.ParentID
+
 		// Do we have a broadcast config? Create the new record
 		if m.draftTransaction.Configuration.Sync != nil {
 			m.syncTransaction = newSyncTransaction(
@@ -399,6 +452,18 @@ func (m *Transaction) BeforeCreating(ctx context.Context) error {
 				m.draftTransaction.Configuration.Sync,
 				m.GetOptions(true)...,
 			)
+
+			// Non-final transactions are held until the lock time has passed
+			if m.syncTransaction.BroadcastStatus == SyncStatusReady {
+				var final bool
+				if final, err = isTransactionFinal(
+					ctx, m.TransactionBase.parsedTx, m.GetOptions(false)...,
+				); err != nil {
+					return err
+				} else if !final {
+					m.syncTransaction.BroadcastStatus = SyncStatusPending
+				}
+			}
 		}
 	}
 
@@ -424,6 +489,9 @@ func (m *Transaction) BeforeCreating(ctx context.Context) error {
 		m.NumberOfOutputs = uint32(len(m.TransactionBase.parsedTx.Outputs))
 	}
 
+	// Add the protocols of the op_return outputs (B, AIP, BAP) to the metadata
+	m.processOpReturnMetadata()
+
 	m.DebugLog("end: " + m.Name() + " BeforeCreating hook")
 	return nil
 }
@@ -463,6 +531,14 @@ func (m *Transaction) AfterCreated(ctx context.Context) error {
 		}
 	}
 
+	// publish the events (transaction & spent utxos)
+	publishEvent(ctx, m.Client(), EventTypeTransactionCreated, m)
+	for index := range m.utxos {
+		if m.utxos[index].SpendingTxID.String == m.ID {
+			publishEvent(ctx, m.Client(), EventTypeUtxoSpent, &m.utxos[index])
+		}
+	}
+
 	m.DebugLog("end: " + m.Name() + " AfterCreated hook")
 	return nil
 }
@@ -665,6 +741,8 @@ func (m *Transaction) Display() interface{} {
 
 	if m.OutputValue > 0 {
 		m.Direction = TransactionDirectionIn
+	} else if m.Fee > 0 && m.OutputValue == -int64(m.Fee) {
+		m.Direction = TransactionDirectionReconcile
 	} else {
 		m.Direction = TransactionDirectionOut
 	}
@@ -13,6 +13,7 @@ import (
 	"github.com/libsv/go-bk/bip32"
 	"github.com/libsv/go-bt/v2"
 	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -129,7 +130,7 @@ func TestTransaction_getTransactionsByXpubID(t *testing.T) {
 	t.Run("tx not found", func(t *testing.T) {
 		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
 		defer deferMe()
-		transactions, err := getTransactionsByXpubID(ctx, testXPub, nil, nil, 0, 0, client.DefaultModelOptions()...)
+		transactions, err := getTransactionsByXpubID(ctx, testXPub, nil, nil, nil, client.DefaultModelOptions()...)
 		require.NoError(t, err)
 		assert.Nil(t, transactions)
 	})
@@ -142,7 +143,7 @@ func TestTransaction_getTransactionsByXpubID(t *testing.T) {
 		txErr := tx.Save(ctx)
 		require.NoError(t, txErr)
 
-		transactions, err := getTransactionsByXpubID(ctx, testXPub, nil, nil, 0, 0, opts...)
+		transactions, err := getTransactionsByXpubID(ctx, testXPub, nil, nil, nil, opts...)
 		require.NoError(t, err)
 		require.NotNil(t, transactions)
 		require.Len(t, transactions, 1)
@@ -150,6 +151,18 @@ func TestTransaction_getTransactionsByXpubID(t *testing.T) {
 		assert.Equal(t, testTxHex, transactions[0].Hex)
 		assert.Equal(t, testXPubID, transactions[0].XpubInIDs[0])
 		assert.Nil(t, transactions[0].XpubOutIDs)
+
+		var count int64
+		count, err = getTransactionsCountByXpubID(ctx, testXPub, nil, nil, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		// The direction is not removed from the conditions
+		conditions := map[string]interface{}{"direction": string(TransactionDirectionOut)}
+		count, err = getTransactionsCountByXpubID(ctx, testXPub, nil, &conditions, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+		assert.Equal(t, string(TransactionDirectionOut), conditions["direction"])
 	})
 }
 
@@ -479,6 +492,19 @@ func TestTransaction_Display(t *testing.T) {
 		assert.Nil(t, displayTx.XpubMetadata)
 		assert.Nil(t, displayTx.XpubOutputValue)
 	})
+	t.Run("reconcile", func(t *testing.T) {
+		tx := Transaction{
+			Fee: 321,
+			XpubOutputValue: XpubOutputValue{
+				testXPubID: -321,
+			},
+			xPubID: testXPubID,
+		}
+
+		displayTx := tx.Display().(*Transaction)
+		assert.Equal(t, int64(-321), displayTx.OutputValue)
+		assert.Equal(t, TransactionDirectionReconcile, displayTx.Direction)
+	})
 }
 
 // TestTransaction_Save will test the method Save()
@@ -792,4 +818,283 @@ func TestEndToEndTransaction(t *testing.T) {
 		assert.Equal(t, uint64(4903), finalTx.TotalValue)
 		assert.Equal(t, uint64(97), finalTx.Fee)
 	})
+
+	t.Run("one key, funding tx, send standard tx (server-side signing)", func(t *testing.T) {
+
+		// Get new random key (the signer holds the xPriv)
+		masterKey, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		var signer Signer
+		signer, err = NewXPrivSigner(masterKey.String())
+		require.NoError(t, err)
+
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+			WithSigner(signer),
+		)
+		defer deferMe()
+
+		var rawXPub string
+		rawXPub, err = bitcoin.GetExtendedPublicKey(masterKey)
+		require.NoError(t, err)
+		_, err = client.NewXpub(ctx, rawXPub)
+		require.NoError(t, err)
+
+		// Fund the xPub
+		var destination *Destination
+		destination, err = client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+		_, err = client.RecordTransaction(ctx, rawXPub,
+			CreateFakeFundingTransaction(t, masterKey, []*Destination{destination}, 10000), "",
+		)
+		require.NoError(t, err)
+
+		// Send to an external address (draft, sign, record & broadcast)
+		var transaction *Transaction
+		transaction, err = client.SendTransaction(ctx, rawXPub, &TransactionConfig{
+			Outputs: []*TransactionOutput{{
+				Satoshis: 5000,
+				To:       "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND",
+			}},
+		}, nil)
+		require.NoError(t, err)
+		require.NotNil(t, transaction)
+		assert.NotEmpty(t, transaction.DraftID)
+		assert.Equal(t, uint64(97), transaction.Fee)
+
+		// Was broadcast
+		var syncTx *SyncTransaction
+		syncTx, err = getSyncTransactionByID(ctx, transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, syncTx)
+		assert.Equal(t, SyncStatusComplete, syncTx.BroadcastStatus)
+
+		// The input is signed with the key of the destination
+		var tx *bt.Tx
+		tx, err = bt.NewTxFromString(transaction.Hex)
+		require.NoError(t, err)
+		require.Len(t, tx.Inputs, 1)
+		var lockingScript *bscript.Script
+		lockingScript, err = bscript.NewFromHexString(destination.LockingScript)
+		require.NoError(t, err)
+		err = interpreter.NewEngine().Execute(
+			interpreter.WithTx(tx, 0, &bt.Output{LockingScript: lockingScript, Satoshis: 10000}),
+			interpreter.WithForkID(),
+			interpreter.WithAfterGenesis(),
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("send with a lock time (broadcast held until final)", func(t *testing.T) {
+		masterKey, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		var signer Signer
+		signer, err = NewXPrivSigner(masterKey.String())
+		require.NoError(t, err)
+
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+			WithSigner(signer),
+		)
+		defer deferMe()
+
+		var rawXPub string
+		rawXPub, err = bitcoin.GetExtendedPublicKey(masterKey)
+		require.NoError(t, err)
+		_, err = client.NewXpub(ctx, rawXPub)
+		require.NoError(t, err)
+
+		var destination *Destination
+		destination, err = client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+		_, err = client.RecordTransaction(ctx, rawXPub,
+			CreateFakeFundingTransaction(t, masterKey, []*Destination{destination}, 10000), "",
+		)
+		require.NoError(t, err)
+
+		// Locked until the block height of the chain tip
+		headers := loadTestBlockHeaders(t)
+		tip := headers[len(headers)-1]
+
+		var transaction *Transaction
+		transaction, err = client.SendTransaction(ctx, rawXPub, &TransactionConfig{
+			LockTime: uint32(tip.Height),
+			Outputs: []*TransactionOutput{{
+				Satoshis: 5000,
+				To:       "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND",
+			}},
+		}, nil)
+		require.NoError(t, err)
+		require.NotNil(t, transaction)
+
+		var tx *bt.Tx
+		tx, err = bt.NewTxFromString(transaction.Hex)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(tip.Height), tx.LockTime)
+		require.Len(t, tx.Inputs, 1)
+		assert.Equal(t, bt.DefaultSequenceNumber-1, tx.Inputs[0].SequenceNumber)
+
+		// Not final (no block headers synced), not broadcast
+		var syncTx *SyncTransaction
+		syncTx, err = getSyncTransactionByID(ctx, transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, syncTx)
+		assert.Equal(t, SyncStatusPending, syncTx.BroadcastStatus)
+
+		require.NoError(t, processBroadcastTransactions(ctx, 10, client.DefaultModelOptions()...))
+		syncTx, err = getSyncTransactionByID(ctx, transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, SyncStatusPending, syncTx.BroadcastStatus)
+
+		// Chain tip reached the lock time, broadcast by the task
+		for _, header := range headers {
+			require.NoError(t, newBlockHeader(header, append(client.DefaultModelOptions(), New())...).Save(ctx))
+		}
+		require.NoError(t, processBroadcastTransactions(ctx, 10, client.DefaultModelOptions()...))
+		syncTx, err = getSyncTransactionByID(ctx, transaction.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, SyncStatusComplete, syncTx.BroadcastStatus)
+	})
+
+	t.Run("record with a lock time or sequence that does not match the draft", func(t *testing.T) {
+		masterKey, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		var signer Signer
+		signer, err = NewXPrivSigner(masterKey.String())
+		require.NoError(t, err)
+
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+		)
+		defer deferMe()
+
+		var rawXPub string
+		rawXPub, err = bitcoin.GetExtendedPublicKey(masterKey)
+		require.NoError(t, err)
+		_, err = client.NewXpub(ctx, rawXPub)
+		require.NoError(t, err)
+
+		var destination *Destination
+		destination, err = client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+		_, err = client.RecordTransaction(ctx, rawXPub,
+			CreateFakeFundingTransaction(t, masterKey, []*Destination{destination}, 10000), "",
+		)
+		require.NoError(t, err)
+
+		var utxos []*Utxo
+		utxos, err = GetSpendableUtxos(
+			ctx, utils.Hash(rawXPub), utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+
+		// Custom sequence for the input
+		sequence := uint32(10)
+		var draftTransaction *DraftTransaction
+		draftTransaction, err = client.NewTransaction(ctx, rawXPub, &TransactionConfig{
+			FromUtxos: []*UtxoPointer{{
+				OutputIndex:   utxos[0].OutputIndex,
+				Sequence:      &sequence,
+				TransactionID: utxos[0].TransactionID,
+			}},
+			LockTime: 1000,
+			Outputs: []*TransactionOutput{{
+				Satoshis: 5000,
+				To:       "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND",
+			}},
+		}, nil)
+		require.NoError(t, err)
+		require.Len(t, draftTransaction.Configuration.Inputs, 1)
+		assert.Equal(t, sequence, draftTransaction.Configuration.Inputs[0].Sequence)
+
+		var txHex string
+		txHex, err = signer.SignDraftTransaction(ctx, draftTransaction)
+		require.NoError(t, err)
+
+		var tx *bt.Tx
+		tx, err = bt.NewTxFromString(txHex)
+		require.NoError(t, err)
+		tx.LockTime = 0
+		_, err = client.RecordTransaction(ctx, rawXPub, tx.String(), draftTransaction.ID)
+		require.ErrorIs(t, err, ErrLockTimeMismatch)
+
+		tx.LockTime = 1000
+		tx.Inputs[0].SequenceNumber = bt.DefaultSequenceNumber
+		_, err = client.RecordTransaction(ctx, rawXPub, tx.String(), draftTransaction.ID)
+		require.ErrorIs(t, err, ErrSequenceMismatch)
+	})
+
+	t.Run("send that fails to record cancels the draft", func(t *testing.T) {
+		masterKey, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		var signer Signer
+		signer, err = NewXPrivSigner(masterKey.String())
+		require.NoError(t, err)
+
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+			WithRateLimit(RateLimitRecord, 1, time.Minute),
+			WithSigner(signer),
+		)
+		defer deferMe()
+
+		var rawXPub string
+		rawXPub, err = bitcoin.GetExtendedPublicKey(masterKey)
+		require.NoError(t, err)
+		_, err = client.NewXpub(ctx, rawXPub)
+		require.NoError(t, err)
+
+		var destination *Destination
+		destination, err = client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+		_, err = client.RecordTransaction(ctx, rawXPub,
+			CreateFakeFundingTransaction(t, masterKey, []*Destination{destination}, 10000), "",
+		)
+		require.NoError(t, err)
+
+		// Request without a scope that used its record rate limit (see allowRequest)
+		requestCtx := context.WithValue(ctx, rateLimitIDKey, utils.Hash(rawXPub))
+		require.NoError(t, client.Allow(requestCtx, utils.Hash(rawXPub), RateLimitRecord))
+
+		config := &TransactionConfig{
+			Outputs: []*TransactionOutput{{
+				Satoshis: 5000,
+				To:       "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND",
+			}},
+		}
+		_, err = client.SendTransaction(requestCtx, rawXPub, config, nil)
+		require.ErrorIs(t, err, ErrRateLimitExceeded)
+
+		// The config of the caller is not changed
+		assert.Nil(t, config.Sync)
+
+		// The utxo is not reserved by the canceled draft
+		var utxos []*Utxo
+		utxos, err = GetSpendableUtxos(
+			ctx, utils.Hash(rawXPub), utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		assert.Len(t, utxos, 1)
+	})
+
+	t.Run("send without a signer", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true)
+		defer deferMe()
+
+		_, err := client.SendTransaction(ctx, testXPub, &TransactionConfig{}, nil)
+		require.ErrorIs(t, err, ErrMissingSigner)
+	})
 }
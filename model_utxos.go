@@ -3,9 +3,11 @@ package bux
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/BuxOrg/bux/datastore"
+	"github.com/BuxOrg/bux/taskmanager"
 	"github.com/BuxOrg/bux/utils"
 	"github.com/pkg/errors"
 )
@@ -32,8 +34,9 @@ type Utxo struct {
 
 // UtxoPointer is a pointer to a utxo
 type UtxoPointer struct {
-	TransactionID string `json:"transaction_id" toml:"transaction_id" yaml:"transaction_id" gorm:"<-:create;type:char(64);index;comment:This is the id of the related transaction" bson:"transaction_id"`
-	OutputIndex   uint32 `json:"output_index" toml:"output_index" yaml:"output_index" gorm:"<-:create;type:uint;comment:This is the index of the output in the transaction" bson:"output_index"`
+	TransactionID string  `json:"transaction_id" toml:"transaction_id" yaml:"transaction_id" gorm:"<-:create;type:char(64);index;comment:This is the id of the related transaction" bson:"transaction_id"`
+	OutputIndex   uint32  `json:"output_index" toml:"output_index" yaml:"output_index" gorm:"<-:create;type:uint;comment:This is the index of the output in the transaction" bson:"output_index"`
+	Sequence      *uint32 `json:"sequence,omitempty" toml:"sequence" yaml:"sequence" gorm:"-" bson:"-"` // Sequence of the input (when spending this utxo)
 }
 
 // newUtxo will start a new utxo model
@@ -56,17 +59,22 @@ func GetSpendableUtxos(ctx context.Context, xPubID, utxoType string, fromUtxos [
 	conditions := map[string]interface{}{
 		xPubIDField:       xPubID,
 		typeField:         utxoType,
+		deletedAtField:    nil,
 		draftIDField:      nil,
 		spendingTxIDField: nil,
 	}
 
 	if fromUtxos != nil {
 		for _, fromUtxo := range fromUtxos {
-			utxo, err := getUtxo(ctx, fromUtxo.TransactionID, fromUtxo.OutputIndex, opts...)
+			utxo, err := getUtxo(
+				ctx, fromUtxo.TransactionID, fromUtxo.OutputIndex, append(opts, WithDeleted())...,
+			)
 			if err != nil {
 				return nil, err
+			} else if utxo == nil {
+				return nil, ErrMissingUtxo
 			}
-			if utxo.XpubID != xPubID || utxo.SpendingTxID.Valid {
+			if utxo.XpubID != xPubID || utxo.SpendingTxID.Valid || utxo.DeletedAt.Valid {
 				return nil, ErrUtxoAlreadySpent
 			}
 			models = append(models, *utxo)
@@ -128,12 +136,20 @@ func UnReserveUtxos(ctx context.Context, xPubID, draftID string, opts ...ModelOp
 }
 
 // ReserveUtxos reserve utxos for the given draft ID and amount
-func ReserveUtxos(ctx context.Context, xPubID, draftID string,
-	satoshis uint64, feePerByte float64, fromUtxos []*UtxoPointer, opts ...ModelOps) ([]*Utxo, error) {
+//
+// The utxos are selected using the coin selection strategy (empty is the default strategy)
+func ReserveUtxos(ctx context.Context, xPubID, draftID string, satoshis uint64, feePerByte float64,
+	fromUtxos []*UtxoPointer, strategy CoinSelectionStrategy, opts ...ModelOps) ([]*Utxo, error) {
 
 	// Create base model
 	m := NewBaseModel(ModelNameEmpty, opts...)
 
+	// Get the coin selector
+	selector := m.Client().CoinSelector(strategy)
+	if selector == nil {
+		return nil, ErrUnknownCoinSelectionStrategy
+	}
+
 	// Create the lock and set the release for after the function completes
 	unlock, err := newWaitWriteLock(
 		ctx, "model-utxos-reserve-utxos-"+xPubID, m.Client().Cachestore(),
@@ -144,7 +160,6 @@ func ReserveUtxos(ctx context.Context, xPubID, draftID string,
 	}
 
 	// Get spendable utxos
-	// todo improve this to not Get all utxos - make smarter
 	var freeUtxos []*Utxo
 	if freeUtxos, err = GetSpendableUtxos(
 		ctx, xPubID, utils.ScriptTypePubKeyHash, fromUtxos, opts..., // todo: allow reservation of utxos by a different utxo destination type
@@ -152,13 +167,19 @@ func ReserveUtxos(ctx context.Context, xPubID, draftID string,
 		return nil, err
 	}
 
-	// Set vars
+	// Select the utxos (the fee for each new input is added to the satoshis needed)
 	size := utils.GetInputSizeForType(utils.ScriptTypePubKeyHash)
-	feeNeeded := uint64(0)
+	if freeUtxos, err = selector.SelectCoins(
+		freeUtxos, satoshis, uint64(math.Ceil(float64(size)*feePerByte)),
+	); err != nil {
+		return nil, err
+	}
+
+	// Set vars
 	reservedSatoshis := uint64(0)
 	utxos := new([]*Utxo)
 
-	// Loop the returned utxos
+	// Loop the selected utxos
 	for _, utxo := range freeUtxos {
 
 		// Set the values on the UTXO
@@ -178,12 +199,6 @@ func ReserveUtxos(ctx context.Context, xPubID, draftID string,
 
 		// Add the utxo to the final slice
 		*utxos = append(*utxos, utxo)
-
-		// add fee for this new input
-		feeNeeded += uint64(float64(size) * feePerByte)
-		if reservedSatoshis >= (satoshis + feeNeeded) {
-			break
-		}
 	}
 
 	if reservedSatoshis < satoshis {
@@ -209,13 +224,30 @@ func newUtxoFromTxID(txID string, index uint32, opts ...ModelOps) *Utxo {
 	}
 }
 
-// getUtxosByXpubID
-func getUtxosByXpubID(ctx context.Context, xpubID string, pageSize, page int, orderByField,
-	sortDirection string, opts ...ModelOps) ([]*Utxo, error) {
+// getUtxosByXpubID will get the utxos for the given xPubID
+func getUtxosByXpubID(ctx context.Context, xpubID string, queryParams *datastore.QueryParams,
+	opts ...ModelOps) ([]*Utxo, error) {
+	if queryParams == nil {
+		queryParams = &datastore.QueryParams{}
+	}
 	conditions := map[string]interface{}{
 		xPubIDField: xpubID,
 	}
-	return getUtxosByConditions(ctx, conditions, pageSize, page, orderByField, sortDirection, opts...)
+	return getUtxosByConditions(
+		ctx, conditions, queryParams.PageSize, queryParams.Page,
+		queryParams.OrderByField, queryParams.SortDirection, opts...,
+	)
+}
+
+// getUtxosCountByXpubID will count the utxos for the given xPubID
+func getUtxosCountByXpubID(ctx context.Context, xpubID string, opts ...ModelOps) (int64, error) {
+	conditions := map[string]interface{}{
+		xPubIDField: xpubID,
+	}
+	return getModelCount(
+		ctx, NewBaseModel(ModelNameEmpty, opts...).Client().Datastore(),
+		&Utxo{}, conditions, databaseLongReadTimeout,
+	)
 }
 
 // getUtxosByDraftID
@@ -288,6 +320,16 @@ func (m *Utxo) Save(ctx context.Context) (err error) {
 	return Save(ctx, m)
 }
 
+// Delete will (soft) delete the model in the Datastore
+func (m *Utxo) Delete(ctx context.Context) (err error) {
+	return Delete(ctx, m)
+}
+
+// Restore will restore the (soft) deleted model in the Datastore
+func (m *Utxo) Restore(ctx context.Context) (err error) {
+	return Restore(ctx, m)
+}
+
 // GetID will get the ID
 func (m *Utxo) GetID() string {
 	if m.ID == "" {
@@ -350,6 +392,43 @@ func (m *Utxo) GenerateID() string {
 	return utils.Hash(fmt.Sprintf("%s|%d", m.TransactionID, m.OutputIndex))
 }
 
+// RegisterTasks will register the model specific tasks on client initialization
+func (m *Utxo) RegisterTasks() error {
+
+	// No task manager loaded?
+	tm := m.Client().Taskmanager()
+	if tm == nil {
+		return nil
+	}
+
+	// Register the task locally (cron task - set the defaults)
+	consolidateTask := m.Name() + "_consolidate"
+	ctx := context.Background()
+
+	// Register the task
+	if err := tm.RegisterTask(&taskmanager.Task{
+		Name:       consolidateTask,
+		RetryLimit: 1,
+		Handler: func(client *Client) error {
+			if taskErr := TaskConsolidateUtxos(
+				ctx, client.Logger(), client.options.utxos.consolidation, WithClient(client),
+			); taskErr != nil {
+				client.Logger().Error(ctx, "error running "+consolidateTask+" task: "+taskErr.Error())
+			}
+			return nil
+		},
+	}); err != nil {
+		return err
+	}
+
+	// Run the task periodically
+	return tm.RunTask(ctx, &taskmanager.TaskOptions{
+		Arguments:      []interface{}{m.Client()},
+		RunEveryPeriod: m.Client().GetTaskPeriod(consolidateTask),
+		TaskName:       consolidateTask,
+	})
+}
+
 // Migrate model specific migration on startup
 func (m *Utxo) Migrate(client datastore.ClientInterface) error {
 	return client.IndexMetadata(client.GetTableName(tableUTXOs), metadataField)
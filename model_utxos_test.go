@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/BuxOrg/bux/datastore"
 	"github.com/BuxOrg/bux/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -36,6 +37,17 @@ func createTestUtxos(ctx context.Context, client ClientInterface) {
 	_ = _utxo4.Save(ctx)
 }
 
+// coinSelectorLastFirst will select the last utxo first
+type coinSelectorLastFirst struct{}
+
+func (s *coinSelectorLastFirst) SelectCoins(utxos []*Utxo, satoshis, feePerInput uint64) ([]*Utxo, error) {
+	reversed := make([]*Utxo, 0, len(utxos))
+	for i := len(utxos) - 1; i >= 0; i-- {
+		reversed = append(reversed, utxos[i])
+	}
+	return selectCoinsInOrder(reversed, satoshis, feePerInput), nil
+}
+
 // TestUtxo_newUtxo will test the method newUtxo()
 func TestUtxo_newUtxo(t *testing.T) {
 	t.Parallel()
@@ -99,12 +111,16 @@ func TestUtxo_getUtxosByXpubID(t *testing.T) {
 
 		utxos, err := getUtxosByXpubID(
 			ctx, testXPubID,
-			0, 0,
-			"", "",
+			nil,
 			client.DefaultModelOptions()...,
 		)
 		assert.NoError(t, err)
 		assert.Nil(t, utxos)
+
+		var count int64
+		count, err = getUtxosCountByXpubID(ctx, testXPubID, client.DefaultModelOptions()...)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), count)
 	})
 
 	t.Run("getUtxos", func(t *testing.T) {
@@ -114,12 +130,51 @@ func TestUtxo_getUtxosByXpubID(t *testing.T) {
 
 		utxos, err := getUtxosByXpubID(
 			ctx, testXPubID,
-			0, 0,
-			"", "",
+			nil,
 			client.DefaultModelOptions()...,
 		)
 		assert.NoError(t, err)
 		assert.Len(t, utxos, 5)
+
+		var count int64
+		count, err = getUtxosCountByXpubID(ctx, testXPubID, client.DefaultModelOptions()...)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), count)
+	})
+
+	t.Run("getUtxos paged and sorted", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		createTestUtxos(ctx, client)
+
+		utxos, err := getUtxosByXpubID(
+			ctx, testXPubID,
+			&datastore.QueryParams{
+				Page:          1,
+				PageSize:      2,
+				OrderByField:  "output_index",
+				SortDirection: datastore.SortDesc,
+			},
+			client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.Len(t, utxos, 2)
+		assert.Equal(t, uint32(16), utxos[0].OutputIndex)
+		assert.Equal(t, uint32(15), utxos[1].OutputIndex)
+
+		utxos, err = getUtxosByXpubID(
+			ctx, testXPubID,
+			&datastore.QueryParams{
+				Page:          3,
+				PageSize:      2,
+				OrderByField:  "output_index",
+				SortDirection: datastore.SortDesc,
+			},
+			client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+		assert.Equal(t, uint32(12), utxos[0].OutputIndex)
 	})
 }
 
@@ -137,7 +192,7 @@ func TestUtxo_UnReserveUtxos(t *testing.T) {
 		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
 		defer deferMe()
 		createTestUtxos(ctx, client)
-		utxos, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, nil, client.DefaultModelOptions()...)
+		utxos, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, nil, "", client.DefaultModelOptions()...)
 		require.NoError(t, err)
 		assert.Len(t, utxos, 2)
 		for _, utxo := range utxos {
@@ -166,7 +221,7 @@ func TestUtxo_ReserveUtxos(t *testing.T) {
 		defer deferMe()
 		createTestUtxos(ctx, client)
 
-		utxos, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 1000, 0.5, nil, client.DefaultModelOptions()...)
+		utxos, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 1000, 0.5, nil, "", client.DefaultModelOptions()...)
 		require.NoError(t, err)
 		assert.Len(t, utxos, 1)
 		assert.Equal(t, testDraftID2, utxos[0].DraftID.String)
@@ -178,7 +233,7 @@ func TestUtxo_ReserveUtxos(t *testing.T) {
 		defer deferMe()
 		createTestUtxos(ctx, client)
 
-		utxos, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, nil, client.DefaultModelOptions()...)
+		utxos, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, nil, "", client.DefaultModelOptions()...)
 		require.NoError(t, err)
 		assert.Len(t, utxos, 2)
 		assert.Equal(t, testDraftID2, utxos[0].DraftID.String)
@@ -192,7 +247,7 @@ func TestUtxo_ReserveUtxos(t *testing.T) {
 		defer deferMe()
 		createTestUtxos(ctx, client)
 
-		_, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 20000, 0.5, nil, client.DefaultModelOptions()...)
+		_, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 20000, 0.5, nil, "", client.DefaultModelOptions()...)
 		require.Error(t, err, ErrNotEnoughUtxos)
 	})
 
@@ -205,7 +260,7 @@ func TestUtxo_ReserveUtxos(t *testing.T) {
 			TransactionID: testTxID,
 			OutputIndex:   16,
 		}}
-		utxos, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 1000, 0.5, fromUtxos, client.DefaultModelOptions()...)
+		utxos, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 1000, 0.5, fromUtxos, "", client.DefaultModelOptions()...)
 		require.NoError(t, err)
 		assert.Len(t, utxos, 1)
 		assert.Equal(t, testDraftID2, utxos[0].DraftID.String)
@@ -226,7 +281,7 @@ func TestUtxo_ReserveUtxos(t *testing.T) {
 			TransactionID: testTxID,
 			OutputIndex:   16,
 		}}
-		utxos, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, fromUtxos, client.DefaultModelOptions()...)
+		utxos, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, fromUtxos, "", client.DefaultModelOptions()...)
 		require.NoError(t, err)
 		assert.Len(t, utxos, 2)
 		assert.Equal(t, testDraftID2, utxos[0].DraftID.String)
@@ -248,9 +303,33 @@ func TestUtxo_ReserveUtxos(t *testing.T) {
 			TransactionID: testTxID,
 			OutputIndex:   16,
 		}}
-		_, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, fromUtxos, client.DefaultModelOptions()...)
+		_, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, fromUtxos, "", client.DefaultModelOptions()...)
 		require.Error(t, err, ErrNotEnoughUtxos)
 	})
+
+	t.Run("reserve unknown strategy", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		createTestUtxos(ctx, client)
+
+		_, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 1000, 0.5, nil, "unknown", client.DefaultModelOptions()...)
+		require.ErrorIs(t, err, ErrUnknownCoinSelectionStrategy)
+	})
+
+	t.Run("reserve custom strategy", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, false,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCoinSelector("last_first", &coinSelectorLastFirst{}),
+		)
+		defer deferMe()
+		createTestUtxos(ctx, client)
+
+		utxos, err := ReserveUtxos(ctx, testXPubID, testDraftID2, 1000, 0.5, nil, "last_first", client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+		assert.Equal(t, uint32(16), utxos[0].OutputIndex)
+	})
 }
 
 // TestUtxo_GetSpendableUtxos get spendable utxos
@@ -266,14 +345,14 @@ func TestUtxo_GetSpendableUtxos(t *testing.T) {
 		require.NoError(t, err)
 		assert.Len(t, utxos, 5)
 
-		_, err = ReserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, nil, opts...)
+		_, err = ReserveUtxos(ctx, testXPubID, testDraftID2, 2000, 0.5, nil, "", opts...)
 		require.NoError(t, err)
 
 		utxos, err = GetSpendableUtxos(ctx, testXPubID, utils.ScriptTypePubKeyHash, nil, opts...)
 		require.NoError(t, err)
 		assert.Len(t, utxos, 3)
 
-		_, err = ReserveUtxos(ctx, testXPubID, testDraftID3, 1000, 0.5, nil, opts...)
+		_, err = ReserveUtxos(ctx, testXPubID, testDraftID3, 1000, 0.5, nil, "", opts...)
 		require.NoError(t, err)
 
 		utxos, err = GetSpendableUtxos(ctx, testXPubID, utils.ScriptTypePubKeyHash, nil, opts...)
@@ -94,6 +94,40 @@ func (m *Xpub) Save(ctx context.Context) error {
 	return Save(ctx, m)
 }
 
+// Delete will (soft) delete the model in the Datastore
+func (m *Xpub) Delete(ctx context.Context) error {
+	return Delete(ctx, m)
+}
+
+// Restore will restore the (soft) deleted model in the Datastore
+//
+// The destinations, access keys and paymail addresses that were deleted with the xPub are restored as well
+func (m *Xpub) Restore(ctx context.Context) error {
+	if !m.IsDeleted() {
+		return nil
+	}
+
+	// Restore the xPub
+	deletedAt := m.DeletedAt.Time
+	if err := Restore(ctx, m); err != nil {
+		return err
+	}
+
+	// Restore the related models (deleted at the same time or after the xPub)
+	relatedModels, err := m.getRelatedModels(ctx, map[string]interface{}{
+		"$gte": deletedAt,
+	})
+	if err != nil {
+		return err
+	}
+	for _, model := range relatedModels {
+		if err = model.Restore(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetID will get the ID
 func (m *Xpub) GetID() string {
 	return m.ID
@@ -226,6 +260,73 @@ func (m *Xpub) AfterUpdated(ctx context.Context) error {
 	return nil
 }
 
+// AfterDeleted will fire after the model is deleted in the Datastore
+//
+// The destinations, access keys and paymail addresses of the xPub are deleted as well
+func (m *Xpub) AfterDeleted(ctx context.Context) error {
+	m.DebugLog("starting: " + m.Name() + " AfterDeleted hook...")
+
+	// Delete the (active) related models
+	relatedModels, err := m.getRelatedModels(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, model := range relatedModels {
+		if err = model.Delete(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Store in the cache (if enabled)
+	if err = saveToCache(
+		ctx, fmt.Sprintf("%s-id-%s", m.GetModelName(), m.GetID()), m, 0,
+	); err != nil {
+		return err
+	}
+
+	m.DebugLog("end: " + m.Name() + " AfterDeleted hook")
+	return nil
+}
+
+// getRelatedModels will get the destinations, access keys and paymail addresses of the xPub
+//
+// deletedAt is the condition on the deleted_at field of the related models (nil is not deleted)
+func (m *Xpub) getRelatedModels(ctx context.Context, deletedAt interface{}) ([]ModelInterface, error) {
+	var destinations []Destination
+	var accessKeys []AccessKey
+	var paymailAddresses []PaymailAddress
+
+	// Get the records
+	for _, models := range []interface{}{&destinations, &accessKeys, &paymailAddresses} {
+		if err := getModels(
+			ctx, m.Client().Datastore(), models, map[string]interface{}{
+				xPubIDField:    m.ID,
+				deletedAtField: deletedAt,
+			}, 0, 0, "", "", defaultDatabaseReadTimeout,
+		); err != nil && !errors.Is(err, datastore.ErrNoResults) {
+			return nil, err
+		}
+	}
+
+	// Loop and enrich
+	opts := m.GetOptions(false)
+	relatedModels := make([]ModelInterface, 0)
+	for index := range destinations {
+		destinations[index].enrich(ModelDestination, opts...)
+		relatedModels = append(relatedModels, &destinations[index])
+	}
+	for index := range accessKeys {
+		accessKeys[index].enrich(ModelAccessKey, opts...)
+		relatedModels = append(relatedModels, &accessKeys[index])
+	}
+	for index := range paymailAddresses {
+		paymailAddresses[index].enrich(ModelPaymailAddress, opts...)
+		relatedModels = append(relatedModels, &paymailAddresses[index])
+	}
+
+	return relatedModels, nil
+}
+
 // Migrate model specific migration on startup
 func (m *Xpub) Migrate(client datastore.ClientInterface) error {
 	return client.IndexMetadata(client.GetTableName(tableXPubs), metadataField)
@@ -248,6 +248,85 @@ func TestXpub_AfterUpdated(t *testing.T) {
 	})
 }
 
+// TestClient_DeleteXpub will test the methods DeleteXpub() and RestoreXpub()
+func TestClient_DeleteXpub(t *testing.T) {
+
+	t.Run("delete and restore with related models", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, xPub, rawXPub := CreateNewXPub(ctx, t, client)
+		destination, err := client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+
+		// Deleted before the xPub (not restored with the xPub)
+		var deletedDestination *Destination
+		deletedDestination, err = client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+		require.NoError(t, client.DeleteDestination(ctx, rawXPub, deletedDestination.ID))
+
+		var accessKey *AccessKey
+		accessKey, err = client.NewAccessKey(ctx, rawXPub, nil, 0)
+		require.NoError(t, err)
+
+		// Delete the xPub
+		require.NoError(t, client.DeleteXpub(ctx, rawXPub))
+
+		var found *Xpub
+		found, err = client.GetXpubByID(ctx, xPub.ID)
+		require.ErrorIs(t, err, ErrMissingXpub)
+		require.Nil(t, found)
+
+		var count int64
+		count, err = client.GetDestinationsCount(ctx, rawXPub, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+
+		var key *AccessKey
+		key, err = GetAccessKey(ctx, accessKey.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Nil(t, key)
+
+		// Restore the xPub
+		found, err = client.RestoreXpub(ctx, rawXPub)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.False(t, found.IsDeleted())
+
+		found, err = client.GetXpubByID(ctx, xPub.ID)
+		require.NoError(t, err)
+		assert.Equal(t, xPub.ID, found.ID)
+
+		var destinations []*Destination
+		destinations, err = client.GetDestinations(ctx, rawXPub, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, destinations, 1)
+		assert.Equal(t, destination.ID, destinations[0].ID)
+
+		key, err = GetAccessKey(ctx, accessKey.ID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, key)
+		assert.False(t, key.IsDeleted())
+	})
+
+	t.Run("error - missing xPub", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		err := client.DeleteXpub(ctx, testXPub)
+		require.ErrorIs(t, err, ErrMissingXpub)
+
+		var xPub *Xpub
+		xPub, err = client.RestoreXpub(ctx, testXPub)
+		require.ErrorIs(t, err, ErrMissingXpub)
+		require.Nil(t, xPub)
+	})
+}
+
 // TestXpub_Save will test the method Save()
 func (ts *EmbeddedDBTestSuite) TestXpub_Save() {
 
@@ -25,10 +25,11 @@ type Model struct {
 	DeletedAt utils.NullTime `json:"deleted_at" toml:"deleted_at" yaml:"deleted_at" gorm:"index;comment:The time the record was marked as deleted" bson:"deleted_at,omitempty"`
 
 	// Private fields
-	client     ClientInterface // Interface of the parent Client that loaded this bux model
-	name       ModelName       // Name of model (table name)
-	newRecord  bool            // Determine if the record is new (create vs update)
-	rawXpubKey string          // Used on "CREATE" on some instances
+	client         ClientInterface // Interface of the parent Client that loaded this bux model
+	includeDeleted bool            // Include the (soft) deleted record when getting the model
+	name           ModelName       // Name of model (table name)
+	newRecord      bool            // Determine if the record is new (create vs update)
+	rawXpubKey     string          // Used on "CREATE" on some instances
 }
 
 // ModelInterface is the interface that all models share
@@ -41,11 +42,13 @@ type ModelInterface interface {
 	ChildModels() []ModelInterface
 	Client() ClientInterface
 	DebugLog(text string)
+	Delete(ctx context.Context) (err error)
 	Display() interface{}
 	GetID() string
 	GetModelName() string
 	GetModelTableName() string
 	GetOptions(isNewRecord bool) (opts []ModelOps)
+	IsDeleted() bool
 	IsNew() bool
 	Migrate(client datastore.ClientInterface) error
 	Name() string
@@ -53,7 +56,9 @@ type ModelInterface interface {
 	NotNew()
 	RawXpub() string
 	RegisterTasks() error
+	Restore(ctx context.Context) (err error)
 	Save(ctx context.Context) (err error)
+	SetDeletedTime(deleted bool, recordTime time.Time)
 	SetOptions(opts ...ModelOps)
 	SetRecordTime(bool)
 }
@@ -62,6 +62,16 @@ func (m *Model) GetOptions(isNewRecord bool) (opts []ModelOps) {
 	return
 }
 
+// IsDeleted returns true if the model is (soft) deleted
+func (m *Model) IsDeleted() bool {
+	return m.DeletedAt.Valid
+}
+
+// includesDeleted returns true if the (soft) deleted record is included when getting the model
+func (m *Model) includesDeleted() bool {
+	return m.includeDeleted
+}
+
 // IsNew returns true if the model is (or was) a new record
 func (m *Model) IsNew() bool {
 	return m.newRecord
@@ -96,6 +106,15 @@ func (m *Model) SetRecordTime(created bool) {
 	}
 }
 
+// SetDeletedTime will mark the record as deleted (or restored) at the given time
+func (m *Model) SetDeletedTime(deleted bool, recordTime time.Time) {
+	m.DeletedAt.Valid = deleted
+	if deleted {
+		m.DeletedAt.Time = recordTime
+	}
+	m.UpdatedAt = recordTime
+}
+
 // SetOptions will set the options on the model
 func (m *Model) SetOptions(opts ...ModelOps) {
 	for _, opt := range opts {
@@ -0,0 +1,210 @@
+package bux
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/sighash"
+)
+
+// SignMultiSigDraft will create the (partial) signatures of a cosigner for the multisig inputs of the draft
+//
+// The signatures (hex) are in the same order as the inputs of the draft, empty if the xPriv is not a cosigner
+// of the input. The signatures are added to the draft using AddDraftSignatures().
+func SignMultiSigDraft(draft *DraftTransaction, rawXPriv string) ([]string, error) {
+
+	xPriv, err := bitcoin.GenerateHDKeyFromString(rawXPriv)
+	if err != nil {
+		return nil, err
+	} else if !xPriv.IsPrivate() {
+		return nil, ErrMissingXPriv
+	}
+
+	var tx *bt.Tx
+	if tx, err = draft.getInputsTx(); err != nil {
+		return nil, err
+	}
+
+	signatures := make([]string, len(tx.Inputs))
+	for index, input := range draft.Configuration.Inputs {
+		if input.Type != utils.ScriptTypeMultiSig {
+			continue
+		}
+
+		// Derive the key of the destination (same chain/num for all cosigners)
+		var key *bip32.ExtendedKey
+		if key, err = xPriv.Child(input.Destination.Chain); err != nil {
+			return nil, err
+		} else if key, err = key.Child(input.Destination.Num); err != nil {
+			return nil, err
+		}
+
+		var privateKey *bec.PrivateKey
+		if privateKey, err = bitcoin.GetPrivateKeyFromHDKey(key); err != nil {
+			return nil, err
+		}
+
+		// Not a cosigner of this input
+		var pubKeys [][]byte
+		if _, pubKeys, err = utils.ParseMultiSig(input.ScriptPubKey); err != nil {
+			return nil, err
+		} else if !containsPubKey(pubKeys, privateKey.PubKey().SerialiseCompressed()) {
+			continue
+		}
+
+		var hash []byte
+		if hash, err = tx.CalcInputSignatureHash(uint32(index), sighash.AllForkID); err != nil {
+			return nil, err
+		}
+
+		var signature *bec.Signature
+		if signature, err = privateKey.Sign(hash); err != nil {
+			return nil, err
+		}
+		signatures[index] = hex.EncodeToString(append(signature.Serialise(), byte(sighash.AllForkID)))
+	}
+
+	return signatures, nil
+}
+
+// getInputsTx will parse the draft hex and set the previous outputs on the inputs (needed for the signature hash)
+func (m *DraftTransaction) getInputsTx() (*bt.Tx, error) {
+	tx, err := bt.NewTxFromString(m.Hex)
+	if err != nil {
+		return nil, err
+	} else if len(tx.Inputs) != len(m.Configuration.Inputs) {
+		return nil, ErrDraftInputNotFound
+	}
+
+	// Inputs of the draft are in the same order as the inputs of the transaction
+	for index, input := range m.Configuration.Inputs {
+		if tx.Inputs[index].PreviousTxScript, err = bscript.NewFromHexString(
+			input.ScriptPubKey,
+		); err != nil {
+			return nil, err
+		}
+		tx.Inputs[index].PreviousTxSatoshis = input.Satoshis
+	}
+
+	return tx, nil
+}
+
+// addMultiSigSignatures will verify and add the signatures of the cosigner (xPub) to the multisig inputs
+func (m *DraftTransaction) addMultiSigSignatures(hdKey *bip32.ExtendedKey, signatures []string) error {
+	if len(signatures) != len(m.Configuration.Inputs) {
+		return ErrDraftSignaturesMismatch
+	}
+
+	tx, err := m.getInputsTx()
+	if err != nil {
+		return err
+	}
+
+	for index, input := range m.Configuration.Inputs {
+		if len(signatures[index]) == 0 {
+			continue
+		} else if input.Type != utils.ScriptTypeMultiSig {
+			return ErrNotMultiSigInput
+		}
+
+		// The public key of the cosigner needs to be in the multisig script
+		var pubKey *bec.PublicKey
+		if pubKey, err = utils.DerivePublicKey(
+			hdKey, input.Destination.Chain, input.Destination.Num,
+		); err != nil {
+			return err
+		}
+		var pubKeys [][]byte
+		if _, pubKeys, err = utils.ParseMultiSig(input.ScriptPubKey); err != nil {
+			return err
+		} else if !containsPubKey(pubKeys, pubKey.SerialiseCompressed()) {
+			return ErrNotMultiSigCosigner
+		}
+
+		// Verify the signature (DER + sighash flag)
+		var signature []byte
+		if signature, err = hex.DecodeString(signatures[index]); err != nil || len(signature) < 2 ||
+			sighash.Flag(signature[len(signature)-1]) != sighash.AllForkID {
+			return ErrSignatureInvalid
+		}
+		var sig *bec.Signature
+		if sig, err = bec.ParseDERSignature(signature[:len(signature)-1], bec.S256()); err != nil {
+			return ErrSignatureInvalid
+		}
+		var hash []byte
+		if hash, err = tx.CalcInputSignatureHash(uint32(index), sighash.AllForkID); err != nil {
+			return err
+		} else if !sig.Verify(hash, pubKey) {
+			return ErrSignatureInvalid
+		}
+
+		if input.Signatures == nil {
+			input.Signatures = make(map[string]string)
+		}
+		input.Signatures[hex.EncodeToString(pubKey.SerialiseCompressed())] = signatures[index]
+	}
+
+	return nil
+}
+
+// getMultiSigTxHex will return the signed transaction hex, if all the multisig inputs have the required signatures
+func (m *DraftTransaction) getMultiSigTxHex() (string, error) {
+	tx, err := bt.NewTxFromString(m.Hex)
+	if err != nil {
+		return "", err
+	} else if len(tx.Inputs) != len(m.Configuration.Inputs) {
+		return "", ErrDraftInputNotFound
+	}
+
+	for index, input := range m.Configuration.Inputs {
+		if input.Type != utils.ScriptTypeMultiSig {
+			return "", ErrNotMultiSigInput
+		}
+
+		var required int
+		var pubKeys [][]byte
+		if required, pubKeys, err = utils.ParseMultiSig(input.ScriptPubKey); err != nil {
+			return "", err
+		}
+
+		// OP_0 [signatures...] (in the order of the public keys)
+		unlockingScript := &bscript.Script{}
+		if err = unlockingScript.AppendOpcodes(bscript.OpZERO); err != nil {
+			return "", err
+		}
+		signed := 0
+		for _, pubKey := range pubKeys {
+			signature, ok := input.Signatures[hex.EncodeToString(pubKey)]
+			if !ok || signed == required {
+				continue
+			}
+			if err = unlockingScript.AppendPushDataHexString(signature); err != nil {
+				return "", err
+			}
+			signed++
+		}
+		if signed < required {
+			return "", ErrMissingMultiSigSignatures
+		}
+
+		tx.Inputs[index].UnlockingScript = unlockingScript
+	}
+
+	return tx.String(), nil
+}
+
+// containsPubKey will return true if the public key is in the list of public keys
+func containsPubKey(pubKeys [][]byte, pubKey []byte) bool {
+	for _, key := range pubKeys {
+		if bytes.Equal(key, pubKey) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+package bux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestMultiSigDestination will create 3 xPubs and a funded 2-of-3 multisig destination (of the first xPub)
+func createTestMultiSigDestination(ctx context.Context, t *testing.T,
+	client ClientInterface) ([]*bip32.ExtendedKey, []string, *Destination) {
+
+	var masterKeys []*bip32.ExtendedKey
+	var rawXPubs []string
+	for i := 0; i < 3; i++ {
+		masterKey, _, rawXPub := CreateNewXPub(ctx, t, client)
+		masterKeys = append(masterKeys, masterKey)
+		rawXPubs = append(rawXPubs, rawXPub)
+	}
+
+	destination, err := client.NewMultiSigDestination(ctx, rawXPubs, 2, utils.ChainExternal, nil)
+	require.NoError(t, err)
+	require.NotNil(t, destination)
+
+	_, err = client.RecordTransaction(ctx, rawXPubs[0],
+		CreateFakeFundingTransaction(t, masterKeys[0], []*Destination{destination}, 10000), "",
+	)
+	require.NoError(t, err)
+
+	return masterKeys, rawXPubs, destination
+}
+
+// TestClient_NewMultiSigDestination will test the method NewMultiSigDestination()
+func TestClient_NewMultiSigDestination(t *testing.T) {
+
+	t.Run("invalid number of required signatures", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, err := client.NewMultiSigDestination(ctx, []string{testXPub}, 2, utils.ChainExternal, nil)
+		require.ErrorIs(t, err, utils.ErrInvalidMultiSig)
+	})
+
+	t.Run("unknown xpub", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		_, err := client.NewMultiSigDestination(ctx, []string{rawXPub, testXPub}, 2, utils.ChainExternal, nil)
+		require.ErrorIs(t, err, ErrMissingXpub)
+	})
+
+	t.Run("duplicate xpub", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		_, _, otherXPub := CreateNewXPub(ctx, t, client)
+		_, err := client.NewMultiSigDestination(
+			ctx, []string{rawXPub, otherXPub, rawXPub}, 2, utils.ChainExternal, nil,
+		)
+		require.ErrorIs(t, err, ErrDuplicateMultiSigXpub)
+	})
+
+	t.Run("2-of-3", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		var rawXPubs []string
+		for i := 0; i < 3; i++ {
+			_, _, rawXPub := CreateNewXPub(ctx, t, client)
+			rawXPubs = append(rawXPubs, rawXPub)
+		}
+
+		metadata := map[string]interface{}{"treasury": true}
+		destination, err := client.NewMultiSigDestination(ctx, rawXPubs, 2, utils.ChainExternal, &metadata)
+		require.NoError(t, err)
+		require.NotNil(t, destination)
+		assert.Equal(t, utils.Hash(rawXPubs[0]), destination.XpubID)
+		assert.Equal(t, utils.ScriptTypeMultiSig, destination.Type)
+		assert.Equal(t, uint32(0), destination.Num)
+		assert.Empty(t, destination.Address)
+		assert.Equal(t, true, destination.Metadata["treasury"])
+
+		// The public keys of the xPubs (in order) at the same chain/num
+		required, pubKeys, err := utils.ParseMultiSig(destination.LockingScript)
+		require.NoError(t, err)
+		assert.Equal(t, 2, required)
+		require.Len(t, pubKeys, 3)
+		for index, rawXPub := range rawXPubs {
+			hdKey, hdErr := utils.ValidateXPub(rawXPub)
+			require.NoError(t, hdErr)
+			pubKey, keyErr := utils.DerivePublicKey(hdKey, utils.ChainExternal, 0)
+			require.NoError(t, keyErr)
+			assert.Equal(t, pubKey.SerialiseCompressed(), pubKeys[index])
+		}
+
+		// Next destination uses the next num of the first xPub
+		destination, err = client.NewMultiSigDestination(ctx, rawXPubs, 2, utils.ChainExternal, nil)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), destination.Num)
+	})
+}
+
+// TestClient_MultiSigTransaction will test spending a multisig destination (partial signature collection)
+func TestClient_MultiSigTransaction(t *testing.T) {
+
+	t.Run("2-of-3 draft, signatures and record", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+		)
+		defer deferMe()
+		masterKeys, rawXPubs, destination := createTestMultiSigDestination(ctx, t, client)
+
+		utxos, err := GetSpendableUtxos(
+			ctx, utils.Hash(rawXPubs[0]), utils.ScriptTypeMultiSig, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+
+		// Draft spending the multisig utxo (change back to the multisig destination)
+		var draft *DraftTransaction
+		draft, err = client.NewTransaction(ctx, rawXPubs[0], &TransactionConfig{
+			FromUtxos: []*UtxoPointer{{
+				OutputIndex:   utxos[0].OutputIndex,
+				TransactionID: utxos[0].TransactionID,
+			}},
+			Outputs: []*TransactionOutput{{
+				Satoshis: 5000,
+				To:       "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND",
+			}},
+		}, nil)
+		require.NoError(t, err)
+		require.Len(t, draft.Configuration.Inputs, 1)
+		require.Len(t, draft.Configuration.Outputs, 2)
+		assert.Equal(t, destination.LockingScript, draft.Configuration.Outputs[1].Scripts[0].Script)
+		assert.Equal(t, uint64(118), draft.Configuration.Fee) // 2-of-3 multisig input is 190 bytes
+
+		// Cosigner #1 signs
+		var signatures []string
+		signatures, err = SignMultiSigDraft(draft, masterKeys[0].String())
+		require.NoError(t, err)
+		require.Len(t, signatures, 1)
+		_, err = client.AddDraftSignatures(ctx, rawXPubs[0], draft.ID, signatures)
+		require.NoError(t, err)
+
+		// Not enough signatures yet
+		_, err = client.RecordMultiSigTransaction(ctx, rawXPubs[0], draft.ID)
+		require.ErrorIs(t, err, ErrMissingMultiSigSignatures)
+
+		// Signature of cosigner #3 added by cosigner #2 (not the signer)
+		signatures, err = SignMultiSigDraft(draft, masterKeys[2].String())
+		require.NoError(t, err)
+		_, err = client.AddDraftSignatures(ctx, rawXPubs[1], draft.ID, signatures)
+		require.ErrorIs(t, err, ErrSignatureInvalid)
+
+		// Cosigner #3 signs
+		_, err = client.AddDraftSignatures(ctx, rawXPubs[2], draft.ID, signatures)
+		require.NoError(t, err)
+
+		var transaction *Transaction
+		transaction, err = client.RecordMultiSigTransaction(ctx, rawXPubs[0], draft.ID)
+		require.NoError(t, err)
+		require.NotNil(t, transaction)
+		assert.Equal(t, draft.ID, transaction.DraftID)
+
+		// The unlocking script is valid (2-of-3)
+		var tx *bt.Tx
+		tx, err = bt.NewTxFromString(transaction.Hex)
+		require.NoError(t, err)
+		var lockingScript *bscript.Script
+		lockingScript, err = bscript.NewFromHexString(destination.LockingScript)
+		require.NoError(t, err)
+		err = interpreter.NewEngine().Execute(
+			interpreter.WithTx(tx, 0, &bt.Output{LockingScript: lockingScript, Satoshis: 10000}),
+			interpreter.WithForkID(),
+			interpreter.WithAfterGenesis(),
+		)
+		require.NoError(t, err)
+
+		// Change is a new multisig utxo of the first xPub
+		utxos, err = GetSpendableUtxos(
+			ctx, utils.Hash(rawXPubs[0]), utils.ScriptTypeMultiSig, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+		assert.Equal(t, transaction.ID, utxos[0].TransactionID)
+	})
+
+	t.Run("mixed multisig and other inputs", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+		)
+		defer deferMe()
+		masterKeys, rawXPubs, _ := createTestMultiSigDestination(ctx, t, client)
+
+		destination, err := client.NewDestination(
+			ctx, rawXPubs[0], utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+		var funding *Transaction
+		funding, err = client.RecordTransaction(ctx, rawXPubs[0],
+			CreateFakeFundingTransaction(t, masterKeys[0], []*Destination{destination}, 10000), "",
+		)
+		require.NoError(t, err)
+
+		var utxos []*Utxo
+		utxos, err = GetSpendableUtxos(
+			ctx, utils.Hash(rawXPubs[0]), utils.ScriptTypeMultiSig, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+
+		_, err = client.NewTransaction(ctx, rawXPubs[0], &TransactionConfig{
+			FromUtxos: []*UtxoPointer{{
+				OutputIndex:   utxos[0].OutputIndex,
+				TransactionID: utxos[0].TransactionID,
+			}, {
+				OutputIndex:   0,
+				TransactionID: funding.ID,
+			}},
+			Outputs: []*TransactionOutput{{
+				Satoshis: 15000,
+				To:       "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND",
+			}},
+		}, nil)
+		require.ErrorIs(t, err, ErrMixedMultiSigInputs)
+
+		// Nothing was reserved
+		utxos, err = GetSpendableUtxos(
+			ctx, utils.Hash(rawXPubs[0]), utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		assert.Len(t, utxos, 1)
+	})
+
+	t.Run("not a cosigner", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+		)
+		defer deferMe()
+		masterKeys, rawXPubs, _ := createTestMultiSigDestination(ctx, t, client)
+
+		utxos, err := GetSpendableUtxos(
+			ctx, utils.Hash(rawXPubs[0]), utils.ScriptTypeMultiSig, nil, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.Len(t, utxos, 1)
+
+		var draft *DraftTransaction
+		draft, err = client.NewTransaction(ctx, rawXPubs[0], &TransactionConfig{
+			FromUtxos: []*UtxoPointer{{
+				OutputIndex:   utxos[0].OutputIndex,
+				TransactionID: utxos[0].TransactionID,
+			}},
+			SendAllTo: "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND",
+		}, nil)
+		require.NoError(t, err)
+
+		var signatures []string
+		signatures, err = SignMultiSigDraft(draft, masterKeys[0].String())
+		require.NoError(t, err)
+
+		_, _, otherXPub := CreateNewXPub(ctx, t, client)
+		_, err = client.AddDraftSignatures(ctx, otherXPub, draft.ID, signatures)
+		require.ErrorIs(t, err, ErrNotMultiSigCosigner)
+
+		_, err = client.AddDraftSignatures(ctx, rawXPubs[0], draft.ID, nil)
+		require.ErrorIs(t, err, ErrDraftSignaturesMismatch)
+	})
+}
@@ -0,0 +1,296 @@
+package bux
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	magic "github.com/bitcoinschema/go-map"
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// Protocols of the op_return outputs (Bitcom)
+const (
+	aipAlgorithm      = "BITCOIN_ECDSA"                      // Only supported AIP signing algorithm
+	aipPrefix         = "15PciHG22SNLQJXMoSUaWVi7WSqc7hCfva" // AIP (author identity)
+	bPrefix           = "19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut" // B:// (file storage)
+	bapPrefix         = "1BAPSuaPnfGnSBM3GLV9yhxUdYe4vGbdMT" // BAP (attestation)
+	defaultBEncoding  = "binary"                             // Default encoding of the B:// data
+	protocolSeparator = "|"                                  // Separator of the protocols in the op_return
+
+	// Metadata keys of the parsed protocols
+	metadataAipKey = "aip"
+	metadataBKey   = "b"
+	metadataBapKey = "bap"
+)
+
+// Types of BAP (attestation) transactions
+const (
+	// BapTypeAttest is the attestation of an (hashed) identity attribute
+	BapTypeAttest = "ATTEST"
+
+	// BapTypeID is the linking of an address to an identity key
+	BapTypeID = "ID"
+
+	// BapTypeRevoke is the revocation of an attestation
+	BapTypeRevoke = "REVOKE"
+)
+
+// AipSigner will sign the AIP (author identity) of an op_return output of a draft transaction
+//
+// The message is the op_return data that precedes the AIP protocol. The returned address is the
+// (compressed) address of the signing key and the signature is a Bitcoin Signed Message (base64).
+type AipSigner interface {
+	SignAip(ctx context.Context, xPubID string, message []byte) (address, signature string, err error)
+}
+
+// aipKeySigner is an AIP signer using a single private key (for all xPubs)
+type aipKeySigner struct {
+	address    string
+	privateKey string
+}
+
+// NewAipKeySigner will return an AIP signer for the given private key (hex)
+func NewAipKeySigner(privateKey string) (AipSigner, error) {
+	address, err := bitcoin.GetAddressFromPrivateKeyString(privateKey, true)
+	if err != nil {
+		return nil, err
+	}
+	return &aipKeySigner{address: address, privateKey: privateKey}, nil
+}
+
+// SignAip will sign the message using the private key
+func (s *aipKeySigner) SignAip(_ context.Context, _ string, message []byte) (string, string, error) {
+	signature, err := bitcoin.SignMessage(s.privateKey, string(message), true)
+	if err != nil {
+		return "", "", err
+	}
+	return s.address, signature, nil
+}
+
+// signAip will sign the AIP protocol of the op_return (if not already signed) using the signer
+func (o *OpReturn) signAip(ctx context.Context, signer AipSigner, xPubID string) error {
+	if o.Aip == nil || len(o.Aip.Signature) > 0 {
+		return nil
+	} else if signer == nil {
+		return ErrMissingAipSigner
+	}
+
+	data, err := o.getProtocolData()
+	if err != nil {
+		return err
+	}
+
+	o.Aip.Address, o.Aip.Signature, err = signer.SignAip(ctx, xPubID, getAipMessage(data))
+	return err
+}
+
+// getProtocolData will return the op_return data (pushes) of the B, MAP and BAP protocols (excluding AIP)
+func (o *OpReturn) getProtocolData() ([][]byte, error) {
+	var segments [][][]byte
+
+	// B:// <data> <media type> <encoding> [filename]
+	if o.B != nil {
+		if len(o.B.Data) == 0 || len(o.B.MediaType) == 0 {
+			return nil, ErrInvalidOpReturnOutput
+		}
+		encoding := o.B.Encoding
+		if len(encoding) == 0 {
+			encoding = defaultBEncoding
+		}
+		segment := [][]byte{[]byte(bPrefix), o.B.Data, []byte(o.B.MediaType), []byte(encoding)}
+		if len(o.B.Filename) > 0 {
+			segment = append(segment, []byte(o.B.Filename))
+		}
+		segments = append(segments, segment)
+	}
+
+	// MAP SET app <app> type <type> [key value...] (keys are sorted)
+	if o.Map != nil {
+		segment := [][]byte{
+			[]byte(magic.Prefix),
+			[]byte(magic.Set),
+			[]byte(magic.MapAppKey),
+			[]byte(o.Map.App),
+			[]byte(magic.MapTypeKey),
+			[]byte(o.Map.Type),
+		}
+		keys := make([]string, 0, len(o.Map.Keys))
+		for key := range o.Map.Keys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok := o.Map.Keys[key].(string)
+			if !ok {
+				return nil, ErrInvalidOpReturnOutput
+			}
+			segment = append(segment, []byte(key), []byte(value))
+		}
+		segments = append(segments, segment)
+	}
+
+	// BAP ATTEST|REVOKE <hash> <sequence> or BAP ID <identity key> <address> (needs to be signed by AIP)
+	if o.Bap != nil {
+		if o.Aip == nil {
+			return nil, ErrInvalidBapProtocol
+		}
+		segment := [][]byte{[]byte(bapPrefix), []byte(o.Bap.Type)}
+		switch o.Bap.Type {
+		case BapTypeAttest, BapTypeRevoke:
+			if len(o.Bap.Hash) == 0 {
+				return nil, ErrInvalidBapProtocol
+			}
+			segment = append(segment, []byte(o.Bap.Hash), []byte(strconv.FormatUint(o.Bap.Sequence, 10)))
+		case BapTypeID:
+			if len(o.Bap.IdentityKey) == 0 || len(o.Bap.Address) == 0 {
+				return nil, ErrInvalidBapProtocol
+			}
+			segment = append(segment, []byte(o.Bap.IdentityKey), []byte(o.Bap.Address))
+		default:
+			return nil, ErrInvalidBapProtocol
+		}
+		segments = append(segments, segment)
+	}
+
+	if len(segments) == 0 {
+		return nil, ErrInvalidOpReturnOutput
+	}
+
+	data := segments[0]
+	for _, segment := range segments[1:] {
+		data = append(data, []byte(protocolSeparator))
+		data = append(data, segment...)
+	}
+	return data, nil
+}
+
+// getAipData will return the op_return data of all the protocols, including the (verified) AIP signature
+func (o *OpReturn) getAipData() ([][]byte, error) {
+	data, err := o.getProtocolData()
+	if err != nil || o.Aip == nil {
+		return data, err
+	}
+
+	if len(o.Aip.Address) == 0 || len(o.Aip.Signature) == 0 ||
+		bitcoin.VerifyMessage(o.Aip.Address, o.Aip.Signature, string(getAipMessage(data))) != nil {
+		return nil, ErrInvalidAipSignature
+	}
+
+	return append(data,
+		[]byte(protocolSeparator),
+		[]byte(aipPrefix),
+		[]byte(aipAlgorithm),
+		[]byte(o.Aip.Address),
+		[]byte(o.Aip.Signature),
+	), nil
+}
+
+// getAipMessage will return the message signed by AIP (OP_RETURN + all the preceding data)
+func getAipMessage(data [][]byte) []byte {
+	message := []byte{bscript.OpRETURN}
+	for _, part := range data {
+		message = append(message, part...)
+	}
+	return message
+}
+
+// getOpReturnProtocols will parse the B, AIP and BAP protocols of an op_return locking script
+//
+// The protocols are returned as metadata (key per protocol), nil if the script has none of the protocols
+func getOpReturnProtocols(lockingScript []byte) Metadata {
+
+	// Only (OP_FALSE) OP_RETURN outputs
+	if len(lockingScript) > 1 && lockingScript[0] == bscript.OpFALSE && lockingScript[1] == bscript.OpRETURN {
+		lockingScript = lockingScript[2:]
+	} else if len(lockingScript) > 0 && lockingScript[0] == bscript.OpRETURN {
+		lockingScript = lockingScript[1:]
+	} else {
+		return nil
+	}
+
+	parts, err := bscript.DecodeParts(lockingScript)
+	if err != nil {
+		return nil
+	}
+
+	protocols := make(Metadata)
+	start := 0
+	for index := 0; index <= len(parts); index++ {
+		if index < len(parts) && !bytes.Equal(parts[index], []byte(protocolSeparator)) {
+			continue
+		}
+		if segment := parts[start:index]; len(segment) > 1 {
+			switch string(segment[0]) {
+			case bPrefix:
+				if len(segment) >= 4 {
+					b := map[string]interface{}{
+						"encoding":   string(segment[3]),
+						"media_type": string(segment[2]),
+						"size":       len(segment[1]),
+					}
+					if len(segment) >= 5 {
+						b["filename"] = string(segment[4])
+					}
+					protocols[metadataBKey] = b
+				}
+			case bapPrefix:
+				if len(segment) >= 4 {
+					bap := map[string]interface{}{"type": string(segment[1])}
+					if string(segment[1]) == BapTypeID {
+						bap["identity_key"] = string(segment[2])
+						bap["address"] = string(segment[3])
+					} else {
+						bap["hash"] = string(segment[2])
+						bap["sequence"] = string(segment[3])
+					}
+					protocols[metadataBapKey] = bap
+				}
+			case aipPrefix:
+				if len(segment) >= 4 && start > 0 {
+					// The signature is of all the data before the separator of AIP
+					address, signature := string(segment[2]), string(segment[3])
+					protocols[metadataAipKey] = map[string]interface{}{
+						"address":   address,
+						"algorithm": string(segment[1]),
+						"signature": signature,
+						"valid": string(segment[1]) == aipAlgorithm && bitcoin.VerifyMessage(
+							address, signature, string(getAipMessage(parts[:start-1])),
+						) == nil,
+					}
+				}
+			}
+		}
+		start = index + 1
+	}
+
+	if len(protocols) == 0 {
+		return nil
+	}
+	return protocols
+}
+
+// processOpReturnMetadata will add the protocols (B, AIP, BAP) of the op_return outputs to the metadata
+//
+// Existing metadata keys are never overwritten
+func (m *Transaction) processOpReturnMetadata() {
+	if m.TransactionBase.parsedTx == nil {
+		return
+	}
+
+	for _, output := range m.TransactionBase.parsedTx.Outputs {
+		if output.LockingScript == nil {
+			continue
+		}
+		for key, value := range getOpReturnProtocols(*output.LockingScript) {
+			if m.Metadata == nil {
+				m.Metadata = make(Metadata)
+			}
+			if _, ok := m.Metadata[key]; !ok {
+				m.Metadata[key] = value
+			}
+		}
+	}
+}
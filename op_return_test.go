@@ -0,0 +1,284 @@
+package bux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMapOpReturnScript is a MAP op_return (no B, AIP or BAP)
+const testMapOpReturnScript = "006a223150755161374b36324d694b43747373534c4b79316b683536575755374d74555235035345540361707008746f6e6963706f7704747970650b6f666665725f636c69636b"
+
+// newTestAipSigner will return an AIP signer using a new private key
+func newTestAipSigner(t *testing.T) AipSigner {
+	privateKey, err := bitcoin.CreatePrivateKeyString()
+	require.NoError(t, err)
+	var signer AipSigner
+	signer, err = NewAipKeySigner(privateKey)
+	require.NoError(t, err)
+	return signer
+}
+
+// getTestOpReturnScript will return the locking script (bytes) of the op_return
+func getTestOpReturnScript(t *testing.T, opReturn *OpReturn) []byte {
+	output := &TransactionOutput{OpReturn: opReturn}
+	require.NoError(t, output.processOpReturnOutput())
+	require.Len(t, output.Scripts, 1)
+	script, err := bscript.NewFromHexString(output.Scripts[0].Script)
+	require.NoError(t, err)
+	return *script
+}
+
+// TestOpReturn_getProtocolData will test the method getProtocolData()
+func TestOpReturn_getProtocolData(t *testing.T) {
+
+	t.Run("no protocols", func(t *testing.T) {
+		_, err := (&OpReturn{}).getProtocolData()
+		require.ErrorIs(t, err, ErrInvalidOpReturnOutput)
+	})
+
+	t.Run("b (default encoding)", func(t *testing.T) {
+		data, err := (&OpReturn{B: &BProtocol{
+			Data:      []byte("# hello"),
+			Filename:  "hello.md",
+			MediaType: "text/markdown",
+		}}).getProtocolData()
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{
+			[]byte(bPrefix), []byte("# hello"), []byte("text/markdown"), []byte(defaultBEncoding), []byte("hello.md"),
+		}, data)
+	})
+
+	t.Run("b missing media type", func(t *testing.T) {
+		_, err := (&OpReturn{B: &BProtocol{Data: []byte("hello")}}).getProtocolData()
+		require.ErrorIs(t, err, ErrInvalidOpReturnOutput)
+	})
+
+	t.Run("b and map", func(t *testing.T) {
+		data, err := (&OpReturn{
+			B:   &BProtocol{Data: []byte("hello"), Encoding: "utf-8", MediaType: "text/plain"},
+			Map: &MapProtocol{App: "bux", Type: "post", Keys: map[string]interface{}{"b": "2", "a": "1"}},
+		}).getProtocolData()
+		require.NoError(t, err)
+		require.Len(t, data, 15)
+		assert.Equal(t, []byte(protocolSeparator), data[4])
+		assert.Equal(t, [][]byte{[]byte("a"), []byte("1"), []byte("b"), []byte("2")}, data[11:])
+	})
+
+	t.Run("bap without aip", func(t *testing.T) {
+		_, err := (&OpReturn{Bap: &BapProtocol{Type: BapTypeAttest, Hash: "hash"}}).getProtocolData()
+		require.ErrorIs(t, err, ErrInvalidBapProtocol)
+	})
+
+	t.Run("bap invalid", func(t *testing.T) {
+		_, err := (&OpReturn{Aip: &AipProtocol{}, Bap: &BapProtocol{Type: BapTypeID}}).getProtocolData()
+		require.ErrorIs(t, err, ErrInvalidBapProtocol)
+
+		_, err = (&OpReturn{Aip: &AipProtocol{}, Bap: &BapProtocol{Type: "UNKNOWN", Hash: "hash"}}).getProtocolData()
+		require.ErrorIs(t, err, ErrInvalidBapProtocol)
+	})
+
+	t.Run("bap attest", func(t *testing.T) {
+		data, err := (&OpReturn{Aip: &AipProtocol{}, Bap: &BapProtocol{
+			Hash:     "cf39fc55da24dc23eff1809e6e6cf32a0fe6aecc81296543e9ac84b8c501bac5",
+			Sequence: 1,
+			Type:     BapTypeAttest,
+		}}).getProtocolData()
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{
+			[]byte(bapPrefix),
+			[]byte(BapTypeAttest),
+			[]byte("cf39fc55da24dc23eff1809e6e6cf32a0fe6aecc81296543e9ac84b8c501bac5"),
+			[]byte("1"),
+		}, data)
+	})
+}
+
+// TestOpReturn_signAip will test the method signAip()
+func TestOpReturn_signAip(t *testing.T) {
+
+	t.Run("missing signer", func(t *testing.T) {
+		opReturn := &OpReturn{Aip: &AipProtocol{}, B: &BProtocol{Data: []byte("hello"), MediaType: "text/plain"}}
+		require.ErrorIs(t, opReturn.signAip(context.Background(), nil, testXPubID), ErrMissingAipSigner)
+	})
+
+	t.Run("not signed (invalid)", func(t *testing.T) {
+		output := &TransactionOutput{OpReturn: &OpReturn{
+			Aip: &AipProtocol{}, B: &BProtocol{Data: []byte("hello"), MediaType: "text/plain"},
+		}}
+		require.ErrorIs(t, output.processOpReturnOutput(), ErrInvalidAipSignature)
+	})
+
+	t.Run("sign and verify", func(t *testing.T) {
+		opReturn := &OpReturn{Aip: &AipProtocol{}, B: &BProtocol{Data: []byte("hello"), MediaType: "text/plain"}}
+		require.NoError(t, opReturn.signAip(context.Background(), newTestAipSigner(t), testXPubID))
+		assert.NotEmpty(t, opReturn.Aip.Address)
+		assert.NotEmpty(t, opReturn.Aip.Signature)
+
+		data, err := opReturn.getAipData()
+		require.NoError(t, err)
+		require.Len(t, data, 9)
+		assert.Equal(t, []byte(aipPrefix), data[5])
+
+		// Changing the data invalidates the signature
+		opReturn.B.Data = []byte("hello world")
+		_, err = opReturn.getAipData()
+		require.ErrorIs(t, err, ErrInvalidAipSignature)
+	})
+}
+
+// Test_getOpReturnProtocols will test the method getOpReturnProtocols()
+func Test_getOpReturnProtocols(t *testing.T) {
+
+	t.Run("not an op_return", func(t *testing.T) {
+		script, err := bscript.NewFromHexString(testLockingScript)
+		require.NoError(t, err)
+		assert.Nil(t, getOpReturnProtocols(*script))
+	})
+
+	t.Run("no known protocols", func(t *testing.T) {
+		script, err := bscript.NewFromHexString(testMapOpReturnScript)
+		require.NoError(t, err)
+		assert.Nil(t, getOpReturnProtocols(*script))
+	})
+
+	t.Run("b", func(t *testing.T) {
+		script, err := bscript.NewFromHexString(opReturn)
+		require.NoError(t, err)
+		assert.Equal(t, Metadata{metadataBKey: map[string]interface{}{
+			"encoding":   "UTF-8",
+			"media_type": "text/markdown",
+			"size":       50,
+		}}, getOpReturnProtocols(*script))
+	})
+
+	t.Run("b, bap and aip", func(t *testing.T) {
+		opReturn := &OpReturn{
+			Aip: &AipProtocol{},
+			B:   &BProtocol{Data: []byte("hello"), Filename: "hello.txt", MediaType: "text/plain"},
+			Bap: &BapProtocol{Type: BapTypeID, IdentityKey: "identity-key", Address: "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND"},
+		}
+		require.NoError(t, opReturn.signAip(context.Background(), newTestAipSigner(t), testXPubID))
+
+		protocols := getOpReturnProtocols(getTestOpReturnScript(t, opReturn))
+		require.NotNil(t, protocols)
+		assert.Equal(t, map[string]interface{}{
+			"encoding":   defaultBEncoding,
+			"filename":   "hello.txt",
+			"media_type": "text/plain",
+			"size":       5,
+		}, protocols[metadataBKey])
+		assert.Equal(t, map[string]interface{}{
+			"address":      "1LVvLTwaHc7WzKsS5naRov7j3bqQctPPND",
+			"identity_key": "identity-key",
+			"type":         BapTypeID,
+		}, protocols[metadataBapKey])
+		assert.Equal(t, map[string]interface{}{
+			"address":   opReturn.Aip.Address,
+			"algorithm": aipAlgorithm,
+			"signature": opReturn.Aip.Signature,
+			"valid":     true,
+		}, protocols[metadataAipKey])
+	})
+
+	t.Run("aip signature of other data", func(t *testing.T) {
+		opReturn := &OpReturn{Aip: &AipProtocol{}, B: &BProtocol{Data: []byte("hello"), MediaType: "text/plain"}}
+		require.NoError(t, opReturn.signAip(context.Background(), newTestAipSigner(t), testXPubID))
+
+		// Signature of another op_return
+		other := &OpReturn{Aip: &AipProtocol{}, B: &BProtocol{Data: []byte("other"), MediaType: "text/plain"}}
+		require.NoError(t, other.signAip(context.Background(), newTestAipSigner(t), testXPubID))
+		script := getTestOpReturnScript(t, opReturn)
+		parts, err := bscript.DecodeParts(script[2:])
+		require.NoError(t, err)
+		parts[len(parts)-2] = []byte(other.Aip.Address)
+		parts[len(parts)-1] = []byte(other.Aip.Signature)
+		script = []byte{bscript.OpFALSE, bscript.OpRETURN}
+		var pushes []byte
+		pushes, err = bscript.EncodeParts(parts)
+		require.NoError(t, err)
+
+		protocols := getOpReturnProtocols(append(script, pushes...))
+		require.NotNil(t, protocols)
+		assert.Equal(t, false, protocols[metadataAipKey].(map[string]interface{})["valid"])
+	})
+}
+
+// TestClient_NewTransaction_aip will test signing the AIP of op_return outputs using the AIP signer of the client
+func TestClient_NewTransaction_aip(t *testing.T) {
+
+	t.Run("missing aip signer", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		_, err := client.NewXpub(ctx, testXPub)
+		require.NoError(t, err)
+		createTestUtxos(ctx, client)
+
+		_, err = client.NewTransaction(ctx, testXPub, &TransactionConfig{
+			Outputs: []*TransactionOutput{{OpReturn: &OpReturn{
+				Aip: &AipProtocol{},
+				B:   &BProtocol{Data: []byte("hello"), MediaType: "text/plain"},
+			}}},
+		}, nil)
+		require.ErrorIs(t, err, ErrMissingAipSigner)
+	})
+
+	t.Run("signed and parsed into the metadata", func(t *testing.T) {
+		masterKey, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		var signer Signer
+		signer, err = NewXPrivSigner(masterKey.String())
+		require.NoError(t, err)
+
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+			WithSigner(signer),
+			WithAipSigner(newTestAipSigner(t)),
+		)
+		defer deferMe()
+		require.NotNil(t, client.AipSigner())
+
+		var rawXPub string
+		rawXPub, err = bitcoin.GetExtendedPublicKey(masterKey)
+		require.NoError(t, err)
+		_, err = client.NewXpub(ctx, rawXPub)
+		require.NoError(t, err)
+
+		var destination *Destination
+		destination, err = client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+		_, err = client.RecordTransaction(ctx, rawXPub,
+			CreateFakeFundingTransaction(t, masterKey, []*Destination{destination}, 10000), "",
+		)
+		require.NoError(t, err)
+
+		var transaction *Transaction
+		transaction, err = client.SendTransaction(ctx, rawXPub, &TransactionConfig{
+			Outputs: []*TransactionOutput{{OpReturn: &OpReturn{
+				Aip: &AipProtocol{},
+				Bap: &BapProtocol{
+					Hash:     "cf39fc55da24dc23eff1809e6e6cf32a0fe6aecc81296543e9ac84b8c501bac5",
+					Sequence: 0,
+					Type:     BapTypeAttest,
+				},
+			}}},
+		}, nil)
+		require.NoError(t, err)
+		require.NotNil(t, transaction)
+
+		require.NotNil(t, transaction.Metadata[metadataBapKey])
+		assert.Equal(t, BapTypeAttest, transaction.Metadata[metadataBapKey].(map[string]interface{})["type"])
+		require.NotNil(t, transaction.Metadata[metadataAipKey])
+		assert.Equal(t, true, transaction.Metadata[metadataAipKey].(map[string]interface{})["valid"])
+		assert.Nil(t, transaction.Metadata[metadataBKey])
+	})
+}
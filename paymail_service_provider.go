@@ -0,0 +1,262 @@
+package bux
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bec"
+	"github.com/tonicpow/go-paymail"
+	"github.com/tonicpow/go-paymail/server"
+)
+
+// PaymailDefaultServiceProvider is the default implementation of the go-paymail server.PaymailServiceProvider
+//
+// All paymail requests are resolved using the PaymailAddress model, destinations are generated
+// from the related xPub (resolved by the XPubResolver of the client) and incoming P2P transactions
+// are recorded using RecordTransaction()
+type PaymailDefaultServiceProvider struct {
+	client ClientInterface // (pointer) to the Client for accessing BUX model methods & etc
+}
+
+// PaymailServiceProvider will return the default (built-in) Paymail service provider for the bux engine
+//
+// Use this provider when loading a Paymail server configuration: server.NewConfig(c.PaymailServiceProvider())
+func (c *Client) PaymailServiceProvider() server.PaymailServiceProvider {
+	return &PaymailDefaultServiceProvider{client: c}
+}
+
+// GetPaymailByAlias will get a paymail address and information by alias
+func (p *PaymailDefaultServiceProvider) GetPaymailByAlias(ctx context.Context, alias, domain string,
+	_ *server.RequestMetadata) (*paymail.AddressInformation, error) {
+
+	// Get the paymail address
+	paymailAddress, err := p.getPaymailAddress(ctx, alias, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the identity public key (PKI)
+	var rawXPubKey, pubKey string
+	if rawXPubKey, err = p.getRawXPub(ctx, paymailAddress); err != nil {
+		return nil, err
+	} else if pubKey, err = getPaymailPubKey(rawXPubKey); err != nil {
+		return nil, err
+	}
+
+	// Use the alias if no public name is set
+	name := paymailAddress.PublicName
+	if len(name) == 0 {
+		name = paymailAddress.Alias
+	}
+
+	// Return the address information
+	return &paymail.AddressInformation{
+		Alias:  paymailAddress.Alias,
+		Avatar: paymailAddress.Avatar,
+		Domain: paymailAddress.Domain,
+		ID:     paymailAddress.ID,
+		Name:   name,
+		PubKey: pubKey,
+	}, nil
+}
+
+// CreateAddressResolutionResponse will create the address resolution response
+//
+// A new destination is created for every resolution request
+func (p *PaymailDefaultServiceProvider) CreateAddressResolutionResponse(ctx context.Context, alias, domain string,
+	_ bool, _ *server.RequestMetadata) (*paymail.ResolutionPayload, error) {
+
+	// Create a new destination for the paymail address
+	// todo: sign the output if sender validation is enabled (requires the xPriv)
+	destination, err := p.createDestination(
+		ctx, alias, domain, map[string]interface{}{
+			paymailRequestField: "CreateAddressResolutionResponse",
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the resolution
+	return &paymail.ResolutionPayload{
+		Address: destination.Address,
+		Output:  destination.LockingScript,
+	}, nil
+}
+
+// CreateP2PDestinationResponse will create a P2P destination response
+//
+// The reference id is stored on the destination and used to match the incoming P2P transaction
+func (p *PaymailDefaultServiceProvider) CreateP2PDestinationResponse(ctx context.Context, alias, domain string,
+	satoshis uint64, _ *server.RequestMetadata) (*paymail.PaymentDestinationPayload, error) {
+
+	// Create a new reference id
+	referenceID, err := utils.RandomHex(defaultReferenceIDLength)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a new destination for the paymail address
+	var destination *Destination
+	if destination, err = p.createDestination(
+		ctx, alias, domain, map[string]interface{}{
+			paymailRequestField: "CreateP2PDestinationResponse",
+			ReferenceIDField:    referenceID,
+			satoshisField:       satoshis,
+		},
+	); err != nil {
+		return nil, err
+	}
+
+	// Return the payment destination
+	// todo: support splitting the satoshis into multiple outputs
+	return &paymail.PaymentDestinationPayload{
+		Outputs: []*paymail.PaymentOutput{{
+			Address:  destination.Address,
+			Satoshis: satoshis,
+			Script:   destination.LockingScript,
+		}},
+		Reference: referenceID,
+	}, nil
+}
+
+// RecordTransaction will record the incoming P2P transaction using the bux engine
+func (p *PaymailDefaultServiceProvider) RecordTransaction(ctx context.Context,
+	p2pTx *paymail.P2PTransaction, requestMetadata *server.RequestMetadata) (*paymail.P2PTransactionPayload, error) {
+
+	// Make sure we have the request information
+	if p2pTx == nil || requestMetadata == nil {
+		return nil, ErrMissingFieldHex
+	}
+
+	// Get the paymail address
+	paymailAddress, err := p.getPaymailAddress(ctx, requestMetadata.Alias, requestMetadata.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set the metadata for the transaction
+	metadata := map[string]interface{}{
+		paymailRequestField: "RecordTransaction",
+		ReferenceIDField:    p2pTx.Reference,
+	}
+	if p2pTx.MetaData != nil {
+		metadata[p2pMetadataField] = p2pTx.MetaData
+	}
+
+	// Record the transaction
+	var rawXPubKey string
+	if rawXPubKey, err = p.getRawXPub(ctx, paymailAddress); err != nil {
+		return nil, err
+	}
+	var transaction *Transaction
+	if transaction, err = p.client.RecordTransaction(
+		ctx, rawXPubKey, p2pTx.Hex, "", WithMetadatas(metadata),
+	); err != nil {
+		return nil, err
+	}
+
+	// Return the response
+	return &paymail.P2PTransactionPayload{
+		Note: defaultP2PTransactionNote,
+		TxID: transaction.ID,
+	}, nil
+}
+
+// getPaymailAddress will get the paymail address (or return an error if not found)
+func (p *PaymailDefaultServiceProvider) getPaymailAddress(ctx context.Context,
+	alias, domain string) (*PaymailAddress, error) {
+
+	paymailAddress, err := getPaymailAddress(
+		ctx, alias+"@"+domain, p.client.DefaultModelOptions()...,
+	)
+	if err != nil {
+		return nil, err
+	} else if paymailAddress == nil || paymailAddress.IsDeleted() {
+		return nil, ErrMissingPaymail
+	}
+	return paymailAddress, nil
+}
+
+// getRawXPub will resolve the raw xPub of the paymail address (server-side key source)
+func (p *PaymailDefaultServiceProvider) getRawXPub(ctx context.Context,
+	paymailAddress *PaymailAddress) (string, error) {
+
+	resolver := p.client.XPubResolver()
+	if resolver == nil {
+		return "", ErrMissingXPubResolver
+	}
+	rawXPubKey, err := resolver.GetRawXPub(ctx, paymailAddress.XpubID)
+	if err != nil {
+		return "", err
+	} else if len(rawXPubKey) == 0 || utils.Hash(rawXPubKey) != paymailAddress.XpubID {
+		return "", ErrMissingXpub
+	}
+	return rawXPubKey, nil
+}
+
+// createDestination will create and save a new destination for the paymail address
+func (p *PaymailDefaultServiceProvider) createDestination(ctx context.Context, alias, domain string,
+	metadata map[string]interface{}) (*Destination, error) {
+
+	// Get the paymail address
+	paymailAddress, err := p.getPaymailAddress(ctx, alias, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the related xPub
+	var rawXPubKey string
+	if rawXPubKey, err = p.getRawXPub(ctx, paymailAddress); err != nil {
+		return nil, err
+	}
+	var xPub *Xpub
+	if xPub, err = getXpub(
+		ctx, rawXPubKey, p.client.DefaultModelOptions()...,
+	); err != nil {
+		return nil, err
+	} else if xPub == nil {
+		return nil, ErrMissingXpub
+	}
+
+	// Create the destination
+	metadata[paymailField] = paymailAddress.Address()
+	var destination *Destination
+	if destination, err = xPub.getNewDestination(
+		ctx, utils.ChainExternal, utils.ScriptTypePubKeyHash, &metadata,
+	); err != nil {
+		return nil, err
+	}
+
+	// Save the destination
+	if err = destination.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	return destination, nil
+}
+
+// getPaymailPubKey will return the identity public key (PKI) for the given xPub
+//
+// The identity key has its own chain, so it is never used as a receiving key of a destination
+func getPaymailPubKey(rawXpubKey string) (string, error) {
+
+	// Validate and parse the xPub
+	hdKey, err := utils.ValidateXPub(rawXpubKey)
+	if err != nil {
+		return "", err
+	}
+
+	// Derive the first key of the identity chain
+	if hdKey, err = bitcoin.GetHDKeyByPath(hdKey, paymailIdentityChain, 0); err != nil {
+		return "", err
+	}
+	var pubKey *bec.PublicKey
+	if pubKey, err = bitcoin.GetPublicKeyFromHDKey(hdKey); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(pubKey.SerialiseCompressed()), nil
+}
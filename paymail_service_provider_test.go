@@ -0,0 +1,234 @@
+package bux
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tonicpow/go-paymail"
+	"github.com/tonicpow/go-paymail/server"
+)
+
+const (
+	testPaymailAlias  = "tester"
+	testPaymailDomain = "test.com"
+)
+
+// createTestPaymailProvider will create a client, xPub (resolved by the client) and paymail address for testing
+func createTestPaymailProvider(t *testing.T, clientOpts ...ClientOps) (context.Context, ClientInterface, *Xpub, func()) {
+	if len(clientOpts) == 0 {
+		clientOpts = append(clientOpts, WithCustomTaskManager(&taskManagerMockBase{}))
+	}
+	resolver := &xPubResolverMock{xPubs: make(map[string]string)}
+	ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, append(clientOpts, WithXPubResolver(resolver))...)
+
+	_, xPub, rawXPub := CreateNewXPub(ctx, t, client)
+	resolver.xPubs[xPub.ID] = rawXPub
+
+	paymailAddress := newPaymailAddress(
+		testPaymailAlias+"@"+testPaymailDomain, rawXPub,
+		append(client.DefaultModelOptions(), New())...,
+	)
+	require.NoError(t, paymailAddress.Save(ctx))
+
+	return ctx, client, xPub, deferMe
+}
+
+// TestPaymailDefaultServiceProvider_GetPaymailByAlias will test the method GetPaymailByAlias()
+func TestPaymailDefaultServiceProvider_GetPaymailByAlias(t *testing.T) {
+
+	t.Run("valid paymail", func(t *testing.T) {
+		ctx, client, _, deferMe := createTestPaymailProvider(t)
+		defer deferMe()
+
+		provider := client.PaymailServiceProvider()
+		info, err := provider.GetPaymailByAlias(ctx, testPaymailAlias, testPaymailDomain, nil)
+		require.NoError(t, err)
+		require.NotNil(t, info)
+		assert.Equal(t, testPaymailAlias, info.Alias)
+		assert.Equal(t, testPaymailDomain, info.Domain)
+		assert.Equal(t, utils.Hash(testPaymailAlias+"@"+testPaymailDomain), info.ID)
+		assert.Len(t, info.PubKey, paymail.PubKeyLength)
+	})
+
+	t.Run("identity key is not a receiving key", func(t *testing.T) {
+		ctx, client, xPub, deferMe := createTestPaymailProvider(t)
+		defer deferMe()
+
+		provider := client.PaymailServiceProvider()
+		info, err := provider.GetPaymailByAlias(ctx, testPaymailAlias, testPaymailDomain, nil)
+		require.NoError(t, err)
+
+		var rawXPub string
+		rawXPub, err = client.XPubResolver().GetRawXPub(ctx, xPub.ID)
+		require.NoError(t, err)
+		hdKey, err := utils.ValidateXPub(rawXPub)
+		require.NoError(t, err)
+		for _, chain := range []uint32{utils.ChainExternal, utils.ChainInternal} {
+			pubKey, pubKeyErr := utils.DerivePublicKey(hdKey, chain, 0)
+			require.NoError(t, pubKeyErr)
+			assert.NotEqual(t, hex.EncodeToString(pubKey.SerialiseCompressed()), info.PubKey)
+		}
+	})
+
+	t.Run("missing xpub resolver", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		require.NoError(t, newPaymailAddress(
+			testPaymailAlias+"@"+testPaymailDomain, rawXPub,
+			append(client.DefaultModelOptions(), New())...,
+		).Save(ctx))
+
+		provider := client.PaymailServiceProvider()
+		info, err := provider.GetPaymailByAlias(ctx, testPaymailAlias, testPaymailDomain, nil)
+		require.ErrorIs(t, err, ErrMissingXPubResolver)
+		assert.Nil(t, info)
+	})
+
+	t.Run("unknown paymail", func(t *testing.T) {
+		ctx, client, _, deferMe := createTestPaymailProvider(t)
+		defer deferMe()
+
+		provider := client.PaymailServiceProvider()
+		info, err := provider.GetPaymailByAlias(ctx, "unknown", testPaymailDomain, nil)
+		require.ErrorIs(t, err, ErrMissingPaymail)
+		assert.Nil(t, info)
+	})
+}
+
+// TestPaymailDefaultServiceProvider_CreateAddressResolutionResponse will test the method CreateAddressResolutionResponse()
+func TestPaymailDefaultServiceProvider_CreateAddressResolutionResponse(t *testing.T) {
+
+	t.Run("new destination per request", func(t *testing.T) {
+		ctx, client, xPub, deferMe := createTestPaymailProvider(t)
+		defer deferMe()
+
+		provider := client.PaymailServiceProvider()
+		resolution, err := provider.CreateAddressResolutionResponse(
+			ctx, testPaymailAlias, testPaymailDomain, false, nil,
+		)
+		require.NoError(t, err)
+		require.NotNil(t, resolution)
+		assert.NotEmpty(t, resolution.Address)
+		assert.NotEmpty(t, resolution.Output)
+
+		var resolution2 *paymail.ResolutionPayload
+		resolution2, err = provider.CreateAddressResolutionResponse(
+			ctx, testPaymailAlias, testPaymailDomain, false, nil,
+		)
+		require.NoError(t, err)
+		assert.NotEqual(t, resolution.Address, resolution2.Address)
+
+		var destination *Destination
+		destination, err = getDestinationByLockingScript(
+			ctx, resolution.Output, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.NotNil(t, destination)
+		assert.Equal(t, xPub.ID, destination.XpubID)
+		assert.Equal(t, testPaymailAlias+"@"+testPaymailDomain, destination.Metadata[paymailField])
+	})
+
+	t.Run("unknown paymail", func(t *testing.T) {
+		ctx, client, _, deferMe := createTestPaymailProvider(t)
+		defer deferMe()
+
+		provider := client.PaymailServiceProvider()
+		resolution, err := provider.CreateAddressResolutionResponse(
+			ctx, "unknown", testPaymailDomain, false, nil,
+		)
+		require.ErrorIs(t, err, ErrMissingPaymail)
+		assert.Nil(t, resolution)
+	})
+}
+
+// TestPaymailDefaultServiceProvider_CreateP2PDestinationResponse will test the method CreateP2PDestinationResponse()
+func TestPaymailDefaultServiceProvider_CreateP2PDestinationResponse(t *testing.T) {
+
+	t.Run("valid destination", func(t *testing.T) {
+		ctx, client, _, deferMe := createTestPaymailProvider(t)
+		defer deferMe()
+
+		provider := client.PaymailServiceProvider()
+		payload, err := provider.CreateP2PDestinationResponse(
+			ctx, testPaymailAlias, testPaymailDomain, 1000, nil,
+		)
+		require.NoError(t, err)
+		require.NotNil(t, payload)
+		assert.Len(t, payload.Reference, defaultReferenceIDLength*2)
+		require.Len(t, payload.Outputs, 1)
+		assert.Equal(t, uint64(1000), payload.Outputs[0].Satoshis)
+
+		var destination *Destination
+		destination, err = getDestinationByLockingScript(
+			ctx, payload.Outputs[0].Script, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.NotNil(t, destination)
+		assert.Equal(t, payload.Reference, destination.Metadata[ReferenceIDField])
+	})
+}
+
+// TestPaymailDefaultServiceProvider_RecordTransaction will test the method RecordTransaction()
+func TestPaymailDefaultServiceProvider_RecordTransaction(t *testing.T) {
+
+	t.Run("missing request data", func(t *testing.T) {
+		ctx, client, _, deferMe := createTestPaymailProvider(t)
+		defer deferMe()
+
+		provider := client.PaymailServiceProvider()
+		payload, err := provider.RecordTransaction(ctx, nil, nil)
+		require.ErrorIs(t, err, ErrMissingFieldHex)
+		assert.Nil(t, payload)
+	})
+
+	t.Run("valid transaction", func(t *testing.T) {
+		ctx, client, _, deferMe := createTestPaymailProvider(
+			t, WithCustomChainstate(&chainStateEverythingOnChain{}),
+		)
+		defer deferMe()
+
+		provider := client.PaymailServiceProvider()
+		destination, err := provider.CreateP2PDestinationResponse(
+			ctx, testPaymailAlias, testPaymailDomain, 1000, nil,
+		)
+		require.NoError(t, err)
+
+		var masterKey *bip32.ExtendedKey
+		masterKey, err = bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+
+		txHex := CreateFakeFundingTransaction(t, masterKey, []*Destination{{
+			LockingScript: destination.Outputs[0].Script,
+		}}, 1000)
+
+		var payload *paymail.P2PTransactionPayload
+		payload, err = provider.RecordTransaction(ctx, &paymail.P2PTransaction{
+			Hex:       txHex,
+			Reference: destination.Reference,
+		}, &server.RequestMetadata{Alias: testPaymailAlias, Domain: testPaymailDomain})
+		require.NoError(t, err)
+		require.NotNil(t, payload)
+		assert.Equal(t, defaultP2PTransactionNote, payload.Note)
+		assert.Len(t, payload.TxID, 64)
+	})
+
+	t.Run("unknown paymail", func(t *testing.T) {
+		ctx, client, _, deferMe := createTestPaymailProvider(t)
+		defer deferMe()
+
+		provider := client.PaymailServiceProvider()
+		payload, err := provider.RecordTransaction(ctx, &paymail.P2PTransaction{
+			Hex: testTxHex,
+		}, &server.RequestMetadata{Alias: "unknown", Domain: testPaymailDomain})
+		require.ErrorIs(t, err, ErrMissingPaymail)
+		assert.Nil(t, payload)
+	})
+}
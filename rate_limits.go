@@ -0,0 +1,76 @@
+package bux
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Rate limited actions (see WithRateLimit)
+const (
+	RateLimitDraft  = "draft"  // Creating draft transactions (reserves utxos)
+	RateLimitRead   = "read"   // Reading records (read only requests)
+	RateLimitRecord = "record" // Recording transactions
+)
+
+// RateLimit is the maximum number of requests for an action in a period
+//
+// Each xPub or access key has its own token bucket of Limit tokens, refilled with Limit tokens per Period.
+// A burst never exceeds Limit requests, after that the requests are allowed at the refill rate.
+type RateLimit struct {
+	Limit  int64         `json:"limit"`
+	Period time.Duration `json:"period"`
+}
+
+// Allow will take a token from the bucket of the xPub or access key (id) for the given action
+//
+// Returns ErrRateLimitExceeded if the bucket is empty, actions without a rate limit are always allowed.
+// The buckets are stored in the Cachestore (Redis is shared by all the servers, in-memory is per server)
+func (c *Client) Allow(ctx context.Context, id, action string) error {
+
+	// No rate limit for the action
+	rateLimit, ok := c.options.rateLimits[action]
+	if !ok || len(id) == 0 {
+		return nil
+	}
+
+	allowed, err := c.Cachestore().TakeToken(
+		ctx, cacheKeyRateLimit+action+"-"+id, rateLimit.Limit, rateLimit.Period,
+	)
+	if err != nil {
+		return err
+	} else if !allowed {
+		return ErrRateLimitExceeded
+	}
+	return nil
+}
+
+// allowRequest will check the rate limit of the xPub or access key (id) for the scope of the request
+//
+// Admin requests are not rate limited. Requests without a scope are limited by the actions
+// they run (NewTransaction and RecordTransaction), the id is set on the request context for those.
+func (c *Client) allowRequest(ctx context.Context, req *http.Request, id string,
+	adminRequired bool) (*http.Request, error) {
+	if adminRequired {
+		return req, nil
+	}
+	switch getRequiredScope(req, false) {
+	case AccessKeyScopeCreateDraft:
+		return req, c.Allow(ctx, id, RateLimitDraft)
+	case AccessKeyScopeRecordTransaction:
+		return req, c.Allow(ctx, id, RateLimitRecord)
+	case AccessKeyScopeReadOnly:
+		return req, c.Allow(ctx, id, RateLimitRead)
+	case "":
+		return setOnRequest(req, rateLimitIDKey, id), nil
+	}
+	return req, nil
+}
+
+// allowAction will check the rate limit of the action for the request (context) without a scope
+//
+// Only requests authenticated without a scope are limited here (see allowRequest)
+func (c *Client) allowAction(ctx context.Context, action string) error {
+	id, _ := ctx.Value(rateLimitIDKey).(string)
+	return c.Allow(ctx, id, action)
+}
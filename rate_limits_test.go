@@ -0,0 +1,126 @@
+package bux
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient_Allow will test the method Allow()
+func TestClient_Allow(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no rate limit", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false)
+		defer deferMe()
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, client.Allow(ctx, testXPubID, RateLimitDraft))
+		}
+	})
+
+	t.Run("rate limit exceeded", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, false, WithRateLimit(RateLimitDraft, 2, time.Minute),
+		)
+		defer deferMe()
+
+		require.NoError(t, client.Allow(ctx, testXPubID, RateLimitDraft))
+		require.NoError(t, client.Allow(ctx, testXPubID, RateLimitDraft))
+		require.ErrorIs(t, client.Allow(ctx, testXPubID, RateLimitDraft), ErrRateLimitExceeded)
+
+		// Other ids and actions have their own buckets
+		require.NoError(t, client.Allow(ctx, utils.Hash(testXpub), RateLimitDraft))
+		require.NoError(t, client.Allow(ctx, testXPubID, RateLimitRecord))
+	})
+
+	t.Run("bucket is refilled", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, false, WithRateLimit(RateLimitRecord, 1, 200*time.Millisecond),
+		)
+		defer deferMe()
+
+		require.NoError(t, client.Allow(ctx, testXPubID, RateLimitRecord))
+		require.ErrorIs(t, client.Allow(ctx, testXPubID, RateLimitRecord), ErrRateLimitExceeded)
+
+		time.Sleep(250 * time.Millisecond)
+		require.NoError(t, client.Allow(ctx, testXPubID, RateLimitRecord))
+	})
+
+	t.Run("burst across the period is limited", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, false, WithRateLimit(RateLimitRead, 4, 400*time.Millisecond),
+		)
+		defer deferMe()
+
+		// Wait for most of the period, then burst before and after the end of the period
+		require.NoError(t, client.Allow(ctx, testXPubID, RateLimitRead))
+		time.Sleep(350 * time.Millisecond)
+		var allowed int
+		for i := 0; i < 4; i++ {
+			if client.Allow(ctx, testXPubID, RateLimitRead) == nil {
+				allowed++
+			}
+		}
+		time.Sleep(60 * time.Millisecond)
+		for i := 0; i < 4; i++ {
+			if client.Allow(ctx, testXPubID, RateLimitRead) == nil {
+				allowed++
+			}
+		}
+		require.Equal(t, 4, allowed)
+	})
+
+	t.Run("authenticate request", func(t *testing.T) {
+		_, client, deferMe := CreateTestSQLiteClient(
+			t, false, false, WithRateLimit(RateLimitRead, 1, time.Minute),
+		)
+		defer deferMe()
+
+		// authenticate will authenticate a new request (xPub, no signing)
+		authenticate := func(method string, adminRequired bool) error {
+			req, err := http.NewRequestWithContext(context.Background(), method, "", nil)
+			require.NoError(t, err)
+			req.Header.Set(AuthHeader, testXpub)
+			_, err = client.AuthenticateRequest(
+				context.Background(), req, []string{testXpub}, adminRequired, false, true,
+			)
+			return err
+		}
+
+		require.NoError(t, authenticate(http.MethodGet, false))
+		require.ErrorIs(t, authenticate(http.MethodGet, false), ErrRateLimitExceeded)
+
+		// Not a read request, admin requests are not rate limited
+		require.NoError(t, authenticate(http.MethodPost, false))
+		require.NoError(t, authenticate(http.MethodGet, true))
+	})
+
+	t.Run("request without a scope is limited by the action", func(t *testing.T) {
+		_, client, deferMe := CreateTestSQLiteClient(
+			t, false, false, WithCustomTaskManager(&taskManagerMockBase{}),
+			WithRateLimit(RateLimitDraft, 1, time.Minute),
+		)
+		defer deferMe()
+
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "", nil)
+		require.NoError(t, err)
+		req.Header.Set(AuthHeader, testXpub)
+		req, err = client.AuthenticateRequest(context.Background(), req, nil, false, false, true)
+		require.NoError(t, err)
+
+		config := &TransactionConfig{Outputs: []*TransactionOutput{{Satoshis: 1000, To: testPolicyAddress}}}
+		_, err = client.NewTransaction(req.Context(), testXpub, config, nil)
+		require.NotErrorIs(t, err, ErrRateLimitExceeded)
+		_, err = client.NewTransaction(req.Context(), testXpub, config, nil)
+		require.ErrorIs(t, err, ErrRateLimitExceeded)
+
+		// Not a request (internal calls are not rate limited)
+		_, err = client.NewTransaction(context.Background(), testXpub, config, nil)
+		require.NotErrorIs(t, err, ErrRateLimitExceeded)
+	})
+}
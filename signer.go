@@ -0,0 +1,123 @@
+package bux
+
+import (
+	"context"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/libsv/go-bk/bip32"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/libsv/go-bt/v2/unlocker"
+)
+
+// Signer will sign draft transactions for the xPubs it holds the keys for (server-side signing)
+//
+// A signer can also be used as the resolver for the utxo consolidation task (custodial xPubs)
+type Signer interface {
+	XPubResolver
+	DraftSigner
+}
+
+// xPrivSigner is the in-memory signer (xPrivs are kept in memory)
+type xPrivSigner struct {
+	xPrivs map[string]*bip32.ExtendedKey // xPub ID -> xPriv
+	xPubs  map[string]string             // xPub ID -> raw xPub
+}
+
+// NewXPrivSigner will return an in-memory signer for the given (raw) xPrivs
+func NewXPrivSigner(rawXPrivs ...string) (Signer, error) {
+	s := &xPrivSigner{
+		xPrivs: make(map[string]*bip32.ExtendedKey, len(rawXPrivs)),
+		xPubs:  make(map[string]string, len(rawXPrivs)),
+	}
+	for _, rawXPriv := range rawXPrivs {
+		xPriv, err := bitcoin.GenerateHDKeyFromString(rawXPriv)
+		if err != nil {
+			return nil, err
+		} else if !xPriv.IsPrivate() {
+			return nil, ErrMissingXPriv
+		}
+
+		var rawXPub string
+		if rawXPub, err = bitcoin.GetExtendedPublicKey(xPriv); err != nil {
+			return nil, err
+		}
+
+		xPubID := utils.Hash(rawXPub)
+		s.xPrivs[xPubID] = xPriv
+		s.xPubs[xPubID] = rawXPub
+	}
+	return s, nil
+}
+
+// GetRawXPub will return the raw xPub for the xPub ID (empty if the signer does not hold the key)
+func (s *xPrivSigner) GetRawXPub(_ context.Context, xPubID string) (string, error) {
+	return s.xPubs[xPubID], nil
+}
+
+// SignDraftTransaction will sign all the inputs of the draft (empty if the signer does not hold the key)
+func (s *xPrivSigner) SignDraftTransaction(ctx context.Context, draft *DraftTransaction) (string, error) {
+	xPriv, ok := s.xPrivs[draft.XpubID]
+	if !ok {
+		return "", nil
+	}
+	return signDraftTransaction(ctx, xPriv, draft)
+}
+
+// signDraftTransaction will sign the inputs of the draft using the destination (chain/num) derivation paths
+func signDraftTransaction(ctx context.Context, xPriv *bip32.ExtendedKey, draft *DraftTransaction) (string, error) {
+
+	// Parse the draft hex (unsigned)
+	tx, err := bt.NewTxFromString(draft.Hex)
+	if err != nil {
+		return "", err
+	}
+
+	// The draft inputs (by utxo id)
+	inputs := make(map[string]*TransactionInput, len(draft.Configuration.Inputs))
+	for _, input := range draft.Configuration.Inputs {
+		inputs[input.Utxo.GenerateID()] = input
+	}
+
+	// Loop and sign all inputs
+	for index, txInput := range tx.Inputs {
+		utxo := &Utxo{
+			OutputIndex:   txInput.PreviousTxOutIndex,
+			TransactionID: txInput.PreviousTxIDStr(),
+		}
+		input, found := inputs[utxo.GenerateID()]
+		if !found {
+			return "", ErrDraftInputNotFound
+		}
+
+		// The previous output is not in the hex, needed for the signature hash
+		if txInput.PreviousTxScript, err = bscript.NewFromHexString(
+			input.ScriptPubKey,
+		); err != nil {
+			return "", err
+		}
+		txInput.PreviousTxSatoshis = input.Satoshis
+
+		// Derive the key of the destination
+		var key *bip32.ExtendedKey
+		if key, err = xPriv.Child(input.Destination.Chain); err != nil {
+			return "", err
+		} else if key, err = key.Child(input.Destination.Num); err != nil {
+			return "", err
+		}
+
+		var simple unlocker.Simple
+		if simple.PrivateKey, err = bitcoin.GetPrivateKeyFromHDKey(key); err != nil {
+			return "", err
+		}
+
+		if err = tx.FillInput(ctx, &simple, bt.UnlockerParams{
+			InputIdx: uint32(index),
+		}); err != nil {
+			return "", err
+		}
+	}
+
+	return tx.String(), nil
+}
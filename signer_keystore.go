@@ -0,0 +1,146 @@
+package bux
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Keystore settings (scrypt & aes-256-gcm)
+const (
+	keystoreCipher  = "aes-256-gcm"
+	keystoreKDF     = "scrypt"
+	keystoreKeyLen  = 32
+	keystoreScryptN = 1 << 15
+	keystoreScryptP = 1
+	keystoreScryptR = 8
+	keystoreVersion = 1
+)
+
+// keystore is the encrypted keystore (JSON) for the xPrivs of a keystore signer
+type keystore struct {
+	Cipher     string            `json:"cipher"`
+	CipherText string            `json:"cipher_text"`
+	KDF        string            `json:"kdf"`
+	KDFParams  keystoreKDFParams `json:"kdf_params"`
+	Nonce      string            `json:"nonce"`
+	Version    int               `json:"version"`
+}
+
+// keystoreKDFParams are the key derivation (scrypt) params of the keystore
+type keystoreKDFParams struct {
+	N    int    `json:"n"`
+	P    int    `json:"p"`
+	R    int    `json:"r"`
+	Salt string `json:"salt"`
+}
+
+// NewKeystore will encrypt the (raw) xPrivs with the passphrase and return the keystore (JSON)
+func NewKeystore(passphrase string, rawXPrivs ...string) ([]byte, error) {
+
+	// Make sure the keys are valid xPrivs
+	if _, err := NewXPrivSigner(rawXPrivs...); err != nil {
+		return nil, err
+	}
+
+	plainText, err := json.Marshal(rawXPrivs)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &keystore{
+		Cipher: keystoreCipher,
+		KDF:    keystoreKDF,
+		KDFParams: keystoreKDFParams{
+			N: keystoreScryptN,
+			P: keystoreScryptP,
+			R: keystoreScryptR,
+		},
+		Version: keystoreVersion,
+	}
+
+	salt := make([]byte, keystoreKeyLen)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, err
+	}
+	ks.KDFParams.Salt = hex.EncodeToString(salt)
+
+	var aead cipher.AEAD
+	if aead, err = ks.aead(passphrase); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ks.Nonce = hex.EncodeToString(nonce)
+	ks.CipherText = hex.EncodeToString(aead.Seal(nil, nonce, plainText, nil))
+
+	return json.Marshal(ks)
+}
+
+// NewKeystoreSigner will decrypt the keystore (see: NewKeystore) and return a signer for its xPrivs
+func NewKeystoreSigner(keystoreJSON []byte, passphrase string) (Signer, error) {
+
+	ks := new(keystore)
+	if err := json.Unmarshal(keystoreJSON, ks); err != nil {
+		return nil, ErrInvalidKeystore
+	} else if ks.Version != keystoreVersion || ks.Cipher != keystoreCipher || ks.KDF != keystoreKDF {
+		return nil, ErrInvalidKeystore
+	}
+
+	nonce, err := hex.DecodeString(ks.Nonce)
+	if err != nil {
+		return nil, ErrInvalidKeystore
+	}
+	var cipherText []byte
+	if cipherText, err = hex.DecodeString(ks.CipherText); err != nil {
+		return nil, ErrInvalidKeystore
+	}
+
+	var aead cipher.AEAD
+	if aead, err = ks.aead(passphrase); err != nil {
+		return nil, err
+	} else if len(nonce) != aead.NonceSize() {
+		return nil, ErrInvalidKeystore
+	}
+
+	// Decrypt the xPrivs (fails if the passphrase is wrong)
+	var plainText []byte
+	if plainText, err = aead.Open(nil, nonce, cipherText, nil); err != nil {
+		return nil, ErrKeystoreDecryption
+	}
+
+	var rawXPrivs []string
+	if err = json.Unmarshal(plainText, &rawXPrivs); err != nil {
+		return nil, ErrInvalidKeystore
+	}
+
+	return NewXPrivSigner(rawXPrivs...)
+}
+
+// aead will derive the key from the passphrase and return the cipher for the keystore
+func (k *keystore) aead(passphrase string) (cipher.AEAD, error) {
+	salt, err := hex.DecodeString(k.KDFParams.Salt)
+	if err != nil {
+		return nil, ErrInvalidKeystore
+	}
+
+	var key []byte
+	if key, err = scrypt.Key(
+		[]byte(passphrase), salt, k.KDFParams.N, k.KDFParams.R, k.KDFParams.P, keystoreKeyLen,
+	); err != nil {
+		return nil, ErrInvalidKeystore
+	}
+
+	var block cipher.Block
+	if block, err = aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,102 @@
+package bux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/bitcoinschema/go-bitcoin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewXPrivSigner will test the method NewXPrivSigner()
+func TestNewXPrivSigner(t *testing.T) {
+
+	t.Run("valid xpriv", func(t *testing.T) {
+		masterKey, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+		require.NoError(t, err)
+		var rawXPub string
+		rawXPub, err = bitcoin.GetExtendedPublicKey(masterKey)
+		require.NoError(t, err)
+
+		var signer Signer
+		signer, err = NewXPrivSigner(masterKey.String())
+		require.NoError(t, err)
+		require.NotNil(t, signer)
+
+		var key string
+		key, err = signer.GetRawXPub(context.Background(), utils.Hash(rawXPub))
+		require.NoError(t, err)
+		assert.Equal(t, rawXPub, key)
+
+		key, err = signer.GetRawXPub(context.Background(), testXPubID)
+		require.NoError(t, err)
+		assert.Equal(t, "", key)
+	})
+
+	t.Run("xpub is not an xpriv", func(t *testing.T) {
+		signer, err := NewXPrivSigner(testXPub)
+		require.ErrorIs(t, err, ErrMissingXPriv)
+		require.Nil(t, signer)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		signer, err := NewXPrivSigner("invalid-key")
+		require.Error(t, err)
+		require.Nil(t, signer)
+	})
+
+	t.Run("unknown xpub is not signed", func(t *testing.T) {
+		signer, err := NewXPrivSigner()
+		require.NoError(t, err)
+
+		var txHex string
+		txHex, err = signer.SignDraftTransaction(context.Background(), &DraftTransaction{XpubID: testXPubID})
+		require.NoError(t, err)
+		assert.Equal(t, "", txHex)
+	})
+}
+
+// TestNewKeystoreSigner will test the methods NewKeystore() and NewKeystoreSigner()
+func TestNewKeystoreSigner(t *testing.T) {
+
+	masterKey, err := bitcoin.GenerateHDKey(bitcoin.SecureSeedLength)
+	require.NoError(t, err)
+	var rawXPub string
+	rawXPub, err = bitcoin.GetExtendedPublicKey(masterKey)
+	require.NoError(t, err)
+
+	var keystoreJSON []byte
+	keystoreJSON, err = NewKeystore("test-passphrase", masterKey.String())
+	require.NoError(t, err)
+	assert.NotContains(t, string(keystoreJSON), masterKey.String())
+
+	t.Run("valid passphrase", func(t *testing.T) {
+		signer, err := NewKeystoreSigner(keystoreJSON, "test-passphrase")
+		require.NoError(t, err)
+		require.NotNil(t, signer)
+
+		var key string
+		key, err = signer.GetRawXPub(context.Background(), utils.Hash(rawXPub))
+		require.NoError(t, err)
+		assert.Equal(t, rawXPub, key)
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		signer, err := NewKeystoreSigner(keystoreJSON, "wrong-passphrase")
+		require.ErrorIs(t, err, ErrKeystoreDecryption)
+		require.Nil(t, signer)
+	})
+
+	t.Run("invalid keystore", func(t *testing.T) {
+		signer, err := NewKeystoreSigner([]byte(`{"version":1}`), "test-passphrase")
+		require.ErrorIs(t, err, ErrInvalidKeystore)
+		require.Nil(t, signer)
+	})
+
+	t.Run("keystore of an xpub", func(t *testing.T) {
+		_, err := NewKeystore("test-passphrase", testXPub)
+		require.ErrorIs(t, err, ErrMissingXPriv)
+	})
+}
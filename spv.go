@@ -0,0 +1,330 @@
+package bux
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript/interpreter"
+)
+
+// beefVersion is the BEEF (BRC-62) version marker (hex, little endian 0xEFBE0001)
+const beefVersion = "0100beef"
+
+// spvEnvelope is the parsed SPV payload (BEEF or SPV envelope) of a transaction and its ancestry
+type spvEnvelope struct {
+	ancestors   map[string]*spvAncestor // All ancestors by tx id
+	transaction *bt.Tx                  // The transaction being verified
+}
+
+// spvAncestor is an ancestor transaction with an (optional) merkle proof
+type spvAncestor struct {
+	merklePath  *chainstate.MerklePath  // BEEF (BUMP)
+	merkleProof *chainstate.MerkleProof // SPV envelope (TSC)
+	tx          *bt.Tx                  // The ancestor transaction
+}
+
+// spvEnvelopeJSON is the JSON format of the SPV envelope
+//
+// Specs: https://github.com/libsv/go-bc/blob/master/spv/envelope.go
+type spvEnvelopeJSON struct {
+	Parents map[string]*spvEnvelopeJSON `json:"parents,omitempty"`
+	Proof   *chainstate.MerkleProof     `json:"proof,omitempty"`
+	RawTx   string                      `json:"rawTx,omitempty"`
+	TxID    string                      `json:"txid,omitempty"`
+}
+
+// newSPVEnvelope will parse a BEEF (hex) or SPV envelope (JSON) payload
+func newSPVEnvelope(payload string) (*spvEnvelope, error) {
+	payload = strings.TrimSpace(payload)
+	if strings.HasPrefix(payload, "{") {
+		return newSPVEnvelopeFromJSON(payload)
+	} else if strings.HasPrefix(strings.ToLower(payload), beefVersion) {
+		return newSPVEnvelopeFromBEEF(payload)
+	}
+	return nil, ErrSPVInvalidPayload
+}
+
+// newSPVEnvelopeFromBEEF will parse a BEEF payload (hex)
+//
+// Specs: https://brc.dev/62
+func newSPVEnvelopeFromBEEF(beefHex string) (*spvEnvelope, error) {
+
+	// Decode the hex
+	b, err := hex.DecodeString(beefHex)
+	if err != nil || len(b) < len(beefVersion)/2 {
+		return nil, ErrSPVInvalidPayload
+	}
+	r := bytes.NewReader(b[len(beefVersion)/2:])
+
+	// Read all the merkle paths (BUMPs)
+	var numPaths bt.VarInt
+	if _, err = numPaths.ReadFrom(r); err != nil {
+		return nil, ErrSPVInvalidPayload
+	}
+	paths := make([]*chainstate.MerklePath, 0, numPaths)
+	for i := uint64(0); i < uint64(numPaths); i++ {
+		var path *chainstate.MerklePath
+		if path, err = chainstate.NewMerklePathFromReader(r); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+
+	// Read all the transactions (the last transaction is the one being verified)
+	var numTxs bt.VarInt
+	if _, err = numTxs.ReadFrom(r); err != nil || numTxs == 0 {
+		return nil, ErrSPVInvalidPayload
+	}
+	envelope := &spvEnvelope{ancestors: make(map[string]*spvAncestor)}
+	for i := uint64(0); i < uint64(numTxs); i++ {
+		tx := new(bt.Tx)
+		if _, err = tx.ReadFrom(r); err != nil {
+			return nil, ErrSPVInvalidPayload
+		}
+
+		// Check for a merkle path
+		ancestor := &spvAncestor{tx: tx}
+		var hasPath byte
+		if hasPath, err = r.ReadByte(); err != nil {
+			return nil, ErrSPVInvalidPayload
+		} else if hasPath > 1 {
+			return nil, ErrSPVInvalidPayload
+		} else if hasPath == 1 {
+			var pathIndex bt.VarInt
+			if _, err = pathIndex.ReadFrom(r); err != nil || uint64(pathIndex) >= uint64(len(paths)) {
+				return nil, ErrSPVInvalidPayload
+			}
+			ancestor.merklePath = paths[pathIndex]
+		}
+
+		if i == uint64(numTxs)-1 {
+			envelope.transaction = tx
+		} else {
+			envelope.ancestors[tx.TxID()] = ancestor
+		}
+	}
+
+	return envelope, nil
+}
+
+// newSPVEnvelopeFromJSON will parse an SPV envelope payload (JSON)
+func newSPVEnvelopeFromJSON(payload string) (*spvEnvelope, error) {
+
+	// Decode the JSON
+	envelopeJSON := new(spvEnvelopeJSON)
+	if err := json.Unmarshal([]byte(payload), envelopeJSON); err != nil {
+		return nil, ErrSPVInvalidPayload
+	}
+
+	// Parse the transaction
+	tx, err := bt.NewTxFromString(envelopeJSON.RawTx)
+	if err != nil {
+		return nil, ErrSPVInvalidPayload
+	}
+
+	// Flatten the parents
+	envelope := &spvEnvelope{
+		ancestors:   make(map[string]*spvAncestor),
+		transaction: tx,
+	}
+	if err = envelope.addParents(envelopeJSON.Parents); err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+// addParents will add the parents (recursively) of an SPV envelope to the ancestors
+func (e *spvEnvelope) addParents(parents map[string]*spvEnvelopeJSON) error {
+	for txID, parent := range parents {
+		if parent == nil {
+			return ErrSPVInvalidPayload
+		}
+		tx, err := bt.NewTxFromString(parent.RawTx)
+		if err != nil {
+			return ErrSPVInvalidPayload
+		} else if tx.TxID() != txID {
+			return ErrSPVInvalidPayload
+		}
+		e.ancestors[txID] = &spvAncestor{
+			merkleProof: parent.Proof,
+			tx:          tx,
+		}
+		if err = e.addParents(parent.Parents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verify will verify the transaction inputs against the ancestry
+//
+// Merkle proofs are checked against the local block headers, or the chain provider(s) if not synced
+func (e *spvEnvelope) verify(ctx context.Context, c ClientInterface) error {
+	return e.verifyInputs(ctx, c, e.transaction, make(map[string]bool))
+}
+
+// verifyInputs will verify that all inputs of the transaction spend valid ancestor outputs
+func (e *spvEnvelope) verifyInputs(ctx context.Context, c ClientInterface, tx *bt.Tx, verified map[string]bool) error {
+
+	// A transaction must have inputs
+	if len(tx.Inputs) == 0 {
+		return ErrSPVInvalidInput
+	}
+
+	// Check each input
+	var totalInputs uint64
+	for index, input := range tx.Inputs {
+
+		// Find the ancestor
+		parentID := input.PreviousTxIDStr()
+		ancestor, ok := e.ancestors[parentID]
+		if !ok {
+			return ErrSPVMissingAncestor
+		} else if int(input.PreviousTxOutIndex) >= len(ancestor.tx.Outputs) {
+			return ErrSPVInvalidInput
+		}
+
+		// Verify the unlocking script against the ancestor output
+		output := ancestor.tx.Outputs[input.PreviousTxOutIndex]
+		if err := interpreter.NewEngine().Execute(
+			interpreter.WithTx(tx, index, output),
+			interpreter.WithForkID(),
+			interpreter.WithAfterGenesis(),
+		); err != nil {
+			return ErrSPVInvalidScript
+		}
+		totalInputs += output.Satoshis
+
+		// Verify the ancestor (once)
+		if !verified[parentID] {
+			verified[parentID] = true
+			if err := e.verifyAncestor(ctx, c, parentID, ancestor, verified); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Inputs must cover the outputs
+	if totalInputs < tx.TotalOutputSatoshis() {
+		return ErrSPVInsufficientInputs
+	}
+
+	return nil
+}
+
+// verifyAncestor will verify the merkle proof of the ancestor, or its own inputs if no proof is given
+func (e *spvEnvelope) verifyAncestor(ctx context.Context, c ClientInterface, txID string,
+	ancestor *spvAncestor, verified map[string]bool) error {
+
+	// BEEF: merkle path (BUMP)
+	if ancestor.merklePath != nil {
+		root, err := ancestor.merklePath.MerkleRoot(txID)
+		if err != nil {
+			return err
+		}
+		var header *chainstate.BlockHeader
+		if header, err = getSPVBlockHeaderByHeight(ctx, c, ancestor.merklePath.BlockHeight); err != nil {
+			return err
+		} else if header.MerkleRoot != root {
+			return chainstate.ErrMerkleRootMismatch
+		}
+		return nil
+	}
+
+	// SPV envelope: merkle proof (TSC)
+	if ancestor.merkleProof != nil {
+		proofTxID, err := ancestor.merkleProof.TxID()
+		if err != nil {
+			return err
+		} else if proofTxID != txID {
+			return chainstate.ErrTransactionIDMismatch
+		}
+		var root string
+		if root, err = ancestor.merkleProof.Verify(); err != nil {
+			return err
+		}
+		var header *chainstate.BlockHeader
+		if header, err = getSPVBlockHeaderByProof(ctx, c, txID, ancestor.merkleProof); err != nil {
+			return err
+		} else if header.MerkleRoot != root {
+			return chainstate.ErrMerkleRootMismatch
+		}
+		return nil
+	}
+
+	// Unconfirmed ancestor: verify its inputs
+	return e.verifyInputs(ctx, c, ancestor.tx, verified)
+}
+
+// getSPVBlockHeaderByHeight will get the (non-orphaned) block header at the given height
+//
+// The local block headers are used first, then the chain provider(s)
+func getSPVBlockHeaderByHeight(ctx context.Context, c ClientInterface, height uint64) (*chainstate.BlockHeader, error) {
+	blockHeader, err := getBlockHeaderByHeight(ctx, height, c.DefaultModelOptions()...)
+	if err != nil {
+		return nil, err
+	} else if blockHeader != nil {
+		return blockHeader.chainstateHeader(), nil
+	}
+
+	var header *chainstate.BlockHeader
+	if header, err = c.Chainstate().QueryBlockHeader(
+		ctx, height, defaultQueryTimeout,
+	); err != nil || header == nil {
+		return nil, ErrSPVUnknownBlock
+	}
+	return header, nil
+}
+
+// getSPVBlockHeaderByProof will get the (non-orphaned) block header of the merkle proof (TSC)
+//
+// A block hash target is looked up in the local block headers first, otherwise the block
+// of the transaction is found using the chain provider(s)
+func getSPVBlockHeaderByProof(ctx context.Context, c ClientInterface, txID string,
+	proof *chainstate.MerkleProof) (*chainstate.BlockHeader, error) {
+
+	// Only a block hash target identifies the block (merkle root and header targets are checked by Verify())
+	var blockHash string
+	switch proof.TargetType {
+	case "", chainstate.MerkleTargetTypeHash:
+		blockHash = proof.Target
+	case chainstate.MerkleTargetTypeHeader, chainstate.MerkleTargetTypeMerkleRoot:
+	default:
+		return nil, chainstate.ErrInvalidMerkleProof
+	}
+
+	// Check the local block headers
+	if len(blockHash) > 0 {
+		blockHeader, err := getBlockHeaderByHash(ctx, blockHash, c.DefaultModelOptions()...)
+		if err != nil {
+			return nil, err
+		} else if blockHeader != nil {
+			if blockHeader.IsOrphaned() {
+				return nil, ErrBlockHeaderOrphaned
+			}
+			return blockHeader.chainstateHeader(), nil
+		}
+	}
+
+	// Find the block of the transaction
+	info, err := c.Chainstate().QueryTransactionFastest(
+		ctx, txID, chainstate.RequiredOnChain, defaultQueryTimeout,
+	)
+	if err != nil || info == nil || info.BlockHeight <= 0 {
+		return nil, ErrSPVUnknownBlock
+	}
+
+	var header *chainstate.BlockHeader
+	if header, err = getSPVBlockHeaderByHeight(ctx, c, uint64(info.BlockHeight)); err != nil {
+		return nil, err
+	} else if len(blockHash) > 0 && header.Hash != blockHash {
+		return nil, ErrSPVUnknownBlock
+	}
+	return header, nil
+}
@@ -0,0 +1,353 @@
+package bux
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/BuxOrg/bux/chainstate"
+	"github.com/BuxOrg/bux/utils"
+	"github.com/libsv/go-bk/bec"
+	"github.com/libsv/go-bt/v2"
+	"github.com/libsv/go-bt/v2/bscript"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSPVSiblingHash is a fake sibling hash for the merkle proofs of the parent transaction
+const testSPVSiblingHash = "e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d"
+
+// createTestSPVTransactions will create a (fake) confirmed parent and a signed child spending the parent
+func createTestSPVTransactions(t *testing.T, lockingScript string, satoshis uint64) (parent, child *bt.Tx) {
+
+	privateKey, err := bec.NewPrivateKey(bec.S256())
+	require.NoError(t, err)
+
+	var parentScript *bscript.Script
+	parentScript, err = bscript.NewP2PKHFromPubKeyBytes(privateKey.PubKey().SerialiseCompressed())
+	require.NoError(t, err)
+
+	// The parent is in the merkle proof, so its own inputs are never checked
+	parent = bt.NewTx()
+	require.NoError(t, parent.From(testTxScriptSigID, 0, testTxScriptSigOut, 2000))
+	parent.AddOutput(&bt.Output{Satoshis: 1000, LockingScript: parentScript})
+
+	var childScript *bscript.Script
+	childScript, err = bscript.NewFromHexString(lockingScript)
+	require.NoError(t, err)
+
+	child = bt.NewTx()
+	require.NoError(t, child.From(parent.TxID(), 0, parentScript.String(), 1000))
+	child.AddOutput(&bt.Output{Satoshis: satoshis, LockingScript: childScript})
+	require.NoError(t, child.FillAllInputs(context.Background(), &account{PrivateKey: privateKey}))
+
+	return parent, child
+}
+
+// createTestSPVBlockHeader will mine (regtest difficulty) a block header with the merkle root of the parent
+func createTestSPVBlockHeader(t *testing.T, parent *bt.Tx) *chainstate.BlockHeader {
+	root, err := (&chainstate.MerkleProof{
+		Index:  0,
+		TxOrID: parent.TxID(),
+		Nodes:  []string{testSPVSiblingHash},
+	}).MerkleRoot()
+	require.NoError(t, err)
+
+	headers := loadTestBlockHeaders(t)
+	return createTestBlockHeader(t, headers[len(headers)-1], root)
+}
+
+// createTestSPVClient will create a test client with a block header for the parent merkle proofs (if given)
+func createTestSPVClient(t *testing.T, header *chainstate.BlockHeader) (context.Context, ClientInterface, func()) {
+	ctx, client, deferMe := CreateTestSQLiteClient(
+		t, false, true,
+		WithCustomTaskManager(&taskManagerMockBase{}),
+		WithCustomChainstate(&chainStateEverythingOnChain{}),
+	)
+	if header != nil {
+		require.NoError(t, newBlockHeader(header, append(client.DefaultModelOptions(), New())...).Save(ctx))
+	}
+	return ctx, client, deferMe
+}
+
+// createTestBEEF will create a BEEF (hex) for the parent (with a BUMP at the given height) and the child
+func createTestBEEF(t *testing.T, parent, child *bt.Tx, height uint64) string {
+	sibling, err := hex.DecodeString(testSPVSiblingHash)
+	require.NoError(t, err)
+
+	b, _ := hex.DecodeString(beefVersion)
+	b = append(b, bt.VarInt(1).Bytes()...)      // number of BUMPs
+	b = append(b, bt.VarInt(height).Bytes()...) // block height
+	b = append(b, 1, 2)                         // tree height, number of leaves
+	b = append(b, 0, 2)                         // offset 0: client txid
+	b = append(b, bt.ReverseBytes(parent.TxIDBytes())...)
+	b = append(b, 1, 0) // offset 1: hash
+	b = append(b, bt.ReverseBytes(sibling)...)
+	b = append(b, bt.VarInt(2).Bytes()...) // number of transactions
+	b = append(b, parent.Bytes()...)
+	b = append(b, 1, 0) // has BUMP, BUMP index
+	b = append(b, child.Bytes()...)
+	b = append(b, 0) // no BUMP
+
+	return hex.EncodeToString(b)
+}
+
+// createTestSPVEnvelope will create an SPV envelope (JSON) for the parent (with a TSC proof to the given block) and the child
+func createTestSPVEnvelope(t *testing.T, parent, child *bt.Tx, blockHash string) string {
+	payload, err := json.Marshal(&spvEnvelopeJSON{
+		Parents: map[string]*spvEnvelopeJSON{
+			parent.TxID(): {
+				Proof: &chainstate.MerkleProof{
+					Index:      0,
+					TxOrID:     parent.TxID(),
+					Nodes:      []string{testSPVSiblingHash},
+					Target:     blockHash,
+					TargetType: chainstate.MerkleTargetTypeHash,
+				},
+				RawTx: parent.String(),
+				TxID:  parent.TxID(),
+			},
+		},
+		RawTx: child.String(),
+		TxID:  child.TxID(),
+	})
+	require.NoError(t, err)
+
+	return string(payload)
+}
+
+// Test_newSPVEnvelope will test the method newSPVEnvelope()
+func Test_newSPVEnvelope(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid BEEF", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+
+		envelope, err := newSPVEnvelope(createTestBEEF(t, parent, child, 800000))
+		require.NoError(t, err)
+		require.NotNil(t, envelope)
+		assert.Equal(t, child.TxID(), envelope.transaction.TxID())
+		require.Len(t, envelope.ancestors, 1)
+		require.NotNil(t, envelope.ancestors[parent.TxID()])
+		require.NotNil(t, envelope.ancestors[parent.TxID()].merklePath)
+		assert.Equal(t, uint64(800000), envelope.ancestors[parent.TxID()].merklePath.BlockHeight)
+	})
+
+	t.Run("valid SPV envelope", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+
+		envelope, err := newSPVEnvelope(createTestSPVEnvelope(t, parent, child, testSPVSiblingHash))
+		require.NoError(t, err)
+		require.NotNil(t, envelope)
+		assert.Equal(t, child.TxID(), envelope.transaction.TxID())
+		require.Len(t, envelope.ancestors, 1)
+		assert.NotNil(t, envelope.ancestors[parent.TxID()].merkleProof)
+	})
+
+	t.Run("invalid payloads", func(t *testing.T) {
+		_, err := newSPVEnvelope("")
+		assert.ErrorIs(t, err, ErrSPVInvalidPayload)
+
+		_, err = newSPVEnvelope(testTxHex)
+		assert.ErrorIs(t, err, ErrSPVInvalidPayload)
+
+		_, err = newSPVEnvelope(beefVersion + "zz")
+		assert.ErrorIs(t, err, ErrSPVInvalidPayload)
+
+		_, err = newSPVEnvelope("{invalid-json")
+		assert.ErrorIs(t, err, ErrSPVInvalidPayload)
+	})
+
+	t.Run("invalid BEEF merkle path flag", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+
+		// The last byte is the (no) BUMP flag of the child
+		beef := createTestBEEF(t, parent, child, 800000)
+		_, err := newSPVEnvelope(beef[:len(beef)-2] + "02")
+		assert.ErrorIs(t, err, ErrSPVInvalidPayload)
+	})
+
+	t.Run("parent id mismatch", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+
+		_, err := newSPVEnvelope(`{"rawTx":"` + child.String() + `","parents":{"` +
+			testSPVSiblingHash + `":{"rawTx":"` + parent.String() + `"}}}`)
+		assert.ErrorIs(t, err, ErrSPVInvalidPayload)
+	})
+}
+
+// TestSPVEnvelope_verify will test the method verify()
+func TestSPVEnvelope_verify(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid BEEF", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+		header := createTestSPVBlockHeader(t, parent)
+		ctx, client, deferMe := createTestSPVClient(t, header)
+		defer deferMe()
+
+		envelope, err := newSPVEnvelope(createTestBEEF(t, parent, child, header.Height))
+		require.NoError(t, err)
+		assert.NoError(t, envelope.verify(ctx, client))
+	})
+
+	t.Run("valid SPV envelope", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+		header := createTestSPVBlockHeader(t, parent)
+		ctx, client, deferMe := createTestSPVClient(t, header)
+		defer deferMe()
+
+		envelope, err := newSPVEnvelope(createTestSPVEnvelope(t, parent, child, header.Hash))
+		require.NoError(t, err)
+		assert.NoError(t, envelope.verify(ctx, client))
+	})
+
+	t.Run("missing ancestor", func(t *testing.T) {
+		_, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+		ctx, client, deferMe := createTestSPVClient(t, nil)
+		defer deferMe()
+
+		envelope, err := newSPVEnvelope(`{"rawTx":"` + child.String() + `"}`)
+		require.NoError(t, err)
+		assert.ErrorIs(t, envelope.verify(ctx, client), ErrSPVMissingAncestor)
+	})
+
+	t.Run("invalid script", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+		_, other := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+		child.Inputs[0].UnlockingScript = other.Inputs[0].UnlockingScript
+		ctx, client, deferMe := createTestSPVClient(t, nil)
+		defer deferMe()
+
+		envelope, err := newSPVEnvelope(createTestBEEF(t, parent, child, 800000))
+		require.NoError(t, err)
+		assert.ErrorIs(t, envelope.verify(ctx, client), ErrSPVInvalidScript)
+	})
+
+	t.Run("insufficient inputs", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 1001)
+		header := createTestSPVBlockHeader(t, parent)
+		ctx, client, deferMe := createTestSPVClient(t, header)
+		defer deferMe()
+
+		envelope, err := newSPVEnvelope(createTestBEEF(t, parent, child, header.Height))
+		require.NoError(t, err)
+		assert.ErrorIs(t, envelope.verify(ctx, client), ErrSPVInsufficientInputs)
+	})
+
+	t.Run("BEEF merkle root does not match the block header", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+		headers := loadTestBlockHeaders(t)
+		header := createTestBlockHeader(t, headers[len(headers)-1], testBlockHeaderForkRoot)
+		ctx, client, deferMe := createTestSPVClient(t, header)
+		defer deferMe()
+
+		envelope, err := newSPVEnvelope(createTestBEEF(t, parent, child, header.Height))
+		require.NoError(t, err)
+		assert.ErrorIs(t, envelope.verify(ctx, client), chainstate.ErrMerkleRootMismatch)
+	})
+
+	t.Run("BEEF unknown block", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+		ctx, client, deferMe := createTestSPVClient(t, nil)
+		defer deferMe()
+
+		envelope, err := newSPVEnvelope(createTestBEEF(t, parent, child, 800000))
+		require.NoError(t, err)
+		assert.ErrorIs(t, envelope.verify(ctx, client), ErrSPVUnknownBlock)
+	})
+
+	t.Run("SPV envelope merkle root does not match the block header", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+		headers := loadTestBlockHeaders(t)
+		header := createTestBlockHeader(t, headers[len(headers)-1], testBlockHeaderForkRoot)
+		ctx, client, deferMe := createTestSPVClient(t, header)
+		defer deferMe()
+
+		envelope, err := newSPVEnvelope(createTestSPVEnvelope(t, parent, child, header.Hash))
+		require.NoError(t, err)
+		assert.ErrorIs(t, envelope.verify(ctx, client), chainstate.ErrMerkleRootMismatch)
+	})
+
+	t.Run("SPV envelope orphaned block", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+		header := createTestSPVBlockHeader(t, parent)
+		ctx, client, deferMe := createTestSPVClient(t, header)
+		defer deferMe()
+
+		blockHeader, err := getBlockHeaderByHash(ctx, header.Hash, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, blockHeader)
+		require.NoError(t, blockHeader.Delete(ctx))
+
+		var envelope *spvEnvelope
+		envelope, err = newSPVEnvelope(createTestSPVEnvelope(t, parent, child, header.Hash))
+		require.NoError(t, err)
+		assert.ErrorIs(t, envelope.verify(ctx, client), ErrBlockHeaderOrphaned)
+	})
+
+	t.Run("SPV envelope unknown block", func(t *testing.T) {
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+		ctx, client, deferMe := createTestSPVClient(t, nil)
+		defer deferMe()
+
+		envelope, err := newSPVEnvelope(createTestSPVEnvelope(t, parent, child, testSPVSiblingHash))
+		require.NoError(t, err)
+		assert.ErrorIs(t, envelope.verify(ctx, client), ErrSPVUnknownBlock)
+	})
+}
+
+// TestClient_RecordSPVTransaction will test the method RecordSPVTransaction()
+func TestClient_RecordSPVTransaction(t *testing.T) {
+
+	t.Run("valid BEEF", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+		)
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		destination, err := client.NewDestination(
+			ctx, rawXPub, utils.ChainExternal, utils.ScriptTypePubKeyHash, nil,
+		)
+		require.NoError(t, err)
+
+		parent, child := createTestSPVTransactions(t, destination.LockingScript, 900)
+		header := createTestSPVBlockHeader(t, parent)
+		require.NoError(t, newBlockHeader(header, append(client.DefaultModelOptions(), New())...).Save(ctx))
+
+		var transaction *Transaction
+		transaction, err = client.RecordSPVTransaction(ctx, rawXPub, createTestBEEF(t, parent, child, header.Height), "")
+		require.NoError(t, err)
+		require.NotNil(t, transaction)
+		assert.Equal(t, child.TxID(), transaction.ID)
+	})
+
+	t.Run("unknown block", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(
+			t, false, true,
+			WithCustomTaskManager(&taskManagerMockBase{}),
+			WithCustomChainstate(&chainStateEverythingOnChain{}),
+		)
+		defer deferMe()
+
+		_, _, rawXPub := CreateNewXPub(ctx, t, client)
+		parent, child := createTestSPVTransactions(t, testTxScriptSigOut, 900)
+
+		transaction, err := client.RecordSPVTransaction(ctx, rawXPub, createTestBEEF(t, parent, child, 800000), "")
+		require.ErrorIs(t, err, ErrSPVUnknownBlock)
+		require.Nil(t, transaction)
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, false, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		transaction, err := client.RecordSPVTransaction(ctx, testXPub, testTxHex, "")
+		require.ErrorIs(t, err, ErrSPVInvalidPayload)
+		require.Nil(t, transaction)
+	})
+}
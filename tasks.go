@@ -42,12 +42,27 @@ func TaskCleanupDraftTransactions(ctx context.Context, logClient logger.Interfac
 			if err = models[index].Save(ctx); err != nil {
 				return err
 			}
+			publishEvent(ctx, models[index].Client(), EventTypeDraftTransactionExpired, &models[index])
 		}
 	}
 
 	return nil
 }
 
+// TaskConsolidateUtxos will create consolidation drafts for xPubs with too many small utxos
+func TaskConsolidateUtxos(ctx context.Context, logClient logger.Interface, config *UtxoConsolidationConfig,
+	opts ...ModelOps) error {
+
+	// Consolidation is not enabled
+	if config == nil || config.Resolver == nil {
+		return nil
+	}
+
+	logClient.Info(ctx, "running consolidate utxos task...")
+
+	return consolidateUtxos(ctx, config, opts...)
+}
+
 // TaskProcessIncomingTransactions will process any incoming transactions found
 func TaskProcessIncomingTransactions(ctx context.Context, logClient logger.Interface, opts ...ModelOps) error {
 
@@ -83,3 +98,15 @@ func TaskSyncTransactions(ctx context.Context, logClient logger.Interface, opts
 	}
 	return err
 }
+
+// TaskSyncBlockHeaders will sync the latest block headers from the chain providers
+func TaskSyncBlockHeaders(ctx context.Context, logClient logger.Interface, opts ...ModelOps) error {
+
+	logClient.Info(ctx, "running sync block header(s) task...")
+
+	_, err := syncBlockHeaders(ctx, defaultBlockHeaderSyncMax, opts...)
+	if err == nil || errors.Is(err, datastore.ErrNoResults) {
+		return nil
+	}
+	return err
+}
@@ -15,3 +15,6 @@ var ErrHDKeyNil = errors.New("hd key is nil")
 
 // ErrDeriveFailed is when the address derivation failed
 var ErrDeriveFailed = errors.New("derive addresses failed, missing addresses")
+
+// ErrInvalidMultiSig is when the multisig script or the number of required signatures (m-of-n) is invalid
+var ErrInvalidMultiSig = errors.New("invalid multisig, requires 1 <= m <= n <= 16 public keys")
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"encoding/hex"
+
+	"github.com/libsv/go-bt/v2/bscript"
+)
+
+// multiSigMaxKeys is the max number of public keys (and required signatures) in a bare multisig script
+const multiSigMaxKeys = 16
+
+// multiSigSignatureSize is the max size of a signature in the unlocking script (push + DER + sighash flag)
+const multiSigSignatureSize = 74
+
+// NewMultiSigLockingScript will create the m-of-n bare multisig locking script (hex) for the public keys
+//
+// The order of the public keys is kept, signatures need to be in the same order when spending
+func NewMultiSigLockingScript(required int, pubKeys [][]byte) (string, error) {
+	if required < 1 || required > len(pubKeys) || len(pubKeys) > multiSigMaxKeys {
+		return "", ErrInvalidMultiSig
+	}
+
+	script := &bscript.Script{}
+	if err := script.AppendOpcodes(bscript.Op1 + byte(required-1)); err != nil {
+		return "", err
+	}
+	if err := script.AppendPushDataArray(pubKeys); err != nil {
+		return "", err
+	}
+	if err := script.AppendOpcodes(
+		bscript.Op1+byte(len(pubKeys)-1), bscript.OpCHECKMULTISIG,
+	); err != nil {
+		return "", err
+	}
+
+	return script.String(), nil
+}
+
+// ParseMultiSig will return the number of required signatures and the public keys of a bare multisig locking script
+func ParseMultiSig(lockingScript string) (required int, pubKeys [][]byte, err error) {
+	if !IsMultiSig(lockingScript) {
+		return 0, nil, ErrInvalidMultiSig
+	}
+
+	var b []byte
+	if b, err = hex.DecodeString(lockingScript); err != nil {
+		return 0, nil, err
+	}
+
+	var parts [][]byte
+	if parts, err = bscript.DecodeParts(b); err != nil {
+		return 0, nil, err
+	}
+
+	// OP_m [pubKeys...] OP_n OP_CHECKMULTISIG
+	required = smallIntValue(parts[0][0])
+	pubKeys = parts[1 : len(parts)-2]
+	if required < 1 || required > len(pubKeys) || smallIntValue(parts[len(parts)-2][0]) != len(pubKeys) {
+		return 0, nil, ErrInvalidMultiSig
+	}
+	return required, pubKeys, nil
+}
+
+// GetInputSizeForMultiSig get an estimated size for a bare multisig input with the required signatures
+func GetInputSizeForMultiSig(required int) uint64 {
+
+	// OP_0 [signatures...]
+	scriptSize := uint64(1 + required*multiSigSignatureSize)
+
+	// Outpoint (36), sequence (4) and the script length (var int)
+	size := 40 + scriptSize + 1
+	if scriptSize >= 0xfd {
+		size += 2
+	}
+	return size
+}
+
+// smallIntValue will return the value of a small int opcode (OP_0 - OP_16)
+func smallIntValue(opcode byte) int {
+	if opcode == bscript.OpZERO {
+		return 0
+	}
+	return int(opcode-bscript.Op1) + 1
+}
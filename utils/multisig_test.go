@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseMultiSig will test the method ParseMultiSig()
+func TestParseMultiSig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not multisig", func(t *testing.T) {
+		_, _, err := ParseMultiSig(p2pkhHex)
+		require.ErrorIs(t, err, ErrInvalidMultiSig)
+	})
+
+	t.Run("1-of-2", func(t *testing.T) {
+		required, pubKeys, err := ParseMultiSig(multisigHex)
+		require.NoError(t, err)
+		assert.Equal(t, 1, required)
+		require.Len(t, pubKeys, 2)
+		assert.Len(t, pubKeys[0], 65)
+	})
+}
+
+// TestNewMultiSigLockingScript will test the method NewMultiSigLockingScript()
+func TestNewMultiSigLockingScript(t *testing.T) {
+	t.Parallel()
+
+	_, pubKeys, err := ParseMultiSig(multisigHex)
+	require.NoError(t, err)
+
+	t.Run("invalid required signatures", func(t *testing.T) {
+		_, err = NewMultiSigLockingScript(0, pubKeys)
+		require.ErrorIs(t, err, ErrInvalidMultiSig)
+
+		_, err = NewMultiSigLockingScript(3, pubKeys)
+		require.ErrorIs(t, err, ErrInvalidMultiSig)
+	})
+
+	t.Run("same script", func(t *testing.T) {
+		lockingScript, err := NewMultiSigLockingScript(1, pubKeys)
+		require.NoError(t, err)
+		assert.Equal(t, multisigHex, lockingScript)
+		assert.Equal(t, ScriptTypeMultiSig, GetDestinationType(lockingScript))
+	})
+
+	t.Run("2-of-2", func(t *testing.T) {
+		lockingScript, err := NewMultiSigLockingScript(2, pubKeys)
+		require.NoError(t, err)
+
+		var required int
+		required, _, err = ParseMultiSig(lockingScript)
+		require.NoError(t, err)
+		assert.Equal(t, 2, required)
+	})
+}
+
+// TestGetInputSizeForMultiSig will test the method GetInputSizeForMultiSig()
+func TestGetInputSizeForMultiSig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("2-of-3", func(t *testing.T) {
+		assert.Equal(t, uint64(190), GetInputSizeForMultiSig(2))
+	})
+
+	t.Run("large script length", func(t *testing.T) {
+		assert.Equal(t, uint64(340), GetInputSizeForMultiSig(4))
+	})
+}
@@ -0,0 +1,215 @@
+package bux
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/BuxOrg/bux/datastore"
+	"github.com/BuxOrg/bux/utils"
+)
+
+// UtxoConsolidationConfig is the configuration for the utxo consolidation task
+//
+// An xPub with more than MinUtxos spendable utxos of MaxSatoshis (or less) gets a consolidation
+// draft transaction that sends the small utxos to a new internal destination (reconcile).
+// xPubs with an active consolidation draft are skipped until the draft is recorded or expires.
+type UtxoConsolidationConfig struct {
+	ExpiresIn   time.Duration // Expiration of the consolidation draft (time for the xPub owner to sign)
+	MaxInputs   int           // Max number of utxos to consolidate in one draft transaction
+	MaxSatoshis uint64        // Utxos of this amount of satoshis (or less) are consolidated
+	MinUtxos    int           // Consolidate when an xPub has more than this number of small utxos
+	PageSize    int           // Number of xPubs loaded per page (each task run checks all xPubs)
+	Resolver    XPubResolver  // Resolves the raw xPub for an xPub ID (unresolved xPubs are skipped)
+}
+
+// XPubResolver will resolve the raw xPub key for the given xPub ID
+//
+// An empty key is returned if the xPub is unknown to the resolver.
+// If the resolver also implements DraftSigner, the consolidation is signed, recorded and broadcast (custodial).
+type XPubResolver interface {
+	GetRawXPub(ctx context.Context, xPubID string) (string, error)
+}
+
+// DraftSigner will sign the draft transaction and return the signed transaction hex
+//
+// An empty hex is returned if the xPub is not custodial (the draft is left for the xPub owner)
+type DraftSigner interface {
+	SignDraftTransaction(ctx context.Context, draft *DraftTransaction) (string, error)
+}
+
+// setDefaults will set the defaults for any empty values
+func (u *UtxoConsolidationConfig) setDefaults() {
+	if u.ExpiresIn <= 0 {
+		u.ExpiresIn = defaultDraftTxExpiresIn
+	}
+	if u.MaxInputs <= 0 {
+		u.MaxInputs = defaultConsolidateInputs
+	}
+	if u.MaxSatoshis == 0 {
+		u.MaxSatoshis = defaultConsolidateSatoshis
+	}
+	if u.MinUtxos <= 0 {
+		u.MinUtxos = defaultConsolidateMinUtxos
+	}
+	if u.PageSize <= 0 {
+		u.PageSize = defaultConsolidatePageSize
+	}
+}
+
+// consolidateUtxos will create consolidation drafts for all xPubs with too many small utxos
+func consolidateUtxos(ctx context.Context, config *UtxoConsolidationConfig, opts ...ModelOps) error {
+
+	// Get the client
+	client := NewBaseModel(ModelNameEmpty, opts...).Client()
+
+	// Only xPubs with a balance can have utxos
+	conditions := map[string]interface{}{
+		currentBalanceField: map[string]interface{}{
+			"$gt": 0,
+		},
+	}
+
+	// Loop all the pages of xPubs
+	for page := 1; ; page++ {
+		var models []Xpub
+		if err := getModels(
+			ctx, client.Datastore(), &models, conditions, config.PageSize, page,
+			idField, datastore.SortAsc, defaultDatabaseReadTimeout,
+		); err != nil {
+			if errors.Is(err, datastore.ErrNoResults) {
+				return nil
+			}
+			return err
+		}
+
+		for index := range models {
+			if err := consolidateXPubUtxos(
+				ctx, client, config, models[index].ID, opts...,
+			); err != nil {
+				client.Logger().Error(ctx, "error consolidating utxos for xpub "+models[index].ID+": "+err.Error())
+			}
+		}
+
+		if len(models) < config.PageSize {
+			return nil
+		}
+	}
+}
+
+// consolidateXPubUtxos will create (and record if custodial) the consolidation draft for the xPub
+func consolidateXPubUtxos(ctx context.Context, client ClientInterface, config *UtxoConsolidationConfig,
+	xPubID string, opts ...ModelOps) error {
+
+	// Spendable utxos that are considered small
+	conditions := map[string]interface{}{
+		xPubIDField:       xPubID,
+		typeField:         utils.ScriptTypePubKeyHash,
+		draftIDField:      nil,
+		spendingTxIDField: nil,
+		satoshisField: map[string]interface{}{
+			"$lte": config.MaxSatoshis,
+		},
+	}
+
+	// Enough small utxos to consolidate?
+	count, err := getModelCount(
+		ctx, client.Datastore(), &Utxo{}, conditions, databaseLongReadTimeout,
+	)
+	if err != nil {
+		return err
+	} else if count <= int64(config.MinUtxos) {
+		return nil
+	}
+
+	// Skip the xPub if the previous consolidation draft is still active
+	var active bool
+	if active, err = hasActiveConsolidationDraft(ctx, client, xPubID); err != nil || active {
+		return err
+	}
+
+	// Resolve the raw xPub (needed for the draft and the new destination)
+	var rawXPubKey string
+	if rawXPubKey, err = config.Resolver.GetRawXPub(ctx, xPubID); err != nil {
+		return err
+	} else if len(rawXPubKey) == 0 {
+		return nil
+	}
+
+	// Get the small utxos (max number of inputs)
+	var utxos []*Utxo
+	if utxos, err = getUtxosByConditions(
+		ctx, conditions, config.MaxInputs, 1, satoshisField, datastore.SortAsc, opts...,
+	); err != nil {
+		return err
+	}
+	fromUtxos := make([]*UtxoPointer, 0, len(utxos))
+	for _, utxo := range utxos {
+		fromUtxos = append(fromUtxos, &UtxoPointer{
+			OutputIndex:   utxo.OutputIndex,
+			TransactionID: utxo.TransactionID,
+		})
+	}
+
+	// Get a new internal destination
+	var destination *Destination
+	if destination, err = client.NewDestination(
+		ctx, rawXPubKey, utils.ChainInternal, utils.ScriptTypePubKeyHash, nil,
+	); err != nil {
+		return err
+	}
+
+	// Create the draft, sending all the small utxos to the xPub itself
+	var draft *DraftTransaction
+	if draft, err = client.NewTransaction(ctx, rawXPubKey, &TransactionConfig{
+		ExpiresIn: config.ExpiresIn,
+		FromUtxos: fromUtxos,
+		SendAllTo: destination.Address,
+		Sync: &SyncConfig{
+			Broadcast:   true,
+			SyncOnChain: true,
+		},
+	}, Metadata{consolidationField: true}); err != nil {
+		return err
+	}
+
+	// Custodial xPubs: sign and record the transaction (broadcast by the sync task)
+	var txHex string
+	if signer, ok := config.Resolver.(DraftSigner); ok {
+		if txHex, err = signer.SignDraftTransaction(ctx, draft); err != nil {
+			return err
+		}
+	}
+
+	// Not custodial, the xPub owner needs to sign the draft
+	if len(txHex) == 0 {
+		publishEvent(ctx, client, EventTypeUtxoConsolidation, draft)
+		return nil
+	}
+
+	_, err = client.RecordTransaction(ctx, rawXPubKey, txHex, draft.ID)
+	return err
+}
+
+// hasActiveConsolidationDraft will return true if the xPub has an active (not expired) consolidation draft
+func hasActiveConsolidationDraft(ctx context.Context, client ClientInterface, xPubID string) (bool, error) {
+	var models []DraftTransaction
+	if err := getModels(
+		ctx, client.Datastore(), &models, map[string]interface{}{
+			xPubIDField: xPubID,
+			statusField: DraftStatusDraft,
+		}, 0, 0, "", "", defaultDatabaseReadTimeout,
+	); err != nil {
+		if errors.Is(err, datastore.ErrNoResults) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for index := range models {
+		if _, ok := models[index].Metadata[consolidationField]; ok && models[index].isActive() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
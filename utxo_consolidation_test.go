@@ -0,0 +1,174 @@
+package bux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BuxOrg/bux/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// xPubResolverMock will resolve the xPubs in the map
+type xPubResolverMock struct {
+	xPubs map[string]string
+}
+
+func (r *xPubResolverMock) GetRawXPub(_ context.Context, xPubID string) (string, error) {
+	return r.xPubs[xPubID], nil
+}
+
+// createTestConsolidationXPub will create the xPub (with a balance) and the small utxos
+func createTestConsolidationXPub(ctx context.Context, t *testing.T, client ClientInterface) {
+	opts := append(client.DefaultModelOptions(), New())
+
+	xPub := newXpub(testXPub, opts...)
+	xPub.CurrentBalance = 5 * 1225
+	require.NoError(t, xPub.Save(ctx))
+
+	destination := newDestination(testXPubID, testLockingScript, opts...)
+	require.NoError(t, destination.Save(ctx))
+
+	createTestUtxos(ctx, client)
+}
+
+// TestTaskConsolidateUtxos will test the method TaskConsolidateUtxos()
+func TestTaskConsolidateUtxos(t *testing.T) {
+
+	t.Run("not enabled", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+
+		err := TaskConsolidateUtxos(ctx, client.Logger(), nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+	})
+
+	t.Run("not enough small utxos", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		createTestConsolidationXPub(ctx, t, client)
+
+		config := &UtxoConsolidationConfig{
+			MaxSatoshis: 1000,
+			MinUtxos:    3,
+			Resolver:    &xPubResolverMock{xPubs: map[string]string{testXPubID: testXPub}},
+		}
+		config.setDefaults()
+
+		err := TaskConsolidateUtxos(ctx, client.Logger(), config, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+
+		utxos, err := GetSpendableUtxos(ctx, testXPubID, utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Len(t, utxos, 5)
+	})
+
+	t.Run("xpub not resolved", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		createTestConsolidationXPub(ctx, t, client)
+
+		config := &UtxoConsolidationConfig{
+			MaxSatoshis: 2000,
+			MinUtxos:    3,
+			Resolver:    &xPubResolverMock{},
+		}
+		config.setDefaults()
+
+		err := TaskConsolidateUtxos(ctx, client.Logger(), config, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+
+		utxos, err := GetSpendableUtxos(ctx, testXPubID, utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Len(t, utxos, 5)
+	})
+
+	t.Run("consolidation draft", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		createTestConsolidationXPub(ctx, t, client)
+
+		config := &UtxoConsolidationConfig{
+			MaxInputs:   4,
+			MaxSatoshis: 2000,
+			MinUtxos:    3,
+			Resolver:    &xPubResolverMock{xPubs: map[string]string{testXPubID: testXPub}},
+		}
+		config.setDefaults()
+
+		err := TaskConsolidateUtxos(ctx, client.Logger(), config, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+
+		// Max inputs were reserved by the draft
+		utxos, err := GetSpendableUtxos(ctx, testXPubID, utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Len(t, utxos, 1)
+
+		var reserved []*Utxo
+		reserved, err = getUtxosByXpubID(ctx, testXPubID, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		var draftID string
+		for _, utxo := range reserved {
+			if utxo.DraftID.Valid {
+				draftID = utxo.DraftID.String
+			}
+		}
+		require.NotEmpty(t, draftID)
+
+		var draft *DraftTransaction
+		draft, err = getDraftTransactionID(ctx, testXPubID, draftID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, draft)
+		assert.Len(t, draft.Configuration.Inputs, 4)
+		require.Len(t, draft.Configuration.Outputs, 1)
+		assert.Equal(t, 4*1225-draft.Configuration.Fee, draft.Configuration.Outputs[0].Satoshis)
+
+		// Sent to a new internal destination of the xPub
+		var destination *Destination
+		destination, err = getDestinationByAddress(
+			ctx, draft.Configuration.SendAllTo, client.DefaultModelOptions()...,
+		)
+		require.NoError(t, err)
+		require.NotNil(t, destination)
+		assert.Equal(t, testXPubID, destination.XpubID)
+		assert.Equal(t, utils.ChainInternal, destination.Chain)
+	})
+
+	t.Run("active consolidation draft is skipped", func(t *testing.T) {
+		ctx, client, deferMe := CreateTestSQLiteClient(t, false, true, WithCustomTaskManager(&taskManagerMockBase{}))
+		defer deferMe()
+		createTestConsolidationXPub(ctx, t, client)
+
+		// Enough small utxos are left after the first draft
+		config := &UtxoConsolidationConfig{
+			MaxInputs:   2,
+			MaxSatoshis: 2000,
+			MinUtxos:    2,
+			PageSize:    1,
+			Resolver:    &xPubResolverMock{xPubs: map[string]string{testXPubID: testXPub}},
+		}
+		config.setDefaults()
+
+		err := TaskConsolidateUtxos(ctx, client.Logger(), config, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+
+		var xPub *Xpub
+		xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		require.NotNil(t, xPub)
+		nextInternalNum := xPub.NextInternalNum
+
+		// No new draft and no new internal destination
+		err = TaskConsolidateUtxos(ctx, client.Logger(), config, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+
+		var utxos []*Utxo
+		utxos, err = GetSpendableUtxos(ctx, testXPubID, utils.ScriptTypePubKeyHash, nil, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Len(t, utxos, 3)
+
+		xPub, err = getXpubByID(ctx, testXPubID, client.DefaultModelOptions()...)
+		require.NoError(t, err)
+		assert.Equal(t, nextInternalNum, xPub.NextInternalNum)
+	})
+}